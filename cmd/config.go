@@ -0,0 +1,23 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/pkg/config"
+)
+
+func printConfig(cfg *config.Config, args []string) error {
+	if len(args) != 1 || args[0] != "print" {
+		return errors.New("usage: config print")
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tENV\tVALUE")
+	for _, setting := range cfg.Settings() {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", setting.Key, setting.Env, setting.Value)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/transfer"
+	"github.com/adrianosiqe/eulabs-challenge-api/pkg/config"
+)
+
+func exportProducts(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	format := flags.String("format", transfer.FormatNDJSON, "output format: ndjson, json or csv")
+	output := flags.String("output", "", "file to write instead of stdout")
+	flags.Parse(args)
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	writer, err := transfer.NewWriter(out, *format)
+	if err != nil {
+		return err
+	}
+
+	productService, err := newProductService(cfg)
+	if err != nil {
+		return err
+	}
+
+	filter := models.ProductFilter{Page: 1, PerPage: models.MaxPerPage}
+	for {
+		products, err := productService.GetAllProducts(filter)
+		if err != nil {
+			return err
+		}
+
+		for _, product := range products {
+			if err := writer.Write(product); err != nil {
+				return err
+			}
+		}
+
+		if len(products) < filter.PerPage {
+			break
+		}
+		filter.Page++
+	}
+
+	return writer.Close()
+}
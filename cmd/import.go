@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/transfer"
+	"github.com/adrianosiqe/eulabs-challenge-api/pkg/config"
+)
+
+func importProducts(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	file := flags.String("file", "", "CSV file with title, description and price columns")
+	publish := flags.Bool("publish", false, "publish the imported products instead of leaving them as drafts")
+	flags.Parse(args)
+
+	if *file == "" {
+		return errors.New("usage: import --file products.csv")
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	products, err := transfer.ReadCSV(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", *file, err)
+	}
+
+	productService, err := newProductService(cfg)
+	if err != nil {
+		return err
+	}
+
+	for i, product := range products {
+		if _, err := productService.CreateProduct(product); err != nil {
+			return fmt.Errorf("imported %d of %d products: %w", i, len(products), err)
+		}
+		if *publish {
+			if _, err := productService.TransitionProduct(int(product.ID), models.ProductPublished); err != nil {
+				return fmt.Errorf("imported %d of %d products: %w", i, len(products), err)
+			}
+		}
+	}
+
+	fmt.Printf("Imported %d products\n", len(products))
+	return nil
+}
@@ -1,26 +1,95 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"os"
+	"regexp"
 
 	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/repositories"
-	server "github.com/adrianosiqe/eulabs-challenge-api/internal/http"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/services"
 	"github.com/adrianosiqe/eulabs-challenge-api/pkg/config"
 	"github.com/adrianosiqe/eulabs-challenge-api/pkg/database"
+	"gorm.io/gorm"
 )
 
-func main() {
-	config.LoadConfig()
+const usage = `Usage: main [--config file] [--<setting> value ...] <command> [arguments]
+
+Settings are read from the defaults, the config file, the environment and the
+flags, in this order. Run "main config print" to list them.
+
+Commands:
+  serve                                start the HTTP server (default), --storage=memory
+                                       serves sample data without a database
+  migrate up|down|status|redo          manage the database schema
+  seed [--file f.yaml] [--fake N]      insert fixture or fake products, --wipe removes them
+  import --file products.csv           create the products of a CSV file
+  export [--format ndjson|json|csv]    write every product to stdout or --output
+  config print                         print the configuration, hiding secrets
+`
 
-	db, err := database.ConnectDatabase()
+type command func(cfg *config.Config, args []string) error
+
+func main() {
+	cfg, args, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	productRepository := repositories.NewProductRepository(db)
+	if len(args) == 0 {
+		args = []string{"serve"}
+	}
+
+	commands := map[string]command{
+		"serve":   serve,
+		"migrate": migrate,
+		"seed":    seedProducts,
+		"import":  importProducts,
+		"export":  exportProducts,
+		"config":  printConfig,
+	}
+
+	run, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if err := run(cfg, args[1:]); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// connectDatabase connects to the database of commands that can't work on
+// in-memory storage.
+func connectDatabase(cfg *config.Config) (*gorm.DB, error) {
+	if cfg.Storage != "database" {
+		return nil, fmt.Errorf("this command needs a database, but the storage is %s", cfg.Storage)
+	}
+	return database.ConnectDatabase(cfg.Database)
+}
+
+func newProductService(cfg *config.Config) (*services.ProductService, error) {
+	db, err := connectDatabase(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return services.NewProductService(repositories.NewProductRepository(db), repositories.NewCategoryRepository(db), repositories.NewAttributeRepository(db), productOptions(cfg)), nil
+}
 
-	address := fmt.Sprintf(":%s", config.Cfg.PORT)
-	http := server.NewServer(productRepository)
-	http.RouteInit(address)
+// productOptions returns the product rules of cfg. The SKU pattern was
+// checked when the configuration was loaded.
+func productOptions(cfg *config.Config) services.ProductOptions {
+	var options services.ProductOptions
+	if cfg.Products.SKUPattern != "" {
+		options.SKUPattern = regexp.MustCompile(cfg.Products.SKUPattern)
+	}
+	return options
 }
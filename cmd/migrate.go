@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/pkg/config"
+	"github.com/adrianosiqe/eulabs-challenge-api/pkg/database"
+)
+
+func migrate(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: migrate up|down|status|redo")
+	}
+
+	flags := flag.NewFlagSet("migrate "+args[0], flag.ExitOnError)
+	to := flags.Int64("to", -1, "revert the migrations newer than this version (down only, defaults to the latest one)")
+	flags.Parse(args[1:])
+
+	db, err := connectDatabase(cfg)
+	if err != nil {
+		return err
+	}
+
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		printMigrations("Applied", applied)
+		return err
+	case "down":
+		if *to < 0 {
+			if *to, err = previousVersion(ctx, migrator); err != nil {
+				return err
+			}
+		}
+		reverted, err := migrator.DownTo(ctx, *to)
+		printMigrations("Reverted", reverted)
+		return err
+	case "redo":
+		redone, err := migrator.Redo(ctx)
+		if err != nil {
+			return err
+		}
+		printMigrations("Redone", []database.Migration{*redone})
+		return nil
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		return w.Flush()
+	}
+
+	return fmt.Errorf("unknown migrate command %q", args[0])
+}
+
+// previousVersion returns the version before the latest applied migration, so
+// that "migrate down" reverts a single migration.
+func previousVersion(ctx context.Context, migrator *database.Migrator) (int64, error) {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := len(statuses) - 1; i >= 0; i-- {
+		if statuses[i].AppliedAt == nil {
+			continue
+		}
+		if i == 0 {
+			return 0, nil
+		}
+		return statuses[i-1].Version, nil
+	}
+	return 0, errors.New("no migration has been applied")
+}
+
+func printMigrations(action string, migrations []database.Migration) {
+	for _, migration := range migrations {
+		fmt.Printf("%s %d_%s\n", action, migration.Version, migration.Name)
+	}
+}
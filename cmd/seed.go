@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/core/filestore"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/repositories"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/seed"
+	"github.com/adrianosiqe/eulabs-challenge-api/pkg/config"
+)
+
+func seedProducts(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("seed", flag.ExitOnError)
+	file := flags.String("file", "", "YAML or JSON fixture file (defaults to the bundled sample products)")
+	fake := flags.Int("fake", 0, "number of fake products to generate")
+	randomSeed := flags.Int64("seed", 1, "seed of the fake product generator")
+	wipe := flags.Bool("wipe", false, "delete the seeded products instead of creating them")
+	flags.Parse(args)
+
+	db, err := connectDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	seeder := seed.NewSeeder(repositories.NewProductRepository(db)).
+		WithMedia(filestore.NewLocal(cfg.Media.Dir))
+
+	if *wipe {
+		deleted, err := seeder.Wipe()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Deleted %d seeded products\n", deleted)
+		return nil
+	}
+
+	var fixtures []seed.Fixture
+	switch {
+	case *file != "":
+		fixtures, err = seed.LoadFixtures(*file)
+	case *fake == 0:
+		fixtures, err = seed.DefaultFixtures()
+	}
+	if err != nil {
+		return err
+	}
+	fixtures = append(fixtures, seed.Generate(*fake, *randomSeed)...)
+
+	result, err := seeder.Seed(fixtures)
+	fmt.Printf("Seeded %d products, %d already present\n", result.Created, result.Skipped)
+	return err
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/core/cache"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/core/filestore"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/repositories"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/seed"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/services"
+	server "github.com/adrianosiqe/eulabs-challenge-api/internal/http"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/http/middlewares"
+	"github.com/adrianosiqe/eulabs-challenge-api/pkg/config"
+	"github.com/adrianosiqe/eulabs-challenge-api/pkg/database"
+)
+
+func serve(cfg *config.Config, args []string) error {
+	if cfg.Auth.JWTSecret == "" {
+		return errors.New("JWT_SECRET must be set")
+	}
+
+	stores, err := storage(cfg)
+	if err != nil {
+		return err
+	}
+	if cfg.Cache.Enabled {
+		cached := repositories.NewCachedProductRepository(stores.Products, cache.NewLRU(cfg.Cache.Size), repositories.CacheOptions{
+			TTL:         cfg.Cache.TTL,
+			NegativeTTL: cfg.Cache.NegativeTTL,
+		})
+		expvar.Publish("product_cache", expvar.Func(func() interface{} { return cached.Stats() }))
+		stores.Products = cached
+		stores.Inventory = repositories.NewCachedInventoryRepository(stores.Inventory, cached)
+		stores.Categories = repositories.NewCachedCategoryRepository(stores.Categories, cached)
+		stores.Attributes = repositories.NewCachedAttributeRepository(stores.Attributes, cached)
+		stores.Variants = repositories.NewCachedVariantRepository(stores.Variants, cached)
+	}
+
+	policy, err := middlewares.LoadPolicy(cfg.Auth.PolicyFile)
+	if err != nil {
+		return err
+	}
+
+	rateLimits, err := middlewares.ParseRateLimits(cfg.RateLimits)
+	if err != nil {
+		return err
+	}
+	trustedProxies, err := middlewares.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var scheduler sync.WaitGroup
+	if cfg.Scheduler.Enabled {
+		publicationScheduler := services.NewPublicationScheduler(stores.Products, services.SchedulerOptions{
+			Interval:  cfg.Scheduler.Interval,
+			BatchSize: cfg.Scheduler.BatchSize,
+		})
+		scheduler.Add(1)
+		go func() {
+			defer scheduler.Done()
+			publicationScheduler.Run(ctx)
+		}()
+	}
+
+	address := fmt.Sprintf(":%d", cfg.Port)
+	http := server.NewServer(stores, server.Options{
+		Policy:         policy,
+		JWTSecret:      cfg.Auth.JWTSecret,
+		RateLimits:     rateLimits,
+		TrustedProxies: trustedProxies,
+		Products:       productOptions(cfg),
+		Inventory: services.InventoryOptions{
+			ReservationTTL:    cfg.Inventory.ReservationTTL,
+			MaxReservationTTL: cfg.Inventory.ReservationMaxTTL,
+		},
+		Media: services.MediaOptions{
+			MaxSize:       cfg.Media.MaxSize,
+			ThumbnailSize: cfg.Media.ThumbnailSize,
+		},
+	})
+	err = http.Serve(ctx, address)
+
+	// The scheduler finishes the batch it is applying before returning.
+	stop()
+	scheduler.Wait()
+	return err
+}
+
+// storage returns the repositories of cfg.Storage. The in-memory ones start
+// with the sample products. The media files are kept in cfg.Media.Dir either
+// way.
+func storage(cfg *config.Config) (server.Repositories, error) {
+	if cfg.Storage == "memory" {
+		productRepository := repositories.NewMemoryProductRepository()
+		fixtures, err := seed.DefaultFixtures()
+		if err != nil {
+			return server.Repositories{}, err
+		}
+		if _, err := seed.NewSeeder(productRepository).Seed(fixtures); err != nil {
+			return server.Repositories{}, err
+		}
+		log.Println("Serving from memory storage, changes are lost on restart")
+		return server.Repositories{
+			Products:     productRepository,
+			Categories:   repositories.NewMemoryCategoryRepository(productRepository),
+			Attributes:   repositories.NewMemoryAttributeRepository(productRepository),
+			Tags:         repositories.NewMemoryTagRepository(productRepository),
+			Variants:     repositories.NewMemoryVariantRepository(productRepository),
+			Media:        repositories.NewMemoryMediaRepository(productRepository),
+			Inventory:    repositories.NewMemoryInventoryRepository(productRepository),
+			APIKeys:      repositories.NewMemoryAPIKeyRepository(),
+			MediaStorage: filestore.NewLocal(cfg.Media.Dir),
+		}, nil
+	}
+
+	db, err := database.ConnectDatabase(cfg.Database)
+	if err != nil {
+		return server.Repositories{}, err
+	}
+	if err := database.Migrate(db, cfg.Database); err != nil {
+		return server.Repositories{}, err
+	}
+	return server.Repositories{
+		Products:     repositories.NewProductRepository(db),
+		Categories:   repositories.NewCategoryRepository(db),
+		Attributes:   repositories.NewAttributeRepository(db),
+		Tags:         repositories.NewTagRepository(db),
+		Variants:     repositories.NewVariantRepository(db),
+		Media:        repositories.NewMediaRepository(db),
+		Inventory:    repositories.NewInventoryRepository(db),
+		APIKeys:      repositories.NewAPIKeyRepository(db),
+		MediaStorage: filestore.NewLocal(cfg.Media.Dir),
+	}, nil
+}
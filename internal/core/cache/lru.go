@@ -0,0 +1,97 @@
+// Package cache holds the in-process cache backends.
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+type entry struct {
+	key       string
+	value     interface{}
+	expiresAt time.Time
+}
+
+// LRU keeps up to size entries, evicting the least recently used one when
+// full. Expired entries are dropped when read.
+type LRU struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	order   *list.List
+	now     func() time.Time
+}
+
+func NewLRU(size int) *LRU {
+	return &LRU{
+		size:    size,
+		entries: map[string]*list.Element{},
+		order:   list.New(),
+		now:     time.Now,
+	}
+}
+
+func (c *LRU) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	e := element.Value.(*entry)
+	if !c.now().Before(e.expiresAt) {
+		c.remove(element)
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return e.value, true
+}
+
+func (c *LRU) Set(key string, value interface{}, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := c.now().Add(ttl)
+	if element, ok := c.entries[key]; ok {
+		element.Value = &entry{key: key, value: value, expiresAt: expiresAt}
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&entry{key: key, value: value, expiresAt: expiresAt})
+	if c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *LRU) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+}
+
+func (c *LRU) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = map[string]*list.Element{}
+	c.order.Init()
+}
+
+func (c *LRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+func (c *LRU) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*entry).key)
+}
@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRU(t *testing.T) {
+	t.Run("should return the values set", func(t *testing.T) {
+		cache := NewLRU(2)
+		cache.Set("a", 1, time.Minute)
+
+		value, ok := cache.Get("a")
+
+		assert.True(t, ok)
+		assert.Equal(t, 1, value)
+		_, ok = cache.Get("b")
+		assert.False(t, ok)
+	})
+
+	t.Run("should evict the least recently used entry", func(t *testing.T) {
+		cache := NewLRU(2)
+		cache.Set("a", 1, time.Minute)
+		cache.Set("b", 2, time.Minute)
+		cache.Get("a")
+		cache.Set("c", 3, time.Minute)
+
+		_, okA := cache.Get("a")
+		_, okB := cache.Get("b")
+		_, okC := cache.Get("c")
+
+		assert.True(t, okA)
+		assert.False(t, okB)
+		assert.True(t, okC)
+		assert.Equal(t, 2, cache.Len())
+	})
+
+	t.Run("should expire entries after their TTL", func(t *testing.T) {
+		now := time.Now()
+		cache := NewLRU(2)
+		cache.now = func() time.Time { return now }
+		cache.Set("a", 1, time.Second)
+
+		now = now.Add(time.Second)
+		_, ok := cache.Get("a")
+
+		assert.False(t, ok)
+		assert.Equal(t, 0, cache.Len())
+	})
+
+	t.Run("should delete and purge entries", func(t *testing.T) {
+		cache := NewLRU(3)
+		cache.Set("a", 1, time.Minute)
+		cache.Set("b", 2, time.Minute)
+		cache.Set("a", 3, time.Minute)
+
+		cache.Delete("a")
+		_, ok := cache.Get("a")
+		assert.False(t, ok)
+		assert.Equal(t, 1, cache.Len())
+
+		cache.Purge()
+		assert.Equal(t, 0, cache.Len())
+	})
+}
@@ -0,0 +1,83 @@
+// Package filestore holds the storage backends of the product media.
+package filestore
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ErrInvalidKey is returned for keys that are empty, absolute, not clean or
+// that would leave the root directory.
+var ErrInvalidKey = errors.New("invalid key")
+
+// Local keeps the files in a directory of the local filesystem, each key
+// being the path of its file relative to the directory.
+type Local struct {
+	root string
+}
+
+// NewLocal returns a storage rooted at dir, which is created on the first
+// write when missing.
+func NewLocal(dir string) *Local {
+	return &Local{root: dir}
+}
+
+// Put writes the file of key to a temporary file first, then renames it, so
+// that it is never read half written.
+func (s *Local) Put(key string, content io.Reader) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := io.Copy(file, content); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), name)
+}
+
+func (s *Local) Open(key string) (io.ReadCloser, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(name)
+}
+
+func (s *Local) Delete(key string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// path returns the name of the file of key.
+func (s *Local) path(key string) (string, error) {
+	if key == "" || path.IsAbs(key) || path.Clean(key) != key || key == ".." || strings.HasPrefix(key, "../") || strings.Contains(key, `\`) {
+		return "", fmt.Errorf("%w %q", ErrInvalidKey, key)
+	}
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
@@ -0,0 +1,81 @@
+package filestore
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocal(t *testing.T) {
+	t.Run("should read back the files put", func(t *testing.T) {
+		dir := t.TempDir()
+		storage := NewLocal(dir)
+
+		require.NoError(t, storage.Put("products/1/photo.jpg", strings.NewReader("image")))
+		file, err := storage.Open("products/1/photo.jpg")
+
+		require.NoError(t, err)
+		defer file.Close()
+		content, err := io.ReadAll(file)
+		require.NoError(t, err)
+		assert.Equal(t, "image", string(content))
+		assert.FileExists(t, filepath.Join(dir, "products", "1", "photo.jpg"))
+	})
+
+	t.Run("should replace a file put again", func(t *testing.T) {
+		storage := NewLocal(t.TempDir())
+		require.NoError(t, storage.Put("photo.jpg", strings.NewReader("old")))
+
+		require.NoError(t, storage.Put("photo.jpg", strings.NewReader("new")))
+
+		file, err := storage.Open("photo.jpg")
+		require.NoError(t, err)
+		defer file.Close()
+		content, _ := io.ReadAll(file)
+		assert.Equal(t, "new", string(content))
+	})
+
+	t.Run("should delete files, missing ones included", func(t *testing.T) {
+		storage := NewLocal(t.TempDir())
+		require.NoError(t, storage.Put("photo.jpg", strings.NewReader("image")))
+
+		require.NoError(t, storage.Delete("photo.jpg"))
+		require.NoError(t, storage.Delete("photo.jpg"))
+
+		_, err := storage.Open("photo.jpg")
+		assert.True(t, errors.Is(err, fs.ErrNotExist))
+	})
+
+	t.Run("should leave no temporary file when the write fails", func(t *testing.T) {
+		dir := t.TempDir()
+		storage := NewLocal(dir)
+
+		err := storage.Put("photo.jpg", io.MultiReader(strings.NewReader("part"), failingReader{}))
+
+		assert.Error(t, err)
+		entries, _ := os.ReadDir(dir)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("should refuse keys leaving the root", func(t *testing.T) {
+		storage := NewLocal(t.TempDir())
+
+		for _, key := range []string{"", "/etc/passwd", "../photo.jpg", "products/../../photo.jpg", `..\photo.jpg`, "products//photo.jpg"} {
+			err := storage.Put(key, strings.NewReader("image"))
+			assert.True(t, errors.Is(err, ErrInvalidKey), key)
+		}
+	})
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"time"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+)
+
+type APIKeyRepositoryInterface interface {
+	GetAll() ([]*models.APIKey, error)
+	Create(apiKey *models.APIKey) (*models.APIKey, error)
+	GetByID(id int) (*models.APIKey, error)
+	GetByPrefix(prefix string) (*models.APIKey, error)
+	Rotate(id uint, prefix, hash string) error
+	Revoke(id uint, at time.Time) error
+	TouchLastUsed(id uint, at time.Time) error
+}
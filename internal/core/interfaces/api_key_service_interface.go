@@ -0,0 +1,11 @@
+package interfaces
+
+import "github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+
+type APIKeyServiceInterface interface {
+	GetAllAPIKeys() ([]*models.APIKey, error)
+	CreateAPIKey(apiKey *models.APIKey) (*models.APIKey, error)
+	RotateAPIKey(id int) (*models.APIKey, error)
+	RevokeAPIKey(id int) error
+	AuthenticateAPIKey(key string) (*models.APIKey, error)
+}
@@ -0,0 +1,11 @@
+package interfaces
+
+import "github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+
+type AttributeRepositoryInterface interface {
+	GetAll() ([]*models.AttributeDefinition, error)
+	GetByKey(key string) (*models.AttributeDefinition, error)
+	Create(definition *models.AttributeDefinition) (*models.AttributeDefinition, error)
+	Update(definition *models.AttributeDefinition) (*models.AttributeDefinition, error)
+	Delete(key string) error
+}
@@ -0,0 +1,11 @@
+package interfaces
+
+import "github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+
+type AttributeServiceInterface interface {
+	GetAllAttributes() ([]*models.AttributeDefinition, error)
+	GetAttributeByKey(key string) (*models.AttributeDefinition, error)
+	CreateAttribute(definition *models.AttributeDefinition) (*models.AttributeDefinition, error)
+	UpdateAttribute(definition *models.AttributeDefinition) (*models.AttributeDefinition, error)
+	DeleteAttribute(key string) error
+}
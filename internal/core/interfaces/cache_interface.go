@@ -0,0 +1,13 @@
+package interfaces
+
+import "time"
+
+// CacheInterface is a key-value store whose entries expire after a TTL, used
+// by the caching decorators. Implementations must be safe for concurrent use.
+type CacheInterface interface {
+	Get(key string) (interface{}, bool)
+	Set(key string, value interface{}, ttl time.Duration)
+	Delete(key string)
+	Purge()
+	Len() int
+}
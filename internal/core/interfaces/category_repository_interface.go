@@ -0,0 +1,13 @@
+package interfaces
+
+import "github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+
+type CategoryRepositoryInterface interface {
+	GetAll() ([]*models.Category, error)
+	Create(category *models.Category) (*models.Category, error)
+	GetByID(id int) (*models.Category, error)
+	Update(category *models.Category) (*models.Category, error)
+	Move(id int, parentID *uint) (*models.Category, error)
+	Delete(id int) error
+	DescendantIDs(id int) ([]uint, error)
+}
@@ -0,0 +1,12 @@
+package interfaces
+
+import "github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+
+type CategoryServiceInterface interface {
+	GetAllCategories() ([]*models.Category, error)
+	CreateCategory(category *models.Category) (*models.Category, error)
+	GetCategoryByID(id int) (*models.Category, error)
+	UpdateCategory(category *models.Category) (*models.Category, error)
+	MoveCategory(id int, parentID *uint) (*models.Category, error)
+	DeleteCategory(id int) error
+}
@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"time"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+)
+
+type InventoryRepositoryInterface interface {
+	GetStock(productID int, now time.Time) (*models.Stock, error)
+	Adjust(productID int, adjustment models.StockAdjustment, now time.Time) (*models.StockLevel, error)
+	Reserve(reservation *models.Reservation, now time.Time) (*models.Reservation, error)
+	GetReservation(id int) (*models.Reservation, error)
+	CommitReservation(id int, now time.Time) (*models.Reservation, error)
+	ReleaseReservation(id int, now time.Time) (*models.Reservation, error)
+}
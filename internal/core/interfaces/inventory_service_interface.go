@@ -0,0 +1,12 @@
+package interfaces
+
+import "github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+
+type InventoryServiceInterface interface {
+	GetStock(productID int) (*models.Stock, error)
+	AdjustStock(productID int, adjustment models.StockAdjustment) (*models.StockLevel, error)
+	Reserve(productID int, request models.ReservationRequest) (*models.Reservation, error)
+	GetReservation(id int) (*models.Reservation, error)
+	CommitReservation(id int) (*models.Reservation, error)
+	ReleaseReservation(id int) (*models.Reservation, error)
+}
@@ -0,0 +1,12 @@
+package interfaces
+
+import "github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+
+type MediaRepositoryInterface interface {
+	GetAll(productID int) ([]*models.ProductMedia, error)
+	GetByID(productID, id int) (*models.ProductMedia, error)
+	Create(media *models.ProductMedia) (*models.ProductMedia, error)
+	SetPrimary(productID, id int) error
+	Reorder(productID int, ids []uint) error
+	Delete(productID, id int) error
+}
@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"io"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+)
+
+type MediaServiceInterface interface {
+	GetAllMedia(productID int) ([]*models.ProductMedia, error)
+	GetMedia(productID, id int) (*models.ProductMedia, error)
+	UploadMedia(productID int, filename string, content io.Reader) (*models.ProductMedia, error)
+	OpenMedia(productID, id int, thumbnail bool) (*models.ProductMedia, io.ReadCloser, error)
+	SetPrimaryMedia(productID, id int) ([]*models.ProductMedia, error)
+	ReorderMedia(productID int, ids []uint) ([]*models.ProductMedia, error)
+	DeleteMedia(productID, id int) error
+}
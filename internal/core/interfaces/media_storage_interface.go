@@ -0,0 +1,13 @@
+package interfaces
+
+import "io"
+
+// MediaStorageInterface keeps the files of the product media under keys made
+// of slash separated names, such as products/1/photo.jpg. Opening a missing
+// key returns an error wrapping fs.ErrNotExist, while deleting one is not an
+// error. Implementations must be safe for concurrent use.
+type MediaStorageInterface interface {
+	Put(key string, content io.Reader) error
+	Open(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
@@ -1,11 +1,29 @@
 package interfaces
 
-import "github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+import (
+	"time"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+)
 
 type ProductRespositoryInterface interface {
-	GetAll() ([]*models.Product, error)
+	GetAll(filter models.ProductFilter) ([]*models.Product, error)
 	Create(product *models.Product) (*models.Product, error)
 	GetByID(id int) (*models.Product, error)
-	Update(product *models.Product) (*models.Product, error)
+	GetByIDFromPrimary(id int) (*models.Product, error)
+	GetBySKU(sku string) (*models.Product, error)
+	GetBySlug(slug string) (*models.Product, error)
+	Update(product *models.Product, audit models.Audit) (*models.Product, error)
+	GetPriceHistory(productID int, filter models.PriceHistoryFilter) ([]*models.PriceChange, error)
+	UpdatePublication(product *models.Product, from models.ProductStatus) error
+	ApplySchedules(now time.Time, limit int) ([]uint, error)
 	Delete(id int) error
+	Purge(id int) ([]*models.ProductMedia, error)
+	Restore(id int) error
+	GetBySeedKey(key string) (*models.Product, error)
+	DeleteSeeded() (int64, []*models.ProductMedia, error)
+	SetCategories(productID int, categories []models.Category) error
+	SetOptions(productID int, options []models.ProductOption) error
+	AddTags(productID int, names []string) error
+	RemoveTags(productID int, names []string) error
 }
@@ -3,9 +3,21 @@ package interfaces
 import "github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
 
 type ProductServiceInterface interface {
-	GetAllProducts() ([]*models.Product, error)
+	GetAllProducts(filter models.ProductFilter) ([]*models.Product, error)
 	CreateProduct(product *models.Product) (*models.Product, error)
 	GetProductByID(id int) (*models.Product, error)
-	UpdateProduct(product *models.Product) (*models.Product, error)
+	GetProductForUpdate(id int) (*models.Product, error)
+	GetProductBySKU(sku string) (*models.Product, error)
+	GetProductBySlug(slug string) (*models.Product, error)
+	UpdateProduct(product *models.Product, audit models.Audit) (*models.Product, error)
+	GetPriceHistory(id int, filter models.PriceHistoryFilter) (*models.PriceHistory, error)
 	DeleteProduct(id int) error
+	PurgeProduct(id int) error
+	RestoreProduct(id int) (*models.Product, error)
+	TransitionProduct(id int, status models.ProductStatus) (*models.Product, error)
+	ScheduleProduct(id int, schedule models.ProductSchedule) (*models.Product, error)
+	SetProductCategories(id int, categoryIDs []uint) (*models.Product, error)
+	SetProductOptions(id int, options []models.ProductOption) (*models.Product, error)
+	AddProductTags(id int, names []string) (*models.Product, error)
+	RemoveProductTag(id int, name string) (*models.Product, error)
 }
@@ -0,0 +1,7 @@
+package interfaces
+
+import "github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+
+type TagServiceInterface interface {
+	GetAllTags() ([]*models.TagCount, error)
+}
@@ -0,0 +1,12 @@
+package interfaces
+
+import "github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+
+type VariantRepositoryInterface interface {
+	GetAll(productID int) ([]*models.ProductVariant, error)
+	GetByID(productID, id int) (*models.ProductVariant, error)
+	Create(variant *models.ProductVariant) (*models.ProductVariant, error)
+	Update(variant *models.ProductVariant) (*models.ProductVariant, error)
+	Delete(productID, id int) error
+	Generate(productID int) ([]*models.ProductVariant, error)
+}
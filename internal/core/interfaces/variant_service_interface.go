@@ -0,0 +1,12 @@
+package interfaces
+
+import "github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+
+type VariantServiceInterface interface {
+	GetAllVariants(productID int) ([]*models.ProductVariant, error)
+	GetVariant(productID, id int) (*models.ProductVariant, error)
+	CreateVariant(productID int, variant *models.ProductVariant) (*models.ProductVariant, error)
+	UpdateVariant(variant *models.ProductVariant) (*models.ProductVariant, error)
+	DeleteVariant(productID, id int) error
+	GenerateVariants(productID int) ([]*models.ProductVariant, error)
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/core/interfaces"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/services"
+	"github.com/labstack/echo"
+	"gorm.io/gorm"
+)
+
+type APIKeyHandler struct {
+	apiKeyService interfaces.APIKeyServiceInterface
+	// scopes are the ones a key can be granted.
+	scopes []string
+}
+
+func NewAPIKeyHandler(apiKeyService interfaces.APIKeyServiceInterface, scopes []string) *APIKeyHandler {
+	return &APIKeyHandler{apiKeyService: apiKeyService, scopes: scopes}
+}
+
+func (h *APIKeyHandler) Index(c echo.Context) error {
+	apiKeys, err := h.apiKeyService.GetAllAPIKeys()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list the API keys")
+	}
+
+	return c.JSON(http.StatusOK, apiKeys)
+}
+
+func (h *APIKeyHandler) Create(c echo.Context) error {
+	var apiKey models.APIKey
+
+	err := c.Bind(&apiKey)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Failed to decode API key data")
+	}
+
+	if err = c.Validate(apiKey); err != nil {
+		return err
+	}
+	for _, scope := range apiKey.Scopes {
+		if !h.grantable(scope) {
+			return echo.NewHTTPError(http.StatusUnprocessableEntity, fmt.Sprintf("unknown scope %s, expected one of %s", scope, strings.Join(h.scopes, ", ")))
+		}
+	}
+
+	createdAPIKey, err := h.apiKeyService.CreateAPIKey(&models.APIKey{
+		Name:      apiKey.Name,
+		Scopes:    apiKey.Scopes,
+		ExpiresAt: apiKey.ExpiresAt,
+	})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create API key")
+	}
+
+	return c.JSON(http.StatusCreated, createdAPIKey)
+}
+
+func (h *APIKeyHandler) Rotate(c echo.Context) error {
+	id, err := apiKeyID(c)
+	if err != nil {
+		return err
+	}
+
+	apiKey, err := h.apiKeyService.RotateAPIKey(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "Failed to get API key")
+	}
+	if errors.Is(err, services.ErrInvalidAPIKey) {
+		return echo.NewHTTPError(http.StatusConflict, "API key is revoked or expired")
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to rotate API key")
+	}
+
+	return c.JSON(http.StatusOK, apiKey)
+}
+
+func (h *APIKeyHandler) Revoke(c echo.Context) error {
+	id, err := apiKeyID(c)
+	if err != nil {
+		return err
+	}
+
+	err = h.apiKeyService.RevokeAPIKey(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "Failed to get API key")
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to revoke API key")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func apiKeyID(c echo.Context) (int, error) {
+	idParam := c.Param("id")
+	if idParam == "" {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, "Missing API key ID")
+	}
+
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, "Invalid API key ID")
+	}
+
+	return id, nil
+}
+
+func (h *APIKeyHandler) grantable(scope string) bool {
+	for _, allowed := range h.scopes {
+		if allowed == scope {
+			return true
+		}
+	}
+	return false
+}
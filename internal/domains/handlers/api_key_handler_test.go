@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/services"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/mocks"
+	"github.com/go-playground/validator"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// apiKeyScopes stand in for the permissions of the server.
+var apiKeyScopes = []string{"products:read", "products:write", "products:delete", "products:purge", "products:restore"}
+
+func TestAPIKeyIndex(t *testing.T) {
+	t.Run("should returns 200", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/api-keys", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		mockAPIKeyService := &mocks.MockAPIKeyService{}
+		mockAPIKeyService.On("GetAllAPIKeys").Return(mocks.MockAPIKeys, nil)
+		apiKeyHandler := NewAPIKeyHandler(mockAPIKeyService, apiKeyScopes)
+
+		if assert.NoError(t, apiKeyHandler.Index(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.NotContains(t, rec.Body.String(), "hash")
+			mockAPIKeyService.AssertExpectations(t)
+		}
+	})
+
+	t.Run("should returns 500", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/api-keys", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		mockAPIKeyService := &mocks.MockAPIKeyService{}
+		mockAPIKeyService.On("GetAllAPIKeys").Return(nil, fmt.Errorf("some error"))
+		apiKeyHandler := NewAPIKeyHandler(mockAPIKeyService, apiKeyScopes)
+
+		err := apiKeyHandler.Index(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=500, message=Failed to list the API keys")
+	})
+}
+
+func TestAPIKeyCreate(t *testing.T) {
+	t.Run("should returns 201 with the secret", func(t *testing.T) {
+		e := echo.New()
+		e.Validator = &CustomValidator{validator: validator.New()}
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/api-keys", strings.NewReader(`{"name":"Pokédex partner","scopes":["products:read"]}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		created := *mocks.MockAPIKeys[0]
+		created.Key = "eul_a1b2c3d4e5f6_secret"
+		mockAPIKeyService := &mocks.MockAPIKeyService{}
+		mockAPIKeyService.On("CreateAPIKey", &models.APIKey{Name: "Pokédex partner", Scopes: models.Scopes{"products:read"}}).Return(&created, nil)
+		apiKeyHandler := NewAPIKeyHandler(mockAPIKeyService, apiKeyScopes)
+
+		if assert.NoError(t, apiKeyHandler.Create(c)) {
+			assert.Equal(t, http.StatusCreated, rec.Code)
+
+			var apiKey models.APIKey
+			json.Unmarshal(rec.Body.Bytes(), &apiKey)
+
+			assert.Equal(t, "eul_a1b2c3d4e5f6_secret", apiKey.Key)
+			mockAPIKeyService.AssertExpectations(t)
+		}
+	})
+
+	t.Run("should grant any permission of the server", func(t *testing.T) {
+		e := echo.New()
+		e.Validator = &CustomValidator{validator: validator.New()}
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/api-keys", strings.NewReader(`{"name":"Cleanup job","scopes":["products:purge","products:restore"]}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		mockAPIKeyService := &mocks.MockAPIKeyService{}
+		mockAPIKeyService.On("CreateAPIKey", &models.APIKey{Name: "Cleanup job", Scopes: models.Scopes{"products:purge", "products:restore"}}).Return(mocks.MockAPIKeys[0], nil)
+		apiKeyHandler := NewAPIKeyHandler(mockAPIKeyService, apiKeyScopes)
+
+		if assert.NoError(t, apiKeyHandler.Create(c)) {
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			mockAPIKeyService.AssertExpectations(t)
+		}
+	})
+
+	t.Run("should returns 422", func(t *testing.T) {
+		e := echo.New()
+		e.Validator = &CustomValidator{validator: validator.New()}
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/api-keys", strings.NewReader(`{"name":"Pokédex partner","scopes":["products:sell"]}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		mockAPIKeyService := &mocks.MockAPIKeyService{}
+		apiKeyHandler := NewAPIKeyHandler(mockAPIKeyService, apiKeyScopes)
+		err := apiKeyHandler.Create(c)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "code=422")
+		assert.Contains(t, err.Error(), "products:sell")
+		mockAPIKeyService.AssertNotCalled(t, "CreateAPIKey", mock.Anything)
+	})
+}
+
+func TestAPIKeyRotate(t *testing.T) {
+	t.Run("should returns 200", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/api-keys/:id/rotate", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		mockAPIKeyService := &mocks.MockAPIKeyService{}
+		mockAPIKeyService.On("RotateAPIKey", 1).Return(mocks.MockAPIKeys[0], nil)
+		apiKeyHandler := NewAPIKeyHandler(mockAPIKeyService, apiKeyScopes)
+
+		if assert.NoError(t, apiKeyHandler.Rotate(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			mockAPIKeyService.AssertExpectations(t)
+		}
+	})
+
+	t.Run("should returns 404", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/api-keys/:id/rotate", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		mockAPIKeyService := &mocks.MockAPIKeyService{}
+		mockAPIKeyService.On("RotateAPIKey", 1).Return(nil, gorm.ErrRecordNotFound)
+		apiKeyHandler := NewAPIKeyHandler(mockAPIKeyService, apiKeyScopes)
+
+		err := apiKeyHandler.Rotate(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=404, message=Failed to get API key")
+	})
+
+	t.Run("should returns 409", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/api-keys/:id/rotate", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		mockAPIKeyService := &mocks.MockAPIKeyService{}
+		mockAPIKeyService.On("RotateAPIKey", 1).Return(nil, services.ErrInvalidAPIKey)
+		apiKeyHandler := NewAPIKeyHandler(mockAPIKeyService, apiKeyScopes)
+
+		err := apiKeyHandler.Rotate(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=409, message=API key is revoked or expired")
+	})
+}
+
+func TestAPIKeyRevoke(t *testing.T) {
+	t.Run("should returns 204", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/api-keys/:id", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		mockAPIKeyService := &mocks.MockAPIKeyService{}
+		mockAPIKeyService.On("RevokeAPIKey", 1).Return(nil)
+		apiKeyHandler := NewAPIKeyHandler(mockAPIKeyService, apiKeyScopes)
+
+		if assert.NoError(t, apiKeyHandler.Revoke(c)) {
+			assert.Equal(t, http.StatusNoContent, rec.Code)
+			mockAPIKeyService.AssertExpectations(t)
+		}
+	})
+
+	t.Run("should returns 400", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/api-keys/:id", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("invalid_id")
+
+		apiKeyHandler := NewAPIKeyHandler(&mocks.MockAPIKeyService{}, apiKeyScopes)
+		err := apiKeyHandler.Revoke(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=400, message=Invalid API key ID")
+	})
+}
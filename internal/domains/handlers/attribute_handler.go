@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/core/interfaces"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/labstack/echo"
+	"gorm.io/gorm"
+)
+
+type AttributeHandler struct {
+	attributeService interfaces.AttributeServiceInterface
+}
+
+func NewAttributeHandler(attributeService interfaces.AttributeServiceInterface) *AttributeHandler {
+	return &AttributeHandler{attributeService: attributeService}
+}
+
+func (h *AttributeHandler) Index(c echo.Context) error {
+	definitions, err := h.attributeService.GetAllAttributes()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list the attributes")
+	}
+
+	return c.JSON(http.StatusOK, definitions)
+}
+
+func (h *AttributeHandler) Create(c echo.Context) error {
+	var definition models.AttributeDefinition
+
+	err := c.Bind(&definition)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Failed to decode attribute data")
+	}
+
+	if err = c.Validate(definition); err != nil {
+		return err
+	}
+
+	createdDefinition, err := h.attributeService.CreateAttribute(&definition)
+	if err != nil {
+		return writeAttributeError(err, "Failed to create attribute")
+	}
+
+	return c.JSON(http.StatusCreated, createdDefinition)
+}
+
+func (h *AttributeHandler) Show(c echo.Context) error {
+	definition, err := h.attributeService.GetAttributeByKey(c.Param("key"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Failed to get attribute")
+	}
+
+	return c.JSON(http.StatusOK, definition)
+}
+
+// Update replaces the name and the rules of an attribute. Its key and type
+// don't change.
+func (h *AttributeHandler) Update(c echo.Context) error {
+	var definition models.AttributeDefinition
+	err := c.Bind(&definition)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Failed to decode attribute data")
+	}
+
+	existing, err := h.attributeService.GetAttributeByKey(c.Param("key"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Failed to get attribute")
+	}
+
+	definition.Key = existing.Key
+	if definition.Type == "" {
+		definition.Type = existing.Type
+	}
+	if err = c.Validate(definition); err != nil {
+		return err
+	}
+
+	updatedDefinition, err := h.attributeService.UpdateAttribute(&definition)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "Failed to get attribute")
+	}
+	if err != nil {
+		return writeAttributeError(err, "Failed to update attribute")
+	}
+
+	return c.JSON(http.StatusOK, updatedDefinition)
+}
+
+// Delete removes an attribute and the values products have for it.
+func (h *AttributeHandler) Delete(c echo.Context) error {
+	err := h.attributeService.DeleteAttribute(c.Param("key"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "Failed to get attribute")
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to delete attribute")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// writeAttributeError maps the errors of the attribute service to responses,
+// with message for the unexpected ones.
+func writeAttributeError(err error, message string) error {
+	var conflict *models.ConflictError
+	if errors.As(err, &conflict) {
+		return echo.NewHTTPError(http.StatusConflict, conflict.Error())
+	}
+	if errors.Is(err, models.ErrInvalidAttribute) {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	}
+	return echo.NewHTTPError(http.StatusInternalServerError, message)
+}
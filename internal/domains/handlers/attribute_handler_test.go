@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/mocks"
+	"github.com/go-playground/validator"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func TestAttributeIndex(t *testing.T) {
+	t.Run("should returns 200", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/attributes", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		mockAttributeService := &mocks.MockAttributeService{}
+		mockAttributeService.On("GetAllAttributes").Return(mocks.MockAttributeDefinitions, nil)
+		attributeHandler := NewAttributeHandler(mockAttributeService)
+
+		if assert.NoError(t, attributeHandler.Index(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+
+			var definitions []models.AttributeDefinition
+			json.Unmarshal(rec.Body.Bytes(), &definitions)
+
+			assert.Len(t, definitions, 3)
+			assert.Equal(t, "kg", definitions[1].Unit)
+			assert.Equal(t, models.OptionValues{"Steel", "Wood"}, definitions[0].Values)
+			mockAttributeService.AssertExpectations(t)
+		}
+	})
+
+	t.Run("should returns 500", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/attributes", nil)
+		c := e.NewContext(req, httptest.NewRecorder())
+
+		mockAttributeService := &mocks.MockAttributeService{}
+		mockAttributeService.On("GetAllAttributes").Return(nil, fmt.Errorf("some error"))
+		attributeHandler := NewAttributeHandler(mockAttributeService)
+
+		err := attributeHandler.Index(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=500, message=Failed to list the attributes")
+	})
+}
+
+func TestAttributeCreate(t *testing.T) {
+	create := func(body string) (echo.Context, *httptest.ResponseRecorder) {
+		e := echo.New()
+		e.Validator = &CustomValidator{validator: validator.New()}
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/attributes", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		return e.NewContext(req, rec), rec
+	}
+
+	t.Run("should returns 201", func(t *testing.T) {
+		c, rec := create(`{"key":"voltage","name":"Voltage","type":"number","unit":"V","min":0}`)
+
+		mockAttributeService := &mocks.MockAttributeService{}
+		mockAttributeService.On("CreateAttribute", mock.MatchedBy(func(d *models.AttributeDefinition) bool {
+			return d.Key == "voltage" && d.Type == models.AttributeNumber && d.Unit == "V" && *d.Min == 0
+		})).Return(&models.AttributeDefinition{Key: "voltage", Name: "Voltage", Type: models.AttributeNumber, Unit: "V"}, nil)
+		attributeHandler := NewAttributeHandler(mockAttributeService)
+
+		if assert.NoError(t, attributeHandler.Create(c)) {
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			mockAttributeService.AssertExpectations(t)
+		}
+	})
+
+	t.Run("should returns 422 for an unknown type", func(t *testing.T) {
+		c, _ := create(`{"key":"voltage","name":"Voltage","type":"date"}`)
+
+		attributeHandler := NewAttributeHandler(&mocks.MockAttributeService{})
+
+		err := attributeHandler.Create(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, err.(*echo.HTTPError).Code)
+	})
+
+	t.Run("should returns 409 for a taken key", func(t *testing.T) {
+		c, _ := create(`{"key":"weight","name":"Weight","type":"number"}`)
+
+		mockAttributeService := &mocks.MockAttributeService{}
+		mockAttributeService.On("CreateAttribute", mock.Anything).Return(nil, &models.ConflictError{Field: "key", Value: "weight"})
+		attributeHandler := NewAttributeHandler(mockAttributeService)
+
+		err := attributeHandler.Create(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), `code=409, message=key "weight" is already taken`)
+	})
+
+	t.Run("should returns 422 for invalid rules", func(t *testing.T) {
+		c, _ := create(`{"key":"material","name":"Material","type":"enum"}`)
+
+		mockAttributeService := &mocks.MockAttributeService{}
+		mockAttributeService.On("CreateAttribute", mock.Anything).Return(nil, fmt.Errorf("%w: enum attributes need values", models.ErrInvalidAttribute))
+		attributeHandler := NewAttributeHandler(mockAttributeService)
+
+		err := attributeHandler.Create(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=422, message=invalid attribute: enum attributes need values")
+	})
+}
+
+func TestAttributeShow(t *testing.T) {
+	t.Run("should returns 404", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/attributes/:key", nil)
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("key")
+		c.SetParamValues("voltage")
+
+		mockAttributeService := &mocks.MockAttributeService{}
+		mockAttributeService.On("GetAttributeByKey", "voltage").Return(nil, gorm.ErrRecordNotFound)
+		attributeHandler := NewAttributeHandler(mockAttributeService)
+
+		err := attributeHandler.Show(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=404, message=Failed to get attribute")
+	})
+}
+
+func TestAttributeUpdate(t *testing.T) {
+	t.Run("should returns 200 keeping the key and the type", func(t *testing.T) {
+		e := echo.New()
+		e.Validator = &CustomValidator{validator: validator.New()}
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/attributes/:key", strings.NewReader(`{"key":"mass","name":"Net weight","unit":"g"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("key")
+		c.SetParamValues("weight")
+
+		updated := &models.AttributeDefinition{Key: "weight", Name: "Net weight", Type: models.AttributeNumber, Unit: "g"}
+		mockAttributeService := &mocks.MockAttributeService{}
+		mockAttributeService.On("GetAttributeByKey", "weight").Return(mocks.MockAttributeDefinitions[1], nil)
+		mockAttributeService.On("UpdateAttribute", updated).Return(updated, nil)
+		attributeHandler := NewAttributeHandler(mockAttributeService)
+
+		if assert.NoError(t, attributeHandler.Update(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			mockAttributeService.AssertExpectations(t)
+		}
+	})
+
+	t.Run("should returns 404", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/attributes/:key", strings.NewReader(`{"name":"Voltage"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("key")
+		c.SetParamValues("voltage")
+
+		mockAttributeService := &mocks.MockAttributeService{}
+		mockAttributeService.On("GetAttributeByKey", "voltage").Return(nil, gorm.ErrRecordNotFound)
+		attributeHandler := NewAttributeHandler(mockAttributeService)
+
+		err := attributeHandler.Update(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=404, message=Failed to get attribute")
+	})
+}
+
+func TestAttributeDelete(t *testing.T) {
+	deleteAttribute := func(key string) (echo.Context, *httptest.ResponseRecorder) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/attributes/:key", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("key")
+		c.SetParamValues(key)
+		return c, rec
+	}
+
+	t.Run("should returns 204", func(t *testing.T) {
+		c, rec := deleteAttribute("weight")
+
+		mockAttributeService := &mocks.MockAttributeService{}
+		mockAttributeService.On("DeleteAttribute", "weight").Return(nil)
+		attributeHandler := NewAttributeHandler(mockAttributeService)
+
+		if assert.NoError(t, attributeHandler.Delete(c)) {
+			assert.Equal(t, http.StatusNoContent, rec.Code)
+			mockAttributeService.AssertExpectations(t)
+		}
+	})
+
+	t.Run("should returns 404", func(t *testing.T) {
+		c, _ := deleteAttribute("voltage")
+
+		mockAttributeService := &mocks.MockAttributeService{}
+		mockAttributeService.On("DeleteAttribute", "voltage").Return(gorm.ErrRecordNotFound)
+		attributeHandler := NewAttributeHandler(mockAttributeService)
+
+		err := attributeHandler.Delete(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=404, message=Failed to get attribute")
+	})
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/core/interfaces"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/services"
+	"github.com/labstack/echo"
+	"gorm.io/gorm"
+)
+
+type CategoryHandler struct {
+	categoryService interfaces.CategoryServiceInterface
+}
+
+func NewCategoryHandler(categoryService interfaces.CategoryServiceInterface) *CategoryHandler {
+	return &CategoryHandler{categoryService: categoryService}
+}
+
+func (h *CategoryHandler) Index(c echo.Context) error {
+	categories, err := h.categoryService.GetAllCategories()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list the categories")
+	}
+
+	return c.JSON(http.StatusOK, categories)
+}
+
+func (h *CategoryHandler) Create(c echo.Context) error {
+	var category models.Category
+
+	err := c.Bind(&category)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Failed to decode category data")
+	}
+
+	if err = c.Validate(category); err != nil {
+		return err
+	}
+
+	createdCategory, err := h.categoryService.CreateCategory(&models.Category{Name: category.Name, ParentID: category.ParentID})
+	if errors.Is(err, services.ErrUnknownCategory) {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, "Parent category not found")
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create category")
+	}
+
+	return c.JSON(http.StatusCreated, createdCategory)
+}
+
+func (h *CategoryHandler) Show(c echo.Context) error {
+	id, err := categoryID(c)
+	if err != nil {
+		return err
+	}
+
+	category, err := h.categoryService.GetCategoryByID(id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Failed to get category")
+	}
+
+	return c.JSON(http.StatusOK, category)
+}
+
+// Update renames a category. It is moved with Move.
+func (h *CategoryHandler) Update(c echo.Context) error {
+	id, err := categoryID(c)
+	if err != nil {
+		return err
+	}
+
+	var updateCategory models.Category
+	err = c.Bind(&updateCategory)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Failed to decode category data")
+	}
+
+	category, err := h.categoryService.GetCategoryByID(id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Failed to get category")
+	}
+
+	if updateCategory.Name != "" {
+		category.Name = updateCategory.Name
+	}
+
+	updatedCategory, err := h.categoryService.UpdateCategory(category)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update category")
+	}
+
+	return c.JSON(http.StatusOK, updatedCategory)
+}
+
+func (h *CategoryHandler) Move(c echo.Context) error {
+	id, err := categoryID(c)
+	if err != nil {
+		return err
+	}
+
+	var move models.CategoryMove
+	err = c.Bind(&move)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Failed to decode category data")
+	}
+
+	category, err := h.categoryService.MoveCategory(id, move.ParentID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "Failed to get category")
+	}
+	if errors.Is(err, services.ErrUnknownCategory) {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, "Parent category not found")
+	}
+	if errors.Is(err, models.ErrCategoryCycle) {
+		return echo.NewHTTPError(http.StatusConflict, "A category cannot be moved under itself or its descendants")
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to move category")
+	}
+
+	return c.JSON(http.StatusOK, category)
+}
+
+func (h *CategoryHandler) Delete(c echo.Context) error {
+	id, err := categoryID(c)
+	if err != nil {
+		return err
+	}
+
+	err = h.categoryService.DeleteCategory(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "Failed to get category")
+	}
+	if errors.Is(err, models.ErrCategoryHasChildren) {
+		return echo.NewHTTPError(http.StatusConflict, "Category has subcategories")
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to delete category")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func categoryID(c echo.Context) (int, error) {
+	idParam := c.Param("id")
+	if idParam == "" {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, "Missing category ID")
+	}
+
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, "Invalid category ID")
+	}
+
+	return id, nil
+}
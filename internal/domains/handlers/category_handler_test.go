@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/services"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/mocks"
+	"github.com/go-playground/validator"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestCategoryIndex(t *testing.T) {
+	t.Run("should returns 200", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/categories", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		mockCategoryService := &mocks.MockCategoryService{}
+		mockCategoryService.On("GetAllCategories").Return(mocks.MockCategories, nil)
+		categoryHandler := NewCategoryHandler(mockCategoryService)
+
+		if assert.NoError(t, categoryHandler.Index(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+
+			var categories []models.Category
+			json.Unmarshal(rec.Body.Bytes(), &categories)
+
+			assert.Len(t, categories, 2)
+			assert.Equal(t, "/1/2/", categories[1].Path)
+			assert.Equal(t, uint(1), *categories[1].ParentID)
+			mockCategoryService.AssertExpectations(t)
+		}
+	})
+
+	t.Run("should returns 500", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/categories", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		mockCategoryService := &mocks.MockCategoryService{}
+		mockCategoryService.On("GetAllCategories").Return(nil, fmt.Errorf("some error"))
+		categoryHandler := NewCategoryHandler(mockCategoryService)
+
+		err := categoryHandler.Index(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=500, message=Failed to list the categories")
+	})
+}
+
+func TestCategoryCreate(t *testing.T) {
+	t.Run("should returns 201", func(t *testing.T) {
+		e := echo.New()
+		e.Validator = &CustomValidator{validator: validator.New()}
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/categories", strings.NewReader(`{"name":"Plush","parent_id":1,"path":"/9/"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		parentID := uint(1)
+		mockCategoryService := &mocks.MockCategoryService{}
+		mockCategoryService.On("CreateCategory", &models.Category{Name: "Plush", ParentID: &parentID}).Return(mocks.MockCategories[1], nil)
+		categoryHandler := NewCategoryHandler(mockCategoryService)
+
+		if assert.NoError(t, categoryHandler.Create(c)) {
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			mockCategoryService.AssertExpectations(t)
+		}
+	})
+
+	t.Run("should returns 422 without a name", func(t *testing.T) {
+		e := echo.New()
+		e.Validator = &CustomValidator{validator: validator.New()}
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/categories", strings.NewReader(`{"parent_id":1}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		categoryHandler := NewCategoryHandler(&mocks.MockCategoryService{})
+
+		err := categoryHandler.Create(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, err.(*echo.HTTPError).Code)
+	})
+
+	t.Run("should returns 422 for an unknown parent", func(t *testing.T) {
+		e := echo.New()
+		e.Validator = &CustomValidator{validator: validator.New()}
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/categories", strings.NewReader(`{"name":"Plush","parent_id":42}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		parentID := uint(42)
+		mockCategoryService := &mocks.MockCategoryService{}
+		mockCategoryService.On("CreateCategory", &models.Category{Name: "Plush", ParentID: &parentID}).Return(nil, services.ErrUnknownCategory)
+		categoryHandler := NewCategoryHandler(mockCategoryService)
+
+		err := categoryHandler.Create(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=422, message=Parent category not found")
+	})
+}
+
+func TestCategoryMove(t *testing.T) {
+	move := func(body string) echo.Context {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/categories/:id/move", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+		return c
+	}
+
+	t.Run("should returns 200", func(t *testing.T) {
+		c := move(`{"parent_id":null}`)
+
+		mockCategoryService := &mocks.MockCategoryService{}
+		mockCategoryService.On("MoveCategory", 1, (*uint)(nil)).Return(mocks.MockCategories[0], nil)
+		categoryHandler := NewCategoryHandler(mockCategoryService)
+
+		if assert.NoError(t, categoryHandler.Move(c)) {
+			assert.Equal(t, http.StatusOK, c.Response().Status)
+			mockCategoryService.AssertExpectations(t)
+		}
+	})
+
+	t.Run("should returns 409 for a cycle", func(t *testing.T) {
+		c := move(`{"parent_id":2}`)
+
+		parentID := uint(2)
+		mockCategoryService := &mocks.MockCategoryService{}
+		mockCategoryService.On("MoveCategory", 1, &parentID).Return(nil, models.ErrCategoryCycle)
+		categoryHandler := NewCategoryHandler(mockCategoryService)
+
+		err := categoryHandler.Move(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusConflict, err.(*echo.HTTPError).Code)
+	})
+
+	t.Run("should returns 404", func(t *testing.T) {
+		c := move(`{"parent_id":null}`)
+
+		mockCategoryService := &mocks.MockCategoryService{}
+		mockCategoryService.On("MoveCategory", 1, (*uint)(nil)).Return(nil, gorm.ErrRecordNotFound)
+		categoryHandler := NewCategoryHandler(mockCategoryService)
+
+		err := categoryHandler.Move(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=404, message=Failed to get category")
+	})
+}
+
+func TestCategoryDelete(t *testing.T) {
+	t.Run("should returns 204", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/categories/:id", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("2")
+
+		mockCategoryService := &mocks.MockCategoryService{}
+		mockCategoryService.On("DeleteCategory", 2).Return(nil)
+		categoryHandler := NewCategoryHandler(mockCategoryService)
+
+		if assert.NoError(t, categoryHandler.Delete(c)) {
+			assert.Equal(t, http.StatusNoContent, rec.Code)
+			mockCategoryService.AssertExpectations(t)
+		}
+	})
+
+	t.Run("should returns 409 with subcategories", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/categories/:id", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		mockCategoryService := &mocks.MockCategoryService{}
+		mockCategoryService.On("DeleteCategory", 1).Return(models.ErrCategoryHasChildren)
+		categoryHandler := NewCategoryHandler(mockCategoryService)
+
+		err := categoryHandler.Delete(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=409, message=Category has subcategories")
+	})
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/core/interfaces"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/labstack/echo"
+	"gorm.io/gorm"
+)
+
+type InventoryHandler struct {
+	inventoryService interfaces.InventoryServiceInterface
+	visibility       *ProductVisibility
+}
+
+// NewInventoryHandler returns a handler that only shows the stock of the
+// products visible to the caller.
+func NewInventoryHandler(inventoryService interfaces.InventoryServiceInterface, visibility *ProductVisibility) *InventoryHandler {
+	return &InventoryHandler{inventoryService: inventoryService, visibility: visibility}
+}
+
+// ShowStock returns the stock of a product, in total and per warehouse.
+func (h *InventoryHandler) ShowStock(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid product ID")
+	}
+	if err = h.visibility.check(c, id); err != nil {
+		return err
+	}
+
+	stock, err := h.inventoryService.GetStock(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "Failed to get product")
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get the stock")
+	}
+
+	return c.JSON(http.StatusOK, stock)
+}
+
+// Adjust changes the stock on hand of a product in a warehouse.
+func (h *InventoryHandler) Adjust(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid product ID")
+	}
+
+	var adjustment models.StockAdjustment
+	err = c.Bind(&adjustment)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Failed to decode adjustment")
+	}
+	if err = c.Validate(adjustment); err != nil {
+		return err
+	}
+
+	level, err := h.inventoryService.AdjustStock(id, adjustment)
+	if err != nil {
+		return stockError(err, "Failed to adjust the stock")
+	}
+
+	return c.JSON(http.StatusOK, level)
+}
+
+// Reserve holds stock of a product until the reservation is committed,
+// released or expires.
+func (h *InventoryHandler) Reserve(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid product ID")
+	}
+
+	var request models.ReservationRequest
+	err = c.Bind(&request)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Failed to decode reservation")
+	}
+	if err = c.Validate(request); err != nil {
+		return err
+	}
+
+	reservation, err := h.inventoryService.Reserve(id, request)
+	if err != nil {
+		return stockError(err, "Failed to reserve the stock")
+	}
+
+	return c.JSON(http.StatusCreated, reservation)
+}
+
+func (h *InventoryHandler) ShowReservation(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid reservation ID")
+	}
+
+	reservation, err := h.inventoryService.GetReservation(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "Failed to get reservation")
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get reservation")
+	}
+
+	return c.JSON(http.StatusOK, reservation)
+}
+
+// CommitReservation records the reserved stock as sold.
+func (h *InventoryHandler) CommitReservation(c echo.Context) error {
+	return h.close(c, h.inventoryService.CommitReservation, "Failed to commit the reservation")
+}
+
+// ReleaseReservation gives the reserved stock back.
+func (h *InventoryHandler) ReleaseReservation(c echo.Context) error {
+	return h.close(c, h.inventoryService.ReleaseReservation, "Failed to release the reservation")
+}
+
+func (h *InventoryHandler) close(c echo.Context, close func(id int) (*models.Reservation, error), message string) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid reservation ID")
+	}
+
+	reservation, err := close(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "Failed to get reservation")
+	}
+	if errors.Is(err, models.ErrReservationClosed) {
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, message)
+	}
+
+	return c.JSON(http.StatusOK, reservation)
+}
+
+// stockError maps the errors of a stock change to their status, or to a 500
+// with message.
+func stockError(err error, message string) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "Failed to get product")
+	}
+	if errors.Is(err, models.ErrInvalidWarehouse) || errors.Is(err, models.ErrInvalidExpiry) || errors.Is(err, models.ErrUnknownVariant) {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	}
+	if errors.Is(err, models.ErrInsufficientStock) {
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	}
+	return echo.NewHTTPError(http.StatusInternalServerError, message)
+}
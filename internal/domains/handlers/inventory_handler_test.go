@@ -0,0 +1,295 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/repositories"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/services"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/mocks"
+	"github.com/adrianosiqe/eulabs-challenge-api/pkg/money"
+	"github.com/go-playground/validator"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func TestShowStock(t *testing.T) {
+	t.Run("should returns 200 with the stock", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/products/:id/stock", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		stock := models.NewStock(1, []models.StockLevel{{Warehouse: models.DefaultWarehouse, OnHand: 5, Reserved: 2, Available: 3}})
+		mockInventoryService := &mocks.MockInventoryService{}
+		mockInventoryService.On("GetStock", 1).Return(stock, nil)
+		inventoryHandler := NewInventoryHandler(mockInventoryService, everyProduct)
+
+		if assert.NoError(t, inventoryHandler.ShowStock(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+
+			var body models.Stock
+			json.Unmarshal(rec.Body.Bytes(), &body)
+			assert.Equal(t, int64(3), body.Available)
+			assert.Len(t, body.Warehouses, 1)
+		}
+	})
+
+	t.Run("should returns 404", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/products/:id/stock", nil)
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("id")
+		c.SetParamValues("42")
+
+		mockInventoryService := &mocks.MockInventoryService{}
+		mockInventoryService.On("GetStock", 42).Return(nil, gorm.ErrRecordNotFound)
+		inventoryHandler := NewInventoryHandler(mockInventoryService, everyProduct)
+
+		err := inventoryHandler.ShowStock(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=404, message=Failed to get product")
+	})
+
+	t.Run("should returns 404 to viewers for an archived product", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/products/:id/stock", nil)
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("id")
+		c.SetParamValues("3")
+
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("GetProductByID", 3).Return(&models.Product{ID: 3, Status: models.ProductArchived}, nil)
+		mockInventoryService := &mocks.MockInventoryService{}
+		inventoryHandler := NewInventoryHandler(mockInventoryService, NewProductVisibility(mockProductService, asViewer))
+
+		err := inventoryHandler.ShowStock(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=404, message=Failed to get product")
+		mockInventoryService.AssertNotCalled(t, "GetStock", mock.Anything)
+	})
+}
+
+func TestAdjust(t *testing.T) {
+	t.Run("should returns 200 with the adjusted level", func(t *testing.T) {
+		e := echo.New()
+		e.Validator = &CustomValidator{validator: validator.New()}
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/products/:id/stock/adjustments", strings.NewReader(`{"delta": 5, "reason": "received"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		level := &models.StockLevel{Warehouse: models.DefaultWarehouse, OnHand: 5, Available: 5}
+		mockInventoryService := &mocks.MockInventoryService{}
+		mockInventoryService.On("AdjustStock", 1, models.StockAdjustment{Delta: 5, Reason: models.StockReceived}).Return(level, nil)
+		inventoryHandler := NewInventoryHandler(mockInventoryService, everyProduct)
+
+		if assert.NoError(t, inventoryHandler.Adjust(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			mockInventoryService.AssertExpectations(t)
+		}
+	})
+
+	t.Run("should returns 422 for an unknown reason", func(t *testing.T) {
+		e := echo.New()
+		e.Validator = &CustomValidator{validator: validator.New()}
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/products/:id/stock/adjustments", strings.NewReader(`{"delta": 5, "reason": "found"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		mockInventoryService := &mocks.MockInventoryService{}
+		inventoryHandler := NewInventoryHandler(mockInventoryService, everyProduct)
+
+		err := inventoryHandler.Adjust(c)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "code=422")
+		mockInventoryService.AssertNotCalled(t, "AdjustStock", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should returns 409 when the stock is reserved", func(t *testing.T) {
+		e := echo.New()
+		e.Validator = &CustomValidator{validator: validator.New()}
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/products/:id/stock/adjustments", strings.NewReader(`{"delta": -5, "reason": "damaged"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		mockInventoryService := &mocks.MockInventoryService{}
+		mockInventoryService.On("AdjustStock", 1, models.StockAdjustment{Delta: -5, Reason: models.StockDamaged}).
+			Return(nil, fmt.Errorf("%w: 5 on hand and 2 reserved in default", models.ErrInsufficientStock))
+		inventoryHandler := NewInventoryHandler(mockInventoryService, everyProduct)
+
+		err := inventoryHandler.Adjust(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=409, message=insufficient stock: 5 on hand and 2 reserved in default")
+	})
+}
+
+func TestReserve(t *testing.T) {
+	t.Run("should returns 201 with the reservation", func(t *testing.T) {
+		e := echo.New()
+		e.Validator = &CustomValidator{validator: validator.New()}
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/products/:id/reservations", strings.NewReader(`{"quantity": 2}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		mockInventoryService := &mocks.MockInventoryService{}
+		mockInventoryService.On("Reserve", 1, models.ReservationRequest{Quantity: 2}).Return(mocks.MockReservation, nil)
+		inventoryHandler := NewInventoryHandler(mockInventoryService, everyProduct)
+
+		if assert.NoError(t, inventoryHandler.Reserve(c)) {
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			mockInventoryService.AssertExpectations(t)
+		}
+	})
+
+	t.Run("should returns 201 and hold the stock of a variant", func(t *testing.T) {
+		products := repositories.NewMemoryProductRepository()
+		product, _ := products.Create(&models.Product{Title: "T-shirt", Description: "Cotton", Price: money.MustParseAmount("10"), Currency: money.DefaultCurrency})
+		products.SetOptions(int(product.ID), []models.ProductOption{{Name: "Size", Values: models.OptionValues{"S", "M"}}})
+		variant, _ := repositories.NewMemoryVariantRepository(products).Create(&models.ProductVariant{ProductID: product.ID, Options: models.VariantOptions{"Size": "M"}})
+		inventoryService := services.NewInventoryService(repositories.NewMemoryInventoryRepository(products), services.InventoryOptions{ReservationTTL: time.Minute})
+		inventoryService.AdjustStock(int(product.ID), models.StockAdjustment{VariantID: variant.ID, Delta: 5, Reason: models.StockReceived})
+
+		e := echo.New()
+		e.Validator = &CustomValidator{validator: validator.New()}
+		body := fmt.Sprintf(`{"variant_id": %d, "quantity": 2}`, variant.ID)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/products/:id/reservations", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(fmt.Sprint(product.ID))
+
+		if assert.NoError(t, NewInventoryHandler(inventoryService, everyProduct).Reserve(c)) {
+			assert.Equal(t, http.StatusCreated, rec.Code)
+
+			stock, _ := inventoryService.GetStock(int(product.ID))
+			if assert.Len(t, stock.Warehouses, 1) {
+				assert.Equal(t, variant.ID, stock.Warehouses[0].VariantID)
+				assert.Equal(t, int64(5), stock.Warehouses[0].OnHand)
+				assert.Equal(t, int64(3), stock.Warehouses[0].Available)
+			}
+		}
+	})
+
+	t.Run("should returns 409 when not enough is available", func(t *testing.T) {
+		e := echo.New()
+		e.Validator = &CustomValidator{validator: validator.New()}
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/products/:id/reservations", strings.NewReader(`{"quantity": 20}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		mockInventoryService := &mocks.MockInventoryService{}
+		mockInventoryService.On("Reserve", 1, models.ReservationRequest{Quantity: 20}).
+			Return(nil, fmt.Errorf("%w: 3 available in default", models.ErrInsufficientStock))
+		inventoryHandler := NewInventoryHandler(mockInventoryService, everyProduct)
+
+		err := inventoryHandler.Reserve(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=409, message=insufficient stock: 3 available in default")
+	})
+
+	t.Run("should returns 422 for a reservation lasting too long", func(t *testing.T) {
+		e := echo.New()
+		e.Validator = &CustomValidator{validator: validator.New()}
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/products/:id/reservations", strings.NewReader(`{"quantity": 2, "expires_in": 999999}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		mockInventoryService := &mocks.MockInventoryService{}
+		mockInventoryService.On("Reserve", 1, models.ReservationRequest{Quantity: 2, ExpiresIn: 999999}).
+			Return(nil, fmt.Errorf("%w: reservations last at most 24h0m0s", models.ErrInvalidExpiry))
+		inventoryHandler := NewInventoryHandler(mockInventoryService, everyProduct)
+
+		err := inventoryHandler.Reserve(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=422, message=invalid reservation expiry: reservations last at most 24h0m0s")
+	})
+}
+
+func TestShowReservation(t *testing.T) {
+	t.Run("should returns 404", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/reservations/:id", nil)
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("id")
+		c.SetParamValues("42")
+
+		mockInventoryService := &mocks.MockInventoryService{}
+		mockInventoryService.On("GetReservation", 42).Return(nil, gorm.ErrRecordNotFound)
+		inventoryHandler := NewInventoryHandler(mockInventoryService, everyProduct)
+
+		err := inventoryHandler.ShowReservation(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=404, message=Failed to get reservation")
+	})
+}
+
+func TestCloseReservation(t *testing.T) {
+	t.Run("should returns 200 with the committed reservation", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/reservations/:id/commit", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		committed := *mocks.MockReservation
+		committed.Status = models.ReservationCommitted
+		mockInventoryService := &mocks.MockInventoryService{}
+		mockInventoryService.On("CommitReservation", 1).Return(&committed, nil)
+		inventoryHandler := NewInventoryHandler(mockInventoryService, everyProduct)
+
+		if assert.NoError(t, inventoryHandler.CommitReservation(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Contains(t, rec.Body.String(), `"status":"committed"`)
+		}
+	})
+
+	t.Run("should returns 409 for a closed reservation", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/reservations/:id", nil)
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		mockInventoryService := &mocks.MockInventoryService{}
+		mockInventoryService.On("ReleaseReservation", 1).Return(nil, fmt.Errorf("%w: it is committed", models.ErrReservationClosed))
+		inventoryHandler := NewInventoryHandler(mockInventoryService, everyProduct)
+
+		err := inventoryHandler.ReleaseReservation(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=409, message=reservation is not active: it is committed")
+	})
+}
@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"strconv"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/core/interfaces"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/labstack/echo"
+	"gorm.io/gorm"
+)
+
+// multipartOverhead is how much larger than the file itself an upload can
+// be, for the boundaries and the headers of the multipart form.
+const multipartOverhead = 1 << 20
+
+type MediaHandler struct {
+	mediaService interfaces.MediaServiceInterface
+	visibility   *ProductVisibility
+	maxSize      int64
+}
+
+// NewMediaHandler returns a handler that only shows the media of the products
+// visible to the caller, and refuses uploads of more than maxSize bytes before
+// reading them whole.
+func NewMediaHandler(mediaService interfaces.MediaServiceInterface, visibility *ProductVisibility, maxSize int64) *MediaHandler {
+	return &MediaHandler{mediaService: mediaService, visibility: visibility, maxSize: maxSize}
+}
+
+// Index lists the media of a product by position.
+func (h *MediaHandler) Index(c echo.Context) error {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid product ID")
+	}
+	if err = h.visibility.check(c, productID); err != nil {
+		return err
+	}
+
+	media, err := h.mediaService.GetAllMedia(productID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "Failed to get product")
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list the media")
+	}
+
+	return c.JSON(http.StatusOK, withURLs(media...))
+}
+
+// Upload adds the image in the file field of a multipart form to the media of
+// a product.
+func (h *MediaHandler) Upload(c echo.Context) error {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid product ID")
+	}
+
+	request := c.Request()
+	request.Body = http.MaxBytesReader(c.Response(), request.Body, h.maxSize+multipartOverhead)
+	file, err := c.FormFile("file")
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) || err == nil && file.Size > h.maxSize {
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge, fmt.Sprintf("Files can have up to %d bytes", h.maxSize))
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Failed to read the file of the multipart form")
+	}
+
+	content, err := file.Open()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to read the uploaded file")
+	}
+	defer content.Close()
+
+	media, err := h.mediaService.UploadMedia(productID, file.Filename, content)
+	if err != nil {
+		return mediaError(err, "Failed to upload media")
+	}
+
+	return c.JSON(http.StatusCreated, withURLs(media)[0])
+}
+
+func (h *MediaHandler) Show(c echo.Context) error {
+	productID, id, err := mediaIDs(c)
+	if err != nil {
+		return err
+	}
+	if err = h.visibility.check(c, productID); err != nil {
+		return err
+	}
+
+	media, err := h.mediaService.GetMedia(productID, id)
+	if err != nil {
+		return mediaError(err, "Failed to get media")
+	}
+
+	return c.JSON(http.StatusOK, withURLs(media)[0])
+}
+
+// File serves the image of a media.
+func (h *MediaHandler) File(c echo.Context) error {
+	return h.serve(c, false)
+}
+
+// Thumbnail serves the thumbnail of the image of a media.
+func (h *MediaHandler) Thumbnail(c echo.Context) error {
+	return h.serve(c, true)
+}
+
+func (h *MediaHandler) serve(c echo.Context, thumbnail bool) error {
+	productID, id, err := mediaIDs(c)
+	if err != nil {
+		return err
+	}
+	if err = h.visibility.check(c, productID); err != nil {
+		return err
+	}
+
+	media, file, err := h.mediaService.OpenMedia(productID, id, thumbnail)
+	if err != nil {
+		return mediaError(err, "Failed to read media")
+	}
+	defer file.Close()
+
+	contentType := media.ContentType
+	if thumbnail {
+		contentType = media.ThumbnailType()
+	}
+	// The file of a media never changes, another image is another media.
+	c.Response().Header().Set("Cache-Control", "private, max-age=86400")
+	return c.Stream(http.StatusOK, contentType, file)
+}
+
+// SetPrimary makes a media the primary image of its product, and lists the
+// media of the product.
+func (h *MediaHandler) SetPrimary(c echo.Context) error {
+	productID, id, err := mediaIDs(c)
+	if err != nil {
+		return err
+	}
+
+	media, err := h.mediaService.SetPrimaryMedia(productID, id)
+	if err != nil {
+		return mediaError(err, "Failed to set the primary media")
+	}
+
+	return c.JSON(http.StatusOK, withURLs(media...))
+}
+
+// Reorder puts the media of a product in the order of the ids in the body,
+// and lists them.
+func (h *MediaHandler) Reorder(c echo.Context) error {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid product ID")
+	}
+
+	var order models.MediaOrder
+	err = c.Bind(&order)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Failed to decode media order")
+	}
+
+	if err = c.Validate(order); err != nil {
+		return err
+	}
+
+	media, err := h.mediaService.ReorderMedia(productID, order.IDs)
+	if err != nil {
+		return mediaError(err, "Failed to reorder media")
+	}
+
+	return c.JSON(http.StatusOK, withURLs(media...))
+}
+
+func (h *MediaHandler) Delete(c echo.Context) error {
+	productID, id, err := mediaIDs(c)
+	if err != nil {
+		return err
+	}
+
+	err = h.mediaService.DeleteMedia(productID, id)
+	if err != nil {
+		return mediaError(err, "Failed to delete media")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// withURLs sets the URLs the image and the thumbnail of each media are served
+// at.
+func withURLs(media ...*models.ProductMedia) []*models.ProductMedia {
+	for _, m := range media {
+		m.URL = fmt.Sprintf("/api/v1/products/%d/media/%d/file", m.ProductID, m.ID)
+		m.ThumbnailURL = fmt.Sprintf("/api/v1/products/%d/media/%d/thumbnail", m.ProductID, m.ID)
+	}
+	return media
+}
+
+// mediaIDs parses the ids of the product and of the media in the path.
+func mediaIDs(c echo.Context) (int, int, error) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return 0, 0, echo.NewHTTPError(http.StatusBadRequest, "Invalid product ID")
+	}
+	id, err := strconv.Atoi(c.Param("media_id"))
+	if err != nil {
+		return 0, 0, echo.NewHTTPError(http.StatusBadRequest, "Invalid media ID")
+	}
+	return productID, id, nil
+}
+
+// mediaError maps the errors of the media to their status, or to a 500 with
+// message.
+func mediaError(err error, message string) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound), errors.Is(err, fs.ErrNotExist):
+		return echo.NewHTTPError(http.StatusNotFound, "Failed to get media")
+	case errors.Is(err, models.ErrUnsupportedMedia):
+		return echo.NewHTTPError(http.StatusUnsupportedMediaType, err.Error())
+	case errors.Is(err, models.ErrMediaTooLarge):
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, models.ErrInvalidMediaOrder):
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	}
+	return echo.NewHTTPError(http.StatusInternalServerError, message)
+}
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/mocks"
+	"github.com/go-playground/validator"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// uploadRequest returns a multipart request with content in its file field.
+func uploadRequest(t *testing.T, field, filename string, content []byte) *http.Request {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile(field, filename)
+	require.NoError(t, err)
+	_, err = part.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/products/:id/media", &body)
+	req.Header.Set(echo.HeaderContentType, writer.FormDataContentType())
+	return req
+}
+
+func TestUploadMedia(t *testing.T) {
+	t.Run("should returns 201 with the media and its urls", func(t *testing.T) {
+		e := echo.New()
+		rec := httptest.NewRecorder()
+		c := e.NewContext(uploadRequest(t, "file", "front.jpg", []byte("image")), rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		mockMediaService := &mocks.MockMediaService{}
+		mockMediaService.On("UploadMedia", 1, "front.jpg", mock.Anything).Return(mocks.MockMedia, nil)
+		mediaHandler := NewMediaHandler(mockMediaService, everyProduct, 1024)
+
+		if assert.NoError(t, mediaHandler.Upload(c)) {
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			var media map[string]interface{}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &media))
+			assert.Equal(t, "/api/v1/products/1/media/1/file", media["url"])
+			assert.Equal(t, "/api/v1/products/1/media/1/thumbnail", media["thumbnail_url"])
+			assert.NotContains(t, media, "file_key")
+			content, _ := io.ReadAll(mockMediaService.Calls[0].Arguments.Get(2).(io.Reader))
+			assert.Equal(t, "image", string(content))
+		}
+	})
+
+	t.Run("should returns 413 for a file over the limit", func(t *testing.T) {
+		e := echo.New()
+		c := e.NewContext(uploadRequest(t, "file", "front.jpg", bytes.Repeat([]byte("a"), 2048)), httptest.NewRecorder())
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		mockMediaService := &mocks.MockMediaService{}
+		mediaHandler := NewMediaHandler(mockMediaService, everyProduct, 1024)
+
+		err := mediaHandler.Upload(c)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "code=413")
+		mockMediaService.AssertNotCalled(t, "UploadMedia", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("should returns 413 for a body over the limit", func(t *testing.T) {
+		e := echo.New()
+		c := e.NewContext(uploadRequest(t, "file", "front.jpg", bytes.Repeat([]byte("a"), multipartOverhead+2048)), httptest.NewRecorder())
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		mediaHandler := NewMediaHandler(&mocks.MockMediaService{}, everyProduct, 1024)
+
+		err := mediaHandler.Upload(c)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "code=413")
+	})
+
+	t.Run("should returns 400 without the file field", func(t *testing.T) {
+		e := echo.New()
+		c := e.NewContext(uploadRequest(t, "image", "front.jpg", []byte("image")), httptest.NewRecorder())
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		mediaHandler := NewMediaHandler(&mocks.MockMediaService{}, everyProduct, 1024)
+
+		err := mediaHandler.Upload(c)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "code=400")
+	})
+
+	t.Run("should returns 415 for a file that isn't an image", func(t *testing.T) {
+		e := echo.New()
+		c := e.NewContext(uploadRequest(t, "file", "front.jpg", []byte("text")), httptest.NewRecorder())
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		mockMediaService := &mocks.MockMediaService{}
+		mockMediaService.On("UploadMedia", 1, "front.jpg", mock.Anything).Return(nil, fmt.Errorf("%w text/plain", models.ErrUnsupportedMedia))
+		mediaHandler := NewMediaHandler(mockMediaService, everyProduct, 1024)
+
+		err := mediaHandler.Upload(c)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "code=415")
+	})
+}
+
+func TestMediaFile(t *testing.T) {
+	t.Run("should serve the thumbnail with its content type", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/products/:id/media/:media_id/thumbnail", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id", "media_id")
+		c.SetParamValues("1", "1")
+
+		media := &models.ProductMedia{ID: 1, ProductID: 1, ContentType: "image/gif"}
+		mockMediaService := &mocks.MockMediaService{}
+		mockMediaService.On("OpenMedia", 1, 1, true).Return(media, io.NopCloser(strings.NewReader("thumbnail")), nil)
+		mediaHandler := NewMediaHandler(mockMediaService, everyProduct, 1024)
+
+		if assert.NoError(t, mediaHandler.Thumbnail(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "image/png", rec.Header().Get(echo.HeaderContentType))
+			assert.Equal(t, "thumbnail", rec.Body.String())
+		}
+	})
+}
+
+func TestMediaOfUnpublished(t *testing.T) {
+	t.Run("should returns 404 to viewers for the file of a draft", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/products/:id/media/:media_id/file", nil)
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("id", "media_id")
+		c.SetParamValues("3", "1")
+
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("GetProductByID", 3).Return(&models.Product{ID: 3, Status: models.ProductDraft}, nil)
+		mockMediaService := &mocks.MockMediaService{}
+		mediaHandler := NewMediaHandler(mockMediaService, NewProductVisibility(mockProductService, asViewer), 1024)
+
+		err := mediaHandler.File(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=404, message=Failed to get product")
+		mockMediaService.AssertNotCalled(t, "OpenMedia", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("should list the media of a published product to viewers", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/products/:id/media", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("GetProductByID", 1).Return(mocks.MockProducts[0], nil)
+		mockMediaService := &mocks.MockMediaService{}
+		mockMediaService.On("GetAllMedia", 1).Return([]*models.ProductMedia{mocks.MockMedia}, nil)
+		mediaHandler := NewMediaHandler(mockMediaService, NewProductVisibility(mockProductService, asViewer), 1024)
+
+		if assert.NoError(t, mediaHandler.Index(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			mockMediaService.AssertExpectations(t)
+		}
+	})
+}
+
+func TestReorderMedia(t *testing.T) {
+	t.Run("should returns 422 without ids", func(t *testing.T) {
+		e := echo.New()
+		e.Validator = &CustomValidator{validator: validator.New()}
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/products/:id/media/order", strings.NewReader(`{"ids": []}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		mockMediaService := &mocks.MockMediaService{}
+		mediaHandler := NewMediaHandler(mockMediaService, everyProduct, 1024)
+
+		err := mediaHandler.Reorder(c)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "code=422")
+		mockMediaService.AssertNotCalled(t, "ReorderMedia", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should returns 422 for an order missing media", func(t *testing.T) {
+		e := echo.New()
+		e.Validator = &CustomValidator{validator: validator.New()}
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/products/:id/media/order", strings.NewReader(`{"ids": [2]}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		mockMediaService := &mocks.MockMediaService{}
+		mockMediaService.On("ReorderMedia", 1, []uint{2}).Return(nil, fmt.Errorf("%w: the 2 media of the product must all be listed", models.ErrInvalidMediaOrder))
+		mediaHandler := NewMediaHandler(mockMediaService, everyProduct, 1024)
+
+		err := mediaHandler.Reorder(c)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "code=422")
+	})
+}
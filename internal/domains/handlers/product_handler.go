@@ -1,24 +1,79 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/adrianosiqe/eulabs-challenge-api/internal/core/interfaces"
 	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/services"
+	"github.com/adrianosiqe/eulabs-challenge-api/pkg/money"
 	"github.com/labstack/echo"
+	"gorm.io/gorm"
 )
 
+// maxPriceReason is the most characters the reason of a price change has.
+const maxPriceReason = 255
+
 type ProductHandler struct {
 	productService interfaces.ProductServiceInterface
+	isEditor       func(c echo.Context) bool
+	actor          func(c echo.Context) string
 }
 
-func NewProductHandler(productService interfaces.ProductServiceInterface) *ProductHandler {
-	return &ProductHandler{productService: productService}
+// NewProductHandler returns a handler that only shows the published products
+// to the callers for which isEditor returns false, and records the changes of
+// price as made by actor.
+func NewProductHandler(productService interfaces.ProductServiceInterface, isEditor func(c echo.Context) bool, actor func(c echo.Context) string) *ProductHandler {
+	return &ProductHandler{productService: productService, isEditor: isEditor, actor: actor}
 }
 
 func (h *ProductHandler) Index(c echo.Context) error {
-	products, err := h.productService.GetAllProducts()
+	var filter models.ProductFilter
+
+	err := c.Bind(&filter)
+	if err != nil || filter.Page < 0 || filter.PerPage < 0 || filter.PerPage > models.MaxPerPage || filter.Category < 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid pagination parameters")
+	}
+
+	if filter.Tags != "" {
+		filter.TagNames, err = models.NormalizeTags(strings.Split(filter.Tags, ","))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid tags")
+		}
+	}
+	if filter.TagMatch != "" && filter.TagMatch != models.TagMatchAny && filter.TagMatch != models.TagMatchAll {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid tag_match, expected any or all")
+	}
+	if filter.Status != "" && !filter.Status.Valid() {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid status, expected draft, published or archived")
+	}
+
+	switch {
+	case !h.isEditor(c):
+		filter.Statuses = []models.ProductStatus{models.ProductPublished}
+	case filter.Status != "":
+		filter.Statuses = []models.ProductStatus{filter.Status}
+	}
+
+	for param, values := range c.QueryParams() {
+		if strings.HasPrefix(param, models.AttributeQueryPrefix) && len(values) > 0 {
+			if filter.AttributeQuery == nil {
+				filter.AttributeQuery = map[string]string{}
+			}
+			filter.AttributeQuery[strings.TrimPrefix(param, models.AttributeQueryPrefix)] = values[0]
+		}
+	}
+
+	products, err := h.productService.GetAllProducts(filter)
+	if errors.Is(err, models.ErrInvalidAttribute) {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list the products")
 	}
@@ -38,9 +93,16 @@ func (h *ProductHandler) Create(c echo.Context) error {
 		return err
 	}
 
+	if product.Currency == "" {
+		product.Currency = money.DefaultCurrency
+	}
+	if err = product.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	}
+
 	createdProduct, err := h.productService.CreateProduct(&product)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create product")
+		return writeError(err, "Failed to create product")
 	}
 
 	return c.JSON(http.StatusCreated, createdProduct)
@@ -58,13 +120,66 @@ func (h *ProductHandler) Show(c echo.Context) error {
 	}
 
 	product, err := h.productService.GetProductByID(id)
-	if err != nil {
+	return h.show(c, product, err)
+}
+
+// ShowBySKU returns the product with the SKU in the path.
+func (h *ProductHandler) ShowBySKU(c echo.Context) error {
+	sku, err := url.PathUnescape(c.Param("sku"))
+	if err != nil || sku == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid SKU")
+	}
+
+	product, err := h.productService.GetProductBySKU(sku)
+	return h.show(c, product, err)
+}
+
+// ShowBySlug returns the product with the slug in the path.
+func (h *ProductHandler) ShowBySlug(c echo.Context) error {
+	slug := c.Param("slug")
+	if slug == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid slug")
+	}
+
+	product, err := h.productService.GetProductBySlug(slug)
+	return h.show(c, product, err)
+}
+
+// show returns product, unless it wasn't found or the caller can't see it.
+func (h *ProductHandler) show(c echo.Context, product *models.Product, err error) error {
+	if err != nil || product.Status != models.ProductPublished && !h.isEditor(c) {
 		return echo.NewHTTPError(http.StatusNotFound, "Failed to get product")
 	}
 
 	return c.JSON(http.StatusOK, product)
 }
 
+// ProductVisibility applies the rule of show to the routes below a product,
+// such as its variants, media and stock: the callers for which isEditor
+// returns false only reach the published products.
+type ProductVisibility struct {
+	productService interfaces.ProductServiceInterface
+	isEditor       func(c echo.Context) bool
+}
+
+func NewProductVisibility(productService interfaces.ProductServiceInterface, isEditor func(c echo.Context) bool) *ProductVisibility {
+	return &ProductVisibility{productService: productService, isEditor: isEditor}
+}
+
+// check returns 404 unless the caller can see the product. Editors see every
+// product, so it is only loaded for the other callers.
+func (v *ProductVisibility) check(c echo.Context, productID int) error {
+	if v.isEditor(c) {
+		return nil
+	}
+
+	product, err := v.productService.GetProductByID(productID)
+	if err != nil || product.Status != models.ProductPublished {
+		return echo.NewHTTPError(http.StatusNotFound, "Failed to get product")
+	}
+	return nil
+}
+
 func (h *ProductHandler) Update(c echo.Context) error {
 	idParam := c.Param("id")
 	if idParam == "" {
@@ -82,7 +197,7 @@ func (h *ProductHandler) Update(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "Failed to decode product data")
 	}
 
-	product, err := h.productService.GetProductByID(id)
+	product, err := h.productService.GetProductForUpdate(id)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusNotFound, "Failed to get product")
 	}
@@ -99,14 +214,89 @@ func (h *ProductHandler) Update(c echo.Context) error {
 		product.Price = updateProduct.Price
 	}
 
-	updatedProduct, err := h.productService.UpdateProduct(product)
+	if updateProduct.Currency != "" {
+		// The price is read in its currency, so a new currency takes a new
+		// price instead of reinterpreting the amount stored.
+		if updateProduct.Currency != product.Currency && updateProduct.Price <= 0 {
+			return echo.NewHTTPError(http.StatusUnprocessableEntity, "price is required to change the currency")
+		}
+		product.Currency = updateProduct.Currency
+	}
+
+	if updateProduct.SKU != nil {
+		product.SKU = updateProduct.SKU
+	}
+
+	if updateProduct.Attributes != nil {
+		product.Attributes = product.Attributes.Merge(updateProduct.Attributes)
+	}
+
+	if err = product.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	}
+	if utf8.RuneCountInString(updateProduct.PriceReason) > maxPriceReason {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, fmt.Sprintf("price_reason must have at most %d characters", maxPriceReason))
+	}
+
+	audit := models.Audit{Actor: h.actor(c), Reason: updateProduct.PriceReason}
+	updatedProduct, err := h.productService.UpdateProduct(product, audit)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// The product was deleted after it was read.
+		return echo.NewHTTPError(http.StatusNotFound, "Failed to get product")
+	}
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update product")
+		return writeError(err, "Failed to update product")
 	}
 
 	return c.JSON(http.StatusOK, updatedProduct)
 }
 
+// PriceHistory returns the price of the product in the path, its lowest price
+// in the last 30 days and the changes of its price between the from and to
+// query parameters.
+func (h *ProductHandler) PriceHistory(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid product ID")
+	}
+
+	var filter models.PriceHistoryFilter
+	if err := c.Bind(&filter); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid price history parameters")
+	}
+	filter, err = models.ParsePriceHistoryFilter(filter)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid from or to, expected a date such as 2024-05-31 or an RFC 3339 time")
+	}
+	if filter.Since != nil && filter.Until != nil && !filter.Since.Before(*filter.Until) {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid range, from must be before to")
+	}
+
+	if err := NewProductVisibility(h.productService, h.isEditor).check(c, id); err != nil {
+		return err
+	}
+
+	history, err := h.productService.GetPriceHistory(id, filter)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "Failed to get product")
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get the price history")
+	}
+
+	if !h.isEditor(c) {
+		// Who changed the price and why are kept for the editors.
+		changes := make([]*models.PriceChange, len(history.Changes))
+		for i, change := range history.Changes {
+			redacted := *change
+			redacted.ChangedBy, redacted.Reason = "", ""
+			changes[i] = &redacted
+		}
+		history.Changes = changes
+	}
+	return c.JSON(http.StatusOK, history)
+}
+
 func (h *ProductHandler) Delete(c echo.Context) error {
 	idParam := c.Param("id")
 	if idParam == "" {
@@ -123,5 +313,235 @@ func (h *ProductHandler) Delete(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to delete product")
 	}
 
-	return c.JSON(http.StatusNoContent, nil)
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Purge permanently removes a product, deleted or not, with its media.
+func (h *ProductHandler) Purge(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid product ID")
+	}
+
+	err = h.productService.PurgeProduct(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "Failed to get product")
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to purge product")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Restore brings back a deleted product.
+func (h *ProductHandler) Restore(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid product ID")
+	}
+
+	product, err := h.productService.RestoreProduct(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "Failed to get product")
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to restore product")
+	}
+
+	return c.JSON(http.StatusOK, product)
+}
+
+// Publish publishes a draft product.
+func (h *ProductHandler) Publish(c echo.Context) error {
+	return h.transition(c, models.ProductPublished)
+}
+
+// Archive archives a published product.
+func (h *ProductHandler) Archive(c echo.Context) error {
+	return h.transition(c, models.ProductArchived)
+}
+
+// Unarchive turns an archived product back into a draft.
+func (h *ProductHandler) Unarchive(c echo.Context) error {
+	return h.transition(c, models.ProductDraft)
+}
+
+func (h *ProductHandler) transition(c echo.Context, status models.ProductStatus) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid product ID")
+	}
+
+	product, err := h.productService.TransitionProduct(id, status)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "Failed to get product")
+	}
+	if errors.Is(err, models.ErrInvalidTransition) {
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to change the product status")
+	}
+
+	return c.JSON(http.StatusOK, product)
+}
+
+// Schedule replaces the scheduled publication and archiving of a product.
+func (h *ProductHandler) Schedule(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid product ID")
+	}
+
+	var schedule models.ProductSchedule
+	err = c.Bind(&schedule)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Failed to decode schedule")
+	}
+
+	product, err := h.productService.ScheduleProduct(id, schedule)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "Failed to get product")
+	}
+	if errors.Is(err, models.ErrInvalidSchedule) {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	}
+	if errors.Is(err, models.ErrInvalidTransition) {
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to schedule the product")
+	}
+
+	return c.JSON(http.StatusOK, product)
+}
+
+// SetCategories replaces the categories of a product.
+func (h *ProductHandler) SetCategories(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid product ID")
+	}
+
+	var body models.ProductCategories
+	err = c.Bind(&body)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Failed to decode categories")
+	}
+
+	if err = c.Validate(body); err != nil {
+		return err
+	}
+
+	product, err := h.productService.SetProductCategories(id, body.CategoryIDs)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "Failed to get product")
+	}
+	if errors.Is(err, services.ErrUnknownCategory) {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, "Category not found")
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update the product categories")
+	}
+
+	return c.JSON(http.StatusOK, product)
+}
+
+// SetOptions replaces the options the variants of a product are made of.
+func (h *ProductHandler) SetOptions(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid product ID")
+	}
+
+	var body models.ProductOptions
+	err = c.Bind(&body)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Failed to decode options")
+	}
+
+	if err = c.Validate(body); err != nil {
+		return err
+	}
+
+	product, err := h.productService.SetProductOptions(id, body.Options)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "Failed to get product")
+	}
+	if errors.Is(err, models.ErrInvalidOptions) {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	}
+	if errors.Is(err, models.ErrOptionsInUse) {
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update the product options")
+	}
+
+	return c.JSON(http.StatusOK, product)
+}
+
+// AddTags adds tags to a product, creating the tags that don't exist yet.
+func (h *ProductHandler) AddTags(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid product ID")
+	}
+
+	var body models.ProductTags
+	err = c.Bind(&body)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Failed to decode tags")
+	}
+
+	if err = c.Validate(body); err != nil {
+		return err
+	}
+
+	product, err := h.productService.AddProductTags(id, body.Tags)
+	return h.tagsResponse(c, product, err)
+}
+
+// RemoveTag removes a tag from a product.
+func (h *ProductHandler) RemoveTag(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid product ID")
+	}
+
+	tag, err := url.PathUnescape(c.Param("tag"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid tag")
+	}
+
+	product, err := h.productService.RemoveProductTag(id, tag)
+	return h.tagsResponse(c, product, err)
+}
+
+func (h *ProductHandler) tagsResponse(c echo.Context, product *models.Product, err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "Failed to get product")
+	}
+	if errors.Is(err, models.ErrInvalidTag) {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update the product tags")
+	}
+
+	return c.JSON(http.StatusOK, product)
+}
+
+// writeError maps the errors of a product write to their status, or to a 500
+// with message.
+func writeError(err error, message string) error {
+	var conflict *models.ConflictError
+	if errors.As(err, &conflict) {
+		return echo.NewHTTPError(http.StatusConflict, conflict.Error())
+	}
+	if errors.Is(err, models.ErrInvalidSKU) || errors.Is(err, models.ErrInvalidAttribute) {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	}
+	return echo.NewHTTPError(http.StatusInternalServerError, message)
 }
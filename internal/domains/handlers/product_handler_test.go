@@ -7,12 +7,17 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/services"
 	"github.com/adrianosiqe/eulabs-challenge-api/internal/mocks"
+	"github.com/adrianosiqe/eulabs-challenge-api/pkg/money"
 	"github.com/go-playground/validator"
 	"github.com/labstack/echo"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
 )
 
 type CustomValidator struct {
@@ -26,6 +31,16 @@ func (cv *CustomValidator) Validate(i interface{}) error {
 	return nil
 }
 
+// asEditor and asViewer stand in for the permission check of the server.
+func asEditor(echo.Context) bool { return true }
+func asViewer(echo.Context) bool { return false }
+
+// everyProduct lets every caller see every product, as editors do.
+var everyProduct = NewProductVisibility(nil, asEditor)
+
+// asActor stands in for the subject of the caller.
+func asActor(echo.Context) string { return "editor@example.com" }
+
 func TestIndex(t *testing.T) {
 	t.Run("should returns 200", func(t *testing.T) {
 		e := echo.New()
@@ -34,8 +49,8 @@ func TestIndex(t *testing.T) {
 		c := e.NewContext(req, rec)
 
 		mockProductService := &mocks.MockProductService{}
-		mockProductService.On("GetAllProducts").Return(mocks.MockProducts, nil)
-		productHandler := NewProductHandler(mockProductService)
+		mockProductService.On("GetAllProducts", models.ProductFilter{}).Return(mocks.MockProducts, nil)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
 
 		if assert.NoError(t, productHandler.Index(c)) {
 			assert.Equal(t, http.StatusOK, rec.Code)
@@ -57,6 +72,37 @@ func TestIndex(t *testing.T) {
 		}
 	})
 
+	t.Run("should returns 200 with a page", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/products?page=2&per_page=1", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("GetAllProducts", models.ProductFilter{Page: 2, PerPage: 1}).Return(mocks.MockProducts[1:], nil)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
+
+		if assert.NoError(t, productHandler.Index(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			mockProductService.AssertExpectations(t)
+		}
+	})
+
+	t.Run("should returns 400", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/products?per_page=1000", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		mockProductService := &mocks.MockProductService{}
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
+
+		err := productHandler.Index(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=400, message=Invalid pagination parameters")
+	})
+
 	t.Run("should returns 500", func(t *testing.T) {
 		e := echo.New()
 		req := httptest.NewRequest(http.MethodGet, "/api/v1/products", nil)
@@ -64,8 +110,8 @@ func TestIndex(t *testing.T) {
 		c := e.NewContext(req, rec)
 
 		mockProductService := &mocks.MockProductService{}
-		mockProductService.On("GetAllProducts").Return(nil, fmt.Errorf("some error"))
-		productHandler := NewProductHandler(mockProductService)
+		mockProductService.On("GetAllProducts", models.ProductFilter{}).Return(nil, fmt.Errorf("some error"))
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
 
 		err := productHandler.Index(c)
 
@@ -88,9 +134,11 @@ func TestCreate(t *testing.T) {
 
 		var productBind models.Product
 		json.Unmarshal([]byte(productJSON), &productBind)
+		// Products created without a currency get the default one.
+		productBind.Currency = money.DefaultCurrency
 		mockProductService := &mocks.MockProductService{}
 		mockProductService.On("CreateProduct", &productBind).Return(mocks.MockProducts[1], nil)
-		productHandler := NewProductHandler(mockProductService)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
 
 		if assert.NoError(t, productHandler.Create(c)) {
 			assert.Equal(t, http.StatusCreated, rec.Code)
@@ -116,7 +164,7 @@ func TestCreate(t *testing.T) {
 		c := e.NewContext(req, rec)
 
 		mockProductService := &mocks.MockProductService{}
-		productHandler := NewProductHandler(mockProductService)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
 		err := productHandler.Create(c)
 
 		assert.Error(t, err)
@@ -132,13 +180,43 @@ func TestCreate(t *testing.T) {
 		c := e.NewContext(req, rec)
 
 		mockProductService := &mocks.MockProductService{}
-		productHandler := NewProductHandler(mockProductService)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
 		err := productHandler.Create(c)
 
 		assert.Error(t, err)
 		assert.Equal(t, err.Error(), "code=422, message=Key: 'Product.Description' Error:Field validation for 'Description' failed on the 'required' tag\nKey: 'Product.Price' Error:Field validation for 'Price' failed on the 'required' tag")
 	})
 
+	t.Run("should returns 422 for a price with too many decimal places", func(t *testing.T) {
+		e := echo.New()
+		e.Validator = &CustomValidator{validator: validator.New()}
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/products", strings.NewReader(`{"title":"Charmander","description":"Fire","price":1093.45,"currency":"JPY"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		productHandler := NewProductHandler(&mocks.MockProductService{}, asEditor, asActor)
+		err := productHandler.Create(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=422, message=too many decimal places for the currency: JPY allows 0")
+	})
+
+	t.Run("should returns 400 for an unknown currency", func(t *testing.T) {
+		e := echo.New()
+		e.Validator = &CustomValidator{validator: validator.New()}
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/products", strings.NewReader(`{"title":"Charmander","description":"Fire","price":1093.45,"currency":"XYZ"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		productHandler := NewProductHandler(&mocks.MockProductService{}, asEditor, asActor)
+		err := productHandler.Create(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=400, message=Failed to decode product data")
+	})
+
 	t.Run("should returns 500", func(t *testing.T) {
 		e := echo.New()
 		e.Validator = &CustomValidator{validator: validator.New()}
@@ -149,9 +227,10 @@ func TestCreate(t *testing.T) {
 
 		var productBind models.Product
 		json.Unmarshal([]byte(productJSON), &productBind)
+		productBind.Currency = money.DefaultCurrency
 		mockProductService := &mocks.MockProductService{}
 		mockProductService.On("CreateProduct", &productBind).Return(nil, fmt.Errorf("some error"))
-		productHandler := NewProductHandler(mockProductService)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
 
 		err := productHandler.Create(c)
 
@@ -172,7 +251,7 @@ func TestShow(t *testing.T) {
 
 		mockProductService := &mocks.MockProductService{}
 		mockProductService.On("GetProductByID", 1).Return(mocks.MockProducts[0], nil)
-		productHandler := NewProductHandler(mockProductService)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
 
 		if assert.NoError(t, productHandler.Show(c)) {
 			assert.Equal(t, http.StatusOK, rec.Code)
@@ -196,7 +275,7 @@ func TestShow(t *testing.T) {
 		c := e.NewContext(req, rec)
 
 		mockProductService := &mocks.MockProductService{}
-		productHandler := NewProductHandler(mockProductService)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
 
 		err := productHandler.Show(c)
 
@@ -213,7 +292,7 @@ func TestShow(t *testing.T) {
 		c.SetParamValues("invalid_id")
 
 		mockProductService := &mocks.MockProductService{}
-		productHandler := NewProductHandler(mockProductService)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
 
 		err := productHandler.Show(c)
 
@@ -231,7 +310,7 @@ func TestShow(t *testing.T) {
 
 		mockProductService := &mocks.MockProductService{}
 		mockProductService.On("GetProductByID", 1).Return(nil, fmt.Errorf("some error"))
-		productHandler := NewProductHandler(mockProductService)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
 
 		err := productHandler.Show(c)
 
@@ -247,7 +326,9 @@ func TestUpdate(t *testing.T) {
 		ID:          mocks.MockProducts[0].ID,
 		Title:       "Charmander",
 		Description: "It has a preference for hot things. When it rains, steam is said to spout from the tip of its tail.",
-		Price:       1093.45,
+		Price:       money.MustParseAmount("1093.45"),
+		Currency:    money.DefaultCurrency,
+		Status:      models.ProductPublished,
 		CreatedAt:   mocks.MockProducts[0].CreatedAt,
 		UpdatedAt:   mocks.MockProducts[0].UpdatedAt,
 		DeletedAt:   mocks.MockProducts[0].DeletedAt,
@@ -263,9 +344,9 @@ func TestUpdate(t *testing.T) {
 		c.SetParamValues("1")
 
 		mockProductService := &mocks.MockProductService{}
-		mockProductService.On("GetProductByID", 1).Return(mocks.MockProducts[0], nil)
-		mockProductService.On("UpdateProduct", &updatedProduct).Return(&updatedProduct, nil)
-		productHandler := NewProductHandler(mockProductService)
+		mockProductService.On("GetProductForUpdate", 1).Return(mocks.MockProducts[0], nil)
+		mockProductService.On("UpdateProduct", &updatedProduct, models.Audit{Actor: "editor@example.com"}).Return(&updatedProduct, nil)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
 
 		if assert.NoError(t, productHandler.Update(c)) {
 			assert.Equal(t, http.StatusOK, rec.Code)
@@ -290,7 +371,7 @@ func TestUpdate(t *testing.T) {
 		c := e.NewContext(req, rec)
 
 		mockProductService := &mocks.MockProductService{}
-		productHandler := NewProductHandler(mockProductService)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
 
 		err := productHandler.Update(c)
 
@@ -308,7 +389,7 @@ func TestUpdate(t *testing.T) {
 		c.SetParamValues("invalid_id")
 
 		mockProductService := &mocks.MockProductService{}
-		productHandler := NewProductHandler(mockProductService)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
 
 		err := productHandler.Update(c)
 
@@ -326,8 +407,29 @@ func TestUpdate(t *testing.T) {
 		c.SetParamValues("1")
 
 		mockProductService := &mocks.MockProductService{}
-		mockProductService.On("GetProductByID", 1).Return(nil, fmt.Errorf("some error"))
-		productHandler := NewProductHandler(mockProductService)
+		mockProductService.On("GetProductForUpdate", 1).Return(nil, fmt.Errorf("some error"))
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
+
+		err := productHandler.Update(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=404, message=Failed to get product")
+		mockProductService.AssertExpectations(t)
+	})
+
+	t.Run("should returns 404 when the product is deleted meanwhile", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/products/:id", strings.NewReader(productJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("GetProductForUpdate", 1).Return(mocks.MockProducts[0], nil)
+		mockProductService.On("UpdateProduct", &updatedProduct, models.Audit{Actor: "editor@example.com"}).Return(nil, gorm.ErrRecordNotFound)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
 
 		err := productHandler.Update(c)
 
@@ -348,9 +450,9 @@ func TestUpdate(t *testing.T) {
 		var productBind models.Product
 		json.Unmarshal([]byte(productJSON), &productBind)
 		mockProductService := &mocks.MockProductService{}
-		mockProductService.On("GetProductByID", 1).Return(mocks.MockProducts[0], nil)
-		mockProductService.On("UpdateProduct", &updatedProduct).Return(nil, fmt.Errorf("some error"))
-		productHandler := NewProductHandler(mockProductService)
+		mockProductService.On("GetProductForUpdate", 1).Return(mocks.MockProducts[0], nil)
+		mockProductService.On("UpdateProduct", &updatedProduct, models.Audit{Actor: "editor@example.com"}).Return(nil, fmt.Errorf("some error"))
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
 
 		err := productHandler.Update(c)
 
@@ -358,6 +460,187 @@ func TestUpdate(t *testing.T) {
 		assert.Equal(t, err.Error(), "code=500, message=Failed to update product")
 		mockProductService.AssertExpectations(t)
 	})
+
+	t.Run("should pass who changes the price and why", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPatch, "/api/v1/products/:id", strings.NewReader(`{"price": 1093.45, "price_reason": "Black Friday"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		stored := *mocks.MockProducts[0]
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("GetProductForUpdate", 1).Return(&stored, nil)
+		mockProductService.On("UpdateProduct", mock.Anything, models.Audit{Actor: "editor@example.com", Reason: "Black Friday"}).Return(mocks.MockProducts[0], nil)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
+
+		assert.NoError(t, productHandler.Update(c))
+		mockProductService.AssertExpectations(t)
+	})
+
+	t.Run("should returns 422 for a new currency without a price", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPatch, "/api/v1/products/:id", strings.NewReader(`{"currency": "USD"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		stored := *mocks.MockProducts[0]
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("GetProductForUpdate", 1).Return(&stored, nil)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
+
+		err := productHandler.Update(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=422, message=price is required to change the currency")
+		mockProductService.AssertNotCalled(t, "UpdateProduct", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should change the currency with the price", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPatch, "/api/v1/products/:id", strings.NewReader(`{"price": 19.99, "currency": "USD"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		stored := *mocks.MockProducts[0]
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("GetProductForUpdate", 1).Return(&stored, nil)
+		mockProductService.On("UpdateProduct", mock.MatchedBy(func(p *models.Product) bool {
+			return p.Price == money.MustParseAmount("19.99") && p.Currency == "USD"
+		}), mock.Anything).Return(&stored, nil)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
+
+		assert.NoError(t, productHandler.Update(c))
+		mockProductService.AssertExpectations(t)
+	})
+
+	t.Run("should returns 422 for a reason too long", func(t *testing.T) {
+		e := echo.New()
+		body := fmt.Sprintf(`{"price": 1093.45, "price_reason": %q}`, strings.Repeat("é", 256))
+		req := httptest.NewRequest(http.MethodPatch, "/api/v1/products/:id", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		stored := *mocks.MockProducts[0]
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("GetProductForUpdate", 1).Return(&stored, nil)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
+
+		err := productHandler.Update(c)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "code=422")
+		mockProductService.AssertNotCalled(t, "UpdateProduct", mock.Anything, mock.Anything)
+	})
+}
+
+func TestPriceHistory(t *testing.T) {
+	t.Run("should returns 200 with the changes between the dates", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/products/:id/price-history?from=2024-05-01&to=2024-05-31", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		since := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+		until := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+		history := &models.PriceHistory{Price: money.MustParseAmount("80"), Currency: money.DefaultCurrency, LowestPrice30d: money.MustParseAmount("80"), Changes: []*models.PriceChange{}}
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("GetPriceHistory", 1, mock.MatchedBy(func(filter models.PriceHistoryFilter) bool {
+			return filter.Since.Equal(since) && filter.Until.Equal(until)
+		})).Return(history, nil)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
+
+		if assert.NoError(t, productHandler.PriceHistory(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.JSONEq(t, `{"price": 80, "currency": "BRL", "lowest_price_30d": 80, "changes": []}`, rec.Body.String())
+		}
+	})
+
+	t.Run("should returns 400 for an invalid date", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/products/:id/price-history?from=yesterday", nil)
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		mockProductService := &mocks.MockProductService{}
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
+
+		err := productHandler.PriceHistory(c)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "code=400")
+		mockProductService.AssertNotCalled(t, "GetPriceHistory", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should returns 404", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/products/:id/price-history", nil)
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("id")
+		c.SetParamValues("9")
+
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("GetPriceHistory", 9, models.PriceHistoryFilter{}).Return(nil, gorm.ErrRecordNotFound)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
+
+		err := productHandler.PriceHistory(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, "code=404, message=Failed to get product", err.Error())
+	})
+
+	t.Run("should returns 404 to a viewer for an unpublished product", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/products/:id/price-history", nil)
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		draft := &models.Product{ID: 1, Title: "Bulbasaur", Status: models.ProductDraft}
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("GetProductByID", 1).Return(draft, nil)
+		productHandler := NewProductHandler(mockProductService, asViewer, asActor)
+
+		err := productHandler.PriceHistory(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, "code=404, message=Failed to get product", err.Error())
+		mockProductService.AssertNotCalled(t, "GetPriceHistory", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should hide who changed the price and why from a viewer", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/products/:id/price-history", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		published := &models.Product{ID: 1, Title: "Bulbasaur", Status: models.ProductPublished}
+		change := &models.PriceChange{ID: 1, ProductID: 1, OldPrice: money.MustParseAmount("100"), OldCurrency: money.DefaultCurrency, NewPrice: money.MustParseAmount("80"), NewCurrency: money.DefaultCurrency, ChangedBy: "editor@example.com", Reason: "Black Friday"}
+		history := &models.PriceHistory{Price: money.MustParseAmount("80"), Currency: money.DefaultCurrency, LowestPrice30d: money.MustParseAmount("80"), Changes: []*models.PriceChange{change}}
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("GetProductByID", 1).Return(published, nil)
+		mockProductService.On("GetPriceHistory", 1, models.PriceHistoryFilter{}).Return(history, nil)
+		productHandler := NewProductHandler(mockProductService, asViewer, asActor)
+
+		if assert.NoError(t, productHandler.PriceHistory(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.NotContains(t, rec.Body.String(), "editor@example.com")
+			assert.NotContains(t, rec.Body.String(), "Black Friday")
+			assert.Equal(t, "editor@example.com", change.ChangedBy)
+		}
+	})
 }
 
 func TestDelete(t *testing.T) {
@@ -371,7 +654,7 @@ func TestDelete(t *testing.T) {
 
 		mockProductService := &mocks.MockProductService{}
 		mockProductService.On("DeleteProduct", 1).Return(nil)
-		productHandler := NewProductHandler(mockProductService)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
 
 		if assert.NoError(t, productHandler.Delete(c)) {
 			assert.Equal(t, http.StatusNoContent, rec.Code)
@@ -386,7 +669,7 @@ func TestDelete(t *testing.T) {
 		c := e.NewContext(req, rec)
 
 		mockProductService := &mocks.MockProductService{}
-		productHandler := NewProductHandler(mockProductService)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
 
 		err := productHandler.Delete(c)
 
@@ -403,7 +686,7 @@ func TestDelete(t *testing.T) {
 		c.SetParamValues("invalid_id")
 
 		mockProductService := &mocks.MockProductService{}
-		productHandler := NewProductHandler(mockProductService)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
 
 		err := productHandler.Delete(c)
 
@@ -421,7 +704,7 @@ func TestDelete(t *testing.T) {
 
 		mockProductService := &mocks.MockProductService{}
 		mockProductService.On("DeleteProduct", 1).Return(fmt.Errorf("some error"))
-		productHandler := NewProductHandler(mockProductService)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
 
 		err := productHandler.Delete(c)
 
@@ -430,3 +713,741 @@ func TestDelete(t *testing.T) {
 		mockProductService.AssertExpectations(t)
 	})
 }
+
+func TestPurge(t *testing.T) {
+	t.Run("should returns 204", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/products/:id/purge", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("PurgeProduct", 1).Return(nil)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
+
+		if assert.NoError(t, productHandler.Purge(c)) {
+			assert.Equal(t, http.StatusNoContent, rec.Code)
+			mockProductService.AssertExpectations(t)
+		}
+	})
+
+	t.Run("should returns 404", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/products/:id/purge", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("PurgeProduct", 1).Return(gorm.ErrRecordNotFound)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
+
+		err := productHandler.Purge(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=404, message=Failed to get product")
+	})
+
+	t.Run("should returns 500", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/products/:id/purge", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("PurgeProduct", 1).Return(fmt.Errorf("some error"))
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
+
+		err := productHandler.Purge(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=500, message=Failed to purge product")
+	})
+}
+
+func TestRestore(t *testing.T) {
+	t.Run("should returns 200", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/products/:id/restore", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("RestoreProduct", 1).Return(mocks.MockProducts[0], nil)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
+
+		if assert.NoError(t, productHandler.Restore(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			mockProductService.AssertExpectations(t)
+		}
+	})
+
+	t.Run("should returns 400", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/products/:id/restore", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("invalid_id")
+
+		productHandler := NewProductHandler(&mocks.MockProductService{}, asEditor, asActor)
+
+		err := productHandler.Restore(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=400, message=Invalid product ID")
+	})
+
+	t.Run("should returns 404", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/products/:id/restore", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("RestoreProduct", 1).Return(nil, gorm.ErrRecordNotFound)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
+
+		err := productHandler.Restore(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=404, message=Failed to get product")
+	})
+}
+
+func TestSetCategories(t *testing.T) {
+	setCategories := func(body string) echo.Context {
+		e := echo.New()
+		e.Validator = &CustomValidator{validator: validator.New()}
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/products/:id/categories", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+		return c
+	}
+
+	t.Run("should returns 200", func(t *testing.T) {
+		c := setCategories(`{"category_ids":[2]}`)
+
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("SetProductCategories", 1, []uint{2}).Return(mocks.MockProducts[0], nil)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
+
+		if assert.NoError(t, productHandler.SetCategories(c)) {
+			assert.Equal(t, http.StatusOK, c.Response().Status)
+			mockProductService.AssertExpectations(t)
+		}
+	})
+
+	t.Run("should returns 422 for an unknown category", func(t *testing.T) {
+		c := setCategories(`{"category_ids":[42]}`)
+
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("SetProductCategories", 1, []uint{42}).Return(nil, services.ErrUnknownCategory)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
+
+		err := productHandler.SetCategories(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=422, message=Category not found")
+	})
+
+	t.Run("should returns 404", func(t *testing.T) {
+		c := setCategories(`{"category_ids":[]}`)
+
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("SetProductCategories", 1, []uint{}).Return(nil, gorm.ErrRecordNotFound)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
+
+		err := productHandler.SetCategories(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=404, message=Failed to get product")
+	})
+}
+
+func TestSetOptions(t *testing.T) {
+	setOptions := func(body string) echo.Context {
+		e := echo.New()
+		e.Validator = &CustomValidator{validator: validator.New()}
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/products/:id/options", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+		return c
+	}
+
+	t.Run("should returns 200", func(t *testing.T) {
+		c := setOptions(`{"options":[{"name":"Size","values":["S","M"]}]}`)
+
+		options := []models.ProductOption{{Name: "Size", Values: models.OptionValues{"S", "M"}}}
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("SetProductOptions", 1, options).Return(mocks.MockProducts[0], nil)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
+
+		if assert.NoError(t, productHandler.SetOptions(c)) {
+			assert.Equal(t, http.StatusOK, c.Response().Status)
+			mockProductService.AssertExpectations(t)
+		}
+	})
+
+	t.Run("should returns 422 for an option without values", func(t *testing.T) {
+		c := setOptions(`{"options":[{"name":"Size","values":[]}]}`)
+
+		mockProductService := &mocks.MockProductService{}
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
+
+		err := productHandler.SetOptions(c)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "code=422")
+		mockProductService.AssertNotCalled(t, "SetProductOptions", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should returns 409 when a variant uses a removed value", func(t *testing.T) {
+		c := setOptions(`{"options":[{"name":"Size","values":["S"]}]}`)
+
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("SetProductOptions", 1, mock.Anything).Return(nil, fmt.Errorf("%w: variant 2 doesn't match them", models.ErrOptionsInUse))
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
+
+		err := productHandler.SetOptions(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=409, message=options in use: variant 2 doesn't match them")
+	})
+}
+
+func TestIndexByTags(t *testing.T) {
+	t.Run("should returns 200 with the normalized tags", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/products?tags=Starter,fire_type&tag_match=all", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		filter := models.ProductFilter{Tags: "Starter,fire_type", TagMatch: "all", TagNames: []string{"starter", "fire-type"}}
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("GetAllProducts", filter).Return(mocks.MockProducts[1:], nil)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
+
+		if assert.NoError(t, productHandler.Index(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			mockProductService.AssertExpectations(t)
+		}
+	})
+
+	t.Run("should returns 400 for an invalid tag", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/products?tags=starter,,new", nil)
+		c := e.NewContext(req, httptest.NewRecorder())
+
+		productHandler := NewProductHandler(&mocks.MockProductService{}, asEditor, asActor)
+
+		err := productHandler.Index(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=400, message=Invalid tags")
+	})
+
+	t.Run("should returns 400 for an invalid tag_match", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/products?tags=starter&tag_match=some", nil)
+		c := e.NewContext(req, httptest.NewRecorder())
+
+		productHandler := NewProductHandler(&mocks.MockProductService{}, asEditor, asActor)
+
+		err := productHandler.Index(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code)
+	})
+}
+
+func TestAddTags(t *testing.T) {
+	addTags := func(body string) echo.Context {
+		e := echo.New()
+		e.Validator = &CustomValidator{validator: validator.New()}
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/products/:id/tags", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+		return c
+	}
+
+	t.Run("should returns 200", func(t *testing.T) {
+		c := addTags(`{"tags":["starter"]}`)
+
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("AddProductTags", 1, []string{"starter"}).Return(mocks.MockProducts[0], nil)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
+
+		if assert.NoError(t, productHandler.AddTags(c)) {
+			assert.Equal(t, http.StatusOK, c.Response().Status)
+			mockProductService.AssertExpectations(t)
+		}
+	})
+
+	t.Run("should returns 422 without tags", func(t *testing.T) {
+		c := addTags(`{"tags":[]}`)
+
+		productHandler := NewProductHandler(&mocks.MockProductService{}, asEditor, asActor)
+
+		err := productHandler.AddTags(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, err.(*echo.HTTPError).Code)
+	})
+
+	t.Run("should returns 422 for an invalid tag", func(t *testing.T) {
+		c := addTags(`{"tags":["50% off"]}`)
+
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("AddProductTags", 1, []string{"50% off"}).Return(nil, fmt.Errorf("%w %q", models.ErrInvalidTag, "50%-off"))
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
+
+		err := productHandler.AddTags(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, err.(*echo.HTTPError).Code)
+	})
+}
+
+func TestRemoveTag(t *testing.T) {
+	t.Run("should returns 200", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/products/:id/tags/:tag", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id", "tag")
+		c.SetParamValues("1", "pok%C3%A9mon")
+
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("RemoveProductTag", 1, "pokémon").Return(mocks.MockProducts[0], nil)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
+
+		if assert.NoError(t, productHandler.RemoveTag(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			mockProductService.AssertExpectations(t)
+		}
+	})
+
+	t.Run("should returns 404", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/products/:id/tags/:tag", nil)
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("id", "tag")
+		c.SetParamValues("42", "starter")
+
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("RemoveProductTag", 42, "starter").Return(nil, gorm.ErrRecordNotFound)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
+
+		err := productHandler.RemoveTag(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=404, message=Failed to get product")
+	})
+}
+
+func TestShowBySKU(t *testing.T) {
+	t.Run("should returns 200", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/products/by-sku/:sku", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("sku")
+		c.SetParamValues("BULB-001")
+
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("GetProductBySKU", "BULB-001").Return(mocks.MockProducts[0], nil)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
+
+		if assert.NoError(t, productHandler.ShowBySKU(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			mockProductService.AssertExpectations(t)
+		}
+	})
+
+	t.Run("should returns 404", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/products/by-sku/:sku", nil)
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("sku")
+		c.SetParamValues("MISSING")
+
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("GetProductBySKU", "MISSING").Return(nil, gorm.ErrRecordNotFound)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
+
+		err := productHandler.ShowBySKU(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=404, message=Failed to get product")
+	})
+}
+
+func TestShowBySlug(t *testing.T) {
+	t.Run("should returns 200", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/products/by-slug/:slug", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("slug")
+		c.SetParamValues("bulbasaur")
+
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("GetProductBySlug", "bulbasaur").Return(mocks.MockProducts[0], nil)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
+
+		if assert.NoError(t, productHandler.ShowBySlug(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			mockProductService.AssertExpectations(t)
+		}
+	})
+}
+
+func TestCreateSKU(t *testing.T) {
+	create := func() echo.Context {
+		e := echo.New()
+		e.Validator = &CustomValidator{validator: validator.New()}
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/products", strings.NewReader(`{"title":"Bulbasaur","description":"A seed.","price":99.99,"sku":"BULB-001"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		return e.NewContext(req, httptest.NewRecorder())
+	}
+
+	t.Run("should returns 409 for a taken sku", func(t *testing.T) {
+		c := create()
+
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("CreateProduct", mock.Anything).Return(nil, &models.ConflictError{Field: "sku", Value: "BULB-001"})
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
+
+		err := productHandler.Create(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), `code=409, message=sku "BULB-001" is already taken`)
+	})
+
+	t.Run("should returns 422 for an invalid sku", func(t *testing.T) {
+		c := create()
+
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("CreateProduct", mock.Anything).Return(nil, fmt.Errorf("%w %q", models.ErrInvalidSKU, "BULB-001"))
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
+
+		err := productHandler.Create(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, err.(*echo.HTTPError).Code)
+	})
+}
+
+func TestIndexByStatus(t *testing.T) {
+	t.Run("should list only the published products to viewers", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/products?status=draft", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		filter := models.ProductFilter{Status: models.ProductDraft, Statuses: []models.ProductStatus{models.ProductPublished}}
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("GetAllProducts", filter).Return(mocks.MockProducts, nil)
+		productHandler := NewProductHandler(mockProductService, asViewer, asActor)
+
+		if assert.NoError(t, productHandler.Index(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			mockProductService.AssertExpectations(t)
+		}
+	})
+
+	t.Run("should list the products with the status to editors", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/products?status=draft", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		filter := models.ProductFilter{Status: models.ProductDraft, Statuses: []models.ProductStatus{models.ProductDraft}}
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("GetAllProducts", filter).Return([]*models.Product{}, nil)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
+
+		if assert.NoError(t, productHandler.Index(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			mockProductService.AssertExpectations(t)
+		}
+	})
+
+	t.Run("should returns 400 for an invalid status", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/products?status=deleted", nil)
+		c := e.NewContext(req, httptest.NewRecorder())
+
+		productHandler := NewProductHandler(&mocks.MockProductService{}, asEditor, asActor)
+
+		err := productHandler.Index(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=400, message=Invalid status, expected draft, published or archived")
+	})
+}
+
+func TestShowUnpublished(t *testing.T) {
+	draft := &models.Product{ID: 3, Title: "Squirtle", Status: models.ProductDraft}
+
+	t.Run("should returns 404 to viewers", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/products/:id", nil)
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("id")
+		c.SetParamValues("3")
+
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("GetProductByID", 3).Return(draft, nil)
+		productHandler := NewProductHandler(mockProductService, asViewer, asActor)
+
+		err := productHandler.Show(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=404, message=Failed to get product")
+	})
+
+	t.Run("should returns 200 to editors", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/products/by-slug/:slug", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("slug")
+		c.SetParamValues("squirtle")
+
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("GetProductBySlug", "squirtle").Return(draft, nil)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
+
+		if assert.NoError(t, productHandler.ShowBySlug(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+		}
+	})
+}
+
+func TestTransition(t *testing.T) {
+	t.Run("should returns 200 with the published product", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/products/:id/publish", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("TransitionProduct", 1, models.ProductPublished).Return(mocks.MockProducts[0], nil)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
+
+		if assert.NoError(t, productHandler.Publish(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+
+			var product models.Product
+			json.Unmarshal(rec.Body.Bytes(), &product)
+			assert.Equal(t, models.ProductPublished, product.Status)
+			mockProductService.AssertExpectations(t)
+		}
+	})
+
+	t.Run("should returns 409 for an invalid transition", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/products/:id/archive", nil)
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("TransitionProduct", 1, models.ProductArchived).Return(nil, fmt.Errorf("%w from draft to archived", models.ErrInvalidTransition))
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
+
+		err := productHandler.Archive(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=409, message=invalid status transition from draft to archived")
+	})
+
+	t.Run("should returns 404", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/products/:id/unarchive", nil)
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("id")
+		c.SetParamValues("42")
+
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("TransitionProduct", 42, models.ProductDraft).Return(nil, gorm.ErrRecordNotFound)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
+
+		err := productHandler.Unarchive(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=404, message=Failed to get product")
+	})
+
+	t.Run("should returns 400", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/products/:id/publish", nil)
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("id")
+		c.SetParamValues("abc")
+
+		productHandler := NewProductHandler(&mocks.MockProductService{}, asEditor, asActor)
+
+		err := productHandler.Publish(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=400, message=Invalid product ID")
+	})
+}
+
+func TestSchedule(t *testing.T) {
+	t.Run("should returns 200 with the scheduled product", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/products/:id/schedule", strings.NewReader(`{"publish_at": "2024-11-29T00:00:00Z", "unpublish_at": null}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		publishAt := time.Date(2024, 11, 29, 0, 0, 0, 0, time.UTC)
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("ScheduleProduct", 1, models.ProductSchedule{PublishAt: &publishAt}).Return(mocks.MockProducts[0], nil)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
+
+		if assert.NoError(t, productHandler.Schedule(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			mockProductService.AssertExpectations(t)
+		}
+	})
+
+	t.Run("should returns 422 for an invalid schedule", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/products/:id/schedule", strings.NewReader(`{}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("ScheduleProduct", 1, models.ProductSchedule{}).Return(nil, fmt.Errorf("%w: unpublish_at must be after publish_at", models.ErrInvalidSchedule))
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
+
+		err := productHandler.Schedule(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=422, message=invalid schedule: unpublish_at must be after publish_at")
+	})
+
+	t.Run("should returns 404", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/products/:id/schedule", strings.NewReader(`{}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("id")
+		c.SetParamValues("42")
+
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("ScheduleProduct", 42, models.ProductSchedule{}).Return(nil, gorm.ErrRecordNotFound)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
+
+		err := productHandler.Schedule(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=404, message=Failed to get product")
+	})
+}
+
+func TestProductAttributes(t *testing.T) {
+	t.Run("should returns 200 filtering by attribute", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/products?attr.weight_gt=2&attr.material=steel", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		filter := models.ProductFilter{AttributeQuery: map[string]string{"weight_gt": "2", "material": "steel"}}
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("GetAllProducts", filter).Return(mocks.MockProducts[1:], nil)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
+
+		if assert.NoError(t, productHandler.Index(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			mockProductService.AssertExpectations(t)
+		}
+	})
+
+	t.Run("should returns 400 for an invalid attribute filter", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/products?attr.material_gt=steel", nil)
+		c := e.NewContext(req, httptest.NewRecorder())
+
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("GetAllProducts", mock.Anything).Return(nil, fmt.Errorf("%w: only number attributes compare with gt", models.ErrInvalidAttribute))
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
+
+		err := productHandler.Index(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code)
+	})
+
+	t.Run("should returns 422 for an invalid attribute value", func(t *testing.T) {
+		e := echo.New()
+		e.Validator = &CustomValidator{validator: validator.New()}
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/products", strings.NewReader(`{"title":"Drill","description":"A drill.","price":99.99,"attributes":{"weight":"heavy"}}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		c := e.NewContext(req, httptest.NewRecorder())
+
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("CreateProduct", mock.MatchedBy(func(p *models.Product) bool {
+			return len(p.Attributes) == 1 && p.Attributes[0].Key == "weight" && p.Attributes[0].Value == "heavy"
+		})).Return(nil, fmt.Errorf("%w: weight must be a number", models.ErrInvalidAttribute))
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
+
+		err := productHandler.Create(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=422, message=invalid attribute: weight must be a number")
+		mockProductService.AssertExpectations(t)
+	})
+
+	t.Run("should returns 200 merging the attributes on update", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/products/:id", strings.NewReader(`{"attributes":{"material":"Wood","wireless":null}}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		weight, wireless := 2.5, true
+		product := *mocks.MockProducts[0]
+		product.Attributes = models.ProductAttributes{{ProductID: 1, Key: "weight", NumberValue: &weight}, {ProductID: 1, Key: "wireless", BoolValue: &wireless}}
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("GetProductForUpdate", 1).Return(&product, nil)
+		mockProductService.On("UpdateProduct", mock.MatchedBy(func(p *models.Product) bool {
+			return len(p.Attributes) == 2 && p.Attributes[0].Value == "Wood" && *p.Attributes[1].NumberValue == 2.5
+		}), mock.Anything).Return(&product, nil)
+		productHandler := NewProductHandler(mockProductService, asEditor, asActor)
+
+		if assert.NoError(t, productHandler.Update(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Contains(t, rec.Body.String(), `"attributes":{"material":"Wood","weight":2.5}`)
+			mockProductService.AssertExpectations(t)
+		}
+	})
+}
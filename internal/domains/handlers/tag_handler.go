@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/core/interfaces"
+	"github.com/labstack/echo"
+)
+
+type TagHandler struct {
+	tagService interfaces.TagServiceInterface
+}
+
+func NewTagHandler(tagService interfaces.TagServiceInterface) *TagHandler {
+	return &TagHandler{tagService: tagService}
+}
+
+// Index lists the tags in use with how many products have each, for facet
+// navigation.
+func (h *TagHandler) Index(c echo.Context) error {
+	tags, err := h.tagService.GetAllTags()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list the tags")
+	}
+
+	return c.JSON(http.StatusOK, tags)
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/mocks"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTagIndex(t *testing.T) {
+	t.Run("should returns 200", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/tags", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		mockTagService := &mocks.MockTagService{}
+		mockTagService.On("GetAllTags").Return(mocks.MockTagCounts, nil)
+		tagHandler := NewTagHandler(mockTagService)
+
+		if assert.NoError(t, tagHandler.Index(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+
+			var tags []models.TagCount
+			json.Unmarshal(rec.Body.Bytes(), &tags)
+
+			assert.Equal(t, []models.TagCount{{Name: "starter", Count: 3}, {Name: "fire-type", Count: 1}}, tags)
+			mockTagService.AssertExpectations(t)
+		}
+	})
+
+	t.Run("should returns 500", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/tags", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		mockTagService := &mocks.MockTagService{}
+		mockTagService.On("GetAllTags").Return(nil, fmt.Errorf("some error"))
+		tagHandler := NewTagHandler(mockTagService)
+
+		err := tagHandler.Index(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=500, message=Failed to list the tags")
+	})
+}
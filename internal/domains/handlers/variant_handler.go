@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/core/interfaces"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/labstack/echo"
+	"gorm.io/gorm"
+)
+
+type VariantHandler struct {
+	variantService interfaces.VariantServiceInterface
+	visibility     *ProductVisibility
+}
+
+// NewVariantHandler returns a handler that only shows the variants of the
+// products visible to the caller.
+func NewVariantHandler(variantService interfaces.VariantServiceInterface, visibility *ProductVisibility) *VariantHandler {
+	return &VariantHandler{variantService: variantService, visibility: visibility}
+}
+
+// Index lists the variants of a product.
+func (h *VariantHandler) Index(c echo.Context) error {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid product ID")
+	}
+	if err = h.visibility.check(c, productID); err != nil {
+		return err
+	}
+
+	variants, err := h.variantService.GetAllVariants(productID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "Failed to get product")
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list the variants")
+	}
+
+	return c.JSON(http.StatusOK, variants)
+}
+
+// Create adds a variant to a product.
+func (h *VariantHandler) Create(c echo.Context) error {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid product ID")
+	}
+
+	var variant models.ProductVariant
+	err = c.Bind(&variant)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Failed to decode variant data")
+	}
+
+	if err = c.Validate(variant); err != nil {
+		return err
+	}
+
+	createdVariant, err := h.variantService.CreateVariant(productID, &variant)
+	if err != nil {
+		return variantError(err, "Failed to create variant")
+	}
+
+	return c.JSON(http.StatusCreated, createdVariant)
+}
+
+// Generate adds a variant for every combination of the options of a product
+// that has none.
+func (h *VariantHandler) Generate(c echo.Context) error {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid product ID")
+	}
+
+	variants, err := h.variantService.GenerateVariants(productID)
+	if err != nil {
+		return variantError(err, "Failed to generate the variants")
+	}
+
+	return c.JSON(http.StatusCreated, variants)
+}
+
+func (h *VariantHandler) Show(c echo.Context) error {
+	productID, id, err := variantIDs(c)
+	if err != nil {
+		return err
+	}
+	if err = h.visibility.check(c, productID); err != nil {
+		return err
+	}
+
+	variant, err := h.variantService.GetVariant(productID, id)
+	if err != nil {
+		return variantError(err, "Failed to get variant")
+	}
+
+	return c.JSON(http.StatusOK, variant)
+}
+
+// Update changes the fields of a variant present in the body. A price of zero
+// removes the override, so that the variant has the price of the product.
+func (h *VariantHandler) Update(c echo.Context) error {
+	productID, id, err := variantIDs(c)
+	if err != nil {
+		return err
+	}
+
+	var updateVariant models.ProductVariant
+	err = c.Bind(&updateVariant)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Failed to decode variant data")
+	}
+
+	variant, err := h.variantService.GetVariant(productID, id)
+	if err != nil {
+		return variantError(err, "Failed to get variant")
+	}
+
+	if len(updateVariant.Options) > 0 {
+		variant.Options = updateVariant.Options
+	}
+
+	if updateVariant.SKU != nil {
+		variant.SKU = updateVariant.SKU
+	}
+
+	if updateVariant.Price != nil {
+		switch {
+		case *updateVariant.Price < 0:
+			return echo.NewHTTPError(http.StatusUnprocessableEntity, "The price of a variant can't be negative")
+		case *updateVariant.Price == 0:
+			variant.Price = nil
+		default:
+			variant.Price = updateVariant.Price
+		}
+	}
+
+	updatedVariant, err := h.variantService.UpdateVariant(variant)
+	if err != nil {
+		return variantError(err, "Failed to update variant")
+	}
+
+	return c.JSON(http.StatusOK, updatedVariant)
+}
+
+func (h *VariantHandler) Delete(c echo.Context) error {
+	productID, id, err := variantIDs(c)
+	if err != nil {
+		return err
+	}
+
+	err = h.variantService.DeleteVariant(productID, id)
+	if err != nil {
+		return variantError(err, "Failed to delete variant")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// variantIDs parses the ids of the product and of the variant in the path.
+func variantIDs(c echo.Context) (int, int, error) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return 0, 0, echo.NewHTTPError(http.StatusBadRequest, "Invalid product ID")
+	}
+	id, err := strconv.Atoi(c.Param("variant_id"))
+	if err != nil {
+		return 0, 0, echo.NewHTTPError(http.StatusBadRequest, "Invalid variant ID")
+	}
+	return productID, id, nil
+}
+
+// variantError maps the errors of the variants to their status, or to a 500
+// with message.
+func variantError(err error, message string) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "Failed to get variant")
+	}
+	if errors.Is(err, models.ErrInvalidVariant) || errors.Is(err, models.ErrInvalidSKU) {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	}
+	var conflict *models.ConflictError
+	if errors.As(err, &conflict) {
+		return echo.NewHTTPError(http.StatusConflict, conflict.Error())
+	}
+	return echo.NewHTTPError(http.StatusInternalServerError, message)
+}
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/mocks"
+	"github.com/adrianosiqe/eulabs-challenge-api/pkg/money"
+	"github.com/go-playground/validator"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func TestVariantsOfUnpublished(t *testing.T) {
+	draft := &models.Product{ID: 3, Status: models.ProductDraft}
+
+	t.Run("should returns 404 to viewers", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/products/:id/variants/:variant_id", nil)
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("id", "variant_id")
+		c.SetParamValues("3", "1")
+
+		mockProductService := &mocks.MockProductService{}
+		mockProductService.On("GetProductByID", 3).Return(draft, nil)
+		mockVariantService := &mocks.MockVariantService{}
+		variantHandler := NewVariantHandler(mockVariantService, NewProductVisibility(mockProductService, asViewer))
+
+		err := variantHandler.Show(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=404, message=Failed to get product")
+		mockVariantService.AssertNotCalled(t, "GetVariant", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should returns 200 to editors", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/products/:id/variants", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("3")
+
+		mockVariantService := &mocks.MockVariantService{}
+		mockVariantService.On("GetAllVariants", 3).Return([]*models.ProductVariant{}, nil)
+		variantHandler := NewVariantHandler(mockVariantService, NewProductVisibility(&mocks.MockProductService{}, asEditor))
+
+		if assert.NoError(t, variantHandler.Index(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+		}
+	})
+}
+
+func TestCreateVariant(t *testing.T) {
+	t.Run("should returns 201 with the variant", func(t *testing.T) {
+		e := echo.New()
+		e.Validator = &CustomValidator{validator: validator.New()}
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/products/:id/variants", strings.NewReader(`{"options": {"Size": "S"}}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		mockVariantService := &mocks.MockVariantService{}
+		mockVariantService.On("CreateVariant", 1, &models.ProductVariant{Options: models.VariantOptions{"Size": "S"}}).Return(mocks.MockVariant, nil)
+		variantHandler := NewVariantHandler(mockVariantService, everyProduct)
+
+		if assert.NoError(t, variantHandler.Create(c)) {
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			mockVariantService.AssertExpectations(t)
+		}
+	})
+
+	t.Run("should returns 422 without options", func(t *testing.T) {
+		e := echo.New()
+		e.Validator = &CustomValidator{validator: validator.New()}
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/products/:id/variants", strings.NewReader(`{"price": "9.90"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		mockVariantService := &mocks.MockVariantService{}
+		variantHandler := NewVariantHandler(mockVariantService, everyProduct)
+
+		err := variantHandler.Create(c)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "code=422")
+		mockVariantService.AssertNotCalled(t, "CreateVariant", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should returns 409 for a combination in use", func(t *testing.T) {
+		e := echo.New()
+		e.Validator = &CustomValidator{validator: validator.New()}
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/products/:id/variants", strings.NewReader(`{"options": {"Size": "S"}}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		mockVariantService := &mocks.MockVariantService{}
+		mockVariantService.On("CreateVariant", 1, mock.Anything).Return(nil, &models.ConflictError{Field: "options", Value: "size=s"})
+		variantHandler := NewVariantHandler(mockVariantService, everyProduct)
+
+		err := variantHandler.Create(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), `code=409, message=options "size=s" is already taken`)
+	})
+
+	t.Run("should returns 422 for a value the option doesn't have", func(t *testing.T) {
+		e := echo.New()
+		e.Validator = &CustomValidator{validator: validator.New()}
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/products/:id/variants", strings.NewReader(`{"options": {"Size": "XL"}}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		mockVariantService := &mocks.MockVariantService{}
+		mockVariantService.On("CreateVariant", 1, mock.Anything).Return(nil, fmt.Errorf(`%w: "XL" is not a value of "Size"`, models.ErrInvalidVariant))
+		variantHandler := NewVariantHandler(mockVariantService, everyProduct)
+
+		err := variantHandler.Create(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), `code=422, message=invalid variant: "XL" is not a value of "Size"`)
+	})
+}
+
+func TestUpdateVariant(t *testing.T) {
+	t.Run("should remove the price override with a price of zero", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/products/:id/variants/:variant_id", strings.NewReader(`{"price": 0}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id", "variant_id")
+		c.SetParamValues("1", "1")
+
+		price := money.MustParseAmount("9.9")
+		variant := *mocks.MockVariant
+		variant.Price = &price
+		mockVariantService := &mocks.MockVariantService{}
+		mockVariantService.On("GetVariant", 1, 1).Return(&variant, nil)
+		mockVariantService.On("UpdateVariant", mock.Anything).Return(mocks.MockVariant, nil)
+		variantHandler := NewVariantHandler(mockVariantService, everyProduct)
+
+		if assert.NoError(t, variantHandler.Update(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			updated := mockVariantService.Calls[1].Arguments.Get(0).(*models.ProductVariant)
+			assert.Nil(t, updated.Price)
+			assert.Equal(t, mocks.MockVariant.Options, updated.Options)
+		}
+	})
+
+	t.Run("should returns 404 for a variant of another product", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/products/:id/variants/:variant_id", strings.NewReader(`{"sku": "TSHIRT-S"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("id", "variant_id")
+		c.SetParamValues("2", "1")
+
+		mockVariantService := &mocks.MockVariantService{}
+		mockVariantService.On("GetVariant", 2, 1).Return(nil, gorm.ErrRecordNotFound)
+		variantHandler := NewVariantHandler(mockVariantService, everyProduct)
+
+		err := variantHandler.Update(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=404, message=Failed to get variant")
+		mockVariantService.AssertNotCalled(t, "UpdateVariant", mock.Anything)
+	})
+}
+
+func TestDeleteVariant(t *testing.T) {
+	t.Run("should returns 204", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/products/:id/variants/:variant_id", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id", "variant_id")
+		c.SetParamValues("1", "1")
+
+		mockVariantService := &mocks.MockVariantService{}
+		mockVariantService.On("DeleteVariant", 1, 1).Return(nil)
+		variantHandler := NewVariantHandler(mockVariantService, everyProduct)
+
+		if assert.NoError(t, variantHandler.Delete(c)) {
+			assert.Equal(t, http.StatusNoContent, rec.Code)
+		}
+	})
+
+	t.Run("should returns 400 for an invalid variant id", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/products/:id/variants/:variant_id", nil)
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.SetParamNames("id", "variant_id")
+		c.SetParamValues("1", "small")
+
+		variantHandler := NewVariantHandler(&mocks.MockVariantService{}, everyProduct)
+
+		err := variantHandler.Delete(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, err.Error(), "code=400, message=Invalid variant ID")
+	})
+}
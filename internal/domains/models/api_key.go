@@ -0,0 +1,65 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type APIKey struct {
+	ID     uint   `gorm:"primaryKey" json:"id"`
+	Name   string `gorm:"size:255" json:"name" validate:"required"`
+	Prefix string `gorm:"size:32;uniqueIndex" json:"prefix" openapi:"readOnly"`
+	Hash   string `gorm:"size:64" json:"-"`
+	// Scopes are the permissions granted to the key; the handler checks them
+	// against the permissions of the server.
+	Scopes     Scopes     `gorm:"size:255" json:"scopes" validate:"required,dive,required"`
+	Key        string     `gorm:"-" json:"key,omitempty" openapi:"readOnly"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at" openapi:"readOnly"`
+	RevokedAt  *time.Time `json:"revoked_at" openapi:"readOnly"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// Scopes is stored as a comma separated list.
+type Scopes []string
+
+// GormDataType stores the scopes in a string column on every dialect.
+func (Scopes) GormDataType() string {
+	return "string"
+}
+
+func (s Scopes) Value() (driver.Value, error) {
+	return strings.Join(s, ","), nil
+}
+
+func (s *Scopes) Scan(value interface{}) error {
+	var raw string
+	switch v := value.(type) {
+	case string:
+		raw = v
+	case []byte:
+		raw = string(v)
+	case nil:
+		*s = nil
+		return nil
+	default:
+		return fmt.Errorf("unsupported scopes value %T", value)
+	}
+
+	if raw == "" {
+		*s = Scopes{}
+		return nil
+	}
+	*s = strings.Split(raw, ",")
+	return nil
+}
+
+func (k *APIKey) Active(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
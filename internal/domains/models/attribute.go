@@ -0,0 +1,424 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrInvalidAttribute is returned for attribute definitions that don't make
+// sense, and for attribute values or filters that don't match their
+// definitions.
+var ErrInvalidAttribute = errors.New("invalid attribute")
+
+const (
+	// MaxAttributeLength bounds the values of string and enum attributes.
+	MaxAttributeLength = 255
+	// AttributeQueryPrefix starts the query parameters that filter the
+	// products by attribute, as in attr.weight_gt=2.
+	AttributeQueryPrefix = "attr."
+)
+
+// attributeKeyPattern is matched by the keys of the attributes, which are used
+// in query parameters.
+var attributeKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+// AttributeType is the type of the values of an attribute.
+type AttributeType string
+
+const (
+	AttributeString AttributeType = "string"
+	AttributeNumber AttributeType = "number"
+	AttributeBool   AttributeType = "bool"
+	AttributeEnum   AttributeType = "enum"
+)
+
+// AttributeDefinition describes a spec that products can have, such as their
+// weight, with the rules its values follow.
+type AttributeDefinition struct {
+	// Key names the attribute in the products and in the filters. It doesn't
+	// change after creation.
+	Key  string        `gorm:"primaryKey;size:50" json:"key" validate:"required"`
+	Name string        `gorm:"size:100;not null" json:"name" validate:"required,max=100"`
+	Type AttributeType `gorm:"size:10;not null" json:"type" validate:"required,oneof=string number bool enum"`
+	// Unit is the unit of a number attribute, such as kg or V.
+	Unit string `gorm:"size:20;not null;default:''" json:"unit,omitempty" validate:"max=20"`
+	// Values lists the values of an enum attribute.
+	Values OptionValues `gorm:"column:enum_values;size:1000" json:"values,omitempty"`
+	// Min and Max bound the values of a number attribute.
+	Min *float64 `gorm:"column:min_value" json:"min,omitempty"`
+	Max *float64 `gorm:"column:max_value" json:"max,omitempty"`
+	// MaxLength bounds the values of a string attribute, up to
+	// MaxAttributeLength characters.
+	MaxLength int       `gorm:"not null;default:0" json:"max_length,omitempty" validate:"gte=0"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Normalize trims the definition and checks that its rules fit its type.
+func (d *AttributeDefinition) Normalize() error {
+	d.Key, d.Name, d.Unit = strings.TrimSpace(d.Key), strings.TrimSpace(d.Name), strings.TrimSpace(d.Unit)
+	if !attributeKeyPattern.MatchString(d.Key) {
+		return fmt.Errorf("%w: the key %q must be lowercase letters, digits and _, starting with a letter, up to 50 characters", ErrInvalidAttribute, d.Key)
+	}
+
+	if d.Type != AttributeNumber && (d.Unit != "" || d.Min != nil || d.Max != nil) {
+		return fmt.Errorf("%w: only number attributes have a unit, a min or a max", ErrInvalidAttribute)
+	}
+	if d.Type != AttributeString && d.MaxLength != 0 {
+		return fmt.Errorf("%w: only string attributes have a max_length", ErrInvalidAttribute)
+	}
+	if d.Type != AttributeEnum && len(d.Values) > 0 {
+		return fmt.Errorf("%w: only enum attributes have values", ErrInvalidAttribute)
+	}
+
+	switch d.Type {
+	case AttributeNumber:
+		if d.Min != nil && d.Max != nil && *d.Min > *d.Max {
+			return fmt.Errorf("%w: min is greater than max", ErrInvalidAttribute)
+		}
+	case AttributeString:
+		if d.MaxLength > MaxAttributeLength {
+			return fmt.Errorf("%w: max_length is at most %d", ErrInvalidAttribute, MaxAttributeLength)
+		}
+	case AttributeEnum:
+		if len(d.Values) == 0 {
+			return fmt.Errorf("%w: enum attributes need values", ErrInvalidAttribute)
+		}
+		seen := map[string]bool{}
+		for i, value := range d.Values {
+			value = strings.TrimSpace(value)
+			switch {
+			case value == "" || utf8.RuneCountInString(value) > MaxOptionLength:
+				return fmt.Errorf("%w: values have 1 to %d characters", ErrInvalidAttribute, MaxOptionLength)
+			case strings.Contains(value, ","):
+				return fmt.Errorf("%w: %q has a comma", ErrInvalidAttribute, value)
+			case seen[strings.ToLower(value)]:
+				return fmt.Errorf("%w: %q is repeated", ErrInvalidAttribute, value)
+			}
+			seen[strings.ToLower(value)] = true
+			d.Values[i] = value
+		}
+	case AttributeBool:
+	default:
+		return fmt.Errorf("%w: the type must be string, number, bool or enum", ErrInvalidAttribute)
+	}
+	return nil
+}
+
+// Check checks a value against the definition and returns the attribute of
+// key d.Key holding it, with enum values spelled like in the definition.
+func (d *AttributeDefinition) Check(value interface{}) (ProductAttribute, error) {
+	attribute := ProductAttribute{Key: d.Key}
+	switch d.Type {
+	case AttributeNumber:
+		number, ok := value.(float64)
+		if !ok {
+			return attribute, fmt.Errorf("%w: %s must be a number", ErrInvalidAttribute, d.Key)
+		}
+		if d.Min != nil && number < *d.Min || d.Max != nil && number > *d.Max {
+			return attribute, fmt.Errorf("%w: %s must be between %s", ErrInvalidAttribute, d.Key, d.bounds())
+		}
+		attribute.NumberValue = &number
+	case AttributeBool:
+		flag, ok := value.(bool)
+		if !ok {
+			return attribute, fmt.Errorf("%w: %s must be true or false", ErrInvalidAttribute, d.Key)
+		}
+		attribute.BoolValue = &flag
+	case AttributeString, AttributeEnum:
+		text, ok := value.(string)
+		if !ok {
+			return attribute, fmt.Errorf("%w: %s must be a string", ErrInvalidAttribute, d.Key)
+		}
+		text = strings.TrimSpace(text)
+		maxLength := MaxAttributeLength
+		if d.MaxLength > 0 {
+			maxLength = d.MaxLength
+		}
+		if text == "" || utf8.RuneCountInString(text) > maxLength {
+			return attribute, fmt.Errorf("%w: %s must have 1 to %d characters", ErrInvalidAttribute, d.Key, maxLength)
+		}
+		if d.Type == AttributeEnum {
+			allowed, found := "", false
+			for _, allowed = range d.Values {
+				if found = strings.EqualFold(allowed, text); found {
+					break
+				}
+			}
+			if !found {
+				return attribute, fmt.Errorf("%w: %s must be one of %s", ErrInvalidAttribute, d.Key, strings.Join(d.Values, ", "))
+			}
+			text = allowed
+		}
+		attribute.StringValue = &text
+	default:
+		return attribute, fmt.Errorf("%w: %s has the unknown type %q", ErrInvalidAttribute, d.Key, d.Type)
+	}
+	return attribute, nil
+}
+
+// bounds describes the range of a number attribute.
+func (d *AttributeDefinition) bounds() string {
+	format := func(bound *float64, unbounded string) string {
+		if bound == nil {
+			return unbounded
+		}
+		return strconv.FormatFloat(*bound, 'f', -1, 64)
+	}
+	return format(d.Min, "-∞") + " and " + format(d.Max, "∞")
+}
+
+// ProductAttribute is the value of an attribute of a product, stored in the
+// column of the type of the attribute.
+type ProductAttribute struct {
+	ProductID   uint     `gorm:"primaryKey" json:"-"`
+	Key         string   `gorm:"column:attribute_key;primaryKey;size:50" json:"key"`
+	StringValue *string  `json:"-"`
+	NumberValue *float64 `json:"-"`
+	BoolValue   *bool    `json:"-"`
+	// Value holds the value decoded from a request until it is checked
+	// against the definition of the attribute.
+	Value interface{} `gorm:"-" json:"value"`
+}
+
+// Typed returns the value of the attribute, nil when it has none.
+func (a ProductAttribute) Typed() interface{} {
+	switch {
+	case a.StringValue != nil:
+		return *a.StringValue
+	case a.NumberValue != nil:
+		return *a.NumberValue
+	case a.BoolValue != nil:
+		return *a.BoolValue
+	}
+	return a.Value
+}
+
+// Column returns the column holding the value of the attribute and the value.
+func (a ProductAttribute) Column() (string, interface{}) {
+	switch {
+	case a.NumberValue != nil:
+		return "number_value", *a.NumberValue
+	case a.BoolValue != nil:
+		return "bool_value", *a.BoolValue
+	}
+	return "string_value", a.StringValue
+}
+
+// ProductAttributes are the attributes of a product, sorted by key. They are
+// encoded in JSON as an object of values keyed by attribute, where null
+// removes an attribute on update.
+type ProductAttributes []ProductAttribute
+
+// OpenAPIType documents the attributes as the object they are encoded to.
+func (ProductAttributes) OpenAPIType() string {
+	return "object"
+}
+
+func (a ProductAttributes) MarshalJSON() ([]byte, error) {
+	values := make(map[string]interface{}, len(a))
+	for _, attribute := range a {
+		values[attribute.Key] = attribute.Typed()
+	}
+	return json.Marshal(values)
+}
+
+func (a *ProductAttributes) UnmarshalJSON(data []byte) error {
+	var values map[string]interface{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	if values == nil {
+		*a = nil
+		return nil
+	}
+
+	attributes := make(ProductAttributes, 0, len(values))
+	for key, value := range values {
+		attributes = append(attributes, ProductAttribute{Key: key, Value: value})
+	}
+	attributes.sort()
+	*a = attributes
+	return nil
+}
+
+func (a ProductAttributes) sort() {
+	sort.Slice(a, func(i, j int) bool { return a[i].Key < a[j].Key })
+}
+
+// Decoded reports whether some of the attributes were decoded from a request
+// and are yet to be checked.
+func (a ProductAttributes) Decoded() bool {
+	for _, attribute := range a {
+		if attribute.Value != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Merge returns the attributes with changes applied over them. The changes
+// without a value remove the attribute.
+func (a ProductAttributes) Merge(changes ProductAttributes) ProductAttributes {
+	merged := ProductAttributes{}
+	changed := map[string]bool{}
+	for _, change := range changes {
+		changed[change.Key] = true
+		if change.Typed() != nil {
+			merged = append(merged, change)
+		}
+	}
+	for _, attribute := range a {
+		if !changed[attribute.Key] {
+			merged = append(merged, attribute)
+		}
+	}
+	merged.sort()
+	return merged
+}
+
+// NormalizeAttributes checks the attributes decoded from a request against
+// definitions, keyed by attribute, and returns them with their values in the
+// columns of their types. The attributes without a value are left out, and
+// the stored ones are kept as they are.
+func NormalizeAttributes(attributes ProductAttributes, definitions map[string]AttributeDefinition) (ProductAttributes, error) {
+	normalized := ProductAttributes{}
+	for _, attribute := range attributes {
+		value := attribute.Value
+		if value == nil {
+			if attribute.Typed() != nil {
+				normalized = append(normalized, attribute)
+			}
+			continue
+		}
+		definition, ok := definitions[attribute.Key]
+		if !ok {
+			return nil, fmt.Errorf("%w: there is no attribute %q", ErrInvalidAttribute, attribute.Key)
+		}
+		checked, err := definition.Check(value)
+		if err != nil {
+			return nil, err
+		}
+		checked.ProductID = attribute.ProductID
+		normalized = append(normalized, checked)
+	}
+	normalized.sort()
+	return normalized, nil
+}
+
+// AttributeOperator compares the value of an attribute in a filter.
+type AttributeOperator string
+
+const (
+	AttributeEq  AttributeOperator = "eq"
+	AttributeGt  AttributeOperator = "gt"
+	AttributeGte AttributeOperator = "gte"
+	AttributeLt  AttributeOperator = "lt"
+	AttributeLte AttributeOperator = "lte"
+)
+
+// SQL returns the SQL comparison of the operator.
+func (o AttributeOperator) SQL() string {
+	return map[AttributeOperator]string{AttributeEq: "=", AttributeGt: ">", AttributeGte: ">=", AttributeLt: "<", AttributeLte: "<="}[o]
+}
+
+// AttributeCondition keeps the products whose attribute Attribute.Key
+// compares to the value of Attribute with Operator.
+type AttributeCondition struct {
+	Attribute ProductAttribute
+	Operator  AttributeOperator
+}
+
+// Match reports whether attributes meet the condition.
+func (c AttributeCondition) Match(attributes ProductAttributes) bool {
+	for _, attribute := range attributes {
+		if attribute.Key != c.Attribute.Key {
+			continue
+		}
+		switch {
+		case c.Attribute.NumberValue != nil && attribute.NumberValue != nil:
+			value, bound := *attribute.NumberValue, *c.Attribute.NumberValue
+			switch c.Operator {
+			case AttributeGt:
+				return value > bound
+			case AttributeGte:
+				return value >= bound
+			case AttributeLt:
+				return value < bound
+			case AttributeLte:
+				return value <= bound
+			}
+			return value == bound
+		case c.Attribute.StringValue != nil && attribute.StringValue != nil:
+			return *attribute.StringValue == *c.Attribute.StringValue
+		case c.Attribute.BoolValue != nil && attribute.BoolValue != nil:
+			return *attribute.BoolValue == *c.Attribute.BoolValue
+		}
+		return false
+	}
+	return false
+}
+
+// ParseAttributeConditions parses the filters of query, keyed by parameter
+// without AttributeQueryPrefix, as in weight_gt=2 or material=steel, against
+// definitions. Numbers compare with eq, gt, gte, lt and lte; the other types
+// only with eq, which is the default.
+func ParseAttributeConditions(query map[string]string, definitions map[string]AttributeDefinition) ([]AttributeCondition, error) {
+	params := make([]string, 0, len(query))
+	for param := range query {
+		params = append(params, param)
+	}
+	sort.Strings(params)
+
+	conditions := []AttributeCondition{}
+	for _, param := range params {
+		key, operator := param, AttributeEq
+		definition, ok := definitions[key]
+		if !ok {
+			if i := strings.LastIndex(param, "_"); i > 0 {
+				key, operator = param[:i], AttributeOperator(param[i+1:])
+				definition, ok = definitions[key]
+			}
+		}
+		if !ok || operator.SQL() == "" {
+			return nil, fmt.Errorf("%w: %s%s is not an attribute filter", ErrInvalidAttribute, AttributeQueryPrefix, param)
+		}
+		if operator != AttributeEq && definition.Type != AttributeNumber {
+			return nil, fmt.Errorf("%w: only number attributes compare with %s", ErrInvalidAttribute, operator)
+		}
+
+		var value interface{} = query[param]
+		switch definition.Type {
+		case AttributeNumber:
+			number, err := strconv.ParseFloat(query[param], 64)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s must be a number", ErrInvalidAttribute, key)
+			}
+			value = number
+		case AttributeBool:
+			flag, err := strconv.ParseBool(query[param])
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s must be true or false", ErrInvalidAttribute, key)
+			}
+			value = flag
+		}
+
+		// Filters aren't bound by the rules of the values, so that
+		// weight_gt=0 works on weights from 1.
+		unbounded := definition
+		unbounded.Min, unbounded.Max, unbounded.MaxLength = nil, nil, 0
+		attribute, err := unbounded.Check(value)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, AttributeCondition{Attribute: attribute, Operator: operator})
+	}
+	return conditions, nil
+}
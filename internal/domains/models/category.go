@@ -0,0 +1,54 @@
+package models
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrCategoryCycle is returned when a category would be moved under
+	// itself or one of its descendants.
+	ErrCategoryCycle = errors.New("a category cannot be moved under itself or its descendants")
+	// ErrCategoryHasChildren is returned when deleting a category that still
+	// has subcategories.
+	ErrCategoryHasChildren = errors.New("category has subcategories")
+)
+
+// Category classifies products in a tree. Each category points to its parent
+// and also keeps the materialized Path of ids from the root, such as "/1/4/9/",
+// so that a whole subtree is found with a prefix match.
+type Category struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"size:255" json:"name" validate:"required"`
+	ParentID  *uint     `gorm:"index" json:"parent_id"`
+	Path      string    `gorm:"size:255;index" json:"path" openapi:"readOnly"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CategoryPath returns the path of the category id under the parent path,
+// which is empty for root categories.
+func CategoryPath(parentPath string, id uint) string {
+	if parentPath == "" {
+		parentPath = "/"
+	}
+	return parentPath + strconv.FormatUint(uint64(id), 10) + "/"
+}
+
+// IsDescendantOf reports whether c is other or lies in its subtree.
+func (c *Category) IsDescendantOf(other *Category) bool {
+	return strings.HasPrefix(c.Path, other.Path)
+}
+
+// CategoryMove is the body of a move: the new parent, or null to make the
+// category a root.
+type CategoryMove struct {
+	ParentID *uint `json:"parent_id"`
+}
+
+// ProductCategories is the body replacing the categories of a product.
+type ProductCategories struct {
+	CategoryIDs []uint `json:"category_ids" validate:"required"`
+}
@@ -0,0 +1,169 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	// ErrInsufficientStock is returned when a reservation or an adjustment
+	// would take more than the available stock.
+	ErrInsufficientStock = errors.New("insufficient stock")
+	// ErrReservationClosed is returned when committing or releasing a
+	// reservation that expired or was already committed or released.
+	ErrReservationClosed = errors.New("reservation is not active")
+	// ErrInvalidWarehouse is returned for warehouse names with characters
+	// other than letters, digits and dashes, or that are too long.
+	ErrInvalidWarehouse = errors.New("invalid warehouse")
+	// ErrInvalidExpiry is returned for reservations that would last longer
+	// than allowed.
+	ErrInvalidExpiry = errors.New("invalid reservation expiry")
+)
+
+// DefaultWarehouse holds the stock of the requests that don't name a
+// warehouse.
+const DefaultWarehouse = "default"
+
+const MaxWarehouseLength = 50
+
+// StockLevel is the stock of a product, or of one of its variants, in a
+// warehouse. Reserved and Available are computed from the active reservations
+// when the level is read.
+type StockLevel struct {
+	ID        uint `gorm:"primaryKey" json:"-"`
+	ProductID uint `gorm:"not null;uniqueIndex:idx_stock_levels_product_variant_warehouse" json:"-"`
+	// VariantID is zero for the stock of the product itself.
+	VariantID uint      `gorm:"not null;default:0;uniqueIndex:idx_stock_levels_product_variant_warehouse" json:"variant_id,omitempty"`
+	Warehouse string    `gorm:"size:50;not null;uniqueIndex:idx_stock_levels_product_variant_warehouse" json:"warehouse"`
+	OnHand    int64     `gorm:"not null;default:0" json:"on_hand"`
+	Reserved  int64     `gorm:"-" json:"reserved"`
+	Available int64     `gorm:"-" json:"available"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Stock is the stock of a product, in total and per warehouse.
+type Stock struct {
+	ProductID  uint         `json:"product_id"`
+	OnHand     int64        `json:"on_hand"`
+	Reserved   int64        `json:"reserved"`
+	Available  int64        `json:"available"`
+	Warehouses []StockLevel `json:"warehouses"`
+}
+
+// NewStock sums up the levels of a product.
+func NewStock(productID uint, levels []StockLevel) *Stock {
+	stock := &Stock{ProductID: productID, Warehouses: levels}
+	for _, level := range levels {
+		stock.OnHand += level.OnHand
+		stock.Reserved += level.Reserved
+		stock.Available += level.Available
+	}
+	return stock
+}
+
+// StockReason tells why the stock of a product changed.
+type StockReason string
+
+const (
+	StockReceived   StockReason = "received"
+	StockSold       StockReason = "sold"
+	StockReturned   StockReason = "returned"
+	StockDamaged    StockReason = "damaged"
+	StockCorrection StockReason = "correction"
+)
+
+// StockMovement records a change of the stock of a product, for auditing.
+type StockMovement struct {
+	ID            uint        `gorm:"primaryKey" json:"id"`
+	ProductID     uint        `gorm:"not null;index" json:"product_id"`
+	VariantID     uint        `gorm:"not null;default:0" json:"variant_id,omitempty"`
+	Warehouse     string      `gorm:"size:50;not null" json:"warehouse"`
+	Delta         int64       `gorm:"not null" json:"delta"`
+	Reason        StockReason `gorm:"size:20;not null" json:"reason"`
+	Note          string      `gorm:"size:255" json:"note"`
+	ReservationID *uint       `json:"reservation_id"`
+	CreatedAt     time.Time   `json:"created_at"`
+}
+
+// StockAdjustment is the body of the requests that change the on hand stock
+// of a product, such as a delivery (a positive delta) or a loss (a negative
+// one).
+type StockAdjustment struct {
+	// VariantID adjusts the stock of a variant of the product instead.
+	VariantID uint        `json:"variant_id"`
+	Warehouse string      `json:"warehouse"`
+	Delta     int64       `json:"delta" validate:"required"`
+	Reason    StockReason `json:"reason" validate:"required,oneof=received sold returned damaged correction"`
+	Note      string      `json:"note" validate:"max=255"`
+}
+
+// ReservationStatus is the state of a reservation. Expired is never stored:
+// it is shown for the active reservations past their expiry.
+type ReservationStatus string
+
+const (
+	ReservationActive    ReservationStatus = "active"
+	ReservationCommitted ReservationStatus = "committed"
+	ReservationReleased  ReservationStatus = "released"
+	ReservationExpired   ReservationStatus = "expired"
+)
+
+// Reservation holds stock of a product, for a checkout for instance, until it
+// is committed as a sale, released or expires.
+type Reservation struct {
+	ID        uint              `gorm:"primaryKey" json:"id"`
+	ProductID uint              `gorm:"not null;index" json:"product_id"`
+	VariantID uint              `gorm:"not null;default:0" json:"variant_id,omitempty"`
+	Warehouse string            `gorm:"size:50;not null" json:"warehouse"`
+	Quantity  int64             `gorm:"not null" json:"quantity"`
+	Status    ReservationStatus `gorm:"size:20;not null;index" json:"status"`
+	ExpiresAt time.Time         `gorm:"index" json:"expires_at"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// Active reports whether the reservation still holds its stock at now.
+func (r *Reservation) Active(now time.Time) bool {
+	return r.Status == ReservationActive && r.ExpiresAt.After(now)
+}
+
+// ShowExpiry reports an active reservation past its expiry at now as
+// expired.
+func (r *Reservation) ShowExpiry(now time.Time) {
+	if r.Status == ReservationActive && !r.Active(now) {
+		r.Status = ReservationExpired
+	}
+}
+
+// ReservationRequest is the body of the requests that reserve stock.
+type ReservationRequest struct {
+	// VariantID reserves stock of a variant of the product instead.
+	VariantID uint   `json:"variant_id"`
+	Warehouse string `json:"warehouse"`
+	Quantity  int64  `json:"quantity" validate:"required,gt=0"`
+	// ExpiresIn is how many seconds the reservation lasts; zero uses the
+	// configured default.
+	ExpiresIn int `json:"expires_in" validate:"gte=0"`
+}
+
+// NormalizeWarehouse lower cases name, falling back to DefaultWarehouse when
+// it is blank.
+func NormalizeWarehouse(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return DefaultWarehouse, nil
+	}
+	if utf8.RuneCountInString(name) > MaxWarehouseLength {
+		return "", fmt.Errorf("%w %q", ErrInvalidWarehouse, name)
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' {
+			return "", fmt.Errorf("%w %q", ErrInvalidWarehouse, name)
+		}
+	}
+	return name, nil
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"errors"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	// ErrUnsupportedMedia is returned for uploads that aren't JPEG, PNG or
+	// GIF images, or that can't be decoded as one.
+	ErrUnsupportedMedia = errors.New("unsupported media")
+	// ErrMediaTooLarge is returned for uploads over the size limit, or with
+	// too many pixels to make a thumbnail of.
+	ErrMediaTooLarge = errors.New("media too large")
+	// ErrInvalidMediaOrder is returned when reordering the media of a product
+	// doesn't list each of them exactly once.
+	ErrInvalidMediaOrder = errors.New("invalid media order")
+)
+
+// MaxMediaPixels bounds the size of the images that are decoded, so that a
+// small file can't take a lot of memory to make a thumbnail of.
+const MaxMediaPixels = 40_000_000
+
+// MediaTypes maps the content types accepted for the media to the extension
+// their files are stored with.
+var MediaTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// ProductMedia is an image of a product. Its file, and a thumbnail of it, are
+// kept in the media storage under FileKey and ThumbnailKey. The media of a
+// product are shown by Position, and one of them is its primary image.
+type ProductMedia struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	ProductID    uint      `gorm:"not null;index" json:"product_id"`
+	Filename     string    `gorm:"size:255;not null" json:"filename"`
+	ContentType  string    `gorm:"size:50;not null" json:"content_type"`
+	Size         int64     `gorm:"not null" json:"size"`
+	Width        int       `gorm:"not null" json:"width"`
+	Height       int       `gorm:"not null" json:"height"`
+	Position     int       `gorm:"not null;default:0" json:"position"`
+	Primary      bool      `gorm:"column:is_primary;not null;default:false" json:"primary"`
+	FileKey      string    `gorm:"size:255;not null" json:"-"`
+	ThumbnailKey string    `gorm:"size:255;not null" json:"-"`
+	URL          string    `gorm:"-" json:"url"`
+	ThumbnailURL string    `gorm:"-" json:"thumbnail_url"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (ProductMedia) TableName() string {
+	return "product_media"
+}
+
+// ThumbnailType returns the content type of the thumbnail, which is a JPEG
+// for JPEG images and a PNG otherwise, to keep their transparency.
+func (m ProductMedia) ThumbnailType() string {
+	if m.ContentType == "image/jpeg" {
+		return "image/jpeg"
+	}
+	return "image/png"
+}
+
+// CleanFilename returns the base name of an uploaded file, dropping the
+// directories some clients send.
+func CleanFilename(filename string) string {
+	name := path.Base(path.Clean("/" + strings.ReplaceAll(filename, `\`, "/")))
+	if name == "/" || name == "." {
+		return "file"
+	}
+	for len(name) > 255 {
+		_, size := utf8.DecodeRuneInString(name)
+		name = name[size:]
+	}
+	return name
+}
+
+// MediaOrder lists the ids of all the media of a product, in the order they
+// should be shown.
+type MediaOrder struct {
+	IDs []uint `json:"ids" validate:"required,min=1"`
+}
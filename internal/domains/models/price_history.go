@@ -0,0 +1,93 @@
+package models
+
+import (
+	"time"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/pkg/money"
+)
+
+// LowestPriceWindow is how far back Product.LowestPrice30d looks, as the EU
+// rules for announcing price reductions require.
+const LowestPriceWindow = 30 * 24 * time.Hour
+
+// PriceChange records a change of the price of a product. It is written with
+// the update of the product, in the same transaction.
+type PriceChange struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	ProductID   uint           `gorm:"not null;index" json:"product_id"`
+	OldPrice    money.Amount   `gorm:"precision:20;scale:4;not null" json:"old_price"`
+	OldCurrency money.Currency `gorm:"size:3;not null" json:"old_currency"`
+	NewPrice    money.Amount   `gorm:"precision:20;scale:4;not null" json:"new_price"`
+	NewCurrency money.Currency `gorm:"size:3;not null" json:"new_currency"`
+	// ChangedBy is the subject of the caller that made the change, empty when
+	// it was made without authentication.
+	ChangedBy string    `gorm:"size:255;not null;default:''" json:"changed_by"`
+	Reason    string    `gorm:"size:255;not null;default:''" json:"reason"`
+	ChangedAt time.Time `gorm:"not null;index" json:"changed_at"`
+}
+
+func (PriceChange) TableName() string {
+	return "price_history"
+}
+
+// Audit says who makes a change and why, for the changes that are recorded.
+type Audit struct {
+	Actor  string
+	Reason string
+}
+
+// PriceHistory is the price of a product with the changes that led to it.
+type PriceHistory struct {
+	Price    money.Amount   `json:"price"`
+	Currency money.Currency `json:"currency"`
+	// LowestPrice30d is the lowest price of the product in the last 30 days,
+	// in its current currency.
+	LowestPrice30d money.Amount   `json:"lowest_price_30d"`
+	Changes        []*PriceChange `json:"changes"`
+}
+
+// PriceHistoryFilter selects the price changes made between From and To, both
+// optional and inclusive. They are dates, as in 2024-05-31, or RFC 3339
+// times.
+type PriceHistoryFilter struct {
+	From string `query:"from"`
+	To   string `query:"to"`
+	// Since and Until hold From and To parsed by the handler, Until being
+	// exclusive.
+	Since *time.Time `query:"-"`
+	Until *time.Time `query:"-"`
+}
+
+// ParsePriceHistoryFilter resolves Since and Until from From and To. A date
+// in To includes the whole day.
+func ParsePriceHistoryFilter(filter PriceHistoryFilter) (PriceHistoryFilter, error) {
+	if filter.From != "" {
+		since, _, err := parseFilterTime(filter.From)
+		if err != nil {
+			return filter, err
+		}
+		filter.Since = &since
+	}
+	if filter.To != "" {
+		until, date, err := parseFilterTime(filter.To)
+		if err != nil {
+			return filter, err
+		}
+		if date {
+			until = until.AddDate(0, 0, 1)
+		} else {
+			until = until.Add(time.Nanosecond)
+		}
+		filter.Until = &until
+	}
+	return filter, nil
+}
+
+// parseFilterTime parses a date or an RFC 3339 time, reporting which it was.
+func parseFilterTime(value string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	return t, false, err
+}
@@ -1,17 +1,324 @@
 package models
 
 import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
+	"unicode"
 
+	"github.com/adrianosiqe/eulabs-challenge-api/pkg/money"
+	"golang.org/x/text/unicode/norm"
 	"gorm.io/gorm"
 )
 
+// ErrInvalidSKU is returned for SKUs that don't match the configured pattern.
+var ErrInvalidSKU = errors.New("invalid sku")
+
+// ConflictError is returned when a unique field of a product, such as its
+// SKU, already belongs to another product.
+type ConflictError struct {
+	Field string
+	Value string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s %q is already taken", e.Field, e.Value)
+}
+
 type Product struct {
-	ID          uint           `gorm:"primaryKey" json:"id"`
-	Title       string         `gorm:"type:VARCHAR(255);" json:"title" validate:"required"`
-	Description string         `gorm:"type:TEXT;" json:"description" validate:"required"`
-	Price       float64        `gorm:"type:DECIMAL(20,2);" json:"price" validate:"required,gt=0"`
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	Title       string `gorm:"size:255" json:"title" validate:"required"`
+	Description string `gorm:"type:text" json:"description" validate:"required"`
+	// SKU is optional but unique, even among deleted products.
+	SKU *string `gorm:"size:64;uniqueIndex" json:"sku"`
+	// Slug is derived from the title when the product is created and doesn't
+	// change afterwards, so that URLs stay stable.
+	Slug string `gorm:"size:255;uniqueIndex" json:"slug" openapi:"readOnly"`
+	// Price is in Currency, and has no more decimal places than it allows.
+	Price    money.Amount   `gorm:"precision:20;scale:4" json:"price" validate:"required,gt=0"`
+	Currency money.Currency `gorm:"size:3;not null;default:BRL" json:"currency"`
+	// Status is changed through the transition endpoints only, and products
+	// are hidden from the callers that can't edit them until published.
+	Status ProductStatus `gorm:"size:20;not null;default:draft;index" json:"status" openapi:"readOnly"`
+	// PublishedAt is set when the product is published and cleared when it
+	// goes back to draft.
+	PublishedAt *time.Time `json:"published_at" openapi:"readOnly"`
+	// PublishAt and UnpublishAt schedule the publication of a draft and the
+	// archiving of a published product. They are cleared once applied, and
+	// changed through PUT /products/:id/schedule after creation.
+	PublishAt   *time.Time `gorm:"index" json:"publish_at"`
+	UnpublishAt *time.Time `gorm:"index" json:"unpublish_at"`
+	// SeedKey identifies the products created by the seeder, so that seeding
+	// is idempotent and seeded data can be wiped on its own.
+	SeedKey *string `gorm:"size:100;index" json:"-"`
+	// Categories is loaded with the product; it is changed through
+	// PUT /products/:id/categories only.
+	Categories []Category `gorm:"many2many:product_categories" json:"categories" openapi:"readOnly"`
+	// Tags is loaded with the product, sorted by name; it is changed through
+	// /products/:id/tags only.
+	Tags []Tag `gorm:"many2many:product_tags" json:"tags" openapi:"readOnly"`
+	// Options is loaded with the product, in order; it is changed through
+	// PUT /products/:id/options only.
+	Options []ProductOption `json:"options" openapi:"readOnly"`
+	// Attributes holds the specs of the product, such as its weight, whose
+	// values are checked against the attribute definitions. On update, the
+	// attributes sent replace the ones with the same key and null removes
+	// one.
+	Attributes ProductAttributes `gorm:"foreignKey:ProductID" json:"attributes"`
+	// Available is the stock on hand in every warehouse minus the active
+	// reservations, computed when the product is read. It is changed through
+	// /products/:id/stock and /products/:id/reservations only.
+	Available int64 `gorm:"->;-:migration" json:"available" openapi:"readOnly"`
+	// LowestPrice30d is the lowest price of the product in the last 30 days,
+	// in its current currency, computed from the price history when the
+	// product is read. Shops in the EU must show it next to price reductions.
+	LowestPrice30d money.Amount `gorm:"column:lowest_price_30d;->;-:migration" json:"lowest_price_30d" openapi:"readOnly"`
+	// PriceReason says why an update changes the price, for the price
+	// history. It isn't stored with the product.
+	PriceReason string         `gorm:"-" json:"price_reason,omitempty" validate:"max=255"`
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"deleted_at"`
 }
+
+// Money returns the price of the product.
+func (p *Product) Money() money.Money {
+	currency := p.Currency
+	if currency == "" {
+		currency = money.DefaultCurrency
+	}
+	return money.Money{Amount: p.Price, Currency: currency}
+}
+
+// Clone returns a deep copy of the product, which shares neither its
+// associations nor the values behind its pointers with p.
+func (p *Product) Clone() *Product {
+	clone := *p
+	clone.SKU = clonePointer(p.SKU)
+	clone.PublishedAt = clonePointer(p.PublishedAt)
+	clone.PublishAt = clonePointer(p.PublishAt)
+	clone.UnpublishAt = clonePointer(p.UnpublishAt)
+	clone.SeedKey = clonePointer(p.SeedKey)
+
+	if p.Categories != nil {
+		clone.Categories = make([]Category, len(p.Categories))
+		for i, category := range p.Categories {
+			category.ParentID = clonePointer(category.ParentID)
+			clone.Categories[i] = category
+		}
+	}
+	if p.Tags != nil {
+		clone.Tags = append([]Tag{}, p.Tags...)
+	}
+	if p.Options != nil {
+		clone.Options = make([]ProductOption, len(p.Options))
+		for i, option := range p.Options {
+			option.Values = append(OptionValues{}, option.Values...)
+			clone.Options[i] = option
+		}
+	}
+	if p.Attributes != nil {
+		clone.Attributes = make(ProductAttributes, len(p.Attributes))
+		for i, attribute := range p.Attributes {
+			attribute.StringValue = clonePointer(attribute.StringValue)
+			attribute.NumberValue = clonePointer(attribute.NumberValue)
+			attribute.BoolValue = clonePointer(attribute.BoolValue)
+			clone.Attributes[i] = attribute
+		}
+	}
+	return &clone
+}
+
+func clonePointer[T any](value *T) *T {
+	if value == nil {
+		return nil
+	}
+	clone := *value
+	return &clone
+}
+
+// Validate checks the rules the validate tags can't express.
+func (p *Product) Validate() error {
+	if err := p.Money().Validate(); err != nil {
+		return err
+	}
+	return p.Schedule().Validate()
+}
+
+// Schedule returns the scheduled transitions of the product.
+func (p *Product) Schedule() ProductSchedule {
+	return ProductSchedule{PublishAt: p.PublishAt, UnpublishAt: p.UnpublishAt}
+}
+
+// ProductStatus is the publication state of a product.
+type ProductStatus string
+
+const (
+	ProductDraft     ProductStatus = "draft"
+	ProductPublished ProductStatus = "published"
+	ProductArchived  ProductStatus = "archived"
+)
+
+// ErrInvalidTransition is returned when a product can't go from its status to
+// the requested one.
+var ErrInvalidTransition = errors.New("invalid status transition")
+
+// productTransitions holds the statuses each status can go to.
+var productTransitions = map[ProductStatus][]ProductStatus{
+	ProductDraft:     {ProductPublished},
+	ProductPublished: {ProductArchived},
+	ProductArchived:  {ProductDraft},
+}
+
+// Valid reports whether s is one of the known statuses.
+func (s ProductStatus) Valid() bool {
+	_, ok := productTransitions[s]
+	return ok
+}
+
+// Transition moves the product to status, recording now as the publication
+// time when it is published.
+func (p *Product) Transition(status ProductStatus, now time.Time) error {
+	from := p.Status
+	if from == "" {
+		from = ProductDraft
+	}
+
+	for _, allowed := range productTransitions[from] {
+		if allowed != status {
+			continue
+		}
+
+		p.Status = status
+		switch status {
+		case ProductPublished:
+			p.PublishedAt, p.PublishAt = &now, nil
+		case ProductArchived:
+			p.UnpublishAt = nil
+		case ProductDraft:
+			p.PublishedAt = nil
+		}
+		return nil
+	}
+	return fmt.Errorf("%w from %s to %s", ErrInvalidTransition, from, status)
+}
+
+// ErrInvalidSchedule is returned for schedules that archive a product before
+// publishing it.
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+// ProductSchedule is the body of the requests that schedule the transitions
+// of a product; a null time cancels the transition.
+type ProductSchedule struct {
+	PublishAt   *time.Time `json:"publish_at"`
+	UnpublishAt *time.Time `json:"unpublish_at"`
+}
+
+func (s ProductSchedule) Validate() error {
+	if s.PublishAt != nil && s.UnpublishAt != nil && !s.UnpublishAt.After(*s.PublishAt) {
+		return fmt.Errorf("%w: unpublish_at must be after publish_at", ErrInvalidSchedule)
+	}
+	return nil
+}
+
+// ApplySchedule publishes the product when it is a draft whose PublishAt is
+// due by now, then archives it when it is published and its UnpublishAt is
+// due, so that both happen when a run was missed. It reports whether the
+// status changed.
+func (p *Product) ApplySchedule(now time.Time) bool {
+	changed := false
+	if p.Status == ProductDraft && p.PublishAt != nil && !p.PublishAt.After(now) {
+		changed = p.Transition(ProductPublished, now) == nil
+	}
+	if p.Status == ProductPublished && p.UnpublishAt != nil && !p.UnpublishAt.After(now) {
+		changed = p.Transition(ProductArchived, now) == nil || changed
+	}
+	return changed
+}
+
+// MaxSlugLength leaves room for a collision suffix in the slug column.
+const MaxSlugLength = 200
+
+// Slugify turns a title into a lower case, URL-safe slug such as
+// "pokemon-plush", dropping accents. It falls back to "product" for titles
+// without letters or digits.
+func Slugify(title string) string {
+	var slug strings.Builder
+	dash := false
+	for _, r := range norm.NFD.String(strings.ToLower(title)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			if dash && slug.Len() > 0 {
+				slug.WriteByte('-')
+			}
+			dash = false
+			slug.WriteRune(r)
+		default:
+			dash = true
+		}
+		if slug.Len() >= MaxSlugLength {
+			break
+		}
+	}
+
+	if slug.Len() == 0 {
+		return "product"
+	}
+	return strings.TrimSuffix(slug.String()[:min(slug.Len(), MaxSlugLength)], "-")
+}
+
+// UniqueSlug returns base, or base followed by the lowest suffix from -2 on
+// that isn't taken.
+func UniqueSlug(base string, taken []string) string {
+	used := map[string]bool{}
+	for _, slug := range taken {
+		used[slug] = true
+	}
+
+	slug := base
+	for n := 2; used[slug]; n++ {
+		slug = base + "-" + strconv.Itoa(n)
+	}
+	return slug
+}
+
+// ProductFilter narrows down a product listing. A zero PerPage lists every
+// product.
+type ProductFilter struct {
+	Page    int `query:"page" validate:"gte=0"`
+	PerPage int `query:"per_page" validate:"gte=0,lte=100"`
+	// Category lists the products of the category and of its descendants.
+	Category int `query:"category" validate:"gte=0"`
+	// CategoryIDs holds the ids of Category and its descendants, resolved by
+	// the service.
+	CategoryIDs []uint `query:"-"`
+	// Tags lists the products with any of the comma separated tags, or with
+	// all of them when TagMatch is "all".
+	Tags     string `query:"tags"`
+	TagMatch string `query:"tag_match" validate:"omitempty,oneof=any all"`
+	// TagNames holds the normalized Tags, parsed by the handler.
+	TagNames []string `query:"-"`
+	// Status lists the products with the status; only editors can list other
+	// products than the published ones.
+	Status ProductStatus `query:"status" validate:"omitempty,oneof=draft published archived"`
+	// Statuses holds the statuses the caller may list, resolved by the
+	// handler; empty lists every status.
+	Statuses []ProductStatus `query:"-"`
+	// AttributeQuery holds the attr.* query parameters, keyed without the
+	// prefix, as in weight_gt=2, set by the handler.
+	AttributeQuery map[string]string `query:"-"`
+	// Attributes holds AttributeQuery parsed against the attribute
+	// definitions by the service.
+	Attributes []AttributeCondition `query:"-"`
+}
+
+// MatchAllTags reports whether the products must have every tag of TagNames.
+func (f ProductFilter) MatchAllTags() bool {
+	return f.TagMatch == TagMatchAll
+}
+
+const MaxPerPage = 100
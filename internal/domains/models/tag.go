@@ -0,0 +1,75 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrInvalidTag is returned for tag names that are empty, too long or have
+// characters other than letters, digits and dashes.
+var ErrInvalidTag = errors.New("invalid tag")
+
+const MaxTagLength = 50
+
+// Tag is a free-form label of products, such as "promo" or "fire-type". Tags
+// are identified by their normalized name and created when first used.
+type Tag struct {
+	ID        uint      `gorm:"primaryKey" json:"-"`
+	Name      string    `gorm:"size:50;uniqueIndex" json:"name"`
+	CreatedAt time.Time `json:"-"`
+}
+
+// TagCount is a tag and how many products have it.
+type TagCount struct {
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+// ProductTags is the body of the requests that add tags to a product.
+type ProductTags struct {
+	Tags []string `json:"tags" validate:"required,min=1"`
+}
+
+// Tag match modes of ProductFilter.
+const (
+	TagMatchAny = "any"
+	TagMatchAll = "all"
+)
+
+// NormalizeTag lower cases name and replaces spaces and underscores with
+// dashes, so that "Fire Type" and "fire_type" are the same tag.
+func NormalizeTag(name string) (string, error) {
+	name = strings.ToLower(strings.Join(strings.FieldsFunc(name, func(r rune) bool {
+		return unicode.IsSpace(r) || r == '_'
+	}), "-"))
+	if name == "" || utf8.RuneCountInString(name) > MaxTagLength {
+		return "", fmt.Errorf("%w %q", ErrInvalidTag, name)
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' {
+			return "", fmt.Errorf("%w %q", ErrInvalidTag, name)
+		}
+	}
+	return name, nil
+}
+
+// NormalizeTags normalizes every name, dropping duplicates.
+func NormalizeTags(names []string) ([]string, error) {
+	normalized := []string{}
+	seen := map[string]bool{}
+	for _, name := range names {
+		tag, err := NormalizeTag(name)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized, nil
+}
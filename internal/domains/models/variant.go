@@ -0,0 +1,279 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/pkg/money"
+)
+
+var (
+	// ErrInvalidOptions is returned for option definitions with blank,
+	// repeated or too long names or values, or with too many combinations.
+	ErrInvalidOptions = errors.New("invalid options")
+	// ErrOptionsInUse is returned when replacing the options of a product
+	// would leave some of its variants without a valid combination.
+	ErrOptionsInUse = errors.New("options in use")
+	// ErrInvalidVariant is returned for variants whose options don't pick
+	// exactly one of the values of every option of the product.
+	ErrInvalidVariant = errors.New("invalid variant")
+	// ErrUnknownVariant is returned when stock is moved for a variant that
+	// isn't one of the product.
+	ErrUnknownVariant = errors.New("unknown variant")
+)
+
+const (
+	MaxOptions      = 3
+	MaxOptionLength = 50
+	// MaxVariants bounds how many combinations the options of a product can
+	// have, so that generating them stays cheap.
+	MaxVariants = 100
+)
+
+// ProductOption is something a product varies on, such as its size, with the
+// values it can take.
+type ProductOption struct {
+	ID        uint         `gorm:"primaryKey" json:"-"`
+	ProductID uint         `gorm:"not null;index" json:"-"`
+	Name      string       `gorm:"size:50;not null" json:"name" validate:"required"`
+	Values    OptionValues `gorm:"column:option_values;size:1000;not null" json:"values" validate:"required,min=1"`
+	Position  int          `gorm:"not null;default:0" json:"-"`
+}
+
+// OptionValues is stored as a comma separated list, so values can't have
+// commas.
+type OptionValues []string
+
+// GormDataType stores the values in a string column on every dialect.
+func (OptionValues) GormDataType() string {
+	return "string"
+}
+
+func (v OptionValues) Value() (driver.Value, error) {
+	return strings.Join(v, ","), nil
+}
+
+func (v *OptionValues) Scan(value interface{}) error {
+	var raw string
+	switch value := value.(type) {
+	case string:
+		raw = value
+	case []byte:
+		raw = string(value)
+	case nil:
+		*v = nil
+		return nil
+	default:
+		return fmt.Errorf("unsupported option values %T", value)
+	}
+
+	if raw == "" {
+		*v = OptionValues{}
+		return nil
+	}
+	*v = strings.Split(raw, ",")
+	return nil
+}
+
+// ProductOptions is the body of the requests that replace the options of a
+// product.
+type ProductOptions struct {
+	Options []ProductOption `json:"options" validate:"dive"`
+}
+
+// NormalizeOptions trims the names and values of options and numbers them in
+// order. Names and the values of each option must be unique, ignoring case.
+func NormalizeOptions(options []ProductOption) ([]ProductOption, error) {
+	if len(options) > MaxOptions {
+		return nil, fmt.Errorf("%w: a product has at most %d options", ErrInvalidOptions, MaxOptions)
+	}
+
+	normalized := make([]ProductOption, len(options))
+	combinations := 1
+	names := map[string]bool{}
+	for i, option := range options {
+		name, err := optionText(option.Name)
+		if err != nil {
+			return nil, err
+		}
+		if names[strings.ToLower(name)] {
+			return nil, fmt.Errorf("%w: %q is repeated", ErrInvalidOptions, name)
+		}
+		names[strings.ToLower(name)] = true
+
+		values := OptionValues{}
+		seen := map[string]bool{}
+		for _, value := range option.Values {
+			value, err := optionText(value)
+			if err != nil {
+				return nil, err
+			}
+			if seen[strings.ToLower(value)] {
+				return nil, fmt.Errorf("%w: %q is repeated in %q", ErrInvalidOptions, value, name)
+			}
+			seen[strings.ToLower(value)] = true
+			values = append(values, value)
+		}
+		if len(values) == 0 {
+			return nil, fmt.Errorf("%w: %q has no values", ErrInvalidOptions, name)
+		}
+
+		combinations *= len(values)
+		if combinations > MaxVariants {
+			return nil, fmt.Errorf("%w: they have more than %d combinations", ErrInvalidOptions, MaxVariants)
+		}
+		normalized[i] = ProductOption{Name: name, Values: values, Position: i}
+	}
+	return normalized, nil
+}
+
+// optionText trims an option name or value and checks it.
+func optionText(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	switch {
+	case text == "":
+		return "", fmt.Errorf("%w: names and values can't be blank", ErrInvalidOptions)
+	case utf8.RuneCountInString(text) > MaxOptionLength:
+		return "", fmt.Errorf("%w: %q is longer than %d characters", ErrInvalidOptions, text, MaxOptionLength)
+	case strings.ContainsAny(text, ",="):
+		return "", fmt.Errorf("%w: %q has a comma or an equals sign", ErrInvalidOptions, text)
+	}
+	return text, nil
+}
+
+// VariantOptions holds the value of each option of a variant, keyed by the
+// option name. It is stored as JSON.
+type VariantOptions map[string]string
+
+// GormDataType stores the options in a string column on every dialect.
+func (VariantOptions) GormDataType() string {
+	return "string"
+}
+
+func (o VariantOptions) Value() (driver.Value, error) {
+	encoded, err := json.Marshal(o)
+	if err != nil {
+		return nil, err
+	}
+	return string(encoded), nil
+}
+
+func (o *VariantOptions) Scan(value interface{}) error {
+	switch value := value.(type) {
+	case string:
+		return json.Unmarshal([]byte(value), o)
+	case []byte:
+		return json.Unmarshal(value, o)
+	case nil:
+		*o = nil
+		return nil
+	default:
+		return fmt.Errorf("unsupported variant options %T", value)
+	}
+}
+
+// Key identifies the combination of the options, ignoring case, so that each
+// combination is used once per product.
+func (o VariantOptions) Key() string {
+	pairs := make([]string, 0, len(o))
+	for name, value := range o {
+		pairs = append(pairs, strings.ToLower(name)+"="+strings.ToLower(value))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// ProductVariant is a combination of the options of a product, such as a
+// small red T-shirt, with its own SKU, price and stock.
+type ProductVariant struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	ProductID uint           `gorm:"not null;uniqueIndex:idx_product_variants_options" json:"product_id" openapi:"readOnly"`
+	Options   VariantOptions `gorm:"size:1000;not null" json:"options" validate:"required"`
+	// OptionsKey makes each combination of options unique per product.
+	OptionsKey string `gorm:"size:400;not null;uniqueIndex:idx_product_variants_options" json:"-"`
+	// SKU is optional but unique, among the products too.
+	SKU *string `gorm:"size:64;uniqueIndex" json:"sku"`
+	// Price overrides the price of the product when set, in its currency.
+	Price *money.Amount `gorm:"precision:20;scale:4" json:"price" validate:"omitempty,gt=0"`
+	// Available is the stock of the variant on hand minus its active
+	// reservations, computed when the variant is read.
+	Available int64     `gorm:"->;-:migration" json:"available" openapi:"readOnly"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// MatchOptions checks that the variant has exactly one of the values of each
+// of options, ignoring case, and spells its options like them.
+func (v *ProductVariant) MatchOptions(options []ProductOption) error {
+	if len(options) == 0 {
+		return fmt.Errorf("%w: the product has no options", ErrInvalidVariant)
+	}
+
+	matched := VariantOptions{}
+	for name, value := range v.Options {
+		option, ok := findOption(options, name)
+		if !ok {
+			return fmt.Errorf("%w: the product has no option %q", ErrInvalidVariant, name)
+		}
+		if _, ok := matched[option.Name]; ok {
+			return fmt.Errorf("%w: %q is repeated", ErrInvalidVariant, option.Name)
+		}
+		found := false
+		for _, allowed := range option.Values {
+			if strings.EqualFold(allowed, strings.TrimSpace(value)) {
+				matched[option.Name], found = allowed, true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%w: %q is not a value of %q", ErrInvalidVariant, value, option.Name)
+		}
+	}
+	for _, option := range options {
+		if _, ok := matched[option.Name]; !ok {
+			return fmt.Errorf("%w: %q is missing", ErrInvalidVariant, option.Name)
+		}
+	}
+
+	v.Options, v.OptionsKey = matched, matched.Key()
+	return nil
+}
+
+func findOption(options []ProductOption, name string) (ProductOption, bool) {
+	for _, option := range options {
+		if strings.EqualFold(option.Name, strings.TrimSpace(name)) {
+			return option, true
+		}
+	}
+	return ProductOption{}, false
+}
+
+// Combinations returns every combination of the values of options, in the
+// order of the options and their values.
+func Combinations(options []ProductOption) []VariantOptions {
+	if len(options) == 0 {
+		return nil
+	}
+
+	combinations := []VariantOptions{{}}
+	for _, option := range options {
+		var next []VariantOptions
+		for _, combination := range combinations {
+			for _, value := range option.Values {
+				extended := VariantOptions{option.Name: value}
+				for name, picked := range combination {
+					extended[name] = picked
+				}
+				next = append(next, extended)
+			}
+		}
+		combinations = next
+	}
+	return combinations
+}
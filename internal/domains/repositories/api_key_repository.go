@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"gorm.io/gorm"
+)
+
+type APIKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+func (r *APIKeyRepository) GetAll() ([]*models.APIKey, error) {
+	var apiKeys []*models.APIKey
+	err := r.db.Find(&apiKeys).Error
+	if err != nil {
+		return nil, err
+	}
+	return apiKeys, nil
+}
+
+func (r *APIKeyRepository) Create(apiKey *models.APIKey) (*models.APIKey, error) {
+	err := r.db.Create(&apiKey).Error
+	if err != nil {
+		return nil, err
+	}
+	return apiKey, nil
+}
+
+func (r *APIKeyRepository) GetByID(id int) (*models.APIKey, error) {
+	var apiKey models.APIKey
+	err := r.db.First(&apiKey, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &apiKey, nil
+}
+
+func (r *APIKeyRepository) GetByPrefix(prefix string) (*models.APIKey, error) {
+	var apiKey models.APIKey
+	err := r.db.Where("prefix = ?", prefix).First(&apiKey).Error
+	if err != nil {
+		return nil, err
+	}
+	return &apiKey, nil
+}
+
+// Rotate replaces the prefix and the hash of an api key that isn't revoked. It
+// returns gorm.ErrRecordNotFound when there is no such key, so that a rotation
+// racing a revocation can't bring the key back.
+func (r *APIKeyRepository) Rotate(id uint, prefix, hash string) error {
+	result := r.db.Model(&models.APIKey{}).Where("id = ? AND revoked_at IS NULL", id).Updates(map[string]interface{}{"prefix": prefix, "hash": hash})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Revoke sets the revocation of an api key that isn't revoked yet, and returns
+// gorm.ErrRecordNotFound when there is no such key.
+func (r *APIKeyRepository) Revoke(id uint, at time.Time) error {
+	result := r.db.Model(&models.APIKey{}).Where("id = ? AND revoked_at IS NULL", id).Updates(map[string]interface{}{"revoked_at": at})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// TouchLastUsed sets the last use of an api key and nothing else, so that it
+// can't undo a revocation or a rotation made since the key was read.
+func (r *APIKeyRepository) TouchLastUsed(id uint, at time.Time) error {
+	return r.db.Model(&models.APIKey{}).Where("id = ?", id).UpdateColumn("last_used_at", at).Error
+}
@@ -0,0 +1,165 @@
+package repositories
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+var apiKeyColumns = []string{"id", "name", "prefix", "hash", "scopes", "expires_at", "last_used_at", "revoked_at", "created_at", "updated_at"}
+
+func TestAPIKeyGetAll(t *testing.T) {
+	t.Run("should return a list the api keys", func(t *testing.T) {
+		db, mock := NewMockDB()
+		rows := sqlmock.NewRows(apiKeyColumns).
+			AddRow(1, "Pokédex partner", "a1b2c3d4e5f6", "hash", "products:read,products:write", nil, nil, nil, time.Now(), time.Now())
+		mock.ExpectQuery("SELECT (.+) FROM `api_keys`").WillReturnRows(rows)
+
+		apiKeyRepository := NewAPIKeyRepository(db)
+		apiKeys, err := apiKeyRepository.GetAll()
+
+		assert.NoError(t, err)
+		assert.Len(t, apiKeys, 1)
+		assert.Equal(t, models.Scopes{"products:read", "products:write"}, apiKeys[0].Scopes)
+	})
+
+	t.Run("should return an error", func(t *testing.T) {
+		db, mock := NewMockDB()
+		mock.ExpectQuery("SELECT (.+) FROM `api_keys`").WillReturnError(fmt.Errorf("some error"))
+
+		apiKeyRepository := NewAPIKeyRepository(db)
+		_, err := apiKeyRepository.GetAll()
+
+		assert.Error(t, err)
+	})
+}
+
+func TestAPIKeyCreate(t *testing.T) {
+	t.Run("should return an api key", func(t *testing.T) {
+		db, mock := NewMockDB()
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO `api_keys` (.+) VALUES (.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		apiKeyRepository := NewAPIKeyRepository(db)
+		apiKey, err := apiKeyRepository.Create(&models.APIKey{Name: "Pokédex partner", Prefix: "a1b2c3d4e5f6", Scopes: models.Scopes{"products:read"}})
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), apiKey.ID)
+	})
+
+	t.Run("should return an error", func(t *testing.T) {
+		db, mock := NewMockDB()
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO `api_keys` (.+) VALUES (.+)").WillReturnError(fmt.Errorf("some error"))
+		mock.ExpectRollback()
+
+		apiKeyRepository := NewAPIKeyRepository(db)
+		_, err := apiKeyRepository.Create(&models.APIKey{Name: "Pokédex partner"})
+
+		assert.Error(t, err)
+	})
+}
+
+func TestAPIKeyGetByPrefix(t *testing.T) {
+	t.Run("should return the api key", func(t *testing.T) {
+		db, mock := NewMockDB()
+		rows := sqlmock.NewRows(apiKeyColumns).
+			AddRow(1, "Pokédex partner", "a1b2c3d4e5f6", "hash", "products:read", nil, nil, nil, time.Now(), time.Now())
+		mock.ExpectQuery("SELECT (.+) FROM `api_keys` WHERE prefix = (.+)").WithArgs("a1b2c3d4e5f6").WillReturnRows(rows)
+
+		apiKeyRepository := NewAPIKeyRepository(db)
+		apiKey, err := apiKeyRepository.GetByPrefix("a1b2c3d4e5f6")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "a1b2c3d4e5f6", apiKey.Prefix)
+	})
+
+	t.Run("should return an error", func(t *testing.T) {
+		db, mock := NewMockDB()
+		mock.ExpectQuery("SELECT (.+) FROM `api_keys` WHERE prefix = (.+)").WillReturnError(fmt.Errorf("some error"))
+
+		apiKeyRepository := NewAPIKeyRepository(db)
+		_, err := apiKeyRepository.GetByPrefix("a1b2c3d4e5f6")
+
+		assert.Error(t, err)
+	})
+}
+
+func TestAPIKeyRotate(t *testing.T) {
+	expectedSQL := "UPDATE `api_keys` SET `hash`=\\?,`prefix`=\\?,`updated_at`=\\? WHERE id = \\? AND revoked_at IS NULL"
+
+	t.Run("should only replace the prefix and the hash", func(t *testing.T) {
+		db, mock := NewMockDB()
+		mock.ExpectBegin()
+		mock.ExpectExec(expectedSQL).WithArgs("hash", "prefix", sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		apiKeyRepository := NewAPIKeyRepository(db)
+		err := apiKeyRepository.Rotate(1, "prefix", "hash")
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should return not found for a revoked api key", func(t *testing.T) {
+		db, mock := NewMockDB()
+		mock.ExpectBegin()
+		mock.ExpectExec(expectedSQL).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		apiKeyRepository := NewAPIKeyRepository(db)
+		err := apiKeyRepository.Rotate(1, "prefix", "hash")
+
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+}
+
+func TestAPIKeyRevoke(t *testing.T) {
+	expectedSQL := "UPDATE `api_keys` SET `revoked_at`=\\?,`updated_at`=\\? WHERE id = \\? AND revoked_at IS NULL"
+
+	t.Run("should only set revoked at", func(t *testing.T) {
+		db, mock := NewMockDB()
+		mock.ExpectBegin()
+		mock.ExpectExec(expectedSQL).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		apiKeyRepository := NewAPIKeyRepository(db)
+		err := apiKeyRepository.Revoke(1, time.Now())
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should return not found for a revoked api key", func(t *testing.T) {
+		db, mock := NewMockDB()
+		mock.ExpectBegin()
+		mock.ExpectExec(expectedSQL).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		apiKeyRepository := NewAPIKeyRepository(db)
+		err := apiKeyRepository.Revoke(1, time.Now())
+
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+}
+
+func TestAPIKeyTouchLastUsed(t *testing.T) {
+	t.Run("should only update the last use", func(t *testing.T) {
+		db, mock := NewMockDB()
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE `api_keys` SET `last_used_at`=\\? WHERE id = \\?").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		apiKeyRepository := NewAPIKeyRepository(db)
+		err := apiKeyRepository.TouchLastUsed(1, time.Now())
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
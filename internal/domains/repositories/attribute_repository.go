@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// keyColumn is quoted by GORM, key being a reserved word in MySQL.
+var keyColumn = clause.Column{Name: "key"}
+
+type AttributeRepository struct {
+	db *gorm.DB
+}
+
+func NewAttributeRepository(db *gorm.DB) *AttributeRepository {
+	return &AttributeRepository{db: db}
+}
+
+// GetAll returns every attribute definition ordered by key.
+func (r *AttributeRepository) GetAll() ([]*models.AttributeDefinition, error) {
+	definitions := []*models.AttributeDefinition{}
+	err := r.db.Order(clause.OrderByColumn{Column: keyColumn}).Find(&definitions).Error
+	if err != nil {
+		return nil, err
+	}
+	return definitions, nil
+}
+
+func (r *AttributeRepository) GetByKey(key string) (*models.AttributeDefinition, error) {
+	var definition models.AttributeDefinition
+	err := r.db.Where(clause.Eq{Column: keyColumn, Value: key}).Take(&definition).Error
+	if err != nil {
+		return nil, err
+	}
+	return &definition, nil
+}
+
+// Create inserts the definition. It returns a *models.ConflictError when the
+// key is taken.
+func (r *AttributeRepository) Create(definition *models.AttributeDefinition) (*models.AttributeDefinition, error) {
+	err := r.db.Create(definition).Error
+	if err != nil {
+		if _, taken := r.GetByKey(definition.Key); taken == nil {
+			return nil, &models.ConflictError{Field: "key", Value: definition.Key}
+		}
+		return nil, err
+	}
+	return definition, nil
+}
+
+// Update saves the rules of the definition; its key and type don't change.
+func (r *AttributeRepository) Update(definition *models.AttributeDefinition) (*models.AttributeDefinition, error) {
+	result := r.db.Model(definition).Select("name", "unit", "enum_values", "min_value", "max_value", "max_length", "updated_at").Updates(definition)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return r.GetByKey(definition.Key)
+}
+
+// Delete removes a definition and the values products have for it.
+func (r *AttributeRepository) Delete(key string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("attribute_key = ?", key).Delete(&models.ProductAttribute{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Where(clause.Eq{Column: keyColumn, Value: key}).Delete(&models.AttributeDefinition{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
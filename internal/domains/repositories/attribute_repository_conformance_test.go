@@ -0,0 +1,178 @@
+package repositories
+
+import (
+	"testing"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/core/interfaces"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// TestAttributeRepositoryConformance runs the same checks against every
+// implementation of the attribute repository, together with the product
+// repository that stores the values.
+func TestAttributeRepositoryConformance(t *testing.T) {
+	implementations := map[string]func(t *testing.T) (interfaces.AttributeRepositoryInterface, interfaces.ProductRespositoryInterface){
+		"memory": func(t *testing.T) (interfaces.AttributeRepositoryInterface, interfaces.ProductRespositoryInterface) {
+			products := NewMemoryProductRepository()
+			return NewMemoryAttributeRepository(products), products
+		},
+		"gorm": func(t *testing.T) (interfaces.AttributeRepositoryInterface, interfaces.ProductRespositoryInterface) {
+			db := newSQLiteDB(t)
+			return NewAttributeRepository(db), NewProductRepository(db)
+		},
+	}
+
+	for name, newRepositories := range implementations {
+		t.Run(name, func(t *testing.T) {
+			testAttributeRepository(t, newRepositories)
+		})
+	}
+}
+
+// defineAttributes creates the weight, voltage, material and wireless
+// attributes and returns them keyed by attribute.
+func defineAttributes(t *testing.T, repository interfaces.AttributeRepositoryInterface) map[string]models.AttributeDefinition {
+	min := 0.0
+	definitions := map[string]models.AttributeDefinition{}
+	for _, definition := range []models.AttributeDefinition{
+		{Key: "weight", Name: "Weight", Type: models.AttributeNumber, Unit: "kg", Min: &min},
+		{Key: "voltage", Name: "Voltage", Type: models.AttributeNumber, Unit: "V"},
+		{Key: "material", Name: "Material", Type: models.AttributeEnum, Values: models.OptionValues{"Steel", "Wood"}},
+		{Key: "wireless", Name: "Wireless", Type: models.AttributeBool},
+	} {
+		definition := definition
+		_, err := repository.Create(&definition)
+		require.NoError(t, err)
+		definitions[definition.Key] = definition
+	}
+	return definitions
+}
+
+// withAttributes returns product with values, keyed by attribute, checked
+// against definitions.
+func withAttributes(t *testing.T, product *models.Product, definitions map[string]models.AttributeDefinition, values map[string]interface{}) *models.Product {
+	attributes := models.ProductAttributes{}
+	for key, value := range values {
+		attributes = append(attributes, models.ProductAttribute{Key: key, Value: value})
+	}
+	normalized, err := models.NormalizeAttributes(attributes, definitions)
+	require.NoError(t, err)
+	product.Attributes = normalized
+	return product
+}
+
+func attributeValues(product *models.Product) map[string]interface{} {
+	values := map[string]interface{}{}
+	for _, attribute := range product.Attributes {
+		values[attribute.Key] = attribute.Typed()
+	}
+	return values
+}
+
+func testAttributeRepository(t *testing.T, newRepositories func(t *testing.T) (interfaces.AttributeRepositoryInterface, interfaces.ProductRespositoryInterface)) {
+	t.Run("should list the definitions by key and reject taken keys", func(t *testing.T) {
+		repository, _ := newRepositories(t)
+		defineAttributes(t, repository)
+
+		definitions, err := repository.GetAll()
+		assert.NoError(t, err)
+		keys := []string{}
+		for _, definition := range definitions {
+			keys = append(keys, definition.Key)
+		}
+		assert.Equal(t, []string{"material", "voltage", "weight", "wireless"}, keys)
+
+		material, err := repository.GetByKey("material")
+		assert.NoError(t, err)
+		assert.Equal(t, models.OptionValues{"Steel", "Wood"}, material.Values)
+
+		_, err = repository.Create(&models.AttributeDefinition{Key: "weight", Name: "Weight", Type: models.AttributeNumber})
+		var conflict *models.ConflictError
+		assert.ErrorAs(t, err, &conflict)
+		_, err = repository.GetByKey("color")
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+
+	t.Run("should update the rules but not the type", func(t *testing.T) {
+		repository, _ := newRepositories(t)
+		defineAttributes(t, repository)
+		max := 100.0
+
+		updated, err := repository.Update(&models.AttributeDefinition{Key: "weight", Name: "Net weight", Type: models.AttributeString, Unit: "g", Max: &max})
+		assert.NoError(t, err)
+		assert.Equal(t, "Net weight", updated.Name)
+		assert.Equal(t, models.AttributeNumber, updated.Type)
+		assert.Equal(t, "g", updated.Unit)
+		assert.Nil(t, updated.Min)
+		assert.Equal(t, 100.0, *updated.Max)
+
+		_, err = repository.Update(&models.AttributeDefinition{Key: "color", Name: "Color"})
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+
+	t.Run("should store the values of the products and replace them on update", func(t *testing.T) {
+		repository, products := newRepositories(t)
+		definitions := defineAttributes(t, repository)
+
+		product, err := products.Create(withAttributes(t, newProduct("Drill"), definitions, map[string]interface{}{"weight": 2.5, "material": "steel", "wireless": true}))
+		require.NoError(t, err)
+
+		stored, err := products.GetByID(int(product.ID))
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"material": "Steel", "weight": 2.5, "wireless": true}, attributeValues(stored))
+
+		stored.Attributes = nil
+		_, err = products.Update(stored, models.Audit{})
+		require.NoError(t, err)
+		kept, _ := products.GetByID(int(product.ID))
+		assert.Len(t, kept.Attributes, 3)
+
+		withAttributes(t, kept, definitions, map[string]interface{}{"voltage": 220.0})
+		_, err = products.Update(kept, models.Audit{})
+		require.NoError(t, err)
+		replaced, _ := products.GetByID(int(product.ID))
+		assert.Equal(t, map[string]interface{}{"voltage": 220.0}, attributeValues(replaced))
+	})
+
+	t.Run("should filter the products by attribute value", func(t *testing.T) {
+		repository, products := newRepositories(t)
+		definitions := defineAttributes(t, repository)
+		products.Create(withAttributes(t, newProduct("Drill"), definitions, map[string]interface{}{"weight": 2.5, "material": "Steel", "wireless": true}))
+		products.Create(withAttributes(t, newProduct("Chair"), definitions, map[string]interface{}{"weight": 6.0, "material": "Wood"}))
+		products.Create(withAttributes(t, newProduct("Lamp"), definitions, map[string]interface{}{"weight": 1.0}))
+		products.Create(newProduct("Gift card"))
+
+		titles := func(query map[string]string) []string {
+			conditions, err := models.ParseAttributeConditions(query, definitions)
+			require.NoError(t, err)
+			listed, err := products.GetAll(models.ProductFilter{Attributes: conditions})
+			require.NoError(t, err)
+			titles := []string{}
+			for _, product := range listed {
+				titles = append(titles, product.Title)
+			}
+			return titles
+		}
+
+		assert.Equal(t, []string{"Drill", "Chair"}, titles(map[string]string{"weight_gt": "2"}))
+		assert.Equal(t, []string{"Drill", "Lamp"}, titles(map[string]string{"weight_lte": "2.5"}))
+		assert.Equal(t, []string{"Chair"}, titles(map[string]string{"weight_gte": "2", "material": "wood"}))
+		assert.Equal(t, []string{"Drill"}, titles(map[string]string{"wireless": "true"}))
+		assert.Empty(t, titles(map[string]string{"voltage_lt": "1000"}))
+	})
+
+	t.Run("should delete a definition with its values", func(t *testing.T) {
+		repository, products := newRepositories(t)
+		definitions := defineAttributes(t, repository)
+		product, _ := products.Create(withAttributes(t, newProduct("Drill"), definitions, map[string]interface{}{"weight": 2.5, "wireless": true}))
+
+		assert.NoError(t, repository.Delete("weight"))
+
+		stored, _ := products.GetByID(int(product.ID))
+		assert.Equal(t, map[string]interface{}{"wireless": true}, attributeValues(stored))
+		assert.ErrorIs(t, repository.Delete("weight"), gorm.ErrRecordNotFound)
+	})
+}
@@ -0,0 +1,40 @@
+package repositories
+
+import (
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/core/interfaces"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+)
+
+// CachedAttributeRepository purges the cached products when an attribute is
+// deleted, as its values go with it. The products only carry the values, so
+// changing a definition leaves them as they are.
+type CachedAttributeRepository struct {
+	attributes interfaces.AttributeRepositoryInterface
+	products   *CachedProductRepository
+}
+
+func NewCachedAttributeRepository(attributeRepository interfaces.AttributeRepositoryInterface, products *CachedProductRepository) *CachedAttributeRepository {
+	return &CachedAttributeRepository{attributes: attributeRepository, products: products}
+}
+
+func (r *CachedAttributeRepository) GetAll() ([]*models.AttributeDefinition, error) {
+	return r.attributes.GetAll()
+}
+
+func (r *CachedAttributeRepository) GetByKey(key string) (*models.AttributeDefinition, error) {
+	return r.attributes.GetByKey(key)
+}
+
+func (r *CachedAttributeRepository) Create(definition *models.AttributeDefinition) (*models.AttributeDefinition, error) {
+	return r.attributes.Create(definition)
+}
+
+func (r *CachedAttributeRepository) Update(definition *models.AttributeDefinition) (*models.AttributeDefinition, error) {
+	return r.attributes.Update(definition)
+}
+
+func (r *CachedAttributeRepository) Delete(key string) error {
+	err := r.attributes.Delete(key)
+	r.products.purge()
+	return err
+}
@@ -0,0 +1,52 @@
+package repositories
+
+import (
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/core/interfaces"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+)
+
+// CachedCategoryRepository purges the cached products when a category is
+// renamed, moved or deleted, as the products carry their categories. Any
+// number of products can be in a category, so the whole cache goes.
+type CachedCategoryRepository struct {
+	categories interfaces.CategoryRepositoryInterface
+	products   *CachedProductRepository
+}
+
+func NewCachedCategoryRepository(categoryRepository interfaces.CategoryRepositoryInterface, products *CachedProductRepository) *CachedCategoryRepository {
+	return &CachedCategoryRepository{categories: categoryRepository, products: products}
+}
+
+func (r *CachedCategoryRepository) GetAll() ([]*models.Category, error) {
+	return r.categories.GetAll()
+}
+
+func (r *CachedCategoryRepository) Create(category *models.Category) (*models.Category, error) {
+	return r.categories.Create(category)
+}
+
+func (r *CachedCategoryRepository) GetByID(id int) (*models.Category, error) {
+	return r.categories.GetByID(id)
+}
+
+func (r *CachedCategoryRepository) Update(category *models.Category) (*models.Category, error) {
+	updated, err := r.categories.Update(category)
+	r.products.purge()
+	return updated, err
+}
+
+func (r *CachedCategoryRepository) Move(id int, parentID *uint) (*models.Category, error) {
+	moved, err := r.categories.Move(id, parentID)
+	r.products.purge()
+	return moved, err
+}
+
+func (r *CachedCategoryRepository) Delete(id int) error {
+	err := r.categories.Delete(id)
+	r.products.purge()
+	return err
+}
+
+func (r *CachedCategoryRepository) DescendantIDs(id int) ([]uint, error) {
+	return r.categories.DescendantIDs(id)
+}
@@ -0,0 +1,61 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/core/interfaces"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+)
+
+// CachedInventoryRepository invalidates the cached products whose stock
+// changes, as the products carry what is available of them. A reservation
+// that expires on its own is only noticed once the cached product does.
+type CachedInventoryRepository struct {
+	inventory interfaces.InventoryRepositoryInterface
+	products  *CachedProductRepository
+}
+
+func NewCachedInventoryRepository(inventoryRepository interfaces.InventoryRepositoryInterface, products *CachedProductRepository) *CachedInventoryRepository {
+	return &CachedInventoryRepository{inventory: inventoryRepository, products: products}
+}
+
+func (r *CachedInventoryRepository) GetStock(productID int, now time.Time) (*models.Stock, error) {
+	return r.inventory.GetStock(productID, now)
+}
+
+func (r *CachedInventoryRepository) Adjust(productID int, adjustment models.StockAdjustment, now time.Time) (*models.StockLevel, error) {
+	level, err := r.inventory.Adjust(productID, adjustment, now)
+	r.products.invalidate(uint(productID))
+	return level, err
+}
+
+func (r *CachedInventoryRepository) Reserve(reservation *models.Reservation, now time.Time) (*models.Reservation, error) {
+	reserved, err := r.inventory.Reserve(reservation, now)
+	r.products.invalidate(reservation.ProductID)
+	return reserved, err
+}
+
+func (r *CachedInventoryRepository) GetReservation(id int) (*models.Reservation, error) {
+	return r.inventory.GetReservation(id)
+}
+
+func (r *CachedInventoryRepository) CommitReservation(id int, now time.Time) (*models.Reservation, error) {
+	reservation, err := r.inventory.CommitReservation(id, now)
+	r.invalidateReservation(reservation)
+	return reservation, err
+}
+
+func (r *CachedInventoryRepository) ReleaseReservation(id int, now time.Time) (*models.Reservation, error) {
+	reservation, err := r.inventory.ReleaseReservation(id, now)
+	r.invalidateReservation(reservation)
+	return reservation, err
+}
+
+// invalidateReservation invalidates the product of a reservation. A failed
+// commit or release doesn't return the reservation, and leaves the stock as
+// it was.
+func (r *CachedInventoryRepository) invalidateReservation(reservation *models.Reservation) {
+	if reservation != nil {
+		r.products.invalidate(reservation.ProductID)
+	}
+}
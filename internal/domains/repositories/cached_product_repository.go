@@ -0,0 +1,218 @@
+package repositories
+
+import (
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/core/interfaces"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
+)
+
+// notFound is cached for the products that don't exist.
+type notFound struct{}
+
+type CacheOptions struct {
+	TTL time.Duration
+	// NegativeTTL is how long a missing product is remembered.
+	NegativeTTL time.Duration
+}
+
+// CacheStats counts the lookups served by the cache and the ones that reached
+// the wrapped repository.
+type CacheStats struct {
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+	Entries int    `json:"entries"`
+}
+
+// CachedProductRepository caches GetByID in front of another product
+// repository. Concurrent misses of a product share a single lookup, and every
+// write invalidates the product it touches. Each method is written out rather
+// than embedding the repository, so that a new write can't skip the
+// invalidation.
+type CachedProductRepository struct {
+	products interfaces.ProductRespositoryInterface
+	cache    interfaces.CacheInterface
+	options  CacheOptions
+	group    singleflight.Group
+	// generation changes on every write, so that a lookup started before
+	// a write doesn't cache what it read.
+	generation atomic.Uint64
+	hits       atomic.Uint64
+	misses     atomic.Uint64
+}
+
+func NewCachedProductRepository(productRepository interfaces.ProductRespositoryInterface, cache interfaces.CacheInterface, options CacheOptions) *CachedProductRepository {
+	return &CachedProductRepository{
+		products: productRepository,
+		cache:    cache,
+		options:  options,
+	}
+}
+
+func productKey(id uint) string {
+	return "product:" + strconv.FormatUint(uint64(id), 10)
+}
+
+func (r *CachedProductRepository) GetByID(id int) (*models.Product, error) {
+	key := productKey(uint(id))
+	if value, ok := r.cache.Get(key); ok {
+		r.hits.Add(1)
+		return cachedProduct(value)
+	}
+	r.misses.Add(1)
+
+	value, err, _ := r.group.Do(key, func() (interface{}, error) {
+		generation := r.generation.Load()
+		product, err := r.products.GetByID(id)
+
+		var value interface{}
+		ttl := r.options.TTL
+		switch {
+		case err == nil:
+			value = product.Clone()
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			value, ttl = notFound{}, r.options.NegativeTTL
+		default:
+			return nil, err
+		}
+
+		if ttl > 0 && r.generation.Load() == generation {
+			r.cache.Set(key, value, ttl)
+		}
+		return value, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cachedProduct(value)
+}
+
+// GetByIDFromPrimary bypasses the cache, the product being written back.
+func (r *CachedProductRepository) GetByIDFromPrimary(id int) (*models.Product, error) {
+	return r.products.GetByIDFromPrimary(id)
+}
+
+// cachedProduct returns a deep copy of a cached product, so that callers
+// can't change the cache.
+func cachedProduct(value interface{}) (*models.Product, error) {
+	product, ok := value.(*models.Product)
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return product.Clone(), nil
+}
+
+func (r *CachedProductRepository) GetAll(filter models.ProductFilter) ([]*models.Product, error) {
+	return r.products.GetAll(filter)
+}
+
+func (r *CachedProductRepository) GetBySKU(sku string) (*models.Product, error) {
+	return r.products.GetBySKU(sku)
+}
+
+func (r *CachedProductRepository) GetBySlug(slug string) (*models.Product, error) {
+	return r.products.GetBySlug(slug)
+}
+
+func (r *CachedProductRepository) GetPriceHistory(productID int, filter models.PriceHistoryFilter) ([]*models.PriceChange, error) {
+	return r.products.GetPriceHistory(productID, filter)
+}
+
+func (r *CachedProductRepository) GetBySeedKey(key string) (*models.Product, error) {
+	return r.products.GetBySeedKey(key)
+}
+
+func (r *CachedProductRepository) Create(product *models.Product) (*models.Product, error) {
+	created, err := r.products.Create(product)
+	r.invalidate(product.ID)
+	return created, err
+}
+
+func (r *CachedProductRepository) Update(product *models.Product, audit models.Audit) (*models.Product, error) {
+	updated, err := r.products.Update(product, audit)
+	r.invalidate(product.ID)
+	return updated, err
+}
+
+func (r *CachedProductRepository) Delete(id int) error {
+	err := r.products.Delete(id)
+	r.invalidate(uint(id))
+	return err
+}
+
+func (r *CachedProductRepository) Purge(id int) ([]*models.ProductMedia, error) {
+	media, err := r.products.Purge(id)
+	r.invalidate(uint(id))
+	return media, err
+}
+
+func (r *CachedProductRepository) Restore(id int) error {
+	err := r.products.Restore(id)
+	r.invalidate(uint(id))
+	return err
+}
+
+func (r *CachedProductRepository) SetCategories(productID int, categories []models.Category) error {
+	err := r.products.SetCategories(productID, categories)
+	r.invalidate(uint(productID))
+	return err
+}
+
+func (r *CachedProductRepository) SetOptions(productID int, options []models.ProductOption) error {
+	err := r.products.SetOptions(productID, options)
+	r.invalidate(uint(productID))
+	return err
+}
+
+func (r *CachedProductRepository) AddTags(productID int, names []string) error {
+	err := r.products.AddTags(productID, names)
+	r.invalidate(uint(productID))
+	return err
+}
+
+func (r *CachedProductRepository) RemoveTags(productID int, names []string) error {
+	err := r.products.RemoveTags(productID, names)
+	r.invalidate(uint(productID))
+	return err
+}
+
+func (r *CachedProductRepository) UpdatePublication(product *models.Product, from models.ProductStatus) error {
+	err := r.products.UpdatePublication(product, from)
+	r.invalidate(product.ID)
+	return err
+}
+
+func (r *CachedProductRepository) ApplySchedules(now time.Time, limit int) ([]uint, error) {
+	changed, err := r.products.ApplySchedules(now, limit)
+	for _, id := range changed {
+		r.invalidate(id)
+	}
+	return changed, err
+}
+
+func (r *CachedProductRepository) DeleteSeeded() (int64, []*models.ProductMedia, error) {
+	deleted, media, err := r.products.DeleteSeeded()
+	r.purge()
+	return deleted, media, err
+}
+
+func (r *CachedProductRepository) invalidate(id uint) {
+	r.generation.Add(1)
+	r.cache.Delete(productKey(id))
+}
+
+// purge empties the cache, for writes that touch products without knowing
+// which.
+func (r *CachedProductRepository) purge() {
+	r.generation.Add(1)
+	r.cache.Purge()
+}
+
+func (r *CachedProductRepository) Stats() CacheStats {
+	return CacheStats{Hits: r.hits.Load(), Misses: r.misses.Load(), Entries: r.cache.Len()}
+}
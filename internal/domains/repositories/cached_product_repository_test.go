@@ -0,0 +1,315 @@
+package repositories
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/core/cache"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func newCachedProductRepository() (*CachedProductRepository, *mocks.MockProductRepository) {
+	productRepository := new(mocks.MockProductRepository)
+	return NewCachedProductRepository(productRepository, cache.NewLRU(10), CacheOptions{TTL: time.Minute, NegativeTTL: time.Minute}), productRepository
+}
+
+func TestCachedProductRepositoryGetByID(t *testing.T) {
+	t.Run("should read a product once", func(t *testing.T) {
+		repository, productRepository := newCachedProductRepository()
+		productRepository.On("GetByID", 1).Return(&models.Product{ID: 1, Title: "Product 1"}, nil).Once()
+
+		first, err := repository.GetByID(1)
+		assert.NoError(t, err)
+		first.Title = "Changed"
+		second, err := repository.GetByID(1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Product 1", second.Title)
+		assert.Equal(t, CacheStats{Hits: 1, Misses: 1, Entries: 1}, repository.Stats())
+		productRepository.AssertExpectations(t)
+	})
+
+	t.Run("should not share the associations or the pointers with the callers", func(t *testing.T) {
+		repository, productRepository := newCachedProductRepository()
+		sku, weight := "BULBA-001", 6.9
+		productRepository.On("GetByID", 1).Return(&models.Product{
+			ID:         1,
+			SKU:        &sku,
+			Categories: []models.Category{{ID: 1, Name: "Grama"}},
+			Tags:       []models.Tag{{Name: "starter"}},
+			Options:    []models.ProductOption{{Name: "Tamanho", Values: models.OptionValues{"P", "M"}}},
+			Attributes: models.ProductAttributes{{Key: "weight", NumberValue: &weight}},
+		}, nil).Once()
+
+		first, err := repository.GetByID(1)
+		assert.NoError(t, err)
+		*first.SKU = "CHANGED"
+		first.Categories[0].Name = "Changed"
+		first.Tags[0].Name = "changed"
+		first.Options[0].Values[0] = "G"
+		*first.Attributes[0].NumberValue = 0
+		second, err := repository.GetByID(1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "BULBA-001", *second.SKU)
+		assert.Equal(t, "Grama", second.Categories[0].Name)
+		assert.Equal(t, "starter", second.Tags[0].Name)
+		assert.Equal(t, models.OptionValues{"P", "M"}, second.Options[0].Values)
+		assert.Equal(t, 6.9, *second.Attributes[0].NumberValue)
+		assert.Equal(t, "BULBA-001", sku)
+		productRepository.AssertExpectations(t)
+	})
+
+	t.Run("should cache missing products", func(t *testing.T) {
+		repository, productRepository := newCachedProductRepository()
+		productRepository.On("GetByID", 1).Return(nil, gorm.ErrRecordNotFound).Once()
+
+		_, err := repository.GetByID(1)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		_, err = repository.GetByID(1)
+
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		productRepository.AssertExpectations(t)
+	})
+
+	t.Run("should not cache errors", func(t *testing.T) {
+		repository, productRepository := newCachedProductRepository()
+		productRepository.On("GetByID", 1).Return(nil, errors.New("some error")).Twice()
+
+		_, err := repository.GetByID(1)
+		assert.Error(t, err)
+		_, err = repository.GetByID(1)
+
+		assert.Error(t, err)
+		productRepository.AssertExpectations(t)
+	})
+
+	t.Run("should share a lookup between concurrent misses", func(t *testing.T) {
+		repository, productRepository := newCachedProductRepository()
+		release := make(chan time.Time)
+		productRepository.On("GetByID", 1).WaitUntil(release).Return(&models.Product{ID: 1}, nil).Once()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				product, err := repository.GetByID(1)
+				assert.NoError(t, err)
+				assert.Equal(t, uint(1), product.ID)
+			}()
+		}
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		productRepository.AssertExpectations(t)
+	})
+}
+
+func TestCachedProductRepositoryInvalidation(t *testing.T) {
+	t.Run("should read a product again after it is updated", func(t *testing.T) {
+		repository, productRepository := newCachedProductRepository()
+		product := &models.Product{ID: 1, Title: "Product 1"}
+		productRepository.On("GetByID", 1).Return(product, nil).Twice()
+		productRepository.On("Update", product, models.Audit{}).Return(product, nil)
+
+		repository.GetByID(1)
+		repository.Update(product, models.Audit{})
+		repository.GetByID(1)
+
+		productRepository.AssertExpectations(t)
+	})
+
+	t.Run("should read a product again after its status changes", func(t *testing.T) {
+		repository, productRepository := newCachedProductRepository()
+		product := &models.Product{ID: 1, Status: models.ProductPublished}
+		productRepository.On("GetByID", 1).Return(product, nil).Twice()
+		productRepository.On("UpdatePublication", product, models.ProductDraft).Return(nil)
+
+		repository.GetByID(1)
+		repository.UpdatePublication(product, models.ProductDraft)
+		repository.GetByID(1)
+
+		productRepository.AssertExpectations(t)
+	})
+
+	t.Run("should forget a missing product once it is created", func(t *testing.T) {
+		repository, productRepository := newCachedProductRepository()
+		product := &models.Product{ID: 1, Title: "Product 1"}
+		productRepository.On("GetByID", 1).Return(nil, gorm.ErrRecordNotFound).Once()
+		productRepository.On("Create", product).Return(product, nil)
+		productRepository.On("GetByID", 1).Return(product, nil).Once()
+
+		repository.GetByID(1)
+		repository.Create(product)
+		found, err := repository.GetByID(1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Product 1", found.Title)
+		productRepository.AssertExpectations(t)
+	})
+
+	t.Run("should forget a deleted product", func(t *testing.T) {
+		repository, productRepository := newCachedProductRepository()
+		productRepository.On("GetByID", 1).Return(&models.Product{ID: 1}, nil).Once()
+		productRepository.On("Delete", 1).Return(nil)
+		productRepository.On("GetByID", 1).Return(nil, gorm.ErrRecordNotFound).Once()
+
+		repository.GetByID(1)
+		repository.Delete(1)
+		_, err := repository.GetByID(1)
+
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		productRepository.AssertExpectations(t)
+	})
+
+	t.Run("should purge the cache when the seeded products are deleted", func(t *testing.T) {
+		repository, productRepository := newCachedProductRepository()
+		productRepository.On("GetByID", 1).Return(&models.Product{ID: 1}, nil).Once()
+		productRepository.On("DeleteSeeded").Return(int64(1), []*models.ProductMedia{}, nil)
+
+		repository.GetByID(1)
+		repository.DeleteSeeded()
+
+		assert.Equal(t, 0, repository.Stats().Entries)
+	})
+}
+
+func TestCachedInventoryRepository(t *testing.T) {
+	newRepositories := func() (*CachedProductRepository, *CachedInventoryRepository) {
+		products := NewMemoryProductRepository()
+		cached := NewCachedProductRepository(products, cache.NewLRU(10), CacheOptions{TTL: time.Minute, NegativeTTL: time.Minute})
+		return cached, NewCachedInventoryRepository(NewMemoryInventoryRepository(products), cached)
+	}
+
+	t.Run("should read what is available again after the stock is adjusted", func(t *testing.T) {
+		products, inventory := newRepositories()
+		product, _ := products.Create(newProduct("Bulbasaur"))
+		now := time.Now()
+
+		cached, err := products.GetByID(int(product.ID))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), cached.Available)
+		_, err = inventory.Adjust(int(product.ID), received(models.DefaultWarehouse, 5), now)
+		assert.NoError(t, err)
+		adjusted, err := products.GetByID(int(product.ID))
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(5), adjusted.Available)
+	})
+
+	t.Run("should read what is available again after a reservation", func(t *testing.T) {
+		products, inventory := newRepositories()
+		product, _ := products.Create(newProduct("Bulbasaur"))
+		now := time.Now()
+		inventory.Adjust(int(product.ID), received(models.DefaultWarehouse, 5), now)
+
+		products.GetByID(int(product.ID))
+		reserved, err := inventory.Reserve(reservation(product.ID, 2, now.Add(time.Hour)), now)
+		assert.NoError(t, err)
+		found, _ := products.GetByID(int(product.ID))
+		assert.Equal(t, int64(3), found.Available)
+
+		_, err = inventory.ReleaseReservation(int(reserved.ID), now)
+		assert.NoError(t, err)
+		found, _ = products.GetByID(int(product.ID))
+		assert.Equal(t, int64(5), found.Available)
+	})
+}
+
+func TestCachedVariantRepository(t *testing.T) {
+	t.Run("should read what is available again after a variant is deleted", func(t *testing.T) {
+		products := NewMemoryProductRepository()
+		cached := NewCachedProductRepository(products, cache.NewLRU(10), CacheOptions{TTL: time.Minute, NegativeTTL: time.Minute})
+		inventory := NewCachedInventoryRepository(NewMemoryInventoryRepository(products), cached)
+		variants := NewCachedVariantRepository(NewMemoryVariantRepository(products), cached)
+		product, _ := cached.Create(newProduct("T-shirt"))
+		cached.SetOptions(int(product.ID), []models.ProductOption{{Name: "Size", Values: models.OptionValues{"S", "M"}}})
+		variant, err := variants.Create(&models.ProductVariant{ProductID: product.ID, Options: models.VariantOptions{"Size": "M"}})
+		assert.NoError(t, err)
+		adjustment := received(models.DefaultWarehouse, 5)
+		adjustment.VariantID = variant.ID
+		inventory.Adjust(int(product.ID), adjustment, time.Now())
+
+		found, _ := cached.GetByID(int(product.ID))
+		assert.Equal(t, int64(5), found.Available)
+		assert.NoError(t, variants.Delete(int(product.ID), int(variant.ID)))
+		found, _ = cached.GetByID(int(product.ID))
+
+		assert.Equal(t, int64(0), found.Available)
+	})
+
+	t.Run("should read the product again after its variants change", func(t *testing.T) {
+		products, productRepository := newCachedProductRepository()
+		variantRepository := new(mocks.MockVariantRepository)
+		variants := NewCachedVariantRepository(variantRepository, products)
+		variant := &models.ProductVariant{ID: 1, ProductID: 1}
+		productRepository.On("GetByID", 1).Return(&models.Product{ID: 1}, nil).Times(4)
+		variantRepository.On("Create", variant).Return(variant, nil)
+		variantRepository.On("Update", variant).Return(variant, nil)
+		variantRepository.On("Generate", 1).Return([]*models.ProductVariant{variant}, nil)
+
+		products.GetByID(1)
+		variants.Create(variant)
+		products.GetByID(1)
+		variants.Update(variant)
+		products.GetByID(1)
+		variants.Generate(1)
+		products.GetByID(1)
+
+		productRepository.AssertExpectations(t)
+		variantRepository.AssertExpectations(t)
+	})
+}
+
+func TestCachedCategoryRepository(t *testing.T) {
+	t.Run("should read the products again after a category changes", func(t *testing.T) {
+		products, productRepository := newCachedProductRepository()
+		categoryRepository := new(mocks.MockCategoryRepository)
+		categories := NewCachedCategoryRepository(categoryRepository, products)
+		category := &models.Category{ID: 1, Name: "Roupas"}
+		parentID := uint(2)
+		productRepository.On("GetByID", 1).Return(&models.Product{ID: 1, Categories: []models.Category{*category}}, nil).Times(4)
+		categoryRepository.On("Update", category).Return(category, nil)
+		categoryRepository.On("Move", 1, &parentID).Return(category, nil)
+		categoryRepository.On("Delete", 1).Return(nil)
+
+		products.GetByID(1)
+		categories.Update(category)
+		products.GetByID(1)
+		categories.Move(1, &parentID)
+		products.GetByID(1)
+		categories.Delete(1)
+		products.GetByID(1)
+
+		productRepository.AssertExpectations(t)
+		categoryRepository.AssertExpectations(t)
+	})
+}
+
+func TestCachedAttributeRepository(t *testing.T) {
+	t.Run("should read the products without the values of a deleted attribute", func(t *testing.T) {
+		products := NewMemoryProductRepository()
+		cached := NewCachedProductRepository(products, cache.NewLRU(10), CacheOptions{TTL: time.Minute, NegativeTTL: time.Minute})
+		attributes := NewCachedAttributeRepository(NewMemoryAttributeRepository(products), cached)
+		_, err := attributes.Create(&models.AttributeDefinition{Key: "weight", Name: "Peso", Type: models.AttributeNumber})
+		assert.NoError(t, err)
+		weight := 2.5
+		product := newProduct("Bulbasaur")
+		product.Attributes = models.ProductAttributes{{Key: "weight", NumberValue: &weight}}
+		created, _ := cached.Create(product)
+
+		found, _ := cached.GetByID(int(created.ID))
+		assert.Len(t, found.Attributes, 1)
+		assert.NoError(t, attributes.Delete("weight"))
+		found, _ = cached.GetByID(int(created.ID))
+
+		assert.Empty(t, found.Attributes)
+	})
+}
@@ -0,0 +1,49 @@
+package repositories
+
+import (
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/core/interfaces"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+)
+
+// CachedVariantRepository invalidates the cached product whose variants
+// change, as deleting a variant takes its stock and reservations with it.
+type CachedVariantRepository struct {
+	variants interfaces.VariantRepositoryInterface
+	products *CachedProductRepository
+}
+
+func NewCachedVariantRepository(variantRepository interfaces.VariantRepositoryInterface, products *CachedProductRepository) *CachedVariantRepository {
+	return &CachedVariantRepository{variants: variantRepository, products: products}
+}
+
+func (r *CachedVariantRepository) GetAll(productID int) ([]*models.ProductVariant, error) {
+	return r.variants.GetAll(productID)
+}
+
+func (r *CachedVariantRepository) GetByID(productID, id int) (*models.ProductVariant, error) {
+	return r.variants.GetByID(productID, id)
+}
+
+func (r *CachedVariantRepository) Create(variant *models.ProductVariant) (*models.ProductVariant, error) {
+	created, err := r.variants.Create(variant)
+	r.products.invalidate(variant.ProductID)
+	return created, err
+}
+
+func (r *CachedVariantRepository) Update(variant *models.ProductVariant) (*models.ProductVariant, error) {
+	updated, err := r.variants.Update(variant)
+	r.products.invalidate(variant.ProductID)
+	return updated, err
+}
+
+func (r *CachedVariantRepository) Delete(productID, id int) error {
+	err := r.variants.Delete(productID, id)
+	r.products.invalidate(uint(productID))
+	return err
+}
+
+func (r *CachedVariantRepository) Generate(productID int) ([]*models.ProductVariant, error) {
+	generated, err := r.variants.Generate(productID)
+	r.products.invalidate(uint(productID))
+	return generated, err
+}
@@ -0,0 +1,175 @@
+package repositories
+
+import (
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CategoryRepository struct {
+	db *gorm.DB
+}
+
+func NewCategoryRepository(db *gorm.DB) *CategoryRepository {
+	return &CategoryRepository{db: db}
+}
+
+// GetAll returns every category ordered by path, so that parents come before
+// their children.
+func (r *CategoryRepository) GetAll() ([]*models.Category, error) {
+	var categories []*models.Category
+	err := r.db.Order("path").Find(&categories).Error
+	if err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+// Create inserts the category under its parent. The path holds the id, so it
+// is set once the row exists.
+func (r *CategoryRepository) Create(category *models.Category) (*models.Category, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		parentPath := ""
+		if category.ParentID != nil {
+			// The parent is locked, so that it can't move before the path
+			// of the category is written.
+			var parent models.Category
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&parent, *category.ParentID).Error; err != nil {
+				return err
+			}
+			parentPath = parent.Path
+		}
+
+		if err := tx.Create(category).Error; err != nil {
+			return err
+		}
+		category.Path = models.CategoryPath(parentPath, category.ID)
+		return tx.Model(category).Update("path", category.Path).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+func (r *CategoryRepository) GetByID(id int) (*models.Category, error) {
+	var category models.Category
+	err := r.db.First(&category, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// Update saves the name of the category; Move changes its place in the tree.
+func (r *CategoryRepository) Update(category *models.Category) (*models.Category, error) {
+	err := r.db.Model(category).Select("name", "updated_at").Updates(category).Error
+	if err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// Move makes the category a child of parentID, or a root when parentID is
+// nil, and rewrites the paths of its subtree. The category, the new parent and
+// the subtree stay locked until the paths are rewritten, so that concurrent
+// moves can't create a cycle or leave stale paths. It returns
+// models.ErrCategoryCycle when the parent is in the subtree.
+func (r *CategoryRepository) Move(id int, parentID *uint) (*models.Category, error) {
+	var category models.Category
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// The category and its new parent are locked in id order, so that
+		// moves crossing each other wait instead of deadlocking.
+		ids := []uint{uint(id)}
+		if parentID != nil {
+			ids = append(ids, *parentID)
+		}
+		var locked []models.Category
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN ?", ids).Order("id").Find(&locked).Error; err != nil {
+			return err
+		}
+
+		var parent *models.Category
+		for i := range locked {
+			if locked[i].ID == uint(id) {
+				category = locked[i]
+			}
+			if parentID != nil && locked[i].ID == *parentID {
+				parent = &locked[i]
+			}
+		}
+		if category.ID == 0 || (parentID != nil && parent == nil) {
+			return gorm.ErrRecordNotFound
+		}
+
+		parentPath := ""
+		if parent != nil {
+			if parent.IsDescendantOf(&category) {
+				return models.ErrCategoryCycle
+			}
+			parentPath = parent.Path
+		}
+
+		var subtree []models.Category
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("path LIKE ?", category.Path+"%").Order("id").Find(&subtree).Error; err != nil {
+			return err
+		}
+
+		oldPath, newPath := category.Path, models.CategoryPath(parentPath, category.ID)
+		for _, descendant := range subtree {
+			path := newPath + descendant.Path[len(oldPath):]
+			if err := tx.Model(&descendant).UpdateColumn("path", path).Error; err != nil {
+				return err
+			}
+		}
+
+		category.ParentID, category.Path = parentID, newPath
+		return tx.Model(&category).Select("parent_id", "updated_at").Updates(&category).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// Delete removes a category without subcategories, and its links to products.
+// The category is locked, so that no subcategory is created or moved under it
+// in between.
+func (r *CategoryRepository) Delete(id int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var category models.Category
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&category, id).Error; err != nil {
+			return err
+		}
+
+		var children int64
+		if err := tx.Model(&models.Category{}).Where("parent_id = ?", id).Count(&children).Error; err != nil {
+			return err
+		}
+		if children > 0 {
+			return models.ErrCategoryHasChildren
+		}
+
+		if err := tx.Exec("DELETE FROM product_categories WHERE category_id = ?", id).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(&category).Error
+	})
+}
+
+// DescendantIDs returns the ids of the category and of every category below
+// it.
+func (r *CategoryRepository) DescendantIDs(id int) ([]uint, error) {
+	category, err := r.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []uint
+	err = r.db.Model(&models.Category{}).Where("path LIKE ?", category.Path+"%").Order("path").Pluck("id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
@@ -0,0 +1,158 @@
+package repositories
+
+import (
+	"testing"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/core/interfaces"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// TestCategoryRepositoryConformance runs the same checks against every
+// implementation of the category repository, together with the product
+// repository that shares its storage.
+func TestCategoryRepositoryConformance(t *testing.T) {
+	implementations := map[string]func(t *testing.T) (interfaces.CategoryRepositoryInterface, interfaces.ProductRespositoryInterface){
+		"memory": func(t *testing.T) (interfaces.CategoryRepositoryInterface, interfaces.ProductRespositoryInterface) {
+			products := NewMemoryProductRepository()
+			return NewMemoryCategoryRepository(products), products
+		},
+		"gorm": func(t *testing.T) (interfaces.CategoryRepositoryInterface, interfaces.ProductRespositoryInterface) {
+			db := newSQLiteDB(t)
+			return NewCategoryRepository(db), NewProductRepository(db)
+		},
+	}
+
+	for name, newRepositories := range implementations {
+		t.Run(name, func(t *testing.T) {
+			testCategoryRepository(t, newRepositories)
+		})
+	}
+}
+
+// createCategory creates a category under parent, or a root category when
+// parent is nil.
+func createCategory(t *testing.T, repository interfaces.CategoryRepositoryInterface, name string, parent *models.Category) *models.Category {
+	category := &models.Category{Name: name}
+	if parent != nil {
+		category.ParentID = &parent.ID
+	}
+	created, err := repository.Create(category)
+	require.NoError(t, err)
+	return created
+}
+
+func testCategoryRepository(t *testing.T, newRepositories func(t *testing.T) (interfaces.CategoryRepositoryInterface, interfaces.ProductRespositoryInterface)) {
+	t.Run("should materialize the path on create", func(t *testing.T) {
+		repository, _ := newRepositories(t)
+		pokemon := createCategory(t, repository, "Pokémon", nil)
+		plush := createCategory(t, repository, "Plush", pokemon)
+
+		assert.Equal(t, "/1/", pokemon.Path)
+		assert.Equal(t, "/1/2/", plush.Path)
+
+		categories, err := repository.GetAll()
+		assert.NoError(t, err)
+		assert.Len(t, categories, 2)
+		assert.Equal(t, "Pokémon", categories[0].Name)
+	})
+
+	t.Run("should rename a category", func(t *testing.T) {
+		repository, _ := newRepositories(t)
+		pokemon := createCategory(t, repository, "Pokémon", nil)
+
+		pokemon.Name = "Pocket monsters"
+		_, err := repository.Update(pokemon)
+		assert.NoError(t, err)
+
+		category, err := repository.GetByID(int(pokemon.ID))
+		assert.NoError(t, err)
+		assert.Equal(t, "Pocket monsters", category.Name)
+		assert.Equal(t, "/1/", category.Path)
+	})
+
+	t.Run("should move a subtree", func(t *testing.T) {
+		repository, _ := newRepositories(t)
+		pokemon := createCategory(t, repository, "Pokémon", nil)
+		plush := createCategory(t, repository, "Plush", pokemon)
+		small := createCategory(t, repository, "Small", plush)
+		toys := createCategory(t, repository, "Toys", nil)
+
+		moved, err := repository.Move(int(plush.ID), &toys.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, toys.ID, *moved.ParentID)
+		assert.Equal(t, "/4/2/", moved.Path)
+
+		descendant, _ := repository.GetByID(int(small.ID))
+		assert.Equal(t, "/4/2/3/", descendant.Path)
+
+		root, err := repository.Move(int(plush.ID), nil)
+		assert.NoError(t, err)
+		assert.Nil(t, root.ParentID)
+		descendant, _ = repository.GetByID(int(small.ID))
+		assert.Equal(t, "/2/3/", descendant.Path)
+	})
+
+	t.Run("should not move a category under itself or its descendants", func(t *testing.T) {
+		repository, _ := newRepositories(t)
+		pokemon := createCategory(t, repository, "Pokémon", nil)
+		plush := createCategory(t, repository, "Plush", pokemon)
+
+		_, err := repository.Move(int(pokemon.ID), &plush.ID)
+		assert.ErrorIs(t, err, models.ErrCategoryCycle)
+		_, err = repository.Move(int(pokemon.ID), &pokemon.ID)
+		assert.ErrorIs(t, err, models.ErrCategoryCycle)
+	})
+
+	t.Run("should list a category and its descendants", func(t *testing.T) {
+		repository, _ := newRepositories(t)
+		pokemon := createCategory(t, repository, "Pokémon", nil)
+		plush := createCategory(t, repository, "Plush", pokemon)
+		createCategory(t, repository, "Small", plush)
+		createCategory(t, repository, "Toys", nil)
+
+		ids, err := repository.DescendantIDs(int(pokemon.ID))
+		assert.NoError(t, err)
+		assert.Equal(t, []uint{1, 2, 3}, ids)
+
+		_, err = repository.DescendantIDs(42)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+
+	t.Run("should only delete categories without subcategories", func(t *testing.T) {
+		repository, products := newRepositories(t)
+		pokemon := createCategory(t, repository, "Pokémon", nil)
+		plush := createCategory(t, repository, "Plush", pokemon)
+		product, _ := products.Create(newProduct("Bulbasaur"))
+		require.NoError(t, products.SetCategories(int(product.ID), []models.Category{*plush}))
+
+		assert.ErrorIs(t, repository.Delete(int(pokemon.ID)), models.ErrCategoryHasChildren)
+		assert.NoError(t, repository.Delete(int(plush.ID)))
+		assert.ErrorIs(t, repository.Delete(int(plush.ID)), gorm.ErrRecordNotFound)
+
+		linked, _ := products.GetByID(int(product.ID))
+		assert.Empty(t, linked.Categories)
+	})
+
+	t.Run("should filter the products by category", func(t *testing.T) {
+		repository, products := newRepositories(t)
+		pokemon := createCategory(t, repository, "Pokémon", nil)
+		plush := createCategory(t, repository, "Plush", pokemon)
+		bulbasaur, _ := products.Create(newProduct("Bulbasaur"))
+		products.Create(newProduct("Charmander"))
+		require.NoError(t, products.SetCategories(int(bulbasaur.ID), []models.Category{*pokemon, *plush}))
+
+		filtered, err := products.GetAll(models.ProductFilter{CategoryIDs: []uint{plush.ID}})
+		assert.NoError(t, err)
+		require.Len(t, filtered, 1)
+		assert.Equal(t, "Bulbasaur", filtered[0].Title)
+		assert.Len(t, filtered[0].Categories, 2)
+
+		require.NoError(t, products.SetCategories(int(bulbasaur.ID), []models.Category{}))
+		filtered, _ = products.GetAll(models.ProductFilter{CategoryIDs: []uint{plush.ID}})
+		assert.Empty(t, filtered)
+		assert.ErrorIs(t, products.SetCategories(42, []models.Category{}), gorm.ErrRecordNotFound)
+	})
+}
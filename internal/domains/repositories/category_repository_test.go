@@ -0,0 +1,52 @@
+package repositories
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/stretchr/testify/assert"
+)
+
+var categoryColumns = []string{"id", "name", "parent_id", "path"}
+
+func TestCategoryMove(t *testing.T) {
+	t.Run("should lock the category, the parent and the subtree before rewriting the paths", func(t *testing.T) {
+		db, mock := NewMockDB()
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT \\* FROM `categories` WHERE id IN \\(\\?,\\?\\) ORDER BY id FOR UPDATE").
+			WithArgs(3, 2).
+			WillReturnRows(sqlmock.NewRows(categoryColumns).AddRow(2, "Roupas", nil, "/2/").AddRow(3, "Camisetas", 1, "/1/3/"))
+		mock.ExpectQuery("SELECT \\* FROM `categories` WHERE path LIKE \\? ORDER BY id FOR UPDATE").
+			WithArgs("/1/3/%").
+			WillReturnRows(sqlmock.NewRows(categoryColumns).AddRow(3, "Camisetas", 1, "/1/3/").AddRow(4, "Regatas", 3, "/1/3/4/"))
+		mock.ExpectExec("UPDATE `categories` SET `path`=\\? WHERE `id` = \\?").WithArgs("/2/3/", 3).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE `categories` SET `path`=\\? WHERE `id` = \\?").WithArgs("/2/3/4/", 4).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE `categories` SET `parent_id`=\\?,`updated_at`=\\? WHERE `id` = \\?").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		parentID := uint(2)
+		categoryRepository := NewCategoryRepository(db)
+		category, err := categoryRepository.Move(3, &parentID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "/2/3/", category.Path)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should not move a category under its subtree", func(t *testing.T) {
+		db, mock := NewMockDB()
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT \\* FROM `categories` WHERE id IN \\(\\?,\\?\\) ORDER BY id FOR UPDATE").
+			WithArgs(1, 3).
+			WillReturnRows(sqlmock.NewRows(categoryColumns).AddRow(1, "Moda", nil, "/1/").AddRow(3, "Camisetas", 1, "/1/3/"))
+		mock.ExpectRollback()
+
+		parentID := uint(3)
+		categoryRepository := NewCategoryRepository(db)
+		_, err := categoryRepository.Move(1, &parentID)
+
+		assert.ErrorIs(t, err, models.ErrCategoryCycle)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
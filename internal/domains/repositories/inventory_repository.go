@@ -0,0 +1,255 @@
+package repositories
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// InventoryRepository keeps the stock levels, their movements and the
+// reservations. Every change locks the stock level it touches until it is
+// committed, so that concurrent reservations can't take the same stock.
+type InventoryRepository struct {
+	db *gorm.DB
+}
+
+func NewInventoryRepository(db *gorm.DB) *InventoryRepository {
+	return &InventoryRepository{db: db}
+}
+
+// GetStock returns the stock of a product and of its variants in every
+// warehouse they have been in, with the reservations active at now. It reads
+// from the primary, as stale stock is what leads to overselling.
+func (r *InventoryRepository) GetStock(productID int, now time.Time) (*models.Stock, error) {
+	var product models.Product
+	if err := r.db.Select("id").First(&product, productID).Error; err != nil {
+		return nil, err
+	}
+
+	levels := []models.StockLevel{}
+	if err := r.db.Where("product_id = ?", product.ID).Order("variant_id, warehouse").Find(&levels).Error; err != nil {
+		return nil, err
+	}
+
+	var reserved []struct {
+		VariantID uint
+		Warehouse string
+		Quantity  int64
+	}
+	err := r.active(r.db, product.ID, now).Select("variant_id, warehouse, SUM(quantity) AS quantity").Group("variant_id, warehouse").Find(&reserved).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range levels {
+		for _, sum := range reserved {
+			if sum.VariantID == levels[i].VariantID && sum.Warehouse == levels[i].Warehouse {
+				levels[i].Reserved = sum.Quantity
+			}
+		}
+		levels[i].Available = levels[i].OnHand - levels[i].Reserved
+	}
+	return models.NewStock(product.ID, levels), nil
+}
+
+// Adjust changes the stock of a product, or of one of its variants, in the
+// warehouse of the adjustment, recording the movement. It returns
+// models.ErrInsufficientStock when the stock left wouldn't cover the active
+// reservations.
+func (r *InventoryRepository) Adjust(productID int, adjustment models.StockAdjustment, now time.Time) (*models.StockLevel, error) {
+	var level *models.StockLevel
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := r.checkVariant(tx, uint(productID), adjustment.VariantID); err != nil {
+			return err
+		}
+
+		var err error
+		level, err = r.lockLevel(tx, uint(productID), adjustment.VariantID, adjustment.Warehouse, now)
+		if err != nil {
+			return err
+		}
+		if level.OnHand+adjustment.Delta < level.Reserved {
+			return fmt.Errorf("%w: %d on hand and %d reserved in %s", models.ErrInsufficientStock, level.OnHand, level.Reserved, level.Warehouse)
+		}
+
+		level.OnHand += adjustment.Delta
+		level.Available = level.OnHand - level.Reserved
+		if err := tx.Model(level).Update("on_hand", level.OnHand).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.StockMovement{
+			ProductID: level.ProductID,
+			VariantID: level.VariantID,
+			Warehouse: level.Warehouse,
+			Delta:     adjustment.Delta,
+			Reason:    adjustment.Reason,
+			Note:      adjustment.Note,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return level, nil
+}
+
+// Reserve holds the quantity of the reservation in its warehouse until it
+// expires. It returns models.ErrInsufficientStock when less is available.
+func (r *InventoryRepository) Reserve(reservation *models.Reservation, now time.Time) (*models.Reservation, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := r.checkVariant(tx, reservation.ProductID, reservation.VariantID); err != nil {
+			return err
+		}
+
+		level, err := r.lockLevel(tx, reservation.ProductID, reservation.VariantID, reservation.Warehouse, now)
+		if err != nil {
+			return err
+		}
+		if available := level.OnHand - level.Reserved; available < reservation.Quantity {
+			return fmt.Errorf("%w: %d available in %s", models.ErrInsufficientStock, available, level.Warehouse)
+		}
+
+		reservation.Status = models.ReservationActive
+		return tx.Create(reservation).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reservation, nil
+}
+
+func (r *InventoryRepository) GetReservation(id int) (*models.Reservation, error) {
+	var reservation models.Reservation
+	if err := r.db.First(&reservation, id).Error; err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// CommitReservation takes the reserved quantity out of the stock on hand,
+// recording it as sold. It returns models.ErrReservationClosed when the
+// reservation isn't active at now.
+func (r *InventoryRepository) CommitReservation(id int, now time.Time) (*models.Reservation, error) {
+	return r.close(id, now, func(tx *gorm.DB, reservation *models.Reservation) error {
+		if !reservation.Active(now) {
+			return fmt.Errorf("%w: it is %s", models.ErrReservationClosed, displayStatus(*reservation, now))
+		}
+
+		// The level is locked before the reservation is closed, like Adjust
+		// and Reserve do, so that the stock doesn't look free in between.
+		level, err := r.lockLevel(tx, reservation.ProductID, reservation.VariantID, reservation.Warehouse, now)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(level).Update("on_hand", level.OnHand-reservation.Quantity).Error; err != nil {
+			return err
+		}
+		reservationID := reservation.ID
+		err = tx.Create(&models.StockMovement{
+			ProductID:     reservation.ProductID,
+			VariantID:     reservation.VariantID,
+			Warehouse:     reservation.Warehouse,
+			Delta:         -reservation.Quantity,
+			Reason:        models.StockSold,
+			ReservationID: &reservationID,
+		}).Error
+		if err != nil {
+			return err
+		}
+		reservation.Status = models.ReservationCommitted
+		return nil
+	})
+}
+
+// ReleaseReservation gives the reserved quantity back. Expired reservations
+// can be released too, which only records it; committed and released ones
+// return models.ErrReservationClosed.
+func (r *InventoryRepository) ReleaseReservation(id int, now time.Time) (*models.Reservation, error) {
+	return r.close(id, now, func(tx *gorm.DB, reservation *models.Reservation) error {
+		if reservation.Status != models.ReservationActive {
+			return fmt.Errorf("%w: it is %s", models.ErrReservationClosed, reservation.Status)
+		}
+		reservation.Status = models.ReservationReleased
+		return nil
+	})
+}
+
+// close locks a reservation, lets change update it and saves its status.
+func (r *InventoryRepository) close(id int, now time.Time, change func(tx *gorm.DB, reservation *models.Reservation) error) (*models.Reservation, error) {
+	var reservation models.Reservation
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&reservation, id).Error; err != nil {
+			return err
+		}
+		if err := change(tx, &reservation); err != nil {
+			return err
+		}
+		return tx.Model(&reservation).Update("status", reservation.Status).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// checkVariant returns gorm.ErrRecordNotFound when the product doesn't exist,
+// and models.ErrUnknownVariant when variantID, unless zero, isn't one of its
+// variants.
+func (r *InventoryRepository) checkVariant(tx *gorm.DB, productID, variantID uint) error {
+	var product models.Product
+	if err := tx.Select("id").First(&product, productID).Error; err != nil {
+		return err
+	}
+	if variantID == 0 {
+		return nil
+	}
+
+	var count int64
+	err := tx.Model(&models.ProductVariant{}).Where("id = ? AND product_id = ?", variantID, productID).Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("%w %d", models.ErrUnknownVariant, variantID)
+	}
+	return nil
+}
+
+// lockLevel locks the stock level of a product or variant in a warehouse,
+// creating it when it has never been there, and sums up its reservations
+// active at now.
+func (r *InventoryRepository) lockLevel(tx *gorm.DB, productID, variantID uint, warehouse string, now time.Time) (*models.StockLevel, error) {
+	level := models.StockLevel{ProductID: productID, VariantID: variantID, Warehouse: warehouse}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&level).Error; err != nil {
+		return nil, err
+	}
+
+	level = models.StockLevel{}
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("product_id = ? AND variant_id = ? AND warehouse = ?", productID, variantID, warehouse).
+		First(&level).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.active(tx, productID, now).Where("variant_id = ? AND warehouse = ?", variantID, warehouse).
+		Select("COALESCE(SUM(quantity), 0)").Scan(&level.Reserved).Error
+	if err != nil {
+		return nil, err
+	}
+	level.Available = level.OnHand - level.Reserved
+	return &level, nil
+}
+
+// active selects the reservations of a product active at now.
+func (r *InventoryRepository) active(db *gorm.DB, productID uint, now time.Time) *gorm.DB {
+	return db.Model(&models.Reservation{}).
+		Where("product_id = ? AND status = ? AND expires_at > ?", productID, models.ReservationActive, now)
+}
+
+// displayStatus returns the status of reservation as shown at now.
+func displayStatus(reservation models.Reservation, now time.Time) models.ReservationStatus {
+	reservation.ShowExpiry(now)
+	return reservation.Status
+}
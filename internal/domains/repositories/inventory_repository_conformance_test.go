@@ -0,0 +1,236 @@
+package repositories
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/core/interfaces"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// TestInventoryRepositoryConformance runs the same checks against every
+// implementation of the inventory, whose products are written through the
+// product repository.
+func TestInventoryRepositoryConformance(t *testing.T) {
+	implementations := map[string]func(t *testing.T) (interfaces.InventoryRepositoryInterface, interfaces.ProductRespositoryInterface){
+		"memory": func(t *testing.T) (interfaces.InventoryRepositoryInterface, interfaces.ProductRespositoryInterface) {
+			products := NewMemoryProductRepository()
+			return NewMemoryInventoryRepository(products), products
+		},
+		"gorm": func(t *testing.T) (interfaces.InventoryRepositoryInterface, interfaces.ProductRespositoryInterface) {
+			db := newSQLiteDB(t)
+			return NewInventoryRepository(db), NewProductRepository(db)
+		},
+	}
+
+	for name, newRepositories := range implementations {
+		t.Run(name, func(t *testing.T) {
+			testInventoryRepository(t, newRepositories)
+		})
+	}
+}
+
+func received(warehouse string, delta int64) models.StockAdjustment {
+	return models.StockAdjustment{Warehouse: warehouse, Delta: delta, Reason: models.StockReceived}
+}
+
+func reservation(productID uint, quantity int64, expiresAt time.Time) *models.Reservation {
+	return &models.Reservation{ProductID: productID, Warehouse: models.DefaultWarehouse, Quantity: quantity, ExpiresAt: expiresAt}
+}
+
+func testInventoryRepository(t *testing.T, newRepositories func(t *testing.T) (interfaces.InventoryRepositoryInterface, interfaces.ProductRespositoryInterface)) {
+	t.Run("should sum up the stock of every warehouse", func(t *testing.T) {
+		inventory, products := newRepositories(t)
+		product, _ := products.Create(newProduct("Bulbasaur"))
+		now := time.Now()
+
+		_, err := inventory.Adjust(int(product.ID), received(models.DefaultWarehouse, 5), now)
+		require.NoError(t, err)
+		level, err := inventory.Adjust(int(product.ID), received("north", 3), now)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), level.OnHand)
+		assert.Equal(t, int64(3), level.Available)
+
+		stock, err := inventory.GetStock(int(product.ID), now)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(8), stock.OnHand)
+		assert.Equal(t, int64(8), stock.Available)
+		require.Len(t, stock.Warehouses, 2)
+		assert.Equal(t, models.DefaultWarehouse, stock.Warehouses[0].Warehouse)
+		assert.Equal(t, "north", stock.Warehouses[1].Warehouse)
+	})
+
+	t.Run("should have no stock before any adjustment", func(t *testing.T) {
+		inventory, products := newRepositories(t)
+		product, _ := products.Create(newProduct("Bulbasaur"))
+
+		stock, err := inventory.GetStock(int(product.ID), time.Now())
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), stock.Available)
+		assert.Empty(t, stock.Warehouses)
+	})
+
+	t.Run("should return not found for a missing product", func(t *testing.T) {
+		inventory, _ := newRepositories(t)
+
+		_, err := inventory.GetStock(99, time.Now())
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		_, err = inventory.Adjust(99, received(models.DefaultWarehouse, 1), time.Now())
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		_, err = inventory.Reserve(reservation(99, 1, time.Now().Add(time.Minute)), time.Now())
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		_, err = inventory.GetReservation(99)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+
+	t.Run("should not take the stock below what is reserved", func(t *testing.T) {
+		inventory, products := newRepositories(t)
+		product, _ := products.Create(newProduct("Bulbasaur"))
+		now := time.Now()
+		inventory.Adjust(int(product.ID), received(models.DefaultWarehouse, 5), now)
+		inventory.Reserve(reservation(product.ID, 3, now.Add(time.Minute)), now)
+
+		_, err := inventory.Adjust(int(product.ID), models.StockAdjustment{Warehouse: models.DefaultWarehouse, Delta: -3, Reason: models.StockDamaged}, now)
+		assert.ErrorIs(t, err, models.ErrInsufficientStock)
+
+		level, err := inventory.Adjust(int(product.ID), models.StockAdjustment{Warehouse: models.DefaultWarehouse, Delta: -2, Reason: models.StockDamaged}, now)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), level.OnHand)
+		assert.Equal(t, int64(3), level.Reserved)
+		assert.Equal(t, int64(0), level.Available)
+	})
+
+	t.Run("should reduce the available stock while reserved", func(t *testing.T) {
+		inventory, products := newRepositories(t)
+		product, _ := products.Create(newProduct("Bulbasaur"))
+		now := time.Now()
+		inventory.Adjust(int(product.ID), received(models.DefaultWarehouse, 5), now)
+
+		reserved, err := inventory.Reserve(reservation(product.ID, 2, now.Add(time.Minute)), now)
+		require.NoError(t, err)
+		assert.NotZero(t, reserved.ID)
+		assert.Equal(t, models.ReservationActive, reserved.Status)
+
+		stock, _ := inventory.GetStock(int(product.ID), now)
+		assert.Equal(t, int64(5), stock.OnHand)
+		assert.Equal(t, int64(2), stock.Reserved)
+		assert.Equal(t, int64(3), stock.Available)
+
+		found, err := products.GetByID(int(product.ID))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), found.Available)
+
+		_, err = inventory.Reserve(reservation(product.ID, 4, now.Add(time.Minute)), now)
+		assert.ErrorIs(t, err, models.ErrInsufficientStock)
+	})
+
+	t.Run("should give the stock of expired reservations back", func(t *testing.T) {
+		inventory, products := newRepositories(t)
+		product, _ := products.Create(newProduct("Bulbasaur"))
+		now := time.Now()
+		inventory.Adjust(int(product.ID), received(models.DefaultWarehouse, 5), now)
+		inventory.Reserve(reservation(product.ID, 5, now.Add(time.Minute)), now)
+
+		later := now.Add(2 * time.Minute)
+		stock, _ := inventory.GetStock(int(product.ID), later)
+		assert.Equal(t, int64(5), stock.Available)
+
+		_, err := inventory.Reserve(reservation(product.ID, 5, later.Add(time.Minute)), later)
+		assert.NoError(t, err)
+	})
+
+	t.Run("should take committed reservations out of the stock on hand", func(t *testing.T) {
+		inventory, products := newRepositories(t)
+		product, _ := products.Create(newProduct("Bulbasaur"))
+		now := time.Now()
+		inventory.Adjust(int(product.ID), received(models.DefaultWarehouse, 5), now)
+		reserved, _ := inventory.Reserve(reservation(product.ID, 2, now.Add(time.Minute)), now)
+
+		committed, err := inventory.CommitReservation(int(reserved.ID), now)
+		require.NoError(t, err)
+		assert.Equal(t, models.ReservationCommitted, committed.Status)
+
+		stock, _ := inventory.GetStock(int(product.ID), now)
+		assert.Equal(t, int64(3), stock.OnHand)
+		assert.Equal(t, int64(0), stock.Reserved)
+		assert.Equal(t, int64(3), stock.Available)
+
+		_, err = inventory.CommitReservation(int(reserved.ID), now)
+		assert.ErrorIs(t, err, models.ErrReservationClosed)
+		_, err = inventory.ReleaseReservation(int(reserved.ID), now)
+		assert.ErrorIs(t, err, models.ErrReservationClosed)
+	})
+
+	t.Run("should not commit expired reservations", func(t *testing.T) {
+		inventory, products := newRepositories(t)
+		product, _ := products.Create(newProduct("Bulbasaur"))
+		now := time.Now()
+		inventory.Adjust(int(product.ID), received(models.DefaultWarehouse, 5), now)
+		reserved, _ := inventory.Reserve(reservation(product.ID, 2, now.Add(time.Minute)), now)
+
+		_, err := inventory.CommitReservation(int(reserved.ID), now.Add(2*time.Minute))
+		assert.ErrorIs(t, err, models.ErrReservationClosed)
+
+		released, err := inventory.ReleaseReservation(int(reserved.ID), now.Add(2*time.Minute))
+		assert.NoError(t, err)
+		assert.Equal(t, models.ReservationReleased, released.Status)
+	})
+
+	t.Run("should give the stock of released reservations back", func(t *testing.T) {
+		inventory, products := newRepositories(t)
+		product, _ := products.Create(newProduct("Bulbasaur"))
+		now := time.Now()
+		inventory.Adjust(int(product.ID), received(models.DefaultWarehouse, 5), now)
+		reserved, _ := inventory.Reserve(reservation(product.ID, 2, now.Add(time.Minute)), now)
+
+		released, err := inventory.ReleaseReservation(int(reserved.ID), now)
+		require.NoError(t, err)
+		assert.Equal(t, models.ReservationReleased, released.Status)
+
+		found, err := inventory.GetReservation(int(reserved.ID))
+		assert.NoError(t, err)
+		assert.Equal(t, models.ReservationReleased, found.Status)
+		stock, _ := inventory.GetStock(int(product.ID), now)
+		assert.Equal(t, int64(5), stock.Available)
+	})
+
+	t.Run("should not oversell when reserving concurrently", func(t *testing.T) {
+		inventory, products := newRepositories(t)
+		product, _ := products.Create(newProduct("Bulbasaur"))
+		now := time.Now()
+		inventory.Adjust(int(product.ID), received(models.DefaultWarehouse, 10), now)
+
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		reserved, rejected := 0, 0
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := inventory.Reserve(reservation(product.ID, 1, now.Add(time.Minute)), now)
+				mu.Lock()
+				defer mu.Unlock()
+				switch {
+				case err == nil:
+					reserved++
+				case errors.Is(err, models.ErrInsufficientStock):
+					rejected++
+				default:
+					t.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, 10, reserved)
+		assert.Equal(t, 10, rejected)
+		stock, _ := inventory.GetStock(int(product.ID), now)
+		assert.Equal(t, int64(0), stock.Available)
+	})
+}
@@ -0,0 +1,129 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// expectLockedLevel expects the stock level of product 1, not of a variant,
+// in the default warehouse to be locked, with onHand in stock and reserved held.
+func expectLockedLevel(mock sqlmock.Sqlmock, now time.Time, onHand, reserved int64) {
+	mock.ExpectQuery("SELECT `id` FROM `products` WHERE `products`.`id` = \\? AND `products`.`deleted_at` IS NULL").
+		WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec("INSERT INTO `stock_levels` (.+) ON DUPLICATE KEY UPDATE").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT \\* FROM `stock_levels` WHERE product_id = \\? AND variant_id = \\? AND warehouse = \\? ORDER BY `stock_levels`.`id` LIMIT 1 FOR UPDATE").
+		WithArgs(1, 0, "default").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "product_id", "warehouse", "on_hand"}).AddRow(7, 1, "default", onHand))
+	mock.ExpectQuery("SELECT COALESCE\\(SUM\\(quantity\\), 0\\) FROM `reservations` WHERE \\(product_id = \\? AND status = \\? AND expires_at > \\?\\) AND \\(variant_id = \\? AND warehouse = \\?\\)").
+		WithArgs(1, "active", now, 0, "default").
+		WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(reserved))
+}
+
+func TestAdjust(t *testing.T) {
+	t.Run("should update the locked level and record the movement", func(t *testing.T) {
+		db, mock := NewMockDB()
+		now := time.Now()
+		mock.ExpectBegin()
+		expectLockedLevel(mock, now, 5, 2)
+		mock.ExpectExec("UPDATE `stock_levels` SET `on_hand`=\\?,`updated_at`=\\? WHERE `id` = \\?").
+			WithArgs(8, sqlmock.AnyArg(), 7).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO `stock_movements`").
+			WithArgs(1, 0, "default", 3, "received", "", nil, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		inventoryRepository := NewInventoryRepository(db)
+		level, err := inventoryRepository.Adjust(1, models.StockAdjustment{Warehouse: "default", Delta: 3, Reason: models.StockReceived}, now)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(8), level.OnHand)
+		assert.Equal(t, int64(6), level.Available)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should not take the stock below what is reserved", func(t *testing.T) {
+		db, mock := NewMockDB()
+		now := time.Now()
+		mock.ExpectBegin()
+		expectLockedLevel(mock, now, 5, 2)
+		mock.ExpectRollback()
+
+		inventoryRepository := NewInventoryRepository(db)
+		_, err := inventoryRepository.Adjust(1, models.StockAdjustment{Warehouse: "default", Delta: -4, Reason: models.StockDamaged}, now)
+
+		assert.True(t, errors.Is(err, models.ErrInsufficientStock))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestReserve(t *testing.T) {
+	t.Run("should reserve what is available in the locked level", func(t *testing.T) {
+		db, mock := NewMockDB()
+		now := time.Now()
+		mock.ExpectBegin()
+		expectLockedLevel(mock, now, 5, 2)
+		mock.ExpectExec("INSERT INTO `reservations`").
+			WithArgs(1, 0, "default", 3, "active", now.Add(time.Minute), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(4, 1))
+		mock.ExpectCommit()
+
+		inventoryRepository := NewInventoryRepository(db)
+		reservation, err := inventoryRepository.Reserve(&models.Reservation{ProductID: 1, Warehouse: "default", Quantity: 3, ExpiresAt: now.Add(time.Minute)}, now)
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint(4), reservation.ID)
+		assert.Equal(t, models.ReservationActive, reservation.Status)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should not reserve more than is available", func(t *testing.T) {
+		db, mock := NewMockDB()
+		now := time.Now()
+		mock.ExpectBegin()
+		expectLockedLevel(mock, now, 5, 3)
+		mock.ExpectRollback()
+
+		inventoryRepository := NewInventoryRepository(db)
+		_, err := inventoryRepository.Reserve(&models.Reservation{ProductID: 1, Warehouse: "default", Quantity: 3, ExpiresAt: now.Add(time.Minute)}, now)
+
+		assert.True(t, errors.Is(err, models.ErrInsufficientStock))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should return an error", func(t *testing.T) {
+		db, mock := NewMockDB()
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT (.+) FROM `products`").WillReturnError(fmt.Errorf("some error"))
+		mock.ExpectRollback()
+
+		inventoryRepository := NewInventoryRepository(db)
+		_, err := inventoryRepository.Reserve(&models.Reservation{ProductID: 1, Quantity: 1}, time.Now())
+
+		assert.Error(t, err)
+	})
+}
+
+func TestCommitReservation(t *testing.T) {
+	t.Run("should lock the reservation and reject it once expired", func(t *testing.T) {
+		db, mock := NewMockDB()
+		now := time.Now()
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT \\* FROM `reservations` WHERE `reservations`.`id` = \\? ORDER BY `reservations`.`id` LIMIT 1 FOR UPDATE").
+			WithArgs(4).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "product_id", "warehouse", "quantity", "status", "expires_at"}).
+				AddRow(4, 1, "default", 3, "active", now.Add(-time.Minute)))
+		mock.ExpectRollback()
+
+		inventoryRepository := NewInventoryRepository(db)
+		_, err := inventoryRepository.CommitReservation(4, now)
+
+		assert.True(t, errors.Is(err, models.ErrReservationClosed))
+		assert.EqualError(t, err, "reservation is not active: it is expired")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,195 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MediaRepository keeps the metadata of the product media, their files being
+// in the media storage. Every change locks the product first, so that the
+// positions and the primary image of its media stay consistent.
+type MediaRepository struct {
+	db *gorm.DB
+}
+
+func NewMediaRepository(db *gorm.DB) *MediaRepository {
+	return &MediaRepository{db: db}
+}
+
+// GetAll returns the media of a product by position.
+func (r *MediaRepository) GetAll(productID int) ([]*models.ProductMedia, error) {
+	var product models.Product
+	if err := r.db.Select("id").First(&product, productID).Error; err != nil {
+		return nil, err
+	}
+
+	media := []*models.ProductMedia{}
+	err := r.db.Where("product_id = ?", product.ID).Order("position, id").Find(&media).Error
+	if err != nil {
+		return nil, err
+	}
+	return media, nil
+}
+
+func (r *MediaRepository) GetByID(productID, id int) (*models.ProductMedia, error) {
+	var product models.Product
+	if err := r.db.Select("id").First(&product, productID).Error; err != nil {
+		return nil, err
+	}
+
+	var media models.ProductMedia
+	if err := r.db.Where("product_id = ?", product.ID).First(&media, id).Error; err != nil {
+		return nil, err
+	}
+	return &media, nil
+}
+
+// Create inserts a media after the other ones of its product, as its primary
+// image when it is the first.
+func (r *MediaRepository) Create(media *models.ProductMedia) (*models.ProductMedia, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		product, err := r.lockProduct(tx, media.ProductID)
+		if err != nil {
+			return err
+		}
+
+		var last struct {
+			Count    int64
+			Position int
+		}
+		err = tx.Model(&models.ProductMedia{}).Select("COUNT(*) AS count, COALESCE(MAX(position), -1) AS position").
+			Where("product_id = ?", product.ID).Scan(&last).Error
+		if err != nil {
+			return err
+		}
+
+		media.Position, media.Primary = last.Position+1, last.Count == 0
+		return tx.Create(media).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return media, nil
+}
+
+// SetPrimary makes a media the primary image of its product, in place of the
+// one that was.
+func (r *MediaRepository) SetPrimary(productID, id int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		product, err := r.lockProduct(tx, uint(productID))
+		if err != nil {
+			return err
+		}
+
+		var media models.ProductMedia
+		if err := tx.Select("id").Where("product_id = ?", product.ID).First(&media, id).Error; err != nil {
+			return err
+		}
+		return r.setPrimary(tx, product.ID, media.ID)
+	})
+}
+
+// Reorder moves the media of a product to the position of their id in ids,
+// which must list each of them once. It returns models.ErrInvalidMediaOrder
+// otherwise.
+func (r *MediaRepository) Reorder(productID int, ids []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		product, err := r.lockProduct(tx, uint(productID))
+		if err != nil {
+			return err
+		}
+
+		var stored []uint
+		if err := tx.Model(&models.ProductMedia{}).Where("product_id = ?", product.ID).Pluck("id", &stored).Error; err != nil {
+			return err
+		}
+		if err := checkMediaOrder(stored, ids); err != nil {
+			return err
+		}
+
+		for position, id := range ids {
+			if err := tx.Model(&models.ProductMedia{}).Where("id = ?", id).Update("position", position).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Delete removes the metadata of a media. When it was the primary image of
+// its product, the first of the remaining media takes its place.
+func (r *MediaRepository) Delete(productID, id int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		product, err := r.lockProduct(tx, uint(productID))
+		if err != nil {
+			return err
+		}
+
+		var media models.ProductMedia
+		if err := tx.Where("product_id = ?", product.ID).First(&media, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&media).Error; err != nil {
+			return err
+		}
+		if !media.Primary {
+			return nil
+		}
+
+		var next models.ProductMedia
+		err = tx.Select("id").Where("product_id = ?", product.ID).Order("position, id").First(&next).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return r.setPrimary(tx, product.ID, next.ID)
+	})
+}
+
+// setPrimary marks id as the only primary media of a product.
+func (r *MediaRepository) setPrimary(tx *gorm.DB, productID, id uint) error {
+	err := tx.Model(&models.ProductMedia{}).Where("product_id = ? AND id <> ?", productID, id).Update("is_primary", false).Error
+	if err != nil {
+		return err
+	}
+	return tx.Model(&models.ProductMedia{}).Where("id = ?", id).Update("is_primary", true).Error
+}
+
+// lockProduct locks a product that isn't deleted.
+func (r *MediaRepository) lockProduct(tx *gorm.DB, id uint) (*models.Product, error) {
+	var product models.Product
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&product, id).Error; err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// checkMediaOrder returns models.ErrInvalidMediaOrder unless ids lists each of
+// the stored ids once.
+func checkMediaOrder(stored, ids []uint) error {
+	expected := map[uint]bool{}
+	for _, id := range stored {
+		expected[id] = true
+	}
+
+	seen := map[uint]bool{}
+	for _, id := range ids {
+		if !expected[id] {
+			return fmt.Errorf("%w: media %d is not one of the product", models.ErrInvalidMediaOrder, id)
+		}
+		if seen[id] {
+			return fmt.Errorf("%w: media %d is listed twice", models.ErrInvalidMediaOrder, id)
+		}
+		seen[id] = true
+	}
+	if len(seen) != len(expected) {
+		return fmt.Errorf("%w: the %d media of the product must all be listed", models.ErrInvalidMediaOrder, len(expected))
+	}
+	return nil
+}
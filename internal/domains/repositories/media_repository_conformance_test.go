@@ -0,0 +1,223 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/core/interfaces"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// TestMediaRepositoryConformance runs the same checks against every
+// implementation of the media, whose products are written through the product
+// repository.
+func TestMediaRepositoryConformance(t *testing.T) {
+	implementations := map[string]func(t *testing.T) (interfaces.MediaRepositoryInterface, interfaces.ProductRespositoryInterface){
+		"memory": func(t *testing.T) (interfaces.MediaRepositoryInterface, interfaces.ProductRespositoryInterface) {
+			products := NewMemoryProductRepository()
+			return NewMemoryMediaRepository(products), products
+		},
+		"gorm": func(t *testing.T) (interfaces.MediaRepositoryInterface, interfaces.ProductRespositoryInterface) {
+			db := newSQLiteDB(t)
+			return NewMediaRepository(db), NewProductRepository(db)
+		},
+	}
+
+	for name, newRepositories := range implementations {
+		t.Run(name, func(t *testing.T) {
+			testMediaRepository(t, newRepositories)
+		})
+	}
+}
+
+// createMedia adds an image named name to the media of a product.
+func createMedia(t *testing.T, repository interfaces.MediaRepositoryInterface, productID uint, name string) *models.ProductMedia {
+	media, err := repository.Create(&models.ProductMedia{
+		ProductID:    productID,
+		Filename:     name,
+		ContentType:  "image/png",
+		Size:         1024,
+		Width:        640,
+		Height:       480,
+		FileKey:      fmt.Sprintf("products/%d/%s", productID, name),
+		ThumbnailKey: fmt.Sprintf("products/%d/thumb_%s", productID, name),
+	})
+	require.NoError(t, err)
+	return media
+}
+
+func mediaIDs(media []*models.ProductMedia) []uint {
+	ids := []uint{}
+	for _, m := range media {
+		ids = append(ids, m.ID)
+	}
+	return ids
+}
+
+func primaryMedia(media []*models.ProductMedia) []uint {
+	ids := []uint{}
+	for _, m := range media {
+		if m.Primary {
+			ids = append(ids, m.ID)
+		}
+	}
+	return ids
+}
+
+func testMediaRepository(t *testing.T, newRepositories func(t *testing.T) (interfaces.MediaRepositoryInterface, interfaces.ProductRespositoryInterface)) {
+	t.Run("should add the media after the others, the first as primary", func(t *testing.T) {
+		media, products := newRepositories(t)
+		product, _ := products.Create(newProduct("Camera"))
+
+		front := createMedia(t, media, product.ID, "front.png")
+		back := createMedia(t, media, product.ID, "back.png")
+
+		assert.NotZero(t, front.ID)
+		assert.True(t, front.Primary)
+		assert.False(t, back.Primary)
+		assert.Less(t, front.Position, back.Position)
+
+		found, err := media.GetAll(int(product.ID))
+		require.NoError(t, err)
+		assert.Equal(t, []uint{front.ID, back.ID}, mediaIDs(found))
+		assert.Equal(t, "products/1/front.png", found[0].FileKey)
+		assert.Equal(t, "products/1/thumb_front.png", found[0].ThumbnailKey)
+	})
+
+	t.Run("should get a media of the product only", func(t *testing.T) {
+		media, products := newRepositories(t)
+		camera, _ := products.Create(newProduct("Camera"))
+		lens, _ := products.Create(newProduct("Lens"))
+		front := createMedia(t, media, camera.ID, "front.png")
+
+		found, err := media.GetByID(int(camera.ID), int(front.ID))
+		require.NoError(t, err)
+		assert.Equal(t, "front.png", found.Filename)
+		assert.Equal(t, 640, found.Width)
+
+		_, err = media.GetByID(int(lens.ID), int(front.ID))
+		assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+	})
+
+	t.Run("should not find the media of missing or deleted products", func(t *testing.T) {
+		media, products := newRepositories(t)
+		product, _ := products.Create(newProduct("Camera"))
+		createMedia(t, media, product.ID, "front.png")
+		require.NoError(t, products.Delete(int(product.ID)))
+
+		_, err := media.GetAll(int(product.ID))
+		assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+		_, err = media.Create(&models.ProductMedia{ProductID: 99, Filename: "front.png"})
+		assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+	})
+
+	t.Run("should keep a single primary media", func(t *testing.T) {
+		media, products := newRepositories(t)
+		product, _ := products.Create(newProduct("Camera"))
+		createMedia(t, media, product.ID, "front.png")
+		back := createMedia(t, media, product.ID, "back.png")
+
+		require.NoError(t, media.SetPrimary(int(product.ID), int(back.ID)))
+
+		found, _ := media.GetAll(int(product.ID))
+		assert.Equal(t, []uint{back.ID}, primaryMedia(found))
+		err := media.SetPrimary(int(product.ID), 99)
+		assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+	})
+
+	t.Run("should reorder the media", func(t *testing.T) {
+		media, products := newRepositories(t)
+		product, _ := products.Create(newProduct("Camera"))
+		front := createMedia(t, media, product.ID, "front.png")
+		back := createMedia(t, media, product.ID, "back.png")
+		side := createMedia(t, media, product.ID, "side.png")
+
+		require.NoError(t, media.Reorder(int(product.ID), []uint{side.ID, front.ID, back.ID}))
+
+		found, _ := media.GetAll(int(product.ID))
+		assert.Equal(t, []uint{side.ID, front.ID, back.ID}, mediaIDs(found))
+		assert.Equal(t, []uint{front.ID}, primaryMedia(found))
+	})
+
+	t.Run("should refuse orders that don't list every media once", func(t *testing.T) {
+		media, products := newRepositories(t)
+		camera, _ := products.Create(newProduct("Camera"))
+		lens, _ := products.Create(newProduct("Lens"))
+		front := createMedia(t, media, camera.ID, "front.png")
+		back := createMedia(t, media, camera.ID, "back.png")
+		other := createMedia(t, media, lens.ID, "lens.png")
+
+		for _, ids := range [][]uint{{front.ID}, {front.ID, front.ID}, {front.ID, back.ID, other.ID}} {
+			err := media.Reorder(int(camera.ID), ids)
+			assert.True(t, errors.Is(err, models.ErrInvalidMediaOrder), ids)
+		}
+
+		found, _ := media.GetAll(int(camera.ID))
+		assert.Equal(t, []uint{front.ID, back.ID}, mediaIDs(found))
+	})
+
+	t.Run("should make the first media primary when the primary one is deleted", func(t *testing.T) {
+		media, products := newRepositories(t)
+		product, _ := products.Create(newProduct("Camera"))
+		front := createMedia(t, media, product.ID, "front.png")
+		back := createMedia(t, media, product.ID, "back.png")
+		side := createMedia(t, media, product.ID, "side.png")
+		require.NoError(t, media.Reorder(int(product.ID), []uint{front.ID, side.ID, back.ID}))
+
+		require.NoError(t, media.Delete(int(product.ID), int(front.ID)))
+
+		found, _ := media.GetAll(int(product.ID))
+		assert.Equal(t, []uint{side.ID, back.ID}, mediaIDs(found))
+		assert.Equal(t, []uint{side.ID}, primaryMedia(found))
+		err := media.Delete(int(product.ID), int(front.ID))
+		assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+	})
+
+	t.Run("should remove the media of the seeded products with them", func(t *testing.T) {
+		media, products := newRepositories(t)
+		key := "camera"
+		seeded := newProduct("Camera")
+		seeded.SeedKey = &key
+		seeded, _ = products.Create(seeded)
+		other, _ := products.Create(newProduct("Lens"))
+		front := createMedia(t, media, seeded.ID, "front.png")
+		lens := createMedia(t, media, other.ID, "lens.png")
+
+		deleted, found, err := products.DeleteSeeded()
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), deleted)
+		assert.Equal(t, []uint{front.ID}, mediaIDs(found))
+		assert.Equal(t, "products/1/front.png", found[0].FileKey)
+
+		_, err = media.GetByID(int(seeded.ID), int(front.ID))
+		assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+		remaining, err := media.GetAll(int(other.ID))
+		require.NoError(t, err)
+		assert.Equal(t, []uint{lens.ID}, mediaIDs(remaining))
+	})
+
+	t.Run("should return the media of a purged product and remove them", func(t *testing.T) {
+		media, products := newRepositories(t)
+		product, _ := products.Create(newProduct("Camera"))
+		other, _ := products.Create(newProduct("Lens"))
+		front := createMedia(t, media, product.ID, "front.png")
+		back := createMedia(t, media, product.ID, "back.png")
+		lens := createMedia(t, media, other.ID, "lens.png")
+		require.NoError(t, products.Delete(int(product.ID)))
+
+		purged, err := products.Purge(int(product.ID))
+		require.NoError(t, err)
+		assert.Equal(t, []uint{front.ID, back.ID}, mediaIDs(purged))
+		assert.Equal(t, "products/1/thumb_back.png", purged[1].ThumbnailKey)
+
+		_, err = media.GetByID(int(product.ID), int(front.ID))
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		remaining, err := media.GetAll(int(other.ID))
+		require.NoError(t, err)
+		assert.Equal(t, []uint{lens.ID}, mediaIDs(remaining))
+	})
+}
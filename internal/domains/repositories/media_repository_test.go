@@ -0,0 +1,42 @@
+package repositories
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateMedia(t *testing.T) {
+	t.Run("should insert the media after the others once the product is locked", func(t *testing.T) {
+		db, mock := NewMockDB()
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT `id` FROM `products` WHERE `products`.`id` = \\? AND `products`.`deleted_at` IS NULL ORDER BY `products`.`id` LIMIT 1 FOR UPDATE").
+			WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\) AS count, COALESCE\\(MAX\\(position\\), -1\\) AS position FROM `product_media` WHERE product_id = \\?").
+			WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"count", "position"}).AddRow(2, 4))
+		mock.ExpectExec("INSERT INTO `product_media`").
+			WithArgs(1, "back.jpg", "image/jpeg", 2048, 800, 600, 5, false, "products/1/back.jpg", "products/1/back_thumb.jpg", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(3, 1))
+		mock.ExpectCommit()
+
+		mediaRepository := NewMediaRepository(db)
+		media, err := mediaRepository.Create(&models.ProductMedia{
+			ProductID:    1,
+			Filename:     "back.jpg",
+			ContentType:  "image/jpeg",
+			Size:         2048,
+			Width:        800,
+			Height:       600,
+			FileKey:      "products/1/back.jpg",
+			ThumbnailKey: "products/1/back_thumb.jpg",
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint(3), media.ID)
+		assert.Equal(t, 5, media.Position)
+		assert.False(t, media.Primary)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
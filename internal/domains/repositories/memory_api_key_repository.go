@@ -0,0 +1,116 @@
+package repositories
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"gorm.io/gorm"
+)
+
+// MemoryAPIKeyRepository keeps the API keys in memory, for tests and demos.
+type MemoryAPIKeyRepository struct {
+	mu      sync.RWMutex
+	apiKeys map[uint]models.APIKey
+	lastID  uint
+}
+
+func NewMemoryAPIKeyRepository() *MemoryAPIKeyRepository {
+	return &MemoryAPIKeyRepository{apiKeys: map[uint]models.APIKey{}}
+}
+
+func (r *MemoryAPIKeyRepository) GetAll() ([]*models.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	apiKeys := []*models.APIKey{}
+	for _, apiKey := range r.apiKeys {
+		apiKey := apiKey
+		apiKeys = append(apiKeys, &apiKey)
+	}
+	sort.Slice(apiKeys, func(i, j int) bool { return apiKeys[i].ID < apiKeys[j].ID })
+	return apiKeys, nil
+}
+
+func (r *MemoryAPIKeyRepository) Create(apiKey *models.APIKey) (*models.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.apiKeys {
+		if existing.Prefix == apiKey.Prefix {
+			return nil, gorm.ErrDuplicatedKey
+		}
+	}
+
+	r.lastID++
+	apiKey.ID = r.lastID
+	apiKey.CreatedAt = time.Now()
+	apiKey.UpdatedAt = apiKey.CreatedAt
+	r.apiKeys[apiKey.ID] = *apiKey
+	return apiKey, nil
+}
+
+func (r *MemoryAPIKeyRepository) GetByID(id int) (*models.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	apiKey, ok := r.apiKeys[uint(id)]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &apiKey, nil
+}
+
+func (r *MemoryAPIKeyRepository) GetByPrefix(prefix string) (*models.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, apiKey := range r.apiKeys {
+		if apiKey.Prefix == prefix {
+			return &apiKey, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *MemoryAPIKeyRepository) Rotate(id uint, prefix, hash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	apiKey, ok := r.apiKeys[id]
+	if !ok || apiKey.RevokedAt != nil {
+		return gorm.ErrRecordNotFound
+	}
+	apiKey.Prefix, apiKey.Hash = prefix, hash
+	apiKey.UpdatedAt = time.Now()
+	r.apiKeys[id] = apiKey
+	return nil
+}
+
+func (r *MemoryAPIKeyRepository) Revoke(id uint, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	apiKey, ok := r.apiKeys[id]
+	if !ok || apiKey.RevokedAt != nil {
+		return gorm.ErrRecordNotFound
+	}
+	apiKey.RevokedAt = &at
+	apiKey.UpdatedAt = time.Now()
+	r.apiKeys[id] = apiKey
+	return nil
+}
+
+func (r *MemoryAPIKeyRepository) TouchLastUsed(id uint, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	apiKey, ok := r.apiKeys[id]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	apiKey.LastUsedAt = &at
+	r.apiKeys[id] = apiKey
+	return nil
+}
@@ -0,0 +1,89 @@
+package repositories
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"gorm.io/gorm"
+)
+
+// MemoryAttributeRepository keeps the attribute definitions in memory, for
+// tests and demos. Deleted attributes are removed from the products of
+// products, when given.
+type MemoryAttributeRepository struct {
+	mu          sync.RWMutex
+	definitions map[string]models.AttributeDefinition
+	products    *MemoryProductRepository
+}
+
+func NewMemoryAttributeRepository(products *MemoryProductRepository) *MemoryAttributeRepository {
+	return &MemoryAttributeRepository{definitions: map[string]models.AttributeDefinition{}, products: products}
+}
+
+func (r *MemoryAttributeRepository) GetAll() ([]*models.AttributeDefinition, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	definitions := []*models.AttributeDefinition{}
+	for _, definition := range r.definitions {
+		definition := definition
+		definitions = append(definitions, &definition)
+	}
+	sort.Slice(definitions, func(i, j int) bool { return definitions[i].Key < definitions[j].Key })
+	return definitions, nil
+}
+
+func (r *MemoryAttributeRepository) GetByKey(key string) (*models.AttributeDefinition, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	definition, ok := r.definitions[key]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &definition, nil
+}
+
+func (r *MemoryAttributeRepository) Create(definition *models.AttributeDefinition) (*models.AttributeDefinition, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.definitions[definition.Key]; ok {
+		return nil, &models.ConflictError{Field: "key", Value: definition.Key}
+	}
+	definition.CreatedAt = time.Now()
+	definition.UpdatedAt = definition.CreatedAt
+	r.definitions[definition.Key] = *definition
+	return definition, nil
+}
+
+func (r *MemoryAttributeRepository) Update(definition *models.AttributeDefinition) (*models.AttributeDefinition, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.definitions[definition.Key]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	existing.Name, existing.Unit, existing.Values = definition.Name, definition.Unit, definition.Values
+	existing.Min, existing.Max, existing.MaxLength = definition.Min, definition.Max, definition.MaxLength
+	existing.UpdatedAt = time.Now()
+	r.definitions[existing.Key] = existing
+	return &existing, nil
+}
+
+func (r *MemoryAttributeRepository) Delete(key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.definitions[key]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	delete(r.definitions, key)
+	if r.products != nil {
+		r.products.removeAttribute(key)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package repositories
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"gorm.io/gorm"
+)
+
+// MemoryCategoryRepository keeps the categories in memory, for tests and
+// demos. Deleted categories are unlinked from the products of products, when
+// given.
+type MemoryCategoryRepository struct {
+	mu         sync.RWMutex
+	categories map[uint]models.Category
+	lastID     uint
+	products   *MemoryProductRepository
+}
+
+func NewMemoryCategoryRepository(products *MemoryProductRepository) *MemoryCategoryRepository {
+	return &MemoryCategoryRepository{categories: map[uint]models.Category{}, products: products}
+}
+
+func (r *MemoryCategoryRepository) GetAll() ([]*models.Category, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	categories := []*models.Category{}
+	for _, category := range r.categories {
+		category := category
+		categories = append(categories, &category)
+	}
+	sort.Slice(categories, func(i, j int) bool { return categories[i].Path < categories[j].Path })
+	return categories, nil
+}
+
+func (r *MemoryCategoryRepository) Create(category *models.Category) (*models.Category, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	parentPath := ""
+	if category.ParentID != nil {
+		parent, ok := r.categories[*category.ParentID]
+		if !ok {
+			return nil, gorm.ErrRecordNotFound
+		}
+		parentPath = parent.Path
+	}
+
+	r.lastID++
+	category.ID = r.lastID
+	category.Path = models.CategoryPath(parentPath, category.ID)
+	category.CreatedAt = time.Now()
+	category.UpdatedAt = category.CreatedAt
+	r.categories[category.ID] = *category
+	return category, nil
+}
+
+func (r *MemoryCategoryRepository) GetByID(id int) (*models.Category, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	category, ok := r.categories[uint(id)]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &category, nil
+}
+
+func (r *MemoryCategoryRepository) Update(category *models.Category) (*models.Category, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.categories[category.ID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	existing.Name = category.Name
+	existing.UpdatedAt = time.Now()
+	r.categories[category.ID] = existing
+
+	*category = existing
+	return category, nil
+}
+
+func (r *MemoryCategoryRepository) Move(id int, parentID *uint) (*models.Category, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	category, ok := r.categories[uint(id)]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	parentPath := ""
+	if parentID != nil {
+		parent, ok := r.categories[*parentID]
+		if !ok {
+			return nil, gorm.ErrRecordNotFound
+		}
+		if parent.IsDescendantOf(&category) {
+			return nil, models.ErrCategoryCycle
+		}
+		parentPath = parent.Path
+	}
+
+	oldPath, newPath := category.Path, models.CategoryPath(parentPath, category.ID)
+	for descendantID, descendant := range r.categories {
+		if strings.HasPrefix(descendant.Path, oldPath) {
+			descendant.Path = newPath + descendant.Path[len(oldPath):]
+			r.categories[descendantID] = descendant
+		}
+	}
+
+	category = r.categories[uint(id)]
+	category.ParentID = parentID
+	category.UpdatedAt = time.Now()
+	r.categories[category.ID] = category
+	return &category, nil
+}
+
+func (r *MemoryCategoryRepository) Delete(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.categories[uint(id)]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	for _, category := range r.categories {
+		if category.ParentID != nil && *category.ParentID == uint(id) {
+			return models.ErrCategoryHasChildren
+		}
+	}
+
+	delete(r.categories, uint(id))
+	if r.products != nil {
+		r.products.unlinkCategory(uint(id))
+	}
+	return nil
+}
+
+func (r *MemoryCategoryRepository) DescendantIDs(id int) ([]uint, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	category, ok := r.categories[uint(id)]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var descendants []models.Category
+	for _, descendant := range r.categories {
+		if descendant.IsDescendantOf(&category) {
+			descendants = append(descendants, descendant)
+		}
+	}
+	sort.Slice(descendants, func(i, j int) bool { return descendants[i].Path < descendants[j].Path })
+
+	ids := make([]uint, len(descendants))
+	for i, descendant := range descendants {
+		ids[i] = descendant.ID
+	}
+	return ids, nil
+}
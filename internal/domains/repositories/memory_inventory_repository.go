@@ -0,0 +1,287 @@
+package repositories
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"gorm.io/gorm"
+)
+
+// stockKey identifies the stock level of a product, or of one of its
+// variants, in a warehouse.
+type stockKey struct {
+	productID uint
+	variantID uint
+	warehouse string
+}
+
+// memoryInventory is the stock of the products of a MemoryProductRepository,
+// guarded by its lock.
+type memoryInventory struct {
+	levels          map[stockKey]models.StockLevel
+	movements       []models.StockMovement
+	reservations    map[uint]models.Reservation
+	lastReservation uint
+}
+
+func newMemoryInventory() memoryInventory {
+	return memoryInventory{levels: map[stockKey]models.StockLevel{}, reservations: map[uint]models.Reservation{}}
+}
+
+// level returns the stock level of a product or variant in a warehouse, with
+// the reservations active at now.
+func (i *memoryInventory) level(productID, variantID uint, warehouse string, now time.Time) models.StockLevel {
+	level, ok := i.levels[stockKey{productID, variantID, warehouse}]
+	if !ok {
+		level = models.StockLevel{ProductID: productID, VariantID: variantID, Warehouse: warehouse}
+	}
+	level.Reserved = 0
+	for _, reservation := range i.reservations {
+		if reservation.ProductID == productID && reservation.VariantID == variantID && reservation.Warehouse == warehouse && reservation.Active(now) {
+			level.Reserved += reservation.Quantity
+		}
+	}
+	level.Available = level.OnHand - level.Reserved
+	return level
+}
+
+// available returns the stock of a product, its variants' included,
+// available at now in every warehouse.
+func (i *memoryInventory) available(productID uint, now time.Time) int64 {
+	var available int64
+	for key, level := range i.levels {
+		if key.productID == productID {
+			available += level.OnHand
+		}
+	}
+	for _, reservation := range i.reservations {
+		if reservation.ProductID == productID && reservation.Active(now) {
+			available -= reservation.Quantity
+		}
+	}
+	return available
+}
+
+// variantAvailable returns the stock of a variant available at now in every
+// warehouse.
+func (i *memoryInventory) variantAvailable(variantID uint, now time.Time) int64 {
+	var available int64
+	for key, level := range i.levels {
+		if key.variantID == variantID {
+			available += level.OnHand
+		}
+	}
+	for _, reservation := range i.reservations {
+		if reservation.VariantID == variantID && reservation.Active(now) {
+			available -= reservation.Quantity
+		}
+	}
+	return available
+}
+
+// setOnHand saves the stock on hand of level, recording the movement.
+func (i *memoryInventory) setOnHand(level models.StockLevel, movement models.StockMovement) {
+	level.UpdatedAt = time.Now()
+	i.levels[stockKey{level.ProductID, level.VariantID, level.Warehouse}] = level
+
+	movement.ID = uint(len(i.movements) + 1)
+	movement.CreatedAt = level.UpdatedAt
+	i.movements = append(i.movements, movement)
+}
+
+// removeVariant forgets the stock of a variant.
+func (i *memoryInventory) removeVariant(variantID uint) {
+	for key := range i.levels {
+		if key.variantID == variantID {
+			delete(i.levels, key)
+		}
+	}
+	for id, reservation := range i.reservations {
+		if reservation.VariantID == variantID {
+			delete(i.reservations, id)
+		}
+	}
+}
+
+// remove forgets the stock of a product and of its variants.
+func (i *memoryInventory) remove(productID uint) {
+	for key := range i.levels {
+		if key.productID == productID {
+			delete(i.levels, key)
+		}
+	}
+	for id, reservation := range i.reservations {
+		if reservation.ProductID == productID {
+			delete(i.reservations, id)
+		}
+	}
+}
+
+// MemoryInventoryRepository keeps the stock of the products of a
+// MemoryProductRepository, which is where it is kept, for tests and demos.
+type MemoryInventoryRepository struct {
+	products *MemoryProductRepository
+}
+
+func NewMemoryInventoryRepository(products *MemoryProductRepository) *MemoryInventoryRepository {
+	return &MemoryInventoryRepository{products: products}
+}
+
+func (r *MemoryInventoryRepository) GetStock(productID int, now time.Time) (*models.Stock, error) {
+	r.products.mu.RLock()
+	defer r.products.mu.RUnlock()
+
+	product, err := r.products.product(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	levels := []models.StockLevel{}
+	for key := range r.products.inventory.levels {
+		if key.productID == product.ID {
+			levels = append(levels, r.products.inventory.level(product.ID, key.variantID, key.warehouse, now))
+		}
+	}
+	sort.Slice(levels, func(i, j int) bool {
+		if levels[i].VariantID != levels[j].VariantID {
+			return levels[i].VariantID < levels[j].VariantID
+		}
+		return levels[i].Warehouse < levels[j].Warehouse
+	})
+	return models.NewStock(product.ID, levels), nil
+}
+
+func (r *MemoryInventoryRepository) Adjust(productID int, adjustment models.StockAdjustment, now time.Time) (*models.StockLevel, error) {
+	r.products.mu.Lock()
+	defer r.products.mu.Unlock()
+
+	product, err := r.products.product(productID)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.checkVariant(product.ID, adjustment.VariantID); err != nil {
+		return nil, err
+	}
+
+	level := r.products.inventory.level(product.ID, adjustment.VariantID, adjustment.Warehouse, now)
+	if level.OnHand+adjustment.Delta < level.Reserved {
+		return nil, fmt.Errorf("%w: %d on hand and %d reserved in %s", models.ErrInsufficientStock, level.OnHand, level.Reserved, level.Warehouse)
+	}
+
+	level.OnHand += adjustment.Delta
+	level.Available = level.OnHand - level.Reserved
+	r.products.inventory.setOnHand(level, models.StockMovement{
+		ProductID: product.ID,
+		VariantID: level.VariantID,
+		Warehouse: level.Warehouse,
+		Delta:     adjustment.Delta,
+		Reason:    adjustment.Reason,
+		Note:      adjustment.Note,
+	})
+	return &level, nil
+}
+
+func (r *MemoryInventoryRepository) Reserve(reservation *models.Reservation, now time.Time) (*models.Reservation, error) {
+	r.products.mu.Lock()
+	defer r.products.mu.Unlock()
+
+	if _, err := r.products.product(int(reservation.ProductID)); err != nil {
+		return nil, err
+	}
+	if err := r.checkVariant(reservation.ProductID, reservation.VariantID); err != nil {
+		return nil, err
+	}
+
+	level := r.products.inventory.level(reservation.ProductID, reservation.VariantID, reservation.Warehouse, now)
+	if level.Available < reservation.Quantity {
+		return nil, fmt.Errorf("%w: %d available in %s", models.ErrInsufficientStock, level.Available, level.Warehouse)
+	}
+	key := stockKey{level.ProductID, level.VariantID, level.Warehouse}
+	if _, ok := r.products.inventory.levels[key]; !ok {
+		level.UpdatedAt = time.Now()
+		r.products.inventory.levels[key] = level
+	}
+
+	r.products.inventory.lastReservation++
+	reservation.ID = r.products.inventory.lastReservation
+	reservation.Status = models.ReservationActive
+	reservation.CreatedAt = time.Now()
+	reservation.UpdatedAt = reservation.CreatedAt
+	r.products.inventory.reservations[reservation.ID] = *reservation
+	return reservation, nil
+}
+
+func (r *MemoryInventoryRepository) GetReservation(id int) (*models.Reservation, error) {
+	r.products.mu.RLock()
+	defer r.products.mu.RUnlock()
+
+	reservation, ok := r.products.inventory.reservations[uint(id)]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &reservation, nil
+}
+
+func (r *MemoryInventoryRepository) CommitReservation(id int, now time.Time) (*models.Reservation, error) {
+	return r.close(id, func(reservation *models.Reservation) error {
+		if !reservation.Active(now) {
+			return fmt.Errorf("%w: it is %s", models.ErrReservationClosed, displayStatus(*reservation, now))
+		}
+
+		level := r.products.inventory.level(reservation.ProductID, reservation.VariantID, reservation.Warehouse, now)
+		level.OnHand -= reservation.Quantity
+		reservationID := reservation.ID
+		r.products.inventory.setOnHand(level, models.StockMovement{
+			ProductID:     reservation.ProductID,
+			VariantID:     reservation.VariantID,
+			Warehouse:     reservation.Warehouse,
+			Delta:         -reservation.Quantity,
+			Reason:        models.StockSold,
+			ReservationID: &reservationID,
+		})
+		reservation.Status = models.ReservationCommitted
+		return nil
+	})
+}
+
+func (r *MemoryInventoryRepository) ReleaseReservation(id int, now time.Time) (*models.Reservation, error) {
+	return r.close(id, func(reservation *models.Reservation) error {
+		if reservation.Status != models.ReservationActive {
+			return fmt.Errorf("%w: it is %s", models.ErrReservationClosed, reservation.Status)
+		}
+		reservation.Status = models.ReservationReleased
+		return nil
+	})
+}
+
+// close lets change update a reservation and saves it.
+func (r *MemoryInventoryRepository) close(id int, change func(reservation *models.Reservation) error) (*models.Reservation, error) {
+	r.products.mu.Lock()
+	defer r.products.mu.Unlock()
+
+	reservation, ok := r.products.inventory.reservations[uint(id)]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if err := change(&reservation); err != nil {
+		return nil, err
+	}
+	reservation.UpdatedAt = time.Now()
+	r.products.inventory.reservations[reservation.ID] = reservation
+	return &reservation, nil
+}
+
+// checkVariant returns models.ErrUnknownVariant when variantID, unless zero,
+// isn't one of the variants of the product. It must be called with the lock
+// held.
+func (r *MemoryInventoryRepository) checkVariant(productID, variantID uint) error {
+	if variantID == 0 {
+		return nil
+	}
+	if variant, ok := r.products.variants[variantID]; !ok || variant.ProductID != productID {
+		return fmt.Errorf("%w %d", models.ErrUnknownVariant, variantID)
+	}
+	return nil
+}
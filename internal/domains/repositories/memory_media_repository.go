@@ -0,0 +1,156 @@
+package repositories
+
+import (
+	"sort"
+	"time"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"gorm.io/gorm"
+)
+
+// MemoryMediaRepository keeps the metadata of the media of the products of a
+// MemoryProductRepository, which is where it is kept, for tests and demos.
+type MemoryMediaRepository struct {
+	products *MemoryProductRepository
+}
+
+func NewMemoryMediaRepository(products *MemoryProductRepository) *MemoryMediaRepository {
+	return &MemoryMediaRepository{products: products}
+}
+
+func (r *MemoryMediaRepository) GetAll(productID int) ([]*models.ProductMedia, error) {
+	r.products.mu.RLock()
+	defer r.products.mu.RUnlock()
+
+	product, err := r.products.product(productID)
+	if err != nil {
+		return nil, err
+	}
+	return r.list(product.ID), nil
+}
+
+func (r *MemoryMediaRepository) GetByID(productID, id int) (*models.ProductMedia, error) {
+	r.products.mu.RLock()
+	defer r.products.mu.RUnlock()
+
+	product, err := r.products.product(productID)
+	if err != nil {
+		return nil, err
+	}
+	media, ok := r.products.media[uint(id)]
+	if !ok || media.ProductID != product.ID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &media, nil
+}
+
+func (r *MemoryMediaRepository) Create(media *models.ProductMedia) (*models.ProductMedia, error) {
+	r.products.mu.Lock()
+	defer r.products.mu.Unlock()
+
+	product, err := r.products.product(int(media.ProductID))
+	if err != nil {
+		return nil, err
+	}
+
+	others := r.list(product.ID)
+	media.Position, media.Primary = 0, len(others) == 0
+	if len(others) > 0 {
+		media.Position = others[len(others)-1].Position + 1
+	}
+
+	r.products.lastMediaID++
+	media.ID = r.products.lastMediaID
+	media.CreatedAt = time.Now()
+	r.products.media[media.ID] = *media
+	return media, nil
+}
+
+func (r *MemoryMediaRepository) SetPrimary(productID, id int) error {
+	r.products.mu.Lock()
+	defer r.products.mu.Unlock()
+
+	product, err := r.products.product(productID)
+	if err != nil {
+		return err
+	}
+	media, ok := r.products.media[uint(id)]
+	if !ok || media.ProductID != product.ID {
+		return gorm.ErrRecordNotFound
+	}
+	r.setPrimary(product.ID, media.ID)
+	return nil
+}
+
+func (r *MemoryMediaRepository) Reorder(productID int, ids []uint) error {
+	r.products.mu.Lock()
+	defer r.products.mu.Unlock()
+
+	product, err := r.products.product(productID)
+	if err != nil {
+		return err
+	}
+
+	var stored []uint
+	for _, media := range r.list(product.ID) {
+		stored = append(stored, media.ID)
+	}
+	if err := checkMediaOrder(stored, ids); err != nil {
+		return err
+	}
+
+	for position, id := range ids {
+		media := r.products.media[id]
+		media.Position = position
+		r.products.media[id] = media
+	}
+	return nil
+}
+
+func (r *MemoryMediaRepository) Delete(productID, id int) error {
+	r.products.mu.Lock()
+	defer r.products.mu.Unlock()
+
+	product, err := r.products.product(productID)
+	if err != nil {
+		return err
+	}
+	media, ok := r.products.media[uint(id)]
+	if !ok || media.ProductID != product.ID {
+		return gorm.ErrRecordNotFound
+	}
+
+	delete(r.products.media, media.ID)
+	if others := r.list(product.ID); media.Primary && len(others) > 0 {
+		r.setPrimary(product.ID, others[0].ID)
+	}
+	return nil
+}
+
+// list returns the media of a product by position. It must be called with
+// the lock held.
+func (r *MemoryMediaRepository) list(productID uint) []*models.ProductMedia {
+	media := []*models.ProductMedia{}
+	for _, stored := range r.products.media {
+		if stored.ProductID == productID {
+			stored := stored
+			media = append(media, &stored)
+		}
+	}
+	sort.Slice(media, func(i, j int) bool {
+		if media[i].Position != media[j].Position {
+			return media[i].Position < media[j].Position
+		}
+		return media[i].ID < media[j].ID
+	})
+	return media
+}
+
+// setPrimary marks id as the only primary media of a product. It must be
+// called with the lock held.
+func (r *MemoryMediaRepository) setPrimary(productID, id uint) {
+	for _, media := range r.list(productID) {
+		media.Primary = media.ID == id
+		r.products.media[media.ID] = *media
+	}
+}
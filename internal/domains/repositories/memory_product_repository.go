@@ -0,0 +1,668 @@
+package repositories
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"gorm.io/gorm"
+)
+
+// MemoryProductRepository keeps the products in memory, for tests and demos.
+// It behaves like ProductRepository: ids auto increment, timestamps are set on
+// create and update, deletes are soft and missing products return
+// gorm.ErrRecordNotFound. Categories are linked with SetCategories only, and
+// keep the name they had when they were linked. Tags are kept with the
+// products, and numbered when first used. The variants, the stock and the
+// media are kept with the products too, for MemoryVariantRepository,
+// MemoryInventoryRepository and MemoryMediaRepository, and so is the price
+// history.
+type MemoryProductRepository struct {
+	mu                sync.RWMutex
+	products          map[uint]models.Product
+	lastID            uint
+	tags              map[string]models.Tag
+	lastTagID         uint
+	variants          map[uint]models.ProductVariant
+	lastVariantID     uint
+	inventory         memoryInventory
+	media             map[uint]models.ProductMedia
+	lastMediaID       uint
+	priceHistory      []models.PriceChange
+	lastPriceChangeID uint
+}
+
+func NewMemoryProductRepository() *MemoryProductRepository {
+	return &MemoryProductRepository{
+		products:  map[uint]models.Product{},
+		tags:      map[string]models.Tag{},
+		variants:  map[uint]models.ProductVariant{},
+		inventory: newMemoryInventory(),
+		media:     map[uint]models.ProductMedia{},
+	}
+}
+
+func (r *MemoryProductRepository) GetAll(filter models.ProductFilter) ([]*models.Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	products := []*models.Product{}
+	for _, product := range r.products {
+		if product.DeletedAt.Valid || filter.CategoryIDs != nil && !inCategories(product, filter.CategoryIDs) {
+			continue
+		}
+		if filter.TagNames != nil && !hasTags(product, filter.TagNames, filter.MatchAllTags()) {
+			continue
+		}
+		if len(filter.Statuses) > 0 && !hasStatus(product, filter.Statuses) {
+			continue
+		}
+		if !hasAttributes(product, filter.Attributes) {
+			continue
+		}
+		product := product
+		r.compute(&product, now)
+		products = append(products, &product)
+	}
+	sort.Slice(products, func(i, j int) bool { return products[i].ID < products[j].ID })
+
+	if filter.PerPage > 0 {
+		page := filter.Page
+		if page < 1 {
+			page = 1
+		}
+		start := (page - 1) * filter.PerPage
+		if start > len(products) {
+			start = len(products)
+		}
+		end := start + filter.PerPage
+		if end > len(products) {
+			end = len(products)
+		}
+		products = products[start:end]
+	}
+	return products, nil
+}
+
+func (r *MemoryProductRepository) Create(product *models.Product) (*models.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.skuConflict(product); err != nil {
+		return nil, err
+	}
+	product.Slug = r.freeSlug(product)
+	product.Categories, product.Tags, product.Options = nil, nil, nil
+	r.insert(product)
+	product.Attributes = attributesOf(product.ID, product.Attributes, nil)
+	r.products[product.ID] = *product
+	product.LowestPrice30d = product.Price
+	return product, nil
+}
+
+// attributesOf returns a copy of the attributes of the product with id
+// productID, or of stored when they are nil.
+func attributesOf(productID uint, attributes, stored models.ProductAttributes) models.ProductAttributes {
+	if attributes == nil {
+		attributes = stored
+	}
+	copied := make(models.ProductAttributes, len(attributes))
+	for i, attribute := range attributes {
+		attribute.ProductID = productID
+		copied[i] = attribute
+	}
+	return copied
+}
+
+// insert stores product, assigning its id and timestamps when unset. It must
+// be called with the lock held.
+func (r *MemoryProductRepository) insert(product *models.Product) {
+	if product.ID == 0 {
+		r.lastID++
+		product.ID = r.lastID
+	} else if product.ID > r.lastID {
+		r.lastID = product.ID
+	}
+
+	if product.Status == "" {
+		product.Status = models.ProductDraft
+	}
+
+	now := time.Now()
+	if product.CreatedAt.IsZero() {
+		product.CreatedAt = now
+	}
+	if product.UpdatedAt.IsZero() {
+		product.UpdatedAt = now
+	}
+	r.products[product.ID] = *product
+}
+
+func (r *MemoryProductRepository) GetByID(id int) (*models.Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	product, err := r.product(id)
+	if err != nil {
+		return nil, err
+	}
+	r.compute(product, time.Now())
+	return product, nil
+}
+
+// product returns a copy of the product unless it is deleted. It must be
+// called with the lock held.
+func (r *MemoryProductRepository) product(id int) (*models.Product, error) {
+	product, ok := r.products[uint(id)]
+	if !ok || id <= 0 || product.DeletedAt.Valid {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &product, nil
+}
+
+// GetByIDFromPrimary is GetByID, there being no replicas in memory.
+func (r *MemoryProductRepository) GetByIDFromPrimary(id int) (*models.Product, error) {
+	return r.GetByID(id)
+}
+
+// compute sets the available stock and the lowest price of product at now. It
+// must be called with the lock held.
+func (r *MemoryProductRepository) compute(product *models.Product, now time.Time) {
+	product.Available = r.inventory.available(product.ID, now)
+	product.LowestPrice30d = product.Price
+	since := now.Add(-models.LowestPriceWindow)
+	for _, change := range r.priceHistory {
+		if change.ProductID == product.ID && change.OldCurrency == product.Money().Currency &&
+			!change.ChangedAt.Before(since) && change.OldPrice < product.LowestPrice30d {
+			product.LowestPrice30d = change.OldPrice
+		}
+	}
+}
+
+// Update saves every field of product, recording a change of its price with
+// audit. Like GORM's Save, it creates the product when it has no id or does
+// not exist.
+func (r *MemoryProductRepository) Update(product *models.Product, audit models.Audit) (*models.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.skuConflict(product); err != nil {
+		return nil, err
+	}
+	if product.Slug == "" {
+		product.Slug = r.freeSlug(product)
+	}
+
+	stored, ok := r.products[product.ID]
+	if !ok || stored.DeletedAt.Valid {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	if stored.Price != product.Price || stored.Money().Currency != product.Money().Currency {
+		r.lastPriceChangeID++
+		r.priceHistory = append(r.priceHistory, models.PriceChange{
+			ID:          r.lastPriceChangeID,
+			ProductID:   product.ID,
+			OldPrice:    stored.Price,
+			OldCurrency: stored.Money().Currency,
+			NewPrice:    product.Price,
+			NewCurrency: product.Money().Currency,
+			ChangedBy:   audit.Actor,
+			Reason:      audit.Reason,
+			ChangedAt:   time.Now(),
+		})
+	}
+	product.Categories, product.Tags, product.Options = stored.Categories, stored.Tags, stored.Options
+	product.Attributes = attributesOf(product.ID, product.Attributes, stored.Attributes)
+	product.UpdatedAt = time.Now()
+	updated := *product
+	updated.Status, updated.PublishedAt = stored.Status, stored.PublishedAt
+	updated.PublishAt, updated.UnpublishAt = stored.PublishAt, stored.UnpublishAt
+	r.products[product.ID] = updated
+	r.compute(product, product.UpdatedAt)
+	return product, nil
+}
+
+// GetPriceHistory returns the price changes of a product made in the range of
+// filter, oldest first.
+func (r *MemoryProductRepository) GetPriceHistory(productID int, filter models.PriceHistoryFilter) ([]*models.PriceChange, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	changes := []*models.PriceChange{}
+	for _, change := range r.priceHistory {
+		if change.ProductID != uint(productID) || filter.Since != nil && change.ChangedAt.Before(*filter.Since) ||
+			filter.Until != nil && !change.ChangedAt.Before(*filter.Until) {
+			continue
+		}
+		change := change
+		changes = append(changes, &change)
+	}
+	return changes, nil
+}
+
+func (r *MemoryProductRepository) Delete(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	product, ok := r.products[uint(id)]
+	if !ok || product.DeletedAt.Valid {
+		return nil
+	}
+	product.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	r.products[product.ID] = product
+	return nil
+}
+
+// UpdatePublication saves the status, the publication time and the schedule
+// of product, as long as it still has the status from.
+func (r *MemoryProductRepository) UpdatePublication(product *models.Product, from models.ProductStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.products[product.ID]
+	if !ok || stored.DeletedAt.Valid {
+		return gorm.ErrRecordNotFound
+	}
+	if stored.Status != from {
+		return fmt.Errorf("%w: the product is no longer %s", models.ErrInvalidTransition, from)
+	}
+
+	r.updatePublication(stored, product)
+	return nil
+}
+
+// ApplySchedules applies the publications and archivings that are due by now
+// to at most limit products, and returns the ids of the products it changed.
+func (r *MemoryProductRepository) ApplySchedules(now time.Time, limit int) ([]uint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ids := make([]uint, 0, len(r.products))
+	for id := range r.products {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	changed := []uint{}
+	for _, id := range ids {
+		if len(changed) == limit {
+			break
+		}
+		product := r.products[id]
+		if product.DeletedAt.Valid || !product.ApplySchedule(now) {
+			continue
+		}
+		r.updatePublication(r.products[id], &product)
+		changed = append(changed, id)
+	}
+	return changed, nil
+}
+
+// updatePublication copies the publication fields of product to stored. It
+// must be called with the lock held.
+func (r *MemoryProductRepository) updatePublication(stored models.Product, product *models.Product) {
+	stored.Status, stored.PublishedAt = product.Status, product.PublishedAt
+	stored.PublishAt, stored.UnpublishAt = product.PublishAt, product.UnpublishAt
+	stored.UpdatedAt = time.Now()
+	product.UpdatedAt = stored.UpdatedAt
+	r.products[product.ID] = stored
+}
+
+// skuConflict returns a *models.ConflictError when another product, deleted
+// or not, or a variant has the SKU of product. It must be called with the
+// lock held.
+func (r *MemoryProductRepository) skuConflict(product *models.Product) error {
+	if product.SKU == nil {
+		return nil
+	}
+	for id, other := range r.products {
+		if id != product.ID && other.SKU != nil && *other.SKU == *product.SKU {
+			return &models.ConflictError{Field: "sku", Value: *product.SKU}
+		}
+	}
+	for _, variant := range r.variants {
+		if variant.SKU != nil && *variant.SKU == *product.SKU {
+			return &models.ConflictError{Field: "sku", Value: *product.SKU}
+		}
+	}
+	return nil
+}
+
+// freeSlug returns the slug of the title of product, with a suffix when other
+// products have it already. It must be called with the lock held.
+func (r *MemoryProductRepository) freeSlug(product *models.Product) string {
+	var taken []string
+	for id, other := range r.products {
+		if id != product.ID {
+			taken = append(taken, other.Slug)
+		}
+	}
+	return models.UniqueSlug(models.Slugify(product.Title), taken)
+}
+
+func (r *MemoryProductRepository) GetBySKU(sku string) (*models.Product, error) {
+	return r.find(func(product models.Product) bool {
+		return product.SKU != nil && *product.SKU == sku
+	})
+}
+
+func (r *MemoryProductRepository) GetBySlug(slug string) (*models.Product, error) {
+	return r.find(func(product models.Product) bool {
+		return product.Slug == slug
+	})
+}
+
+// find returns the product that isn't deleted and matches.
+func (r *MemoryProductRepository) find(match func(product models.Product) bool) (*models.Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, product := range r.products {
+		if !product.DeletedAt.Valid && match(product) {
+			r.compute(&product, time.Now())
+			return &product, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *MemoryProductRepository) GetBySeedKey(key string) (*models.Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, product := range r.products {
+		if product.SeedKey != nil && *product.SeedKey == key {
+			return &product, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *MemoryProductRepository) DeleteSeeded() (int64, []*models.ProductMedia, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	media := []*models.ProductMedia{}
+	for _, stored := range r.media {
+		if product := r.products[stored.ProductID]; product.SeedKey != nil {
+			stored := stored
+			media = append(media, &stored)
+		}
+	}
+	sort.Slice(media, func(i, j int) bool { return media[i].ID < media[j].ID })
+
+	var deleted int64
+	for id, product := range r.products {
+		if product.SeedKey != nil {
+			r.remove(id)
+			deleted++
+		}
+	}
+	return deleted, media, nil
+}
+
+// Purge permanently removes a product, deleted or not, with its variants,
+// media, price history and stock, and returns the media it had.
+func (r *MemoryProductRepository) Purge(id int) ([]*models.ProductMedia, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.products[uint(id)]; !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	media := []*models.ProductMedia{}
+	for _, m := range r.media {
+		if m.ProductID == uint(id) {
+			m := m
+			media = append(media, &m)
+		}
+	}
+	sort.Slice(media, func(i, j int) bool { return media[i].ID < media[j].ID })
+	r.remove(uint(id))
+	return media, nil
+}
+
+// Restore brings back a deleted product. Restoring a product that isn't
+// deleted does nothing.
+func (r *MemoryProductRepository) Restore(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	product, ok := r.products[uint(id)]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	product.DeletedAt = gorm.DeletedAt{}
+	r.products[product.ID] = product
+	return nil
+}
+
+// remove forgets a product and everything that belongs to it. It must be
+// called with the lock held.
+func (r *MemoryProductRepository) remove(id uint) {
+	delete(r.products, id)
+	r.removeVariants(id)
+	r.removeMedia(id)
+	r.removePriceHistory(id)
+	r.inventory.remove(id)
+}
+
+// removeVariants forgets the variants of a product. It must be called with
+// the lock held.
+func (r *MemoryProductRepository) removeVariants(productID uint) {
+	for id, variant := range r.variants {
+		if variant.ProductID == productID {
+			delete(r.variants, id)
+		}
+	}
+}
+
+// removeMedia forgets the media of a product. It must be called with the lock
+// held.
+func (r *MemoryProductRepository) removeMedia(productID uint) {
+	for id, media := range r.media {
+		if media.ProductID == productID {
+			delete(r.media, id)
+		}
+	}
+}
+
+// removePriceHistory forgets the price changes of a product. It must be
+// called with the lock held.
+func (r *MemoryProductRepository) removePriceHistory(productID uint) {
+	kept := r.priceHistory[:0]
+	for _, change := range r.priceHistory {
+		if change.ProductID != productID {
+			kept = append(kept, change)
+		}
+	}
+	r.priceHistory = kept
+}
+
+func (r *MemoryProductRepository) SetCategories(productID int, categories []models.Category) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	product, err := r.product(productID)
+	if err != nil {
+		return err
+	}
+	product.Categories = append([]models.Category{}, categories...)
+	r.products[product.ID] = *product
+	return nil
+}
+
+// removeAttribute removes a deleted attribute from every product.
+func (r *MemoryProductRepository) removeAttribute(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for productID, product := range r.products {
+		attributes := models.ProductAttributes{}
+		for _, attribute := range product.Attributes {
+			if attribute.Key != key {
+				attributes = append(attributes, attribute)
+			}
+		}
+		product.Attributes = attributes
+		r.products[productID] = product
+	}
+}
+
+// unlinkCategory removes a deleted category from every product.
+func (r *MemoryProductRepository) unlinkCategory(id uint) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for productID, product := range r.products {
+		var categories []models.Category
+		for _, category := range product.Categories {
+			if category.ID != id {
+				categories = append(categories, category)
+			}
+		}
+		product.Categories = categories
+		r.products[productID] = product
+	}
+}
+
+// SetOptions replaces the options of a product. It returns
+// models.ErrOptionsInUse when a variant of the product doesn't match them, and
+// spells the options of the others like them.
+func (r *MemoryProductRepository) SetOptions(productID int, options []models.ProductOption) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	product, err := r.product(productID)
+	if err != nil {
+		return err
+	}
+
+	matched := map[uint]models.ProductVariant{}
+	for id, variant := range r.variants {
+		if variant.ProductID != product.ID {
+			continue
+		}
+		if err := variant.MatchOptions(options); err != nil {
+			return fmt.Errorf("%w: variant %d doesn't match them", models.ErrOptionsInUse, id)
+		}
+		matched[id] = variant
+	}
+	for id, variant := range matched {
+		r.variants[id] = variant
+	}
+
+	product.Options = nil
+	for _, option := range options {
+		option.ProductID = product.ID
+		product.Options = append(product.Options, option)
+	}
+	r.products[product.ID] = *product
+	return nil
+}
+
+func (r *MemoryProductRepository) AddTags(productID int, names []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	product, err := r.product(productID)
+	if err != nil {
+		return err
+	}
+
+	tags := append([]models.Tag{}, product.Tags...)
+	for _, name := range names {
+		if hasTags(*product, []string{name}, false) {
+			continue
+		}
+		tag, ok := r.tags[name]
+		if !ok {
+			r.lastTagID++
+			tag = models.Tag{ID: r.lastTagID, Name: name, CreatedAt: time.Now()}
+			r.tags[name] = tag
+		}
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+
+	product.Tags = tags
+	r.products[product.ID] = *product
+	return nil
+}
+
+func (r *MemoryProductRepository) RemoveTags(productID int, names []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	product, err := r.product(productID)
+	if err != nil {
+		return err
+	}
+
+	removed := map[string]bool{}
+	for _, name := range names {
+		removed[name] = true
+	}
+
+	var tags []models.Tag
+	for _, tag := range product.Tags {
+		if !removed[tag.Name] {
+			tags = append(tags, tag)
+		}
+	}
+	product.Tags = tags
+	r.products[product.ID] = *product
+	return nil
+}
+
+// hasTags reports whether product has any of the tags, or all of them when
+// all is set.
+func hasTags(product models.Product, names []string, all bool) bool {
+	found := 0
+	for _, name := range names {
+		for _, tag := range product.Tags {
+			if tag.Name == name {
+				found++
+				break
+			}
+		}
+	}
+	if all {
+		return found == len(names)
+	}
+	return found > 0
+}
+
+func hasStatus(product models.Product, statuses []models.ProductStatus) bool {
+	for _, status := range statuses {
+		if product.Status == status {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAttributes reports whether product meets every condition.
+func hasAttributes(product models.Product, conditions []models.AttributeCondition) bool {
+	for _, condition := range conditions {
+		if !condition.Match(product.Attributes) {
+			return false
+		}
+	}
+	return true
+}
+
+func inCategories(product models.Product, ids []uint) bool {
+	for _, category := range product.Categories {
+		for _, id := range ids {
+			if category.ID == id {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package repositories
+
+import (
+	"sort"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+)
+
+// MemoryTagRepository counts the tags of the products of a
+// MemoryProductRepository, which is where they are kept.
+type MemoryTagRepository struct {
+	products *MemoryProductRepository
+}
+
+func NewMemoryTagRepository(products *MemoryProductRepository) *MemoryTagRepository {
+	return &MemoryTagRepository{products: products}
+}
+
+func (r *MemoryTagRepository) GetAll() ([]*models.TagCount, error) {
+	r.products.mu.RLock()
+	defer r.products.mu.RUnlock()
+
+	byName := map[string]*models.TagCount{}
+	counts := []*models.TagCount{}
+	for _, product := range r.products.products {
+		if product.DeletedAt.Valid {
+			continue
+		}
+		for _, tag := range product.Tags {
+			count, ok := byName[tag.Name]
+			if !ok {
+				count = &models.TagCount{Name: tag.Name}
+				byName[tag.Name] = count
+				counts = append(counts, count)
+			}
+			count.Count++
+		}
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Name < counts[j].Name
+	})
+	return counts, nil
+}
@@ -0,0 +1,181 @@
+package repositories
+
+import (
+	"sort"
+	"time"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"gorm.io/gorm"
+)
+
+// MemoryVariantRepository keeps the variants of the products of a
+// MemoryProductRepository, which is where they are kept, for tests and demos.
+type MemoryVariantRepository struct {
+	products *MemoryProductRepository
+}
+
+func NewMemoryVariantRepository(products *MemoryProductRepository) *MemoryVariantRepository {
+	return &MemoryVariantRepository{products: products}
+}
+
+func (r *MemoryVariantRepository) GetAll(productID int) ([]*models.ProductVariant, error) {
+	r.products.mu.RLock()
+	defer r.products.mu.RUnlock()
+
+	product, err := r.products.product(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	variants := []*models.ProductVariant{}
+	for _, variant := range r.products.variants {
+		if variant.ProductID == product.ID {
+			variant := variant
+			variant.Available = r.products.inventory.variantAvailable(variant.ID, now)
+			variants = append(variants, &variant)
+		}
+	}
+	sort.Slice(variants, func(i, j int) bool { return variants[i].ID < variants[j].ID })
+	return variants, nil
+}
+
+func (r *MemoryVariantRepository) GetByID(productID, id int) (*models.ProductVariant, error) {
+	r.products.mu.RLock()
+	defer r.products.mu.RUnlock()
+
+	product, err := r.products.product(productID)
+	if err != nil {
+		return nil, err
+	}
+	variant, ok := r.products.variants[uint(id)]
+	if !ok || variant.ProductID != product.ID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	variant.Available = r.products.inventory.variantAvailable(variant.ID, time.Now())
+	return &variant, nil
+}
+
+func (r *MemoryVariantRepository) Create(variant *models.ProductVariant) (*models.ProductVariant, error) {
+	r.products.mu.Lock()
+	defer r.products.mu.Unlock()
+
+	if err := r.check(variant); err != nil {
+		return nil, err
+	}
+
+	r.products.lastVariantID++
+	variant.ID = r.products.lastVariantID
+	variant.CreatedAt = time.Now()
+	variant.UpdatedAt = variant.CreatedAt
+	r.products.variants[variant.ID] = *variant
+	return variant, nil
+}
+
+func (r *MemoryVariantRepository) Update(variant *models.ProductVariant) (*models.ProductVariant, error) {
+	r.products.mu.Lock()
+	defer r.products.mu.Unlock()
+
+	stored, ok := r.products.variants[variant.ID]
+	if !ok || stored.ProductID != variant.ProductID {
+		if _, err := r.products.product(int(variant.ProductID)); err != nil {
+			return nil, err
+		}
+		return nil, gorm.ErrRecordNotFound
+	}
+	if err := r.check(variant); err != nil {
+		return nil, err
+	}
+
+	variant.CreatedAt = stored.CreatedAt
+	variant.UpdatedAt = time.Now()
+	r.products.variants[variant.ID] = *variant
+	return variant, nil
+}
+
+func (r *MemoryVariantRepository) Delete(productID, id int) error {
+	r.products.mu.Lock()
+	defer r.products.mu.Unlock()
+
+	product, err := r.products.product(productID)
+	if err != nil {
+		return err
+	}
+	variant, ok := r.products.variants[uint(id)]
+	if !ok || variant.ProductID != product.ID {
+		return gorm.ErrRecordNotFound
+	}
+	delete(r.products.variants, variant.ID)
+	r.products.inventory.removeVariant(variant.ID)
+	return nil
+}
+
+func (r *MemoryVariantRepository) Generate(productID int) ([]*models.ProductVariant, error) {
+	r.products.mu.Lock()
+	defer r.products.mu.Unlock()
+
+	product, err := r.products.product(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := map[string]bool{}
+	for _, variant := range r.products.variants {
+		if variant.ProductID == product.ID {
+			existing[variant.OptionsKey] = true
+		}
+	}
+
+	created := []*models.ProductVariant{}
+	for _, options := range models.Combinations(product.Options) {
+		variant := &models.ProductVariant{ProductID: product.ID, Options: options}
+		if err := variant.MatchOptions(product.Options); err != nil {
+			return nil, err
+		}
+		if !existing[variant.OptionsKey] {
+			created = append(created, variant)
+		}
+	}
+
+	now := time.Now()
+	for _, variant := range created {
+		r.products.lastVariantID++
+		variant.ID = r.products.lastVariantID
+		variant.CreatedAt, variant.UpdatedAt = now, now
+		r.products.variants[variant.ID] = *variant
+	}
+	return created, nil
+}
+
+// check matches the options of variant against the ones of its product and
+// looks for conflicts, like VariantRepository does. It must be called with
+// the lock held.
+func (r *MemoryVariantRepository) check(variant *models.ProductVariant) error {
+	product, err := r.products.product(int(variant.ProductID))
+	if err != nil {
+		return err
+	}
+	if err := variant.MatchOptions(product.Options); err != nil {
+		return err
+	}
+
+	for id, other := range r.products.variants {
+		if id == variant.ID {
+			continue
+		}
+		if other.ProductID == variant.ProductID && other.OptionsKey == variant.OptionsKey {
+			return &models.ConflictError{Field: "options", Value: variant.OptionsKey}
+		}
+		if variant.SKU != nil && other.SKU != nil && *other.SKU == *variant.SKU {
+			return &models.ConflictError{Field: "sku", Value: *variant.SKU}
+		}
+	}
+	if variant.SKU != nil {
+		for _, other := range r.products.products {
+			if other.SKU != nil && *other.SKU == *variant.SKU {
+				return &models.ConflictError{Field: "sku", Value: *variant.SKU}
+			}
+		}
+	}
+	return nil
+}
@@ -1,8 +1,16 @@
 package repositories
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+
 	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/adrianosiqe/eulabs-challenge-api/pkg/database"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ProductRepository struct {
@@ -13,40 +21,356 @@ func NewProductRepository(db *gorm.DB) *ProductRepository {
 	return &ProductRepository{db: db}
 }
 
-func (r *ProductRepository) GetAll() ([]*models.Product, error) {
+// reader returns the connection for queries that tolerate the replication lag
+// of the read replicas, when there are any.
+func (r *ProductRepository) reader() *gorm.DB {
+	return r.db.Set(database.ReadFromReplica, true)
+}
+
+// availableColumn computes the available stock of the products: what is on
+// hand in every warehouse minus the active reservations.
+const availableColumn = "COALESCE((SELECT SUM(on_hand) FROM stock_levels WHERE stock_levels.product_id = products.id), 0) - " +
+	"COALESCE((SELECT SUM(quantity) FROM reservations WHERE reservations.product_id = products.id AND status = ? AND expires_at > ?), 0) AS available"
+
+// lowestPriceColumn computes the lowest price of the products since a time:
+// the price they had before each change made since then, or their current
+// price when it is lower. Prices in other currencies are left out.
+const lowestPriceColumn = "COALESCE((SELECT MIN(old_price) FROM price_history WHERE price_history.product_id = products.id " +
+	"AND old_currency = products.currency AND changed_at >= ? AND old_price < products.price), products.price) AS lowest_price_30d"
+
+// preloaded loads the associations, the available stock and the lowest price
+// of the products, from a read replica when there is one.
+func (r *ProductRepository) preloaded() *gorm.DB {
+	return preload(r.reader())
+}
+
+// preload loads the associations, the available stock and the lowest price of
+// the products queried through db.
+func preload(db *gorm.DB) *gorm.DB {
+	now := time.Now()
+	return db.Select("products.*, "+availableColumn+", "+lowestPriceColumn, models.ReservationActive, now, now.Add(-models.LowestPriceWindow)).Preload("Categories").Preload("Tags", func(db *gorm.DB) *gorm.DB {
+		return db.Order("tags.name")
+	}).Preload("Options", func(db *gorm.DB) *gorm.DB {
+		return db.Order("product_options.position")
+	}).Preload("Attributes", func(db *gorm.DB) *gorm.DB {
+		return db.Order("product_attributes.attribute_key")
+	})
+}
+
+func (r *ProductRepository) GetAll(filter models.ProductFilter) ([]*models.Product, error) {
 	var products []*models.Product
-	err := r.db.Find(&products).Error
+	query := r.preloaded().Order("id")
+	if filter.CategoryIDs != nil {
+		query = query.Where("id IN (?)", r.db.Table("product_categories").Select("product_id").Where("category_id IN ?", filter.CategoryIDs))
+	}
+	if filter.TagNames != nil {
+		tagged := r.db.Table("product_tags").Select("product_tags.product_id").
+			Joins("JOIN tags ON tags.id = product_tags.tag_id").
+			Where("tags.name IN ?", filter.TagNames)
+		if filter.MatchAllTags() {
+			tagged = tagged.Group("product_tags.product_id").Having("COUNT(*) = ?", len(filter.TagNames))
+		}
+		query = query.Where("id IN (?)", tagged)
+	}
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status IN ?", filter.Statuses)
+	}
+	for _, condition := range filter.Attributes {
+		column, value := condition.Attribute.Column()
+		query = query.Where("id IN (?)", r.db.Table("product_attributes").Select("product_id").
+			Where("attribute_key = ? AND "+column+" "+condition.Operator.SQL()+" ?", condition.Attribute.Key, value))
+	}
+	if filter.PerPage > 0 {
+		page := filter.Page
+		if page < 1 {
+			page = 1
+		}
+		query = query.Limit(filter.PerPage).Offset((page - 1) * filter.PerPage)
+	}
+
+	err := query.Find(&products).Error
 	if err != nil {
 		return nil, err
 	}
 	return products, nil
 }
 
+// slugAttempts bounds how many slugs Create tries when other products take
+// the ones it picks in the meantime.
+const slugAttempts = 3
+
+// Create inserts the product with a unique slug derived from its title. It
+// returns a *models.ConflictError when the SKU is taken.
 func (r *ProductRepository) Create(product *models.Product) (*models.Product, error) {
-	err := r.db.Create(&product).Error
+	if err := r.skuConflict(product); err != nil {
+		return nil, err
+	}
+	slug, err := r.freeSlug(product)
 	if err != nil {
 		return nil, err
 	}
-	return product, nil
+
+	for attempt := 1; ; attempt++ {
+		product.Slug = slug
+		err = r.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Omit(clause.Associations).Create(&product).Error; err != nil {
+				return err
+			}
+			if len(product.Attributes) == 0 {
+				return nil
+			}
+			return saveAttributes(tx, product)
+		})
+		if err == nil {
+			product.LowestPrice30d = product.Price
+			return product, nil
+		}
+		// The insert may have lost a race for the SKU or the slug.
+		if conflict := r.skuConflict(product); conflict != nil {
+			return nil, conflict
+		}
+		if taken, _ := r.slugTaken(product); !taken || attempt == slugAttempts {
+			return nil, err
+		}
+		// Whoever took the slug may be creating more products with the same
+		// title, so a random suffix is tried instead of the next one.
+		slug = randomSlug(models.Slugify(product.Title))
+	}
 }
 
 func (r *ProductRepository) GetByID(id int) (*models.Product, error) {
 	var product models.Product
-	err := r.db.First(&product, id).Error
+	err := r.preloaded().First(&product, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &product, nil
 }
 
-func (r *ProductRepository) Update(product *models.Product) (*models.Product, error) {
-	err := r.db.Save(&product).Error
+// GetByIDFromPrimary reads the product from the primary, for the reads whose
+// result is written back, which must not see the replication lag.
+func (r *ProductRepository) GetByIDFromPrimary(id int) (*models.Product, error) {
+	var product models.Product
+	err := preload(r.db).First(&product, id).Error
 	if err != nil {
 		return nil, err
 	}
+	return &product, nil
+}
+
+// publicationColumns change through UpdatePublication and ApplySchedules
+// only, so that an update can't undo a transition made in the meantime.
+var publicationColumns = []string{"status", "published_at", "publish_at", "unpublish_at"}
+
+// updateColumns are the columns Update writes. The publication ones change
+// through UpdatePublication and ApplySchedules, and the associations through
+// their own methods.
+var updateColumns = []string{"title", "description", "sku", "slug", "price", "currency", "seed_key", "updated_at"}
+
+// Update saves the updateColumns of a product that isn't deleted, giving it a
+// slug when it has none, and replaces its attributes unless Attributes is nil.
+// A change of its price is recorded in the price history, with audit, in the
+// same transaction. It returns gorm.ErrRecordNotFound when the product is
+// missing or deleted, and a *models.ConflictError when the SKU is taken.
+func (r *ProductRepository) Update(product *models.Product, audit models.Audit) (*models.Product, error) {
+	if err := r.skuConflict(product); err != nil {
+		return nil, err
+	}
+	if product.Slug == "" {
+		slug, err := r.freeSlug(product)
+		if err != nil {
+			return nil, err
+		}
+		product.Slug = slug
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// The stored price is locked so that concurrent updates record the
+		// changes in the order they are made.
+		var stored models.Product
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "price", "currency").Where("id = ?", product.ID).Take(&stored).Error
+		if err != nil {
+			return err
+		}
+
+		result := tx.Model(product).Select(updateColumns).Updates(product)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if err := saveAttributes(tx, product); err != nil {
+			return err
+		}
+		if stored.Price != product.Price || stored.Money().Currency != product.Money().Currency {
+			change := models.PriceChange{
+				ProductID:   product.ID,
+				OldPrice:    stored.Price,
+				OldCurrency: stored.Money().Currency,
+				NewPrice:    product.Price,
+				NewCurrency: product.Money().Currency,
+				ChangedBy:   audit.Actor,
+				Reason:      audit.Reason,
+				ChangedAt:   time.Now(),
+			}
+			if err := tx.Create(&change).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&models.Product{}).Select(lowestPriceColumn, time.Now().Add(-models.LowestPriceWindow)).
+			Where("id = ?", product.ID).Row().Scan(&product.LowestPrice30d)
+	})
+	if err != nil {
+		if conflict := r.skuConflict(product); conflict != nil {
+			return nil, conflict
+		}
+		return nil, err
+	}
 	return product, nil
 }
 
+// saveAttributes replaces the attributes of product with its Attributes,
+// unless they are nil.
+func saveAttributes(tx *gorm.DB, product *models.Product) error {
+	if product.Attributes == nil {
+		return nil
+	}
+	if err := tx.Where("product_id = ?", product.ID).Delete(&models.ProductAttribute{}).Error; err != nil {
+		return err
+	}
+	if len(product.Attributes) == 0 {
+		return nil
+	}
+	for i := range product.Attributes {
+		product.Attributes[i].ProductID = product.ID
+	}
+	return tx.Create(&product.Attributes).Error
+}
+
+// GetPriceHistory returns the price changes of a product made in the range of
+// filter, oldest first.
+func (r *ProductRepository) GetPriceHistory(productID int, filter models.PriceHistoryFilter) ([]*models.PriceChange, error) {
+	changes := []*models.PriceChange{}
+	query := r.reader().Where("product_id = ?", productID)
+	if filter.Since != nil {
+		query = query.Where("changed_at >= ?", *filter.Since)
+	}
+	if filter.Until != nil {
+		query = query.Where("changed_at < ?", *filter.Until)
+	}
+	if err := query.Order("changed_at, id").Find(&changes).Error; err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// UpdatePublication saves the status, the publication time and the schedule
+// of product, as long as it still has the status from. It returns
+// models.ErrInvalidTransition when the status changed in the meantime.
+func (r *ProductRepository) UpdatePublication(product *models.Product, from models.ProductStatus) error {
+	result := r.db.Model(product).Select(publicationColumns).Where("status = ?", from).Updates(product)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		if _, err := r.GetByID(int(product.ID)); err != nil {
+			return err
+		}
+		return fmt.Errorf("%w: the product is no longer %s", models.ErrInvalidTransition, from)
+	}
+	return nil
+}
+
+// ApplySchedules applies the publications and archivings that are due by now
+// to at most limit products, and returns the ids of the products it changed.
+// The products are locked until the changes are committed and the ones
+// locked by another instance are skipped, so that instances running at the
+// same time share the work.
+func (r *ProductRepository) ApplySchedules(now time.Time, limit int) ([]uint, error) {
+	changed := []uint{}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var products []*models.Product
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND publish_at <= ?", models.ProductDraft, now).
+			Or("status = ? AND unpublish_at <= ?", models.ProductPublished, now).
+			Order("id").Limit(limit).Find(&products).Error
+		if err != nil {
+			return err
+		}
+
+		for _, product := range products {
+			if !product.ApplySchedule(now) {
+				continue
+			}
+			if err := tx.Model(product).Select(publicationColumns).Updates(product).Error; err != nil {
+				return err
+			}
+			changed = append(changed, product.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
+
+// skuConflict returns a *models.ConflictError when another product, deleted
+// or not, or a variant has the SKU of product.
+func (r *ProductRepository) skuConflict(product *models.Product) error {
+	if product.SKU == nil {
+		return nil
+	}
+
+	var count int64
+	err := r.db.Unscoped().Model(&models.Product{}).Where("sku = ? AND id <> ?", *product.SKU, product.ID).Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		err = r.db.Model(&models.ProductVariant{}).Where("sku = ?", *product.SKU).Count(&count).Error
+		if err != nil {
+			return err
+		}
+	}
+	if count > 0 {
+		return &models.ConflictError{Field: "sku", Value: *product.SKU}
+	}
+	return nil
+}
+
+// freeSlug returns the slug of the title of product, with a suffix when other
+// products, deleted or not, have it already.
+func (r *ProductRepository) freeSlug(product *models.Product) (string, error) {
+	base := models.Slugify(product.Title)
+
+	var taken []string
+	err := r.db.Unscoped().Model(&models.Product{}).
+		Where("(slug = ? OR slug LIKE ?) AND id <> ?", base, base+"-%", product.ID).
+		Pluck("slug", &taken).Error
+	if err != nil {
+		return "", err
+	}
+	return models.UniqueSlug(base, taken), nil
+}
+
+// randomSlug returns base followed by a random suffix.
+func randomSlug(base string) string {
+	suffix := make([]byte, 3)
+	if _, err := rand.Read(suffix); err != nil {
+		return base + "-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return base + "-" + hex.EncodeToString(suffix)
+}
+
+// slugTaken reports whether another product has the slug of product.
+func (r *ProductRepository) slugTaken(product *models.Product) (bool, error) {
+	var count int64
+	err := r.db.Unscoped().Model(&models.Product{}).Where("slug = ? AND id <> ?", product.Slug, product.ID).Count(&count).Error
+	return count > 0, err
+}
+
 func (r *ProductRepository) Delete(id int) error {
 	var product models.Product
 	err := r.db.Where("id = ?", id).Delete(&product).Error
@@ -55,3 +379,193 @@ func (r *ProductRepository) Delete(id int) error {
 	}
 	return nil
 }
+
+// Purge permanently removes a product, deleted or not, with everything linked
+// to it, and returns the media it had so that their files can be deleted.
+func (r *ProductRepository) Purge(id int) ([]*models.ProductMedia, error) {
+	media := []*models.ProductMedia{}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var product models.Product
+		if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&product, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("product_id = ?", product.ID).Order("id").Find(&media).Error; err != nil {
+			return err
+		}
+		for _, links := range productLinks {
+			if err := tx.Exec("DELETE FROM "+links+" WHERE product_id = ?", product.ID).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Unscoped().Delete(&product).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return media, nil
+}
+
+// Restore brings back a deleted product. Restoring a product that isn't
+// deleted does nothing.
+func (r *ProductRepository) Restore(id int) error {
+	result := r.db.Unscoped().Model(&models.Product{}).Where("id = ? AND deleted_at IS NOT NULL", id).Update("deleted_at", nil)
+	if result.Error != nil || result.RowsAffected > 0 {
+		return result.Error
+	}
+	var product models.Product
+	return r.db.Select("id").First(&product, id).Error
+}
+
+// GetBySKU looks up a product by its SKU.
+func (r *ProductRepository) GetBySKU(sku string) (*models.Product, error) {
+	var product models.Product
+	err := r.preloaded().Where("sku = ?", sku).First(&product).Error
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// GetBySlug looks up a product by its slug.
+func (r *ProductRepository) GetBySlug(slug string) (*models.Product, error) {
+	var product models.Product
+	err := r.preloaded().Where("slug = ?", slug).First(&product).Error
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// GetBySeedKey looks up a seeded product, including soft deleted ones, so that
+// a product removed through the API is not seeded again.
+func (r *ProductRepository) GetBySeedKey(key string) (*models.Product, error) {
+	var product models.Product
+	err := r.db.Unscoped().Where("seed_key = ?", key).First(&product).Error
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// productLinks are the tables whose rows belong to a product, which are removed
+// with it.
+var productLinks = []string{"product_categories", "product_tags", "product_options", "product_variants", "product_media", "product_attributes", "price_history", "stock_levels", "stock_movements", "reservations"}
+
+// DeleteSeeded permanently removes every seeded product, deleted or not, and
+// returns how many were removed with the media they had. The products are
+// locked first, so that no media is added to them before they are gone.
+func (r *ProductRepository) DeleteSeeded() (int64, []*models.ProductMedia, error) {
+	var deleted int64
+	media := []*models.ProductMedia{}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var ids []uint
+		if err := tx.Unscoped().Model(&models.Product{}).Clauses(clause.Locking{Strength: "UPDATE"}).Where("seed_key IS NOT NULL").Order("id").Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		if err := tx.Where("product_id IN ?", ids).Order("id").Find(&media).Error; err != nil {
+			return err
+		}
+		for _, links := range productLinks {
+			if err := tx.Exec("DELETE FROM "+links+" WHERE product_id IN ?", ids).Error; err != nil {
+				return err
+			}
+		}
+
+		result := tx.Unscoped().Where("id IN ?", ids).Delete(&models.Product{})
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return deleted, media, nil
+}
+
+// SetCategories replaces the categories of a product.
+func (r *ProductRepository) SetCategories(productID int, categories []models.Category) error {
+	var product models.Product
+	if err := r.db.First(&product, productID).Error; err != nil {
+		return err
+	}
+	// The categories exist already, only the links are written.
+	return r.db.Model(&product).Omit("Categories.*").Association("Categories").Replace(categories)
+}
+
+// SetOptions replaces the options of a product, which is locked so that its
+// variants don't change in between. It returns models.ErrOptionsInUse when a
+// variant of the product doesn't match the options, and spells the options of
+// the others like them.
+func (r *ProductRepository) SetOptions(productID int, options []models.ProductOption) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var product models.Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&product, productID).Error; err != nil {
+			return err
+		}
+
+		var variants []models.ProductVariant
+		if err := tx.Where("product_id = ?", product.ID).Order("id").Find(&variants).Error; err != nil {
+			return err
+		}
+		for _, variant := range variants {
+			if err := variant.MatchOptions(options); err != nil {
+				return fmt.Errorf("%w: variant %d doesn't match them", models.ErrOptionsInUse, variant.ID)
+			}
+			if err := tx.Model(&variant).Update("options", variant.Options).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Where("product_id = ?", product.ID).Delete(&models.ProductOption{}).Error; err != nil {
+			return err
+		}
+		if len(options) == 0 {
+			return nil
+		}
+		for i := range options {
+			options[i].ID, options[i].ProductID = 0, product.ID
+		}
+		return tx.Create(&options).Error
+	})
+}
+
+// AddTags adds the tags to a product, creating the ones that don't exist yet.
+// Tags the product already has are left as they are.
+func (r *ProductRepository) AddTags(productID int, names []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var product models.Product
+		if err := tx.First(&product, productID).Error; err != nil {
+			return err
+		}
+		if len(names) == 0 {
+			return nil
+		}
+
+		tags := make([]models.Tag, len(names))
+		for i, name := range names {
+			tags[i] = models.Tag{Name: name}
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&tags).Error; err != nil {
+			return err
+		}
+		// The ids of the tags that existed already aren't returned by the insert.
+		tags = nil
+		if err := tx.Where("name IN ?", names).Find(&tags).Error; err != nil {
+			return err
+		}
+		return tx.Model(&product).Omit("Tags.*").Association("Tags").Append(tags)
+	})
+}
+
+// RemoveTags removes the tags from a product. The tags themselves are kept.
+func (r *ProductRepository) RemoveTags(productID int, names []string) error {
+	var product models.Product
+	if err := r.db.First(&product, productID).Error; err != nil {
+		return err
+	}
+	tagIDs := r.db.Model(&models.Tag{}).Select("id").Where("name IN ?", names)
+	return r.db.Exec("DELETE FROM product_tags WHERE product_id = ? AND tag_id IN (?)", product.ID, tagIDs).Error
+}
@@ -0,0 +1,526 @@
+package repositories
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/core/interfaces"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/adrianosiqe/eulabs-challenge-api/pkg/config"
+	"github.com/adrianosiqe/eulabs-challenge-api/pkg/database"
+	"github.com/adrianosiqe/eulabs-challenge-api/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// TestProductRepositoryConformance runs the same checks against every
+// implementation of the product repository, so that they can't drift apart.
+func TestProductRepositoryConformance(t *testing.T) {
+	implementations := map[string]func(t *testing.T) interfaces.ProductRespositoryInterface{
+		"memory": func(t *testing.T) interfaces.ProductRespositoryInterface {
+			return NewMemoryProductRepository()
+		},
+		"gorm": func(t *testing.T) interfaces.ProductRespositoryInterface {
+			return NewProductRepository(newSQLiteDB(t))
+		},
+	}
+
+	for name, newRepository := range implementations {
+		t.Run(name, func(t *testing.T) {
+			testProductRepository(t, newRepository)
+		})
+	}
+}
+
+// newSQLiteDB returns a migrated in-memory SQLite database.
+func newSQLiteDB(t *testing.T) *gorm.DB {
+	cfg := config.DatabaseConfig{Driver: "sqlite", Name: ":memory:", LogLevel: "silent"}
+	db, err := database.ConnectDatabase(cfg)
+	require.NoError(t, err)
+	require.NoError(t, database.Migrate(db, cfg))
+	return db
+}
+
+func newProduct(title string) *models.Product {
+	return &models.Product{Title: title, Description: title + " description", Price: money.MustParseAmount("10.5")}
+}
+
+func testProductRepository(t *testing.T, newRepository func(t *testing.T) interfaces.ProductRespositoryInterface) {
+	t.Run("should assign increasing ids and timestamps on create", func(t *testing.T) {
+		repository := newRepository(t)
+
+		first, err := repository.Create(newProduct("Bulbasaur"))
+		require.NoError(t, err)
+		second, err := repository.Create(newProduct("Charmander"))
+		require.NoError(t, err)
+
+		assert.Equal(t, uint(1), first.ID)
+		assert.Equal(t, uint(2), second.ID)
+		assert.False(t, first.CreatedAt.IsZero())
+		assert.False(t, first.UpdatedAt.IsZero())
+	})
+
+	t.Run("should get a product by id", func(t *testing.T) {
+		repository := newRepository(t)
+		created, _ := repository.Create(newProduct("Bulbasaur"))
+
+		product, err := repository.GetByID(int(created.ID))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Bulbasaur", product.Title)
+		assert.Equal(t, "Bulbasaur description", product.Description)
+		assert.Equal(t, money.MustParseAmount("10.5"), product.Price)
+		assert.False(t, product.DeletedAt.Valid)
+	})
+
+	t.Run("should return not found for a missing product", func(t *testing.T) {
+		repository := newRepository(t)
+
+		product, err := repository.GetByID(42)
+
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		assert.Nil(t, product)
+	})
+
+	t.Run("should list the products by id, a page at a time", func(t *testing.T) {
+		repository := newRepository(t)
+		for _, title := range []string{"Bulbasaur", "Charmander", "Squirtle"} {
+			repository.Create(newProduct(title))
+		}
+
+		all, err := repository.GetAll(models.ProductFilter{})
+		assert.NoError(t, err)
+		assert.Len(t, all, 3)
+
+		page, err := repository.GetAll(models.ProductFilter{Page: 2, PerPage: 2})
+		assert.NoError(t, err)
+		assert.Len(t, page, 1)
+		assert.Equal(t, "Squirtle", page[0].Title)
+
+		past, err := repository.GetAll(models.ProductFilter{Page: 5, PerPage: 2})
+		assert.NoError(t, err)
+		assert.Empty(t, past)
+	})
+
+	t.Run("should update every field and the update time", func(t *testing.T) {
+		repository := newRepository(t)
+		created, _ := repository.Create(newProduct("Bulbasaur"))
+		product, _ := repository.GetByID(int(created.ID))
+		createdAt, updatedAt := product.CreatedAt, product.UpdatedAt
+
+		time.Sleep(5 * time.Millisecond)
+		product.Title = "Ivysaur"
+		product.Price = money.MustParseAmount("199.9")
+		_, err := repository.Update(product, models.Audit{})
+		assert.NoError(t, err)
+
+		updated, err := repository.GetByID(int(created.ID))
+		assert.NoError(t, err)
+		assert.Equal(t, "Ivysaur", updated.Title)
+		assert.Equal(t, money.MustParseAmount("199.9"), updated.Price)
+		assert.WithinDuration(t, createdAt, updated.CreatedAt, time.Millisecond)
+		assert.True(t, updated.UpdatedAt.After(updatedAt))
+	})
+
+	t.Run("should not bring a deleted product back on update", func(t *testing.T) {
+		repository := newRepository(t)
+		created, _ := repository.Create(newProduct("Bulbasaur"))
+		product, _ := repository.GetByID(int(created.ID))
+		require.NoError(t, repository.Delete(int(created.ID)))
+
+		product.Title = "Ivysaur"
+		_, err := repository.Update(product, models.Audit{})
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		_, err = repository.GetByID(int(created.ID))
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+		missing := newProduct("Squirtle")
+		missing.ID = 42
+		_, err = repository.Update(missing, models.Audit{})
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+
+	t.Run("should soft delete a product", func(t *testing.T) {
+		repository := newRepository(t)
+		created, _ := repository.Create(newProduct("Bulbasaur"))
+		repository.Create(newProduct("Charmander"))
+
+		assert.NoError(t, repository.Delete(int(created.ID)))
+
+		_, err := repository.GetByID(int(created.ID))
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		products, _ := repository.GetAll(models.ProductFilter{})
+		assert.Len(t, products, 1)
+		assert.NoError(t, repository.Delete(int(created.ID)))
+		assert.NoError(t, repository.Delete(42))
+
+		third, _ := repository.Create(newProduct("Squirtle"))
+		assert.Equal(t, uint(3), third.ID)
+	})
+
+	t.Run("should restore a deleted product", func(t *testing.T) {
+		repository := newRepository(t)
+		created, _ := repository.Create(newProduct("Bulbasaur"))
+		require.NoError(t, repository.Delete(int(created.ID)))
+
+		assert.NoError(t, repository.Restore(int(created.ID)))
+		restored, err := repository.GetByID(int(created.ID))
+		assert.NoError(t, err)
+		assert.Equal(t, "Bulbasaur", restored.Title)
+		assert.NoError(t, repository.Restore(int(created.ID)))
+		assert.ErrorIs(t, repository.Restore(42), gorm.ErrRecordNotFound)
+	})
+
+	t.Run("should purge a product, deleted or not", func(t *testing.T) {
+		repository := newRepository(t)
+		first, _ := repository.Create(newProduct("Bulbasaur"))
+		second, _ := repository.Create(newProduct("Charmander"))
+		require.NoError(t, repository.Delete(int(second.ID)))
+
+		_, err := repository.Purge(int(first.ID))
+		assert.NoError(t, err)
+		_, err = repository.Purge(int(second.ID))
+		assert.NoError(t, err)
+
+		assert.ErrorIs(t, repository.Restore(int(second.ID)), gorm.ErrRecordNotFound)
+		_, err = repository.Purge(int(first.ID))
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		_, err = repository.GetByID(int(first.ID))
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+
+	t.Run("should not share state with the caller", func(t *testing.T) {
+		repository := newRepository(t)
+		created, _ := repository.Create(newProduct("Bulbasaur"))
+
+		product, _ := repository.GetByID(int(created.ID))
+		product.Title = "Changed"
+		created.Title = "Changed too"
+
+		stored, _ := repository.GetByID(int(created.ID))
+		assert.Equal(t, "Bulbasaur", stored.Title)
+	})
+
+	t.Run("should be safe for concurrent use", func(t *testing.T) {
+		repository := newRepository(t)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				repository.Create(newProduct("Bulbasaur"))
+				repository.GetAll(models.ProductFilter{})
+			}()
+		}
+		wg.Wait()
+
+		products, err := repository.GetAll(models.ProductFilter{})
+		assert.NoError(t, err)
+		assert.Len(t, products, 20)
+		assert.Equal(t, uint(20), products[19].ID)
+	})
+
+	t.Run("should find and wipe only the seeded products", func(t *testing.T) {
+		repository := newRepository(t)
+		key := "bulbasaur"
+		seeded := newProduct("Bulbasaur")
+		seeded.SeedKey = &key
+		repository.Create(seeded)
+		repository.Create(newProduct("Charmander"))
+		repository.Delete(int(seeded.ID))
+
+		found, err := repository.GetBySeedKey(key)
+		assert.NoError(t, err)
+		assert.Equal(t, seeded.ID, found.ID)
+		_, err = repository.GetBySeedKey("missing")
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+		deleted, _, err := repository.DeleteSeeded()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), deleted)
+		_, err = repository.GetBySeedKey(key)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		products, _ := repository.GetAll(models.ProductFilter{})
+		assert.Len(t, products, 1)
+	})
+
+	t.Run("should derive unique slugs from the titles", func(t *testing.T) {
+		repository := newRepository(t)
+
+		first, err := repository.Create(newProduct("Pokémon Plush!"))
+		require.NoError(t, err)
+		second, err := repository.Create(newProduct("pokemon plush"))
+		require.NoError(t, err)
+		repository.Delete(int(second.ID))
+		third, err := repository.Create(newProduct("Pokemon  Plush"))
+		require.NoError(t, err)
+
+		assert.Equal(t, "pokemon-plush", first.Slug)
+		assert.Equal(t, "pokemon-plush-2", second.Slug)
+		assert.Equal(t, "pokemon-plush-3", third.Slug)
+
+		first.Title = "Pikachu Plush"
+		repository.Update(first, models.Audit{})
+		updated, _ := repository.GetByID(int(first.ID))
+		assert.Equal(t, "pokemon-plush", updated.Slug)
+	})
+
+	t.Run("should find a product by sku or slug", func(t *testing.T) {
+		repository := newRepository(t)
+		sku := "BULB-001"
+		product := newProduct("Bulbasaur")
+		product.SKU = &sku
+		repository.Create(product)
+
+		found, err := repository.GetBySKU(sku)
+		assert.NoError(t, err)
+		assert.Equal(t, product.ID, found.ID)
+		found, err = repository.GetBySlug("bulbasaur")
+		assert.NoError(t, err)
+		assert.Equal(t, product.ID, found.ID)
+
+		repository.Delete(int(product.ID))
+		_, err = repository.GetBySKU(sku)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		_, err = repository.GetBySlug("bulbasaur")
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+
+	t.Run("should return a conflict for a taken sku", func(t *testing.T) {
+		repository := newRepository(t)
+		sku, other := "BULB-001", "CHAR-004"
+		bulbasaur := newProduct("Bulbasaur")
+		bulbasaur.SKU = &sku
+		repository.Create(bulbasaur)
+		charmander := newProduct("Charmander")
+		charmander.SKU = &other
+		repository.Create(charmander)
+		repository.Delete(int(bulbasaur.ID))
+
+		duplicate := newProduct("Ivysaur")
+		duplicate.SKU = &sku
+		_, err := repository.Create(duplicate)
+		var conflict *models.ConflictError
+		require.ErrorAs(t, err, &conflict)
+		assert.Equal(t, &models.ConflictError{Field: "sku", Value: sku}, conflict)
+
+		charmander.SKU = &sku
+		_, err = repository.Update(charmander, models.Audit{})
+		assert.ErrorAs(t, err, &conflict)
+
+		charmander.SKU = &other
+		_, err = repository.Update(charmander, models.Audit{})
+		assert.NoError(t, err)
+	})
+
+	t.Run("should create drafts and update the status while it is unchanged", func(t *testing.T) {
+		repository := newRepository(t)
+		created, _ := repository.Create(newProduct("Bulbasaur"))
+		product, _ := repository.GetByID(int(created.ID))
+		assert.Equal(t, models.ProductDraft, product.Status)
+		assert.Nil(t, product.PublishedAt)
+
+		publishedAt := time.Now().Truncate(time.Millisecond)
+		product.Status, product.PublishedAt = models.ProductPublished, &publishedAt
+		require.NoError(t, repository.UpdatePublication(product, models.ProductDraft))
+
+		stored, _ := repository.GetByID(int(created.ID))
+		assert.Equal(t, models.ProductPublished, stored.Status)
+		require.NotNil(t, stored.PublishedAt)
+		assert.WithinDuration(t, publishedAt, *stored.PublishedAt, time.Millisecond)
+
+		stale := *stored
+		stale.Status = models.ProductArchived
+		assert.ErrorIs(t, repository.UpdatePublication(&stale, models.ProductDraft), models.ErrInvalidTransition)
+		stale.ID = 42
+		assert.ErrorIs(t, repository.UpdatePublication(&stale, models.ProductPublished), gorm.ErrRecordNotFound)
+	})
+
+	t.Run("should list the products with some statuses", func(t *testing.T) {
+		repository := newRepository(t)
+		repository.Create(newProduct("Bulbasaur"))
+		published := newProduct("Charmander")
+		published.Status = models.ProductPublished
+		repository.Create(published)
+		archived := newProduct("Squirtle")
+		archived.Status = models.ProductArchived
+		repository.Create(archived)
+
+		products, err := repository.GetAll(models.ProductFilter{Statuses: []models.ProductStatus{models.ProductPublished}})
+		assert.NoError(t, err)
+		require.Len(t, products, 1)
+		assert.Equal(t, "Charmander", products[0].Title)
+
+		products, _ = repository.GetAll(models.ProductFilter{Statuses: []models.ProductStatus{models.ProductDraft, models.ProductArchived}})
+		assert.Len(t, products, 2)
+		products, _ = repository.GetAll(models.ProductFilter{})
+		assert.Len(t, products, 3)
+	})
+
+	t.Run("should keep the publication fields on update", func(t *testing.T) {
+		repository := newRepository(t)
+		created, _ := repository.Create(newProduct("Bulbasaur"))
+		stale, _ := repository.GetByID(int(created.ID))
+		published, _ := repository.GetByID(int(created.ID))
+		published.Transition(models.ProductPublished, time.Now())
+		require.NoError(t, repository.UpdatePublication(published, models.ProductDraft))
+
+		stale.Title = "Ivysaur"
+		_, err := repository.Update(stale, models.Audit{})
+		assert.NoError(t, err)
+
+		updated, _ := repository.GetByID(int(created.ID))
+		assert.Equal(t, "Ivysaur", updated.Title)
+		assert.Equal(t, models.ProductPublished, updated.Status)
+		assert.NotNil(t, updated.PublishedAt)
+	})
+
+	t.Run("should apply the due schedules", func(t *testing.T) {
+		repository := newRepository(t)
+		now := time.Now()
+		past, future := now.Add(-time.Hour), now.Add(time.Hour)
+		schedule := func(title string, status models.ProductStatus, publishAt, unpublishAt *time.Time) *models.Product {
+			product := newProduct(title)
+			product.Status, product.PublishAt, product.UnpublishAt = status, publishAt, unpublishAt
+			created, err := repository.Create(product)
+			require.NoError(t, err)
+			return created
+		}
+		launch := schedule("Bulbasaur", models.ProductDraft, &past, &future)
+		offer := schedule("Charmander", models.ProductDraft, &past, &past)
+		ended := schedule("Squirtle", models.ProductPublished, nil, &past)
+		later := schedule("Pikachu", models.ProductDraft, &future, nil)
+		deleted := schedule("Eevee", models.ProductDraft, &past, nil)
+		repository.Delete(int(deleted.ID))
+
+		changed, err := repository.ApplySchedules(now, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, []uint{launch.ID, offer.ID}, changed)
+		changed, err = repository.ApplySchedules(now, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, []uint{ended.ID}, changed)
+		changed, _ = repository.ApplySchedules(now, 2)
+		assert.Empty(t, changed)
+
+		product, _ := repository.GetByID(int(launch.ID))
+		assert.Equal(t, models.ProductPublished, product.Status)
+		assert.NotNil(t, product.PublishedAt)
+		assert.Nil(t, product.PublishAt)
+		assert.NotNil(t, product.UnpublishAt)
+		product, _ = repository.GetByID(int(offer.ID))
+		assert.Equal(t, models.ProductArchived, product.Status)
+		assert.Nil(t, product.PublishAt)
+		assert.Nil(t, product.UnpublishAt)
+		product, _ = repository.GetByID(int(ended.ID))
+		assert.Equal(t, models.ProductArchived, product.Status)
+		product, _ = repository.GetByID(int(later.ID))
+		assert.Equal(t, models.ProductDraft, product.Status)
+	})
+
+	t.Run("should apply each schedule once when run concurrently", func(t *testing.T) {
+		repository := newRepository(t)
+		past := time.Now().Add(-time.Minute)
+		for i := 0; i < 20; i++ {
+			product := newProduct("Bulbasaur")
+			product.PublishAt = &past
+			repository.Create(product)
+		}
+
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		applied := map[uint]int{}
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					changed, err := repository.ApplySchedules(time.Now(), 3)
+					if err != nil || len(changed) == 0 {
+						return
+					}
+					mu.Lock()
+					for _, id := range changed {
+						applied[id]++
+					}
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Len(t, applied, 20)
+		for id, times := range applied {
+			assert.Equal(t, 1, times, "product %d", id)
+		}
+	})
+
+	t.Run("should record the changes of the price with who made them and why", func(t *testing.T) {
+		repository := newRepository(t)
+		product, _ := repository.Create(newProduct("Bulbasaur"))
+		product.Title = "Ivysaur"
+		_, err := repository.Update(product, models.Audit{Actor: "editor@example.com"})
+		require.NoError(t, err)
+
+		product.Price = money.MustParseAmount("8")
+		_, err = repository.Update(product, models.Audit{Actor: "editor@example.com", Reason: "Sale"})
+		require.NoError(t, err)
+
+		changes, err := repository.GetPriceHistory(int(product.ID), models.PriceHistoryFilter{})
+		require.NoError(t, err)
+		require.Len(t, changes, 1)
+		assert.Equal(t, product.ID, changes[0].ProductID)
+		assert.Equal(t, money.MustParseAmount("10.5"), changes[0].OldPrice)
+		assert.Equal(t, money.MustParseAmount("8"), changes[0].NewPrice)
+		assert.Equal(t, money.DefaultCurrency, changes[0].OldCurrency)
+		assert.Equal(t, "editor@example.com", changes[0].ChangedBy)
+		assert.Equal(t, "Sale", changes[0].Reason)
+		assert.WithinDuration(t, time.Now(), changes[0].ChangedAt, time.Minute)
+	})
+
+	t.Run("should filter the price history by date", func(t *testing.T) {
+		repository := newRepository(t)
+		product, _ := repository.Create(newProduct("Bulbasaur"))
+		product.Price = money.MustParseAmount("8")
+		repository.Update(product, models.Audit{})
+		hourAgo, inAnHour := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+
+		for filter, count := range map[*models.PriceHistoryFilter]int{
+			{Since: &hourAgo}:                   1,
+			{Since: &hourAgo, Until: &inAnHour}: 1,
+			{Since: &inAnHour}:                  0,
+			{Until: &hourAgo}:                   0,
+		} {
+			changes, err := repository.GetPriceHistory(int(product.ID), *filter)
+			require.NoError(t, err)
+			assert.Len(t, changes, count)
+		}
+	})
+
+	t.Run("should compute the lowest price of the last 30 days in the current currency", func(t *testing.T) {
+		repository := newRepository(t)
+		created, _ := repository.Create(newProduct("Bulbasaur"))
+		assert.Equal(t, money.MustParseAmount("10.5"), created.LowestPrice30d)
+
+		for _, price := range []string{"8", "12"} {
+			product, _ := repository.GetByID(int(created.ID))
+			product.Price = money.MustParseAmount(price)
+			updated, err := repository.Update(product, models.Audit{})
+			require.NoError(t, err)
+			assert.Equal(t, money.MustParseAmount("8"), updated.LowestPrice30d)
+		}
+
+		found, _ := repository.GetByID(int(created.ID))
+		assert.Equal(t, money.MustParseAmount("12"), found.Price)
+		assert.Equal(t, money.MustParseAmount("8"), found.LowestPrice30d)
+		listed, _ := repository.GetAll(models.ProductFilter{})
+		assert.Equal(t, money.MustParseAmount("8"), listed[0].LowestPrice30d)
+
+		found.Currency = "USD"
+		repository.Update(found, models.Audit{})
+		found, _ = repository.GetByID(int(created.ID))
+		assert.Equal(t, money.MustParseAmount("12"), found.LowestPrice30d)
+	})
+}
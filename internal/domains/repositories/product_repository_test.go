@@ -9,6 +9,7 @@ import (
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/adrianosiqe/eulabs-challenge-api/pkg/money"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
@@ -32,6 +33,15 @@ func NewMockDB() (*gorm.DB, sqlmock.Sqlmock) {
 	return gormDB, mock
 }
 
+// expectAssociations expects the queries preloading the attributes, the
+// categories, the tags and the options of the products, which have none.
+func expectAssociations(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("SELECT (.+) FROM `product_attributes`").WillReturnRows(sqlmock.NewRows([]string{"product_id", "attribute_key"}))
+	mock.ExpectQuery("SELECT (.+) FROM `product_categories`").WillReturnRows(sqlmock.NewRows([]string{"product_id", "category_id"}))
+	mock.ExpectQuery("SELECT (.+) FROM `product_options`").WillReturnRows(sqlmock.NewRows([]string{"id", "product_id"}))
+	mock.ExpectQuery("SELECT (.+) FROM `product_tags`").WillReturnRows(sqlmock.NewRows([]string{"product_id", "tag_id"}))
+}
+
 func TestGetAll(t *testing.T) {
 	t.Run("should return a list the products", func(t *testing.T) {
 		db, mock := NewMockDB()
@@ -42,14 +52,46 @@ func TestGetAll(t *testing.T) {
 		rows := sqlmock.NewRows([]string{"id", "title", "description", "price", "created_at", "updated_at", "deleted_at"}).AddRows(values...)
 		expectedSQL := "SELECT (.+) FROM `products` WHERE `products`.`deleted_at` IS NULL"
 		mock.ExpectQuery(expectedSQL).WillReturnRows(rows)
+		expectAssociations(mock)
 
 		productRepository := NewProductRepository(db)
-		products, err := productRepository.GetAll()
+		products, err := productRepository.GetAll(models.ProductFilter{})
 
 		assert.NoError(t, err)
 		assert.True(t, len(products) == 2)
 	})
 
+	t.Run("should return a page of products", func(t *testing.T) {
+		db, mock := NewMockDB()
+		rows := sqlmock.NewRows([]string{"id", "title", "description", "price", "created_at", "updated_at", "deleted_at"}).
+			AddRow(2, "Charmander", "It has a preference for hot things. When it rains, steam is said to spout from the tip of its tail.", 1093.45, time.Now(), time.Now(), nil)
+		expectedSQL := "SELECT (.+) FROM `products` WHERE `products`.`deleted_at` IS NULL ORDER BY id LIMIT 1 OFFSET 1"
+		mock.ExpectQuery(expectedSQL).WillReturnRows(rows)
+		expectAssociations(mock)
+
+		productRepository := NewProductRepository(db)
+		products, err := productRepository.GetAll(models.ProductFilter{Page: 2, PerPage: 1})
+
+		assert.NoError(t, err)
+		assert.Len(t, products, 1)
+	})
+
+	t.Run("should filter the products by attribute value", func(t *testing.T) {
+		db, mock := NewMockDB()
+		rows := sqlmock.NewRows([]string{"id", "title", "description", "price", "created_at", "updated_at", "deleted_at"})
+		expectedSQL := "SELECT (.+) FROM `products` WHERE id IN \\(SELECT product_id FROM `product_attributes` WHERE attribute_key = \\? AND number_value > \\?\\) AND `products`.`deleted_at` IS NULL"
+		mock.ExpectQuery(expectedSQL).WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "weight", 2.0).WillReturnRows(rows)
+
+		weight := 2.0
+		condition := models.AttributeCondition{Attribute: models.ProductAttribute{Key: "weight", NumberValue: &weight}, Operator: models.AttributeGt}
+		productRepository := NewProductRepository(db)
+		products, err := productRepository.GetAll(models.ProductFilter{Attributes: []models.AttributeCondition{condition}})
+
+		assert.NoError(t, err)
+		assert.Empty(t, products)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
 	t.Run("should return an empty list", func(t *testing.T) {
 		db, mock := NewMockDB()
 		rows := sqlmock.NewRows([]string{"id", "title", "description", "price", "created_at", "updated_at", "deleted_at"})
@@ -57,7 +99,7 @@ func TestGetAll(t *testing.T) {
 		mock.ExpectQuery(expectedSQL).WillReturnRows(rows)
 
 		productRepository := NewProductRepository(db)
-		products, err := productRepository.GetAll()
+		products, err := productRepository.GetAll(models.ProductFilter{})
 
 		assert.NoError(t, err)
 		assert.Empty(t, products)
@@ -69,7 +111,7 @@ func TestGetAll(t *testing.T) {
 		mock.ExpectQuery(expectedSQL).WillReturnError(fmt.Errorf("some error"))
 
 		productRepository := NewProductRepository(db)
-		_, err := productRepository.GetAll()
+		_, err := productRepository.GetAll(models.ProductFilter{})
 
 		assert.Error(t, err)
 	})
@@ -79,12 +121,13 @@ func TestCreate(t *testing.T) {
 	var mockCreateProduct = &models.Product{
 		Title:       "Charmander",
 		Description: "It has a preference for hot things. When it rains, steam is said to spout from the tip of its tail.",
-		Price:       1093.45,
+		Price:       money.MustParseAmount("1093.45"),
 	}
 
 	t.Run("should return an product", func(t *testing.T) {
 		db, mock := NewMockDB()
 		expectedSQL := "INSERT INTO `products` (.+) VALUES (.+)"
+		mock.ExpectQuery("SELECT `slug` FROM `products`").WillReturnRows(sqlmock.NewRows([]string{"slug"}).AddRow("charmander"))
 		mock.ExpectBegin()
 		mock.ExpectExec(expectedSQL).WillReturnResult(sqlmock.NewResult(1, 1))
 		mock.ExpectCommit()
@@ -94,22 +137,40 @@ func TestCreate(t *testing.T) {
 
 		assert.NoError(t, err)
 		assert.Equal(t, uint(1), product.ID)
+		assert.Equal(t, "charmander-2", product.Slug)
 		assert.Equal(t, "Charmander", product.Title)
 		assert.Contains(t, product.Description, "It has a preference")
-		assert.Equal(t, 1093.45, product.Price)
+		assert.Equal(t, money.MustParseAmount("1093.45"), product.Price)
 	})
 
 	t.Run("should return an error", func(t *testing.T) {
 		db, mock := NewMockDB()
 		expectedSQL := "INSERT INTO `products` (.+) VALUES (.+)"
+		mock.ExpectQuery("SELECT `slug` FROM `products`").WillReturnRows(sqlmock.NewRows([]string{"slug"}))
 		mock.ExpectBegin()
 		mock.ExpectExec(expectedSQL).WillReturnError(fmt.Errorf("some error"))
-		mock.ExpectCommit()
+		mock.ExpectRollback()
+		mock.ExpectQuery("SELECT count(.+) FROM `products` WHERE slug = (.+)").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
 
 		productRepository := NewProductRepository(db)
-		_, err := productRepository.Create(mockCreateProduct)
+		_, err := productRepository.Create(&models.Product{Title: "Charmander"})
 
-		assert.Error(t, err)
+		assert.EqualError(t, err, "some error")
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should return a conflict for a taken sku", func(t *testing.T) {
+		db, mock := NewMockDB()
+		sku := "CHAR-004"
+		mock.ExpectQuery("SELECT count(.+) FROM `products` WHERE sku = (.+)").WithArgs(sku, 0).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+		productRepository := NewProductRepository(db)
+		_, err := productRepository.Create(&models.Product{Title: "Charmander", SKU: &sku})
+
+		var conflict *models.ConflictError
+		assert.ErrorAs(t, err, &conflict)
+		assert.Equal(t, "sku", conflict.Field)
+		assert.Nil(t, mock.ExpectationsWereMet())
 	})
 }
 
@@ -120,6 +181,7 @@ func TestGetByID(t *testing.T) {
 			AddRow(1, "Bulbasaur", "There is a plant seed on its back right from the day this Pokémon is born. The seed slowly grows larger.", 99.99, time.Now(), time.Now(), nil)
 		expectedSQL := "SELECT (.+) FROM `products` WHERE `products`.`id` = (.+) AND `products`.`deleted_at` IS NULL"
 		mock.ExpectQuery(expectedSQL).WillReturnRows(row)
+		expectAssociations(mock)
 
 		productRepository := NewProductRepository(db)
 		product, err := productRepository.GetByID(1)
@@ -128,7 +190,7 @@ func TestGetByID(t *testing.T) {
 		assert.Equal(t, uint(1), product.ID)
 		assert.Equal(t, "Bulbasaur", product.Title)
 		assert.Contains(t, product.Description, "There is a plant seed")
-		assert.Equal(t, 99.99, product.Price)
+		assert.Equal(t, money.MustParseAmount("99.99"), product.Price)
 	})
 
 	t.Run("should return an error", func(t *testing.T) {
@@ -147,36 +209,185 @@ func TestUpdate(t *testing.T) {
 	var mockUpdateProduct = &models.Product{
 		ID:          1,
 		Title:       "Charmander",
+		Slug:        "charmander",
 		Description: "It has a preference for hot things. When it rains, steam is said to spout from the tip of its tail.",
-		Price:       1093.45,
+		Price:       money.MustParseAmount("1093.45"),
 	}
 
+	lockSQL := "SELECT `id`,`price`,`currency` FROM `products` WHERE id = \\? AND `products`.`deleted_at` IS NULL LIMIT 1 FOR UPDATE"
+	lowestSQL := "SELECT COALESCE\\(\\(SELECT MIN\\(old_price\\) FROM price_history .+ AS lowest_price_30d FROM `products` WHERE id = \\?"
+
 	t.Run("should return the product", func(t *testing.T) {
 		db, mock := NewMockDB()
-		expectedSQL := "UPDATE `products` SET .+"
+		expectedSQL := "UPDATE `products` SET `title`=\\?,`description`=\\?,`sku`=\\?,`slug`=\\?,`price`=\\?,`currency`=\\?,`seed_key`=\\?,`updated_at`=\\? WHERE `products`.`deleted_at` IS NULL AND `id` = \\?"
 		mock.ExpectBegin()
+		mock.ExpectQuery(lockSQL).WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "price", "currency"}).AddRow(1, "1093.45", "BRL"))
 		mock.ExpectExec(expectedSQL).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(lowestSQL).WillReturnRows(sqlmock.NewRows([]string{"lowest_price_30d"}).AddRow("999.9"))
 		mock.ExpectCommit()
 
 		productRepository := NewProductRepository(db)
-		product, err := productRepository.Update(mockUpdateProduct)
+		product, err := productRepository.Update(mockUpdateProduct, models.Audit{})
 
 		assert.NoError(t, err)
 		assert.Equal(t, uint(1), product.ID)
 		assert.Equal(t, "Charmander", product.Title)
 		assert.Contains(t, product.Description, "It has a preference")
-		assert.Equal(t, 1093.45, product.Price)
+		assert.Equal(t, money.MustParseAmount("1093.45"), product.Price)
+		assert.Equal(t, money.MustParseAmount("999.9"), product.LowestPrice30d)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should record a change of the price in the same transaction", func(t *testing.T) {
+		db, mock := NewMockDB()
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockSQL).WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "price", "currency"}).AddRow(1, "1200", "BRL"))
+		mock.ExpectExec("UPDATE `products` SET .+").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("INSERT INTO `price_history`").
+			WithArgs(1, money.MustParseAmount("1200"), money.DefaultCurrency, money.MustParseAmount("1093.45"), money.DefaultCurrency, "editor@example.com", "Black Friday", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(lowestSQL).WillReturnRows(sqlmock.NewRows([]string{"lowest_price_30d"}).AddRow("1093.45"))
+		mock.ExpectCommit()
+
+		productRepository := NewProductRepository(db)
+		_, err := productRepository.Update(mockUpdateProduct, models.Audit{Actor: "editor@example.com", Reason: "Black Friday"})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should return not found for a deleted product", func(t *testing.T) {
+		db, mock := NewMockDB()
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockSQL).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "price", "currency"}))
+		mock.ExpectRollback()
+
+		productRepository := NewProductRepository(db)
+		_, err := productRepository.Update(mockUpdateProduct, models.Audit{})
+
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should return not found when nothing is updated", func(t *testing.T) {
+		db, mock := NewMockDB()
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockSQL).WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "price", "currency"}).AddRow(1, "1093.45", "BRL"))
+		mock.ExpectExec("UPDATE `products` SET .+").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		productRepository := NewProductRepository(db)
+		_, err := productRepository.Update(mockUpdateProduct, models.Audit{})
+
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
 	t.Run("should return an error", func(t *testing.T) {
 		db, mock := NewMockDB()
 		expectedSQL := "UPDATE `products` SET .+"
 		mock.ExpectBegin()
+		mock.ExpectQuery(lockSQL).WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "price", "currency"}).AddRow(1, "1200", "BRL"))
 		mock.ExpectExec(expectedSQL).WillReturnError(fmt.Errorf("some error"))
+		mock.ExpectRollback()
+
+		productRepository := NewProductRepository(db)
+		_, err := productRepository.Update(mockUpdateProduct, models.Audit{})
+
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestUpdatePublication(t *testing.T) {
+	t.Run("should update the status while it is unchanged", func(t *testing.T) {
+		db, mock := NewMockDB()
+		now := time.Now()
+		expectedSQL := "UPDATE `products` SET `status`=\\?,`published_at`=\\?,`publish_at`=\\?,`unpublish_at`=\\?,`updated_at`=\\? WHERE status = \\? AND `products`.`deleted_at` IS NULL AND `id` = \\?"
+		mock.ExpectBegin()
+		mock.ExpectExec(expectedSQL).WithArgs("published", now, nil, nil, sqlmock.AnyArg(), "draft", 1).WillReturnResult(sqlmock.NewResult(0, 1))
 		mock.ExpectCommit()
 
 		productRepository := NewProductRepository(db)
-		_, err := productRepository.Update(mockUpdateProduct)
+		err := productRepository.UpdatePublication(&models.Product{ID: 1, Status: models.ProductPublished, PublishedAt: &now}, models.ProductDraft)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should return an invalid transition when the status changed", func(t *testing.T) {
+		db, mock := NewMockDB()
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE `products` SET .+").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+		row := sqlmock.NewRows([]string{"id", "title", "status"}).AddRow(1, "Bulbasaur", "archived")
+		mock.ExpectQuery("SELECT (.+) FROM `products`").WillReturnRows(row)
+		expectAssociations(mock)
+
+		productRepository := NewProductRepository(db)
+		err := productRepository.UpdatePublication(&models.Product{ID: 1, Status: models.ProductPublished}, models.ProductDraft)
+
+		assert.ErrorIs(t, err, models.ErrInvalidTransition)
+	})
+
+	t.Run("should return not found for a missing product", func(t *testing.T) {
+		db, mock := NewMockDB()
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE `products` SET .+").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+		mock.ExpectQuery("SELECT (.+) FROM `products`").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		productRepository := NewProductRepository(db)
+		err := productRepository.UpdatePublication(&models.Product{ID: 1, Status: models.ProductPublished}, models.ProductDraft)
+
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+
+	t.Run("should return an error", func(t *testing.T) {
+		db, mock := NewMockDB()
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE `products` SET .+").WillReturnError(fmt.Errorf("some error"))
+		mock.ExpectRollback()
+
+		productRepository := NewProductRepository(db)
+		err := productRepository.UpdatePublication(&models.Product{ID: 1, Status: models.ProductPublished}, models.ProductDraft)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestApplySchedules(t *testing.T) {
+	t.Run("should lock the due products, skipping the locked ones", func(t *testing.T) {
+		db, mock := NewMockDB()
+		now := time.Now()
+		rows := sqlmock.NewRows([]string{"id", "title", "status", "publish_at"}).AddRow(1, "Bulbasaur", "draft", now.Add(-time.Hour))
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT \\* FROM `products` WHERE \\(\\(status = \\? AND publish_at <= \\?\\) OR \\(status = \\? AND unpublish_at <= \\?\\)\\) AND `products`.`deleted_at` IS NULL ORDER BY id LIMIT 10 FOR UPDATE SKIP LOCKED").
+			WithArgs("draft", now, "published", now).WillReturnRows(rows)
+		mock.ExpectExec("UPDATE `products` SET `status`=\\?,`published_at`=\\?,`publish_at`=\\?,`unpublish_at`=\\?,`updated_at`=\\? WHERE `products`.`deleted_at` IS NULL AND `id` = \\?").
+			WithArgs("published", now, nil, nil, sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		productRepository := NewProductRepository(db)
+		changed, err := productRepository.ApplySchedules(now, 10)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []uint{1}, changed)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should return an error", func(t *testing.T) {
+		db, mock := NewMockDB()
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT (.+) FROM `products`").WillReturnError(fmt.Errorf("some error"))
+		mock.ExpectRollback()
+
+		productRepository := NewProductRepository(db)
+		_, err := productRepository.ApplySchedules(time.Now(), 10)
 
 		assert.Error(t, err)
 	})
@@ -210,3 +421,89 @@ func TestDelete(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+func TestGetBySeedKey(t *testing.T) {
+	t.Run("should return a seeded product", func(t *testing.T) {
+		db, mock := NewMockDB()
+		row := sqlmock.NewRows([]string{"id", "title", "description", "price", "seed_key", "created_at", "updated_at", "deleted_at"}).
+			AddRow(1, "Bulbasaur", "There is a plant seed on its back right from the day this Pokémon is born. The seed slowly grows larger.", 99.99, "bulbasaur", time.Now(), time.Now(), nil)
+		expectedSQL := "SELECT (.+) FROM `products` WHERE seed_key = (.+) ORDER BY"
+		mock.ExpectQuery(expectedSQL).WithArgs("bulbasaur").WillReturnRows(row)
+
+		productRepository := NewProductRepository(db)
+		product, err := productRepository.GetBySeedKey("bulbasaur")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "bulbasaur", *product.SeedKey)
+	})
+
+	t.Run("should return an error", func(t *testing.T) {
+		db, mock := NewMockDB()
+		expectedSQL := "SELECT (.+) FROM `products` WHERE seed_key = (.+) ORDER BY"
+		mock.ExpectQuery(expectedSQL).WillReturnError(gorm.ErrRecordNotFound)
+
+		productRepository := NewProductRepository(db)
+		product, err := productRepository.GetBySeedKey("bulbasaur")
+
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		assert.Nil(t, product)
+	})
+}
+
+func TestDeleteSeeded(t *testing.T) {
+	lockSQL := "SELECT `id` FROM `products` WHERE seed_key IS NOT NULL ORDER BY id FOR UPDATE"
+	mediaSQL := "SELECT \\* FROM `product_media` WHERE product_id IN \\(\\?,\\?\\) ORDER BY id"
+
+	t.Run("should permanently delete the seeded products with their media", func(t *testing.T) {
+		db, mock := NewMockDB()
+		expectedSQL := "DELETE FROM `products` WHERE id IN \\(\\?,\\?\\)"
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockSQL).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(3))
+		mock.ExpectQuery(mediaSQL).WithArgs(1, 3).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "product_id", "file_key"}).AddRow(2, 1, "products/1/front.png"))
+		for _, links := range productLinks {
+			mock.ExpectExec("DELETE FROM "+links+" WHERE product_id IN").WithArgs(1, 3).WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+		mock.ExpectExec(expectedSQL).WithArgs(1, 3).WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		productRepository := NewProductRepository(db)
+		deleted, media, err := productRepository.DeleteSeeded()
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), deleted)
+		assert.Len(t, media, 1)
+		assert.Equal(t, "products/1/front.png", media[0].FileKey)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should do nothing without seeded products", func(t *testing.T) {
+		db, mock := NewMockDB()
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockSQL).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectCommit()
+
+		productRepository := NewProductRepository(db)
+		deleted, media, err := productRepository.DeleteSeeded()
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), deleted)
+		assert.Empty(t, media)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should return an error", func(t *testing.T) {
+		db, mock := NewMockDB()
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockSQL).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(3))
+		mock.ExpectQuery(mediaSQL).WillReturnError(fmt.Errorf("some error"))
+		mock.ExpectRollback()
+
+		productRepository := NewProductRepository(db)
+		_, media, err := productRepository.DeleteSeeded()
+
+		assert.Error(t, err)
+		assert.Nil(t, media)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,32 @@
+package repositories
+
+import (
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/adrianosiqe/eulabs-challenge-api/pkg/database"
+	"gorm.io/gorm"
+)
+
+type TagRepository struct {
+	db *gorm.DB
+}
+
+func NewTagRepository(db *gorm.DB) *TagRepository {
+	return &TagRepository{db: db}
+}
+
+// GetAll returns the tags in use and how many products have each, the most
+// used first. Deleted products aren't counted.
+func (r *TagRepository) GetAll() ([]*models.TagCount, error) {
+	counts := []*models.TagCount{}
+	err := r.db.Set(database.ReadFromReplica, true).Table("tags").
+		Select("tags.name, COUNT(*) AS count").
+		Joins("JOIN product_tags ON product_tags.tag_id = tags.id").
+		Joins("JOIN products ON products.id = product_tags.product_id AND products.deleted_at IS NULL").
+		Group("tags.name").
+		Order("count DESC, tags.name").
+		Find(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
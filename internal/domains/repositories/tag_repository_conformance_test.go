@@ -0,0 +1,121 @@
+package repositories
+
+import (
+	"testing"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/core/interfaces"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// TestTagRepositoryConformance runs the same checks against every
+// implementation of the tags, which are written through the product
+// repository and counted by the tag repository.
+func TestTagRepositoryConformance(t *testing.T) {
+	implementations := map[string]func(t *testing.T) (interfaces.TagRepositoryInterface, interfaces.ProductRespositoryInterface){
+		"memory": func(t *testing.T) (interfaces.TagRepositoryInterface, interfaces.ProductRespositoryInterface) {
+			products := NewMemoryProductRepository()
+			return NewMemoryTagRepository(products), products
+		},
+		"gorm": func(t *testing.T) (interfaces.TagRepositoryInterface, interfaces.ProductRespositoryInterface) {
+			db := newSQLiteDB(t)
+			return NewTagRepository(db), NewProductRepository(db)
+		},
+	}
+
+	for name, newRepositories := range implementations {
+		t.Run(name, func(t *testing.T) {
+			testTagRepository(t, newRepositories)
+		})
+	}
+}
+
+func tagNames(product *models.Product) []string {
+	names := []string{}
+	for _, tag := range product.Tags {
+		names = append(names, tag.Name)
+	}
+	return names
+}
+
+func testTagRepository(t *testing.T, newRepositories func(t *testing.T) (interfaces.TagRepositoryInterface, interfaces.ProductRespositoryInterface)) {
+	t.Run("should add tags once, sorted by name", func(t *testing.T) {
+		_, products := newRepositories(t)
+		product, _ := products.Create(newProduct("Charmander"))
+
+		require.NoError(t, products.AddTags(int(product.ID), []string{"starter", "fire-type"}))
+		require.NoError(t, products.AddTags(int(product.ID), []string{"starter", "new"}))
+
+		tagged, err := products.GetByID(int(product.ID))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"fire-type", "new", "starter"}, tagNames(tagged))
+		assert.ErrorIs(t, products.AddTags(42, []string{"new"}), gorm.ErrRecordNotFound)
+	})
+
+	t.Run("should keep the tags on update", func(t *testing.T) {
+		_, products := newRepositories(t)
+		product, _ := products.Create(newProduct("Charmander"))
+		products.AddTags(int(product.ID), []string{"starter"})
+
+		product.Title = "Charmeleon"
+		product.Tags = nil
+		_, err := products.Update(product, models.Audit{})
+		assert.NoError(t, err)
+
+		updated, _ := products.GetByID(int(product.ID))
+		assert.Equal(t, []string{"starter"}, tagNames(updated))
+	})
+
+	t.Run("should remove tags", func(t *testing.T) {
+		_, products := newRepositories(t)
+		product, _ := products.Create(newProduct("Charmander"))
+		products.AddTags(int(product.ID), []string{"starter", "fire-type"})
+
+		assert.NoError(t, products.RemoveTags(int(product.ID), []string{"starter", "missing"}))
+
+		updated, _ := products.GetByID(int(product.ID))
+		assert.Equal(t, []string{"fire-type"}, tagNames(updated))
+		assert.ErrorIs(t, products.RemoveTags(42, []string{"starter"}), gorm.ErrRecordNotFound)
+	})
+
+	t.Run("should filter the products with any or all of the tags", func(t *testing.T) {
+		_, products := newRepositories(t)
+		bulbasaur, _ := products.Create(newProduct("Bulbasaur"))
+		charmander, _ := products.Create(newProduct("Charmander"))
+		products.Create(newProduct("Pikachu"))
+		products.AddTags(int(bulbasaur.ID), []string{"starter", "grass-type"})
+		products.AddTags(int(charmander.ID), []string{"starter", "fire-type"})
+
+		matchAny, err := products.GetAll(models.ProductFilter{TagNames: []string{"grass-type", "fire-type"}})
+		assert.NoError(t, err)
+		assert.Len(t, matchAny, 2)
+
+		matchAll, err := products.GetAll(models.ProductFilter{TagNames: []string{"starter", "fire-type"}, TagMatch: models.TagMatchAll})
+		assert.NoError(t, err)
+		require.Len(t, matchAll, 1)
+		assert.Equal(t, "Charmander", matchAll[0].Title)
+
+		none, _ := products.GetAll(models.ProductFilter{TagNames: []string{"water-type"}})
+		assert.Empty(t, none)
+	})
+
+	t.Run("should count the products of each tag", func(t *testing.T) {
+		tags, products := newRepositories(t)
+		bulbasaur, _ := products.Create(newProduct("Bulbasaur"))
+		charmander, _ := products.Create(newProduct("Charmander"))
+		squirtle, _ := products.Create(newProduct("Squirtle"))
+		for _, product := range []*models.Product{bulbasaur, charmander, squirtle} {
+			products.AddTags(int(product.ID), []string{"starter"})
+		}
+		products.AddTags(int(charmander.ID), []string{"fire-type"})
+		products.AddTags(int(squirtle.ID), []string{"water-type"})
+		products.Delete(int(squirtle.ID))
+
+		counts, err := tags.GetAll()
+
+		assert.NoError(t, err)
+		assert.Equal(t, []*models.TagCount{{Name: "starter", Count: 2}, {Name: "fire-type", Count: 1}}, counts)
+	})
+}
@@ -0,0 +1,235 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// VariantRepository keeps the variants of the products. Every change locks
+// the product first, so that the options the variants are checked against,
+// and the other variants, don't change in between.
+type VariantRepository struct {
+	db *gorm.DB
+}
+
+func NewVariantRepository(db *gorm.DB) *VariantRepository {
+	return &VariantRepository{db: db}
+}
+
+// variantAvailableColumn computes the available stock of the variants, like
+// availableColumn does for the products.
+const variantAvailableColumn = "COALESCE((SELECT SUM(on_hand) FROM stock_levels WHERE stock_levels.variant_id = product_variants.id), 0) - " +
+	"COALESCE((SELECT SUM(quantity) FROM reservations WHERE reservations.variant_id = product_variants.id AND status = ? AND expires_at > ?), 0) AS available"
+
+// withAvailable selects the variants with their available stock.
+func (r *VariantRepository) withAvailable() *gorm.DB {
+	return r.db.Select("product_variants.*, "+variantAvailableColumn, models.ReservationActive, time.Now())
+}
+
+// GetAll returns the variants of a product in the order they were created.
+func (r *VariantRepository) GetAll(productID int) ([]*models.ProductVariant, error) {
+	var product models.Product
+	if err := r.db.Select("id").First(&product, productID).Error; err != nil {
+		return nil, err
+	}
+
+	variants := []*models.ProductVariant{}
+	err := r.withAvailable().Where("product_id = ?", product.ID).Order("id").Find(&variants).Error
+	if err != nil {
+		return nil, err
+	}
+	return variants, nil
+}
+
+func (r *VariantRepository) GetByID(productID, id int) (*models.ProductVariant, error) {
+	var product models.Product
+	if err := r.db.Select("id").First(&product, productID).Error; err != nil {
+		return nil, err
+	}
+
+	var variant models.ProductVariant
+	err := r.withAvailable().Where("product_id = ?", product.ID).First(&variant, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &variant, nil
+}
+
+// Create inserts a variant of the product it names. It returns
+// models.ErrInvalidVariant when its options don't match the ones of the
+// product, and a *models.ConflictError when another variant has them or when
+// the SKU is taken.
+func (r *VariantRepository) Create(variant *models.ProductVariant) (*models.ProductVariant, error) {
+	var inserted error
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		product, err := r.lockProduct(tx, variant.ProductID)
+		if err != nil {
+			return err
+		}
+		if err := variant.MatchOptions(product.Options); err != nil {
+			return err
+		}
+		if err := r.conflict(tx, variant); err != nil {
+			return err
+		}
+		inserted = tx.Create(variant).Error
+		return inserted
+	})
+	if err != nil {
+		return nil, r.raceError(variant, inserted, err)
+	}
+	return variant, nil
+}
+
+// Update saves the options, the SKU and the price of a variant, checking them
+// like Create does.
+func (r *VariantRepository) Update(variant *models.ProductVariant) (*models.ProductVariant, error) {
+	var updated error
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		product, err := r.lockProduct(tx, variant.ProductID)
+		if err != nil {
+			return err
+		}
+		var stored models.ProductVariant
+		if err := tx.Where("product_id = ?", product.ID).First(&stored, variant.ID).Error; err != nil {
+			return err
+		}
+		if err := variant.MatchOptions(product.Options); err != nil {
+			return err
+		}
+		if err := r.conflict(tx, variant); err != nil {
+			return err
+		}
+		updated = tx.Model(variant).Select("options", "options_key", "sku", "price", "updated_at").Updates(variant).Error
+		return updated
+	})
+	if err != nil {
+		return nil, r.raceError(variant, updated, err)
+	}
+	return variant, nil
+}
+
+// Delete permanently removes a variant with its stock levels and
+// reservations. The stock movements are kept as a record.
+func (r *VariantRepository) Delete(productID, id int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		product, err := r.lockProduct(tx, uint(productID))
+		if err != nil {
+			return err
+		}
+
+		result := tx.Where("product_id = ?", product.ID).Delete(&models.ProductVariant{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		for _, stock := range []interface{}{&models.StockLevel{}, &models.Reservation{}} {
+			if err := tx.Where("variant_id = ?", id).Delete(stock).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Generate creates a variant for every combination of the options of a
+// product that doesn't have one yet, and returns the ones it created.
+func (r *VariantRepository) Generate(productID int) ([]*models.ProductVariant, error) {
+	created := []*models.ProductVariant{}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		product, err := r.lockProduct(tx, uint(productID))
+		if err != nil {
+			return err
+		}
+
+		var keys []string
+		if err := tx.Model(&models.ProductVariant{}).Where("product_id = ?", product.ID).Pluck("options_key", &keys).Error; err != nil {
+			return err
+		}
+		existing := map[string]bool{}
+		for _, key := range keys {
+			existing[key] = true
+		}
+
+		for _, options := range models.Combinations(product.Options) {
+			variant := &models.ProductVariant{ProductID: product.ID, Options: options}
+			if err := variant.MatchOptions(product.Options); err != nil {
+				return err
+			}
+			if !existing[variant.OptionsKey] {
+				created = append(created, variant)
+			}
+		}
+		if len(created) == 0 {
+			return nil
+		}
+		return tx.Create(&created).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// lockProduct locks a product that isn't deleted and loads its options.
+func (r *VariantRepository) lockProduct(tx *gorm.DB, id uint) (*models.Product, error) {
+	var product models.Product
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&product, id).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("product_id = ?", product.ID).Order("position").Find(&product.Options).Error; err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// conflict returns a *models.ConflictError when another variant of the
+// product has the options of variant, or when a product, deleted or not, or
+// another variant has its SKU.
+func (r *VariantRepository) conflict(db *gorm.DB, variant *models.ProductVariant) error {
+	var count int64
+	err := db.Model(&models.ProductVariant{}).
+		Where("product_id = ? AND options_key = ? AND id <> ?", variant.ProductID, variant.OptionsKey, variant.ID).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return &models.ConflictError{Field: "options", Value: variant.OptionsKey}
+	}
+
+	if variant.SKU == nil {
+		return nil
+	}
+	err = db.Unscoped().Model(&models.Product{}).Where("sku = ?", *variant.SKU).Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		err = db.Model(&models.ProductVariant{}).Where("sku = ? AND id <> ?", *variant.SKU, variant.ID).Count(&count).Error
+		if err != nil {
+			return err
+		}
+	}
+	if count > 0 {
+		return &models.ConflictError{Field: "sku", Value: *variant.SKU}
+	}
+	return nil
+}
+
+// raceError returns the conflict a failed write lost a race for, when the
+// write is what failed, or err.
+func (r *VariantRepository) raceError(variant *models.ProductVariant, write, err error) error {
+	if write == nil {
+		return err
+	}
+	if conflict := r.conflict(r.db, variant); conflict != nil {
+		return conflict
+	}
+	return err
+}
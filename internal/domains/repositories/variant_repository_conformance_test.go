@@ -0,0 +1,275 @@
+package repositories
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/core/interfaces"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/adrianosiqe/eulabs-challenge-api/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// variantRepositories are the repositories the variant checks work with,
+// sharing their storage.
+type variantRepositories struct {
+	variants  interfaces.VariantRepositoryInterface
+	products  interfaces.ProductRespositoryInterface
+	inventory interfaces.InventoryRepositoryInterface
+}
+
+// TestVariantRepositoryConformance runs the same checks against every
+// implementation of the variants, whose products and options are written
+// through the product repository.
+func TestVariantRepositoryConformance(t *testing.T) {
+	implementations := map[string]func(t *testing.T) variantRepositories{
+		"memory": func(t *testing.T) variantRepositories {
+			products := NewMemoryProductRepository()
+			return variantRepositories{NewMemoryVariantRepository(products), products, NewMemoryInventoryRepository(products)}
+		},
+		"gorm": func(t *testing.T) variantRepositories {
+			db := newSQLiteDB(t)
+			return variantRepositories{NewVariantRepository(db), NewProductRepository(db), NewInventoryRepository(db)}
+		},
+	}
+
+	for name, newRepositories := range implementations {
+		t.Run(name, func(t *testing.T) {
+			testVariantRepository(t, newRepositories)
+		})
+	}
+}
+
+// createWithOptions creates a product with a size and a color.
+func createWithOptions(t *testing.T, products interfaces.ProductRespositoryInterface) *models.Product {
+	product, err := products.Create(newProduct("T-shirt"))
+	require.NoError(t, err)
+	require.NoError(t, products.SetOptions(int(product.ID), []models.ProductOption{
+		{Name: "Size", Values: models.OptionValues{"S", "M", "L"}, Position: 0},
+		{Name: "Color", Values: models.OptionValues{"Red", "Blue"}, Position: 1},
+	}))
+	return product
+}
+
+func testVariantRepository(t *testing.T, newRepositories func(t *testing.T) variantRepositories) {
+	t.Run("should load the options with the product", func(t *testing.T) {
+		repositories := newRepositories(t)
+		product := createWithOptions(t, repositories.products)
+
+		found, err := repositories.products.GetByID(int(product.ID))
+
+		require.NoError(t, err)
+		require.Len(t, found.Options, 2)
+		assert.Equal(t, "Size", found.Options[0].Name)
+		assert.Equal(t, models.OptionValues{"S", "M", "L"}, found.Options[0].Values)
+		assert.Equal(t, "Color", found.Options[1].Name)
+	})
+
+	t.Run("should create a variant spelled like the options", func(t *testing.T) {
+		repositories := newRepositories(t)
+		product := createWithOptions(t, repositories.products)
+		price := money.MustParseAmount("12.5")
+
+		variant, err := repositories.variants.Create(&models.ProductVariant{
+			ProductID: product.ID,
+			Options:   models.VariantOptions{"size": "m", "COLOR": "red"},
+			Price:     &price,
+		})
+		require.NoError(t, err)
+		assert.NotZero(t, variant.ID)
+
+		found, err := repositories.variants.GetByID(int(product.ID), int(variant.ID))
+		require.NoError(t, err)
+		assert.Equal(t, models.VariantOptions{"Size": "M", "Color": "Red"}, found.Options)
+		assert.Equal(t, money.MustParseAmount("12.5"), *found.Price)
+	})
+
+	t.Run("should reject options the product doesn't have", func(t *testing.T) {
+		repositories := newRepositories(t)
+		product := createWithOptions(t, repositories.products)
+
+		for _, options := range []models.VariantOptions{
+			{"Size": "XL", "Color": "Red"},
+			{"Size": "S"},
+			{"Size": "S", "Color": "Red", "Sleeve": "Long"},
+		} {
+			_, err := repositories.variants.Create(&models.ProductVariant{ProductID: product.ID, Options: options})
+			assert.True(t, errors.Is(err, models.ErrInvalidVariant), "%v", options)
+		}
+	})
+
+	t.Run("should use each combination of options once", func(t *testing.T) {
+		repositories := newRepositories(t)
+		product := createWithOptions(t, repositories.products)
+		_, err := repositories.variants.Create(&models.ProductVariant{ProductID: product.ID, Options: models.VariantOptions{"Size": "S", "Color": "Red"}})
+		require.NoError(t, err)
+
+		_, err = repositories.variants.Create(&models.ProductVariant{ProductID: product.ID, Options: models.VariantOptions{"Size": "s", "Color": "RED"}})
+
+		var conflict *models.ConflictError
+		require.ErrorAs(t, err, &conflict)
+		assert.Equal(t, "options", conflict.Field)
+	})
+
+	t.Run("should share the SKUs with the products", func(t *testing.T) {
+		repositories := newRepositories(t)
+		product := createWithOptions(t, repositories.products)
+		taken := "TSHIRT"
+		product.SKU = &taken
+		_, err := repositories.products.Update(product, models.Audit{})
+		require.NoError(t, err)
+
+		_, err = repositories.variants.Create(&models.ProductVariant{ProductID: product.ID, Options: models.VariantOptions{"Size": "S", "Color": "Red"}, SKU: &taken})
+		var conflict *models.ConflictError
+		require.ErrorAs(t, err, &conflict)
+		assert.Equal(t, "sku", conflict.Field)
+
+		sku := "TSHIRT-S-RED"
+		_, err = repositories.variants.Create(&models.ProductVariant{ProductID: product.ID, Options: models.VariantOptions{"Size": "S", "Color": "Red"}, SKU: &sku})
+		require.NoError(t, err)
+		other, _ := repositories.products.Create(newProduct("Hoodie"))
+		other.SKU = &sku
+		_, err = repositories.products.Update(other, models.Audit{})
+		require.ErrorAs(t, err, &conflict)
+	})
+
+	t.Run("should update a variant", func(t *testing.T) {
+		repositories := newRepositories(t)
+		product := createWithOptions(t, repositories.products)
+		price := money.MustParseAmount("10")
+		variant, err := repositories.variants.Create(&models.ProductVariant{ProductID: product.ID, Options: models.VariantOptions{"Size": "S", "Color": "Red"}, Price: &price})
+		require.NoError(t, err)
+
+		variant.Options, variant.Price = models.VariantOptions{"Size": "L", "Color": "Blue"}, nil
+		_, err = repositories.variants.Update(variant)
+		require.NoError(t, err)
+
+		found, err := repositories.variants.GetByID(int(product.ID), int(variant.ID))
+		require.NoError(t, err)
+		assert.Equal(t, models.VariantOptions{"Size": "L", "Color": "Blue"}, found.Options)
+		assert.Nil(t, found.Price)
+	})
+
+	t.Run("should only find the variants of the product", func(t *testing.T) {
+		repositories := newRepositories(t)
+		product := createWithOptions(t, repositories.products)
+		other := createWithOptions(t, repositories.products)
+		variant, err := repositories.variants.Create(&models.ProductVariant{ProductID: product.ID, Options: models.VariantOptions{"Size": "S", "Color": "Red"}})
+		require.NoError(t, err)
+
+		_, err = repositories.variants.GetByID(int(other.ID), int(variant.ID))
+		assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+		err = repositories.variants.Delete(int(other.ID), int(variant.ID))
+		assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+		variants, err := repositories.variants.GetAll(int(other.ID))
+		assert.NoError(t, err)
+		assert.Empty(t, variants)
+	})
+
+	t.Run("should generate the missing combinations", func(t *testing.T) {
+		repositories := newRepositories(t)
+		product := createWithOptions(t, repositories.products)
+		_, err := repositories.variants.Create(&models.ProductVariant{ProductID: product.ID, Options: models.VariantOptions{"Size": "M", "Color": "Blue"}})
+		require.NoError(t, err)
+
+		created, err := repositories.variants.Generate(int(product.ID))
+		require.NoError(t, err)
+		assert.Len(t, created, 5)
+		assert.Equal(t, models.VariantOptions{"Size": "S", "Color": "Red"}, created[0].Options)
+
+		created, err = repositories.variants.Generate(int(product.ID))
+		assert.NoError(t, err)
+		assert.Empty(t, created)
+		variants, err := repositories.variants.GetAll(int(product.ID))
+		assert.NoError(t, err)
+		assert.Len(t, variants, 6)
+	})
+
+	t.Run("should not change the options variants use", func(t *testing.T) {
+		repositories := newRepositories(t)
+		product := createWithOptions(t, repositories.products)
+		variant, err := repositories.variants.Create(&models.ProductVariant{ProductID: product.ID, Options: models.VariantOptions{"Size": "L", "Color": "Red"}})
+		require.NoError(t, err)
+
+		err = repositories.products.SetOptions(int(product.ID), []models.ProductOption{{Name: "Size", Values: models.OptionValues{"S", "M"}}})
+		assert.True(t, errors.Is(err, models.ErrOptionsInUse))
+
+		err = repositories.products.SetOptions(int(product.ID), []models.ProductOption{
+			{Name: "size", Values: models.OptionValues{"l", "XL"}, Position: 0},
+			{Name: "color", Values: models.OptionValues{"red"}, Position: 1},
+		})
+		require.NoError(t, err)
+		found, err := repositories.variants.GetByID(int(product.ID), int(variant.ID))
+		require.NoError(t, err)
+		assert.Equal(t, models.VariantOptions{"size": "l", "color": "red"}, found.Options)
+	})
+
+	t.Run("should keep the stock of each variant apart", func(t *testing.T) {
+		repositories := newRepositories(t)
+		product := createWithOptions(t, repositories.products)
+		small, err := repositories.variants.Create(&models.ProductVariant{ProductID: product.ID, Options: models.VariantOptions{"Size": "S", "Color": "Red"}})
+		require.NoError(t, err)
+		large, err := repositories.variants.Create(&models.ProductVariant{ProductID: product.ID, Options: models.VariantOptions{"Size": "L", "Color": "Red"}})
+		require.NoError(t, err)
+		now := time.Now()
+
+		_, err = repositories.inventory.Adjust(int(product.ID), models.StockAdjustment{VariantID: small.ID, Warehouse: models.DefaultWarehouse, Delta: 4, Reason: models.StockReceived}, now)
+		require.NoError(t, err)
+		_, err = repositories.inventory.Reserve(&models.Reservation{ProductID: product.ID, VariantID: large.ID, Warehouse: models.DefaultWarehouse, Quantity: 1, ExpiresAt: now.Add(time.Hour)}, now)
+		assert.True(t, errors.Is(err, models.ErrInsufficientStock))
+		_, err = repositories.inventory.Reserve(&models.Reservation{ProductID: product.ID, VariantID: small.ID, Warehouse: models.DefaultWarehouse, Quantity: 1, ExpiresAt: now.Add(time.Hour)}, now)
+		require.NoError(t, err)
+
+		found, err := repositories.variants.GetByID(int(product.ID), int(small.ID))
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), found.Available)
+		stock, err := repositories.inventory.GetStock(int(product.ID), now)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), stock.Available)
+		require.Len(t, stock.Warehouses, 1)
+		assert.Equal(t, small.ID, stock.Warehouses[0].VariantID)
+	})
+
+	t.Run("should not move stock of another product's variant", func(t *testing.T) {
+		repositories := newRepositories(t)
+		product := createWithOptions(t, repositories.products)
+		other := createWithOptions(t, repositories.products)
+		variant, err := repositories.variants.Create(&models.ProductVariant{ProductID: other.ID, Options: models.VariantOptions{"Size": "S", "Color": "Red"}})
+		require.NoError(t, err)
+
+		_, err = repositories.inventory.Adjust(int(product.ID), models.StockAdjustment{VariantID: variant.ID, Warehouse: models.DefaultWarehouse, Delta: 1, Reason: models.StockReceived}, time.Now())
+
+		assert.True(t, errors.Is(err, models.ErrUnknownVariant))
+	})
+
+	t.Run("should delete a variant with its stock", func(t *testing.T) {
+		repositories := newRepositories(t)
+		product := createWithOptions(t, repositories.products)
+		variant, err := repositories.variants.Create(&models.ProductVariant{ProductID: product.ID, Options: models.VariantOptions{"Size": "S", "Color": "Red"}})
+		require.NoError(t, err)
+		_, err = repositories.inventory.Adjust(int(product.ID), models.StockAdjustment{VariantID: variant.ID, Warehouse: models.DefaultWarehouse, Delta: 2, Reason: models.StockReceived}, time.Now())
+		require.NoError(t, err)
+
+		require.NoError(t, repositories.variants.Delete(int(product.ID), int(variant.ID)))
+
+		_, err = repositories.variants.GetByID(int(product.ID), int(variant.ID))
+		assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+		stock, err := repositories.inventory.GetStock(int(product.ID), time.Now())
+		require.NoError(t, err)
+		assert.Empty(t, stock.Warehouses)
+	})
+
+	t.Run("should return not found for a missing product", func(t *testing.T) {
+		repositories := newRepositories(t)
+
+		_, err := repositories.variants.GetAll(42)
+		assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+		_, err = repositories.variants.Generate(42)
+		assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+		err = repositories.products.SetOptions(42, nil)
+		assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+	})
+}
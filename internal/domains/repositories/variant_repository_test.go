@@ -0,0 +1,54 @@
+package repositories
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// expectLockedProduct expects product 1 to be locked and its size option,
+// with the values S and M, to be loaded.
+func expectLockedProduct(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("SELECT `id` FROM `products` WHERE `products`.`id` = \\? AND `products`.`deleted_at` IS NULL ORDER BY `products`.`id` LIMIT 1 FOR UPDATE").
+		WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery("SELECT \\* FROM `product_options` WHERE product_id = \\? ORDER BY position").
+		WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "product_id", "name", "option_values", "position"}).AddRow(1, 1, "Size", "S,M", 0))
+}
+
+func TestCreateVariant(t *testing.T) {
+	t.Run("should insert the variant once the product is locked", func(t *testing.T) {
+		db, mock := NewMockDB()
+		mock.ExpectBegin()
+		expectLockedProduct(mock)
+		mock.ExpectQuery("SELECT count\\(\\*\\) FROM `product_variants` WHERE product_id = \\? AND options_key = \\? AND id <> \\?").
+			WithArgs(1, "size=m", 0).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectExec("INSERT INTO `product_variants`").
+			WithArgs(1, `{"Size":"M"}`, "size=m", nil, nil, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(3, 1))
+		mock.ExpectCommit()
+
+		variantRepository := NewVariantRepository(db)
+		variant, err := variantRepository.Create(&models.ProductVariant{ProductID: 1, Options: models.VariantOptions{"size": "m"}})
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint(3), variant.ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should reject a value the option doesn't have", func(t *testing.T) {
+		db, mock := NewMockDB()
+		mock.ExpectBegin()
+		expectLockedProduct(mock)
+		mock.ExpectRollback()
+
+		variantRepository := NewVariantRepository(db)
+		_, err := variantRepository.Create(&models.ProductVariant{ProductID: 1, Options: models.VariantOptions{"Size": "XL"}})
+
+		assert.True(t, errors.Is(err, models.ErrInvalidVariant))
+		assert.EqualError(t, err, `invalid variant: "XL" is not a value of "Size"`)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
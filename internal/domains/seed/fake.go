@@ -0,0 +1,44 @@
+package seed
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/pkg/money"
+)
+
+var (
+	adjectives = []string{"Ancient", "Brave", "Crystal", "Electric", "Frozen", "Golden", "Mystic", "Shadow", "Shiny", "Wild"}
+	creatures  = []string{"Bulbasaur", "Charmander", "Eevee", "Gengar", "Jigglypuff", "Lapras", "Mewtwo", "Pikachu", "Snorlax", "Squirtle"}
+	items      = []string{"Plush", "Trading Card", "Figure", "Keychain", "Poster", "Mug", "T-Shirt", "Cap"}
+	details    = []string{
+		"A must-have for every trainer's collection.",
+		"Officially licensed and carefully packaged.",
+		"Limited run, numbered on the back.",
+		"Made from durable materials that last for years.",
+		"Perfect as a gift for fans of all ages.",
+	}
+)
+
+// Generate builds n fake products. The same seed always yields the same
+// products, and their keys embed the seed so that different seeds do not
+// collide with each other.
+func Generate(n int, seed int64) []Fixture {
+	random := rand.New(rand.NewSource(seed))
+
+	fixtures := make([]Fixture, n)
+	for i := range fixtures {
+		adjective := adjectives[random.Intn(len(adjectives))]
+		creature := creatures[random.Intn(len(creatures))]
+		item := items[random.Intn(len(items))]
+
+		fixtures[i] = Fixture{
+			Key:         fmt.Sprintf("fake-%d-%d", seed, i+1),
+			Title:       fmt.Sprintf("%s %s %s", adjective, creature, item),
+			Description: fmt.Sprintf("%s %s inspired %s. %s", adjective, creature, item, details[random.Intn(len(details))]),
+			Price:       money.FromMinorUnits(int64(math.Round((1+random.Float64()*999)*100)), money.DefaultCurrency),
+		}
+	}
+	return fixtures
+}
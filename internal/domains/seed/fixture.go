@@ -0,0 +1,95 @@
+package seed
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/adrianosiqe/eulabs-challenge-api/pkg/money"
+	"github.com/go-playground/validator"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed fixtures/*.yaml
+var defaultFixtures embed.FS
+
+// Fixture describes a seeded product. Key identifies it across runs and
+// defaults to the lower cased title.
+type Fixture struct {
+	Key         string         `json:"key" yaml:"key"`
+	Title       string         `json:"title" yaml:"title" validate:"required"`
+	Description string         `json:"description" yaml:"description" validate:"required"`
+	Price       money.Amount   `json:"price" yaml:"price" validate:"required,gt=0"`
+	Currency    money.Currency `json:"currency" yaml:"currency"`
+}
+
+// Product returns the product of the fixture, already published so that the
+// sample data is visible to every caller.
+func (f Fixture) Product() *models.Product {
+	key := f.Key
+	currency := f.Currency
+	if currency == "" {
+		currency = money.DefaultCurrency
+	}
+	now := time.Now()
+	return &models.Product{
+		Title:       f.Title,
+		Description: f.Description,
+		Price:       f.Price,
+		Currency:    currency,
+		Status:      models.ProductPublished,
+		PublishedAt: &now,
+		SeedKey:     &key,
+	}
+}
+
+// DefaultFixtures returns the sample products bundled with the binary.
+func DefaultFixtures() ([]Fixture, error) {
+	return loadFS(defaultFixtures, "fixtures/products.yaml")
+}
+
+// LoadFixtures reads a list of fixtures from a YAML (.yaml, .yml) or JSON
+// (.json) file.
+func LoadFixtures(path string) ([]Fixture, error) {
+	return loadFS(os.DirFS(filepath.Dir(path)), filepath.Base(path))
+}
+
+func loadFS(fsys fs.FS, name string) ([]Fixture, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var fixtures []Fixture
+	switch ext := strings.ToLower(filepath.Ext(name)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &fixtures)
+	case ".json":
+		err = json.Unmarshal(data, &fixtures)
+	default:
+		return nil, fmt.Errorf("%s: unsupported fixture format %q", name, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	validate := validator.New()
+	for i := range fixtures {
+		if err := validate.Struct(fixtures[i]); err != nil {
+			return nil, fmt.Errorf("%s: fixture %d: %w", name, i+1, err)
+		}
+		if err := fixtures[i].Product().Validate(); err != nil {
+			return nil, fmt.Errorf("%s: fixture %d: %w", name, i+1, err)
+		}
+		if fixtures[i].Key == "" {
+			fixtures[i].Key = strings.ToLower(fixtures[i].Title)
+		}
+	}
+	return fixtures, nil
+}
@@ -0,0 +1,78 @@
+// Package seed fills the database with fixture and fake products for local
+// development and tests.
+package seed
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/core/interfaces"
+	"gorm.io/gorm"
+)
+
+type Seeder struct {
+	productRepository interfaces.ProductRespositoryInterface
+	mediaStorage      interfaces.MediaStorageInterface
+}
+
+// Result counts the fixtures created by a run and the ones skipped because
+// they had been seeded before.
+type Result struct {
+	Created int
+	Skipped int
+}
+
+func NewSeeder(productRepository interfaces.ProductRespositoryInterface) *Seeder {
+	return &Seeder{productRepository: productRepository}
+}
+
+// Seed creates the products of the fixtures that have not been seeded yet.
+func (s *Seeder) Seed(fixtures []Fixture) (Result, error) {
+	var result Result
+	for _, fixture := range fixtures {
+		if fixture.Key == "" {
+			return result, fmt.Errorf("fixture %q has no key", fixture.Title)
+		}
+
+		_, err := s.productRepository.GetBySeedKey(fixture.Key)
+		if err == nil {
+			result.Skipped++
+			continue
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return result, err
+		}
+
+		if _, err := s.productRepository.Create(fixture.Product()); err != nil {
+			return result, fmt.Errorf("fixture %q: %w", fixture.Key, err)
+		}
+		result.Created++
+	}
+	return result, nil
+}
+
+// WithMedia makes Wipe delete the files of the media of the seeded products,
+// whose metadata goes with the products.
+func (s *Seeder) WithMedia(mediaStorage interfaces.MediaStorageInterface) *Seeder {
+	s.mediaStorage = mediaStorage
+	return s
+}
+
+// Wipe removes the seeded products, leaving the ones created otherwise.
+func (s *Seeder) Wipe() (int64, error) {
+	deleted, media, err := s.productRepository.DeleteSeeded()
+	if err != nil || s.mediaStorage == nil {
+		return deleted, err
+	}
+
+	// The files are only deleted once the products are gone, so that a
+	// failure doesn't leave media without them.
+	for _, m := range media {
+		for _, key := range []string{m.FileKey, m.ThumbnailKey} {
+			if err := s.mediaStorage.Delete(key); err != nil {
+				return deleted, fmt.Errorf("deleting the media file %s: %w", key, err)
+			}
+		}
+	}
+	return deleted, nil
+}
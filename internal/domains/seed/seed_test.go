@@ -0,0 +1,139 @@
+package seed
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/mocks"
+	"github.com/adrianosiqe/eulabs-challenge-api/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func TestSeed(t *testing.T) {
+	t.Run("should create only the fixtures not seeded before", func(t *testing.T) {
+		fixtures := []Fixture{
+			{Key: "bulbasaur", Title: "Bulbasaur", Description: "Grass", Price: money.MustParseAmount("99.99")},
+			{Key: "charmander", Title: "Charmander", Description: "Fire", Price: money.MustParseAmount("1093.45")},
+		}
+		mockProductRepository := new(mocks.MockProductRepository)
+		mockProductRepository.On("GetBySeedKey", "bulbasaur").Return(mocks.MockProducts[0], nil)
+		mockProductRepository.On("GetBySeedKey", "charmander").Return(nil, gorm.ErrRecordNotFound)
+		mockProductRepository.On("Create", mock.MatchedBy(func(product *models.Product) bool {
+			return product.Title == "Charmander" && *product.SeedKey == "charmander"
+		})).Return(mocks.MockProducts[1], nil)
+
+		result, err := NewSeeder(mockProductRepository).Seed(fixtures)
+
+		assert.NoError(t, err)
+		assert.Equal(t, Result{Created: 1, Skipped: 1}, result)
+		mockProductRepository.AssertExpectations(t)
+	})
+
+	t.Run("should return an error", func(t *testing.T) {
+		mockProductRepository := new(mocks.MockProductRepository)
+		mockProductRepository.On("GetBySeedKey", "bulbasaur").Return(nil, fmt.Errorf("some error"))
+
+		_, err := NewSeeder(mockProductRepository).Seed([]Fixture{{Key: "bulbasaur", Title: "Bulbasaur"}})
+
+		assert.Error(t, err)
+		mockProductRepository.AssertNotCalled(t, "Create", mock.Anything)
+	})
+}
+
+func TestWipe(t *testing.T) {
+	t.Run("should delete the seeded products", func(t *testing.T) {
+		mockProductRepository := new(mocks.MockProductRepository)
+		mockProductRepository.On("DeleteSeeded").Return(int64(2), []*models.ProductMedia{}, nil)
+
+		deleted, err := NewSeeder(mockProductRepository).Wipe()
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), deleted)
+	})
+
+	t.Run("should delete the media files of the seeded products", func(t *testing.T) {
+		mockProductRepository := new(mocks.MockProductRepository)
+		mockProductRepository.On("DeleteSeeded").Return(int64(1), []*models.ProductMedia{mocks.MockMedia}, nil)
+		mockMediaStorage := new(mocks.MockMediaStorage)
+		mockMediaStorage.On("Delete", mock.Anything).Return(nil)
+
+		deleted, err := NewSeeder(mockProductRepository).WithMedia(mockMediaStorage).Wipe()
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), deleted)
+		mockMediaStorage.AssertCalled(t, "Delete", mocks.MockMedia.FileKey)
+		mockMediaStorage.AssertCalled(t, "Delete", mocks.MockMedia.ThumbnailKey)
+	})
+
+	t.Run("should keep the media files when the products can't be deleted", func(t *testing.T) {
+		mockProductRepository := new(mocks.MockProductRepository)
+		mockProductRepository.On("DeleteSeeded").Return(int64(0), nil, fmt.Errorf("some error"))
+		mockMediaStorage := new(mocks.MockMediaStorage)
+
+		_, err := NewSeeder(mockProductRepository).WithMedia(mockMediaStorage).Wipe()
+
+		assert.Error(t, err)
+		mockMediaStorage.AssertNotCalled(t, "Delete", mock.Anything)
+	})
+}
+
+func TestGenerate(t *testing.T) {
+	t.Run("should be deterministic for a seed", func(t *testing.T) {
+		assert.Equal(t, Generate(20, 42), Generate(20, 42))
+		assert.NotEqual(t, Generate(20, 42), Generate(20, 7))
+	})
+
+	t.Run("should generate valid products with unique keys", func(t *testing.T) {
+		keys := map[string]bool{}
+		for _, fixture := range Generate(50, 1) {
+			assert.NotEmpty(t, fixture.Title)
+			assert.NotEmpty(t, fixture.Description)
+			assert.Greater(t, fixture.Price, money.Amount(0))
+			assert.False(t, keys[fixture.Key])
+			keys[fixture.Key] = true
+		}
+	})
+}
+
+func TestLoadFixtures(t *testing.T) {
+	t.Run("should load the default fixtures", func(t *testing.T) {
+		fixtures, err := DefaultFixtures()
+
+		assert.NoError(t, err)
+		assert.Len(t, fixtures, 3)
+		assert.Equal(t, "bulbasaur", fixtures[0].Key)
+	})
+
+	t.Run("should load a JSON file and default the keys", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "products.json")
+		os.WriteFile(path, []byte(`[{"title": "Eevee", "description": "Normal", "price": 10}]`), 0o644)
+
+		fixtures, err := LoadFixtures(path)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []Fixture{{Key: "eevee", Title: "Eevee", Description: "Normal", Price: money.MustParseAmount("10")}}, fixtures)
+	})
+
+	t.Run("should reject invalid fixtures", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "products.yaml")
+		os.WriteFile(path, []byte("- title: Eevee\n  price: 10\n"), 0o644)
+
+		_, err := LoadFixtures(path)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("should reject unknown formats", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "products.txt")
+		os.WriteFile(path, []byte("Eevee"), 0o644)
+
+		_, err := LoadFixtures(path)
+
+		assert.Error(t, err)
+	})
+}
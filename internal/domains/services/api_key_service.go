@@ -0,0 +1,158 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/core/interfaces"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"gorm.io/gorm"
+)
+
+// API keys look like "eul_<prefix>_<secret>". Only the prefix is stored in
+// clear so the key can be looked up; the whole key is stored as a SHA-256 hash.
+const apiKeyIdentifier = "eul"
+
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
+type APIKeyService struct {
+	apiKeyRepository interfaces.APIKeyRepositoryInterface
+	now              func() time.Time
+}
+
+func NewAPIKeyService(apiKeyRepository interfaces.APIKeyRepositoryInterface) *APIKeyService {
+	return &APIKeyService{apiKeyRepository: apiKeyRepository, now: time.Now}
+}
+
+func (s *APIKeyService) GetAllAPIKeys() ([]*models.APIKey, error) {
+	return s.apiKeyRepository.GetAll()
+}
+
+func (s *APIKeyService) CreateAPIKey(apiKey *models.APIKey) (*models.APIKey, error) {
+	key, err := generateAPIKey(apiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	createdAPIKey, err := s.apiKeyRepository.Create(apiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	createdAPIKey.Key = key
+	return createdAPIKey, nil
+}
+
+func (s *APIKeyService) RotateAPIKey(id int) (*models.APIKey, error) {
+	apiKey, err := s.apiKeyRepository.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !apiKey.Active(s.now()) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	key, err := generateAPIKey(apiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// The key is only rotated if it is still not revoked, as it may have been
+	// since it was read.
+	err = s.apiKeyRepository.Rotate(apiKey.ID, apiKey.Prefix, apiKey.Hash)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey.Key = key
+	return apiKey, nil
+}
+
+// RevokeAPIKey revokes an api key. Revoking a revoked key does nothing.
+func (s *APIKeyService) RevokeAPIKey(id int) error {
+	err := s.apiKeyRepository.Revoke(uint(id), s.now())
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	// Nothing was revoked: the key is missing, or was already revoked.
+	_, err = s.apiKeyRepository.GetByID(id)
+	return err
+}
+
+func (s *APIKeyService) AuthenticateAPIKey(key string) (*models.APIKey, error) {
+	prefix, ok := parseAPIKey(key)
+	if !ok {
+		return nil, ErrInvalidAPIKey
+	}
+
+	apiKey, err := s.apiKeyRepository.GetByPrefix(prefix)
+	if err != nil {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashAPIKey(key)), []byte(apiKey.Hash)) != 1 {
+		return nil, ErrInvalidAPIKey
+	}
+
+	now := s.now()
+	if !apiKey.Active(now) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if err := s.apiKeyRepository.TouchLastUsed(apiKey.ID, now); err != nil {
+		return nil, err
+	}
+	apiKey.LastUsedAt = &now
+	return apiKey, nil
+}
+
+// generateAPIKey assigns a fresh prefix and hash to the api key and returns
+// the clear key, which is never stored.
+func generateAPIKey(apiKey *models.APIKey) (string, error) {
+	prefix, err := randomHex(6)
+	if err != nil {
+		return "", err
+	}
+
+	secret, err := randomHex(32)
+	if err != nil {
+		return "", err
+	}
+
+	key := strings.Join([]string{apiKeyIdentifier, prefix, secret}, "_")
+	apiKey.Prefix = prefix
+	apiKey.Hash = hashAPIKey(key)
+
+	return key, nil
+}
+
+func parseAPIKey(key string) (string, bool) {
+	parts := strings.Split(key, "_")
+	if len(parts) != 3 || parts[0] != apiKeyIdentifier || parts[1] == "" || parts[2] == "" {
+		return "", false
+	}
+	return parts[1], true
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func TestCreateAPIKey(t *testing.T) {
+	t.Run("should return the api key with its secret", func(t *testing.T) {
+		mockAPIKeyRepository := &mocks.MockAPIKeyRepository{}
+		mockAPIKeyRepository.On("Create", mock.Anything).Return(mocks.MockAPIKeys[0], nil)
+
+		apiKeyService := NewAPIKeyService(mockAPIKeyRepository)
+		apiKey, err := apiKeyService.CreateAPIKey(&models.APIKey{Name: "Pokédex partner", Scopes: models.Scopes{"products:read"}})
+
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(apiKey.Key, "eul_"))
+
+		created := mockAPIKeyRepository.Calls[0].Arguments.Get(0).(*models.APIKey)
+		assert.Equal(t, hashAPIKey(apiKey.Key), created.Hash)
+		assert.Contains(t, apiKey.Key, created.Prefix)
+		mockAPIKeyRepository.AssertExpectations(t)
+	})
+
+	t.Run("should return an error", func(t *testing.T) {
+		mockAPIKeyRepository := &mocks.MockAPIKeyRepository{}
+		mockAPIKeyRepository.On("Create", mock.Anything).Return(nil, fmt.Errorf("some error"))
+
+		apiKeyService := NewAPIKeyService(mockAPIKeyRepository)
+		_, err := apiKeyService.CreateAPIKey(&models.APIKey{Name: "Pokédex partner"})
+
+		assert.Error(t, err)
+		mockAPIKeyRepository.AssertExpectations(t)
+	})
+}
+
+func TestRotateAPIKey(t *testing.T) {
+	t.Run("should return the api key with a new secret", func(t *testing.T) {
+		apiKey := &models.APIKey{ID: 1, Prefix: "a1b2c3d4e5f6", Hash: "old"}
+		mockAPIKeyRepository := &mocks.MockAPIKeyRepository{}
+		mockAPIKeyRepository.On("GetByID", 1).Return(apiKey, nil)
+		mockAPIKeyRepository.On("Rotate", uint(1), mock.Anything, mock.Anything).Return(nil)
+
+		apiKeyService := NewAPIKeyService(mockAPIKeyRepository)
+		rotated, err := apiKeyService.RotateAPIKey(1)
+
+		assert.NoError(t, err)
+		assert.NotEqual(t, "old", rotated.Hash)
+		assert.NotEqual(t, "a1b2c3d4e5f6", rotated.Prefix)
+		assert.Equal(t, hashAPIKey(rotated.Key), rotated.Hash)
+		mockAPIKeyRepository.AssertCalled(t, "Rotate", uint(1), rotated.Prefix, rotated.Hash)
+	})
+
+	t.Run("should return an error for an api key revoked since it was read", func(t *testing.T) {
+		mockAPIKeyRepository := &mocks.MockAPIKeyRepository{}
+		mockAPIKeyRepository.On("GetByID", 1).Return(&models.APIKey{ID: 1}, nil)
+		mockAPIKeyRepository.On("Rotate", uint(1), mock.Anything, mock.Anything).Return(gorm.ErrRecordNotFound)
+
+		apiKeyService := NewAPIKeyService(mockAPIKeyRepository)
+		_, err := apiKeyService.RotateAPIKey(1)
+
+		assert.ErrorIs(t, err, ErrInvalidAPIKey)
+		mockAPIKeyRepository.AssertExpectations(t)
+	})
+
+	t.Run("should return an error for a revoked api key", func(t *testing.T) {
+		revokedAt := time.Now()
+		mockAPIKeyRepository := &mocks.MockAPIKeyRepository{}
+		mockAPIKeyRepository.On("GetByID", 1).Return(&models.APIKey{ID: 1, RevokedAt: &revokedAt}, nil)
+
+		apiKeyService := NewAPIKeyService(mockAPIKeyRepository)
+		_, err := apiKeyService.RotateAPIKey(1)
+
+		assert.ErrorIs(t, err, ErrInvalidAPIKey)
+		mockAPIKeyRepository.AssertExpectations(t)
+	})
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	t.Run("should set revoked at", func(t *testing.T) {
+		mockAPIKeyRepository := &mocks.MockAPIKeyRepository{}
+		mockAPIKeyRepository.On("Revoke", uint(1), mock.AnythingOfType("time.Time")).Return(nil)
+
+		apiKeyService := NewAPIKeyService(mockAPIKeyRepository)
+		err := apiKeyService.RevokeAPIKey(1)
+
+		assert.NoError(t, err)
+		mockAPIKeyRepository.AssertExpectations(t)
+	})
+
+	t.Run("should do nothing for a revoked api key", func(t *testing.T) {
+		revokedAt := time.Now()
+		mockAPIKeyRepository := &mocks.MockAPIKeyRepository{}
+		mockAPIKeyRepository.On("Revoke", uint(1), mock.AnythingOfType("time.Time")).Return(gorm.ErrRecordNotFound)
+		mockAPIKeyRepository.On("GetByID", 1).Return(&models.APIKey{ID: 1, RevokedAt: &revokedAt}, nil)
+
+		apiKeyService := NewAPIKeyService(mockAPIKeyRepository)
+		err := apiKeyService.RevokeAPIKey(1)
+
+		assert.NoError(t, err)
+		mockAPIKeyRepository.AssertExpectations(t)
+	})
+
+	t.Run("should return an error for a missing api key", func(t *testing.T) {
+		mockAPIKeyRepository := &mocks.MockAPIKeyRepository{}
+		mockAPIKeyRepository.On("Revoke", uint(1), mock.AnythingOfType("time.Time")).Return(gorm.ErrRecordNotFound)
+		mockAPIKeyRepository.On("GetByID", 1).Return(nil, gorm.ErrRecordNotFound)
+
+		apiKeyService := NewAPIKeyService(mockAPIKeyRepository)
+		err := apiKeyService.RevokeAPIKey(1)
+
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		mockAPIKeyRepository.AssertExpectations(t)
+	})
+
+	t.Run("should return an error", func(t *testing.T) {
+		mockAPIKeyRepository := &mocks.MockAPIKeyRepository{}
+		mockAPIKeyRepository.On("Revoke", uint(1), mock.AnythingOfType("time.Time")).Return(fmt.Errorf("some error"))
+
+		apiKeyService := NewAPIKeyService(mockAPIKeyRepository)
+		err := apiKeyService.RevokeAPIKey(1)
+
+		assert.Error(t, err)
+		mockAPIKeyRepository.AssertExpectations(t)
+	})
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	key := "eul_a1b2c3d4e5f6_secret"
+
+	t.Run("should return the api key and record its use", func(t *testing.T) {
+		apiKey := &models.APIKey{ID: 1, Prefix: "a1b2c3d4e5f6", Hash: hashAPIKey(key)}
+		mockAPIKeyRepository := &mocks.MockAPIKeyRepository{}
+		mockAPIKeyRepository.On("GetByPrefix", "a1b2c3d4e5f6").Return(apiKey, nil)
+		mockAPIKeyRepository.On("TouchLastUsed", uint(1), mock.AnythingOfType("time.Time")).Return(nil)
+
+		apiKeyService := NewAPIKeyService(mockAPIKeyRepository)
+		authenticated, err := apiKeyService.AuthenticateAPIKey(key)
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), authenticated.ID)
+		assert.NotNil(t, authenticated.LastUsedAt)
+		mockAPIKeyRepository.AssertExpectations(t)
+	})
+
+	t.Run("should reject a malformed key", func(t *testing.T) {
+		apiKeyService := NewAPIKeyService(&mocks.MockAPIKeyRepository{})
+		_, err := apiKeyService.AuthenticateAPIKey("secret")
+
+		assert.ErrorIs(t, err, ErrInvalidAPIKey)
+	})
+
+	t.Run("should reject a wrong secret", func(t *testing.T) {
+		mockAPIKeyRepository := &mocks.MockAPIKeyRepository{}
+		mockAPIKeyRepository.On("GetByPrefix", "a1b2c3d4e5f6").Return(&models.APIKey{Prefix: "a1b2c3d4e5f6", Hash: hashAPIKey("eul_a1b2c3d4e5f6_other")}, nil)
+
+		apiKeyService := NewAPIKeyService(mockAPIKeyRepository)
+		_, err := apiKeyService.AuthenticateAPIKey(key)
+
+		assert.ErrorIs(t, err, ErrInvalidAPIKey)
+		mockAPIKeyRepository.AssertExpectations(t)
+	})
+
+	t.Run("should reject an expired key", func(t *testing.T) {
+		expiresAt := time.Now().Add(-time.Hour)
+		mockAPIKeyRepository := &mocks.MockAPIKeyRepository{}
+		mockAPIKeyRepository.On("GetByPrefix", "a1b2c3d4e5f6").Return(&models.APIKey{Prefix: "a1b2c3d4e5f6", Hash: hashAPIKey(key), ExpiresAt: &expiresAt}, nil)
+
+		apiKeyService := NewAPIKeyService(mockAPIKeyRepository)
+		_, err := apiKeyService.AuthenticateAPIKey(key)
+
+		assert.ErrorIs(t, err, ErrInvalidAPIKey)
+		mockAPIKeyRepository.AssertExpectations(t)
+	})
+}
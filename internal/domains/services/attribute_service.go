@@ -0,0 +1,58 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/core/interfaces"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+)
+
+type AttributeService struct {
+	attributeRepository interfaces.AttributeRepositoryInterface
+}
+
+func NewAttributeService(attributeRepository interfaces.AttributeRepositoryInterface) *AttributeService {
+	return &AttributeService{attributeRepository: attributeRepository}
+}
+
+func (s *AttributeService) GetAllAttributes() ([]*models.AttributeDefinition, error) {
+	return s.attributeRepository.GetAll()
+}
+
+func (s *AttributeService) GetAttributeByKey(key string) (*models.AttributeDefinition, error) {
+	return s.attributeRepository.GetByKey(key)
+}
+
+// CreateAttribute checks the rules of the definition and creates it. It
+// returns models.ErrInvalidAttribute when they don't fit its type.
+func (s *AttributeService) CreateAttribute(definition *models.AttributeDefinition) (*models.AttributeDefinition, error) {
+	if err := definition.Normalize(); err != nil {
+		return nil, err
+	}
+	return s.attributeRepository.Create(definition)
+}
+
+// UpdateAttribute replaces the name and the rules of a definition. Its type
+// doesn't change, since products have values of it; the values they have are
+// kept, the new rules applying to the values set from now on.
+func (s *AttributeService) UpdateAttribute(definition *models.AttributeDefinition) (*models.AttributeDefinition, error) {
+	existing, err := s.attributeRepository.GetByKey(definition.Key)
+	if err != nil {
+		return nil, err
+	}
+	if definition.Type == "" {
+		definition.Type = existing.Type
+	}
+	if definition.Type != existing.Type {
+		return nil, fmt.Errorf("%w: the type of %s can't change from %s", models.ErrInvalidAttribute, existing.Key, existing.Type)
+	}
+	if err := definition.Normalize(); err != nil {
+		return nil, err
+	}
+	return s.attributeRepository.Update(definition)
+}
+
+// DeleteAttribute deletes a definition and the values products have for it.
+func (s *AttributeService) DeleteAttribute(key string) error {
+	return s.attributeRepository.Delete(key)
+}
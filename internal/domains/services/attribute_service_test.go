@@ -0,0 +1,87 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func TestCreateAttribute(t *testing.T) {
+	t.Run("should trim the definition and create it", func(t *testing.T) {
+		definition := &models.AttributeDefinition{Key: " voltage ", Name: " Voltage ", Type: models.AttributeNumber, Unit: "V"}
+		mockAttributeRepository := &mocks.MockAttributeRepository{}
+		mockAttributeRepository.On("Create", definition).Return(definition, nil)
+
+		attributeService := NewAttributeService(mockAttributeRepository)
+		created, err := attributeService.CreateAttribute(definition)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "voltage", created.Key)
+		assert.Equal(t, "Voltage", created.Name)
+		mockAttributeRepository.AssertExpectations(t)
+	})
+
+	t.Run("should reject rules that don't fit the type", func(t *testing.T) {
+		min, max := 10.0, 1.0
+		for name, definition := range map[string]models.AttributeDefinition{
+			"invalid key":         {Key: "Net Weight", Name: "Net weight", Type: models.AttributeNumber},
+			"unknown type":        {Key: "weight", Name: "Weight", Type: "date"},
+			"min above max":       {Key: "weight", Name: "Weight", Type: models.AttributeNumber, Min: &min, Max: &max},
+			"unit of a string":    {Key: "color", Name: "Color", Type: models.AttributeString, Unit: "kg"},
+			"enum without values": {Key: "material", Name: "Material", Type: models.AttributeEnum},
+			"repeated values":     {Key: "material", Name: "Material", Type: models.AttributeEnum, Values: models.OptionValues{"Steel", "steel"}},
+			"values of a bool":    {Key: "wireless", Name: "Wireless", Type: models.AttributeBool, Values: models.OptionValues{"yes"}},
+		} {
+			t.Run(name, func(t *testing.T) {
+				mockAttributeRepository := &mocks.MockAttributeRepository{}
+
+				attributeService := NewAttributeService(mockAttributeRepository)
+				_, err := attributeService.CreateAttribute(&definition)
+
+				assert.ErrorIs(t, err, models.ErrInvalidAttribute)
+				mockAttributeRepository.AssertNotCalled(t, "Create", mock.Anything)
+			})
+		}
+	})
+}
+
+func TestUpdateAttribute(t *testing.T) {
+	t.Run("should keep the type of the attribute", func(t *testing.T) {
+		definition := &models.AttributeDefinition{Key: "weight", Name: "Net weight", Unit: "g"}
+		mockAttributeRepository := &mocks.MockAttributeRepository{}
+		mockAttributeRepository.On("GetByKey", "weight").Return(mocks.MockAttributeDefinitions[1], nil)
+		mockAttributeRepository.On("Update", definition).Return(definition, nil)
+
+		attributeService := NewAttributeService(mockAttributeRepository)
+		updated, err := attributeService.UpdateAttribute(definition)
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.AttributeNumber, updated.Type)
+		mockAttributeRepository.AssertExpectations(t)
+	})
+
+	t.Run("should reject a change of type", func(t *testing.T) {
+		mockAttributeRepository := &mocks.MockAttributeRepository{}
+		mockAttributeRepository.On("GetByKey", "weight").Return(mocks.MockAttributeDefinitions[1], nil)
+
+		attributeService := NewAttributeService(mockAttributeRepository)
+		_, err := attributeService.UpdateAttribute(&models.AttributeDefinition{Key: "weight", Name: "Weight", Type: models.AttributeString})
+
+		assert.ErrorIs(t, err, models.ErrInvalidAttribute)
+		mockAttributeRepository.AssertNotCalled(t, "Update", mock.Anything)
+	})
+
+	t.Run("should return not found for a missing attribute", func(t *testing.T) {
+		mockAttributeRepository := &mocks.MockAttributeRepository{}
+		mockAttributeRepository.On("GetByKey", "voltage").Return(nil, gorm.ErrRecordNotFound)
+
+		attributeService := NewAttributeService(mockAttributeRepository)
+		_, err := attributeService.UpdateAttribute(&models.AttributeDefinition{Key: "voltage", Name: "Voltage"})
+
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/core/interfaces"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"gorm.io/gorm"
+)
+
+// ErrUnknownCategory is returned when a request refers to a category, other
+// than the one in the path, that doesn't exist.
+var ErrUnknownCategory = errors.New("unknown category")
+
+type CategoryService struct {
+	categoryRepository interfaces.CategoryRepositoryInterface
+}
+
+func NewCategoryService(categoryRepository interfaces.CategoryRepositoryInterface) *CategoryService {
+	return &CategoryService{categoryRepository: categoryRepository}
+}
+
+func (s *CategoryService) GetAllCategories() ([]*models.Category, error) {
+	return s.categoryRepository.GetAll()
+}
+
+func (s *CategoryService) CreateCategory(category *models.Category) (*models.Category, error) {
+	if err := s.checkParent(category.ParentID); err != nil {
+		return nil, err
+	}
+	return s.categoryRepository.Create(category)
+}
+
+func (s *CategoryService) GetCategoryByID(id int) (*models.Category, error) {
+	return s.categoryRepository.GetByID(id)
+}
+
+func (s *CategoryService) UpdateCategory(category *models.Category) (*models.Category, error) {
+	return s.categoryRepository.Update(category)
+}
+
+func (s *CategoryService) MoveCategory(id int, parentID *uint) (*models.Category, error) {
+	if _, err := s.categoryRepository.GetByID(id); err != nil {
+		return nil, err
+	}
+	if err := s.checkParent(parentID); err != nil {
+		return nil, err
+	}
+	return s.categoryRepository.Move(id, parentID)
+}
+
+func (s *CategoryService) DeleteCategory(id int) error {
+	return s.categoryRepository.Delete(id)
+}
+
+func (s *CategoryService) checkParent(parentID *uint) error {
+	if parentID == nil {
+		return nil
+	}
+	_, err := s.categoryRepository.GetByID(int(*parentID))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrUnknownCategory
+	}
+	return err
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func TestCreateCategory(t *testing.T) {
+	t.Run("should create a category under its parent", func(t *testing.T) {
+		category := &models.Category{Name: "Plush", ParentID: mocks.MockCategories[1].ParentID}
+		mockCategoryRepository := &mocks.MockCategoryRepository{}
+		mockCategoryRepository.On("GetByID", 1).Return(mocks.MockCategories[0], nil)
+		mockCategoryRepository.On("Create", category).Return(mocks.MockCategories[1], nil)
+
+		categoryService := NewCategoryService(mockCategoryRepository)
+		created, err := categoryService.CreateCategory(category)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "/1/2/", created.Path)
+		mockCategoryRepository.AssertExpectations(t)
+	})
+
+	t.Run("should return an error for an unknown parent", func(t *testing.T) {
+		parentID := uint(42)
+		mockCategoryRepository := &mocks.MockCategoryRepository{}
+		mockCategoryRepository.On("GetByID", 42).Return(nil, gorm.ErrRecordNotFound)
+
+		categoryService := NewCategoryService(mockCategoryRepository)
+		_, err := categoryService.CreateCategory(&models.Category{Name: "Plush", ParentID: &parentID})
+
+		assert.ErrorIs(t, err, ErrUnknownCategory)
+		mockCategoryRepository.AssertNotCalled(t, "Create", mock.Anything)
+	})
+}
+
+func TestMoveCategory(t *testing.T) {
+	t.Run("should move a category to the root", func(t *testing.T) {
+		mockCategoryRepository := &mocks.MockCategoryRepository{}
+		mockCategoryRepository.On("GetByID", 2).Return(mocks.MockCategories[1], nil)
+		mockCategoryRepository.On("Move", 2, (*uint)(nil)).Return(&models.Category{ID: 2, Name: "Plush", Path: "/2/"}, nil)
+
+		categoryService := NewCategoryService(mockCategoryRepository)
+		moved, err := categoryService.MoveCategory(2, nil)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "/2/", moved.Path)
+		mockCategoryRepository.AssertExpectations(t)
+	})
+
+	t.Run("should return not found for a missing category", func(t *testing.T) {
+		mockCategoryRepository := &mocks.MockCategoryRepository{}
+		mockCategoryRepository.On("GetByID", 42).Return(nil, gorm.ErrRecordNotFound)
+
+		categoryService := NewCategoryService(mockCategoryRepository)
+		_, err := categoryService.MoveCategory(42, nil)
+
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+
+	t.Run("should return an error for an unknown parent", func(t *testing.T) {
+		parentID := uint(42)
+		mockCategoryRepository := &mocks.MockCategoryRepository{}
+		mockCategoryRepository.On("GetByID", 2).Return(mocks.MockCategories[1], nil)
+		mockCategoryRepository.On("GetByID", 42).Return(nil, gorm.ErrRecordNotFound)
+
+		categoryService := NewCategoryService(mockCategoryRepository)
+		_, err := categoryService.MoveCategory(2, &parentID)
+
+		assert.ErrorIs(t, err, ErrUnknownCategory)
+		mockCategoryRepository.AssertNotCalled(t, "Move", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/core/interfaces"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+)
+
+// InventoryOptions configures the reservations of the inventory service.
+type InventoryOptions struct {
+	// ReservationTTL is how long the reservations that don't ask for an
+	// expiry last.
+	ReservationTTL time.Duration
+	// MaxReservationTTL bounds how long a reservation can ask to last.
+	MaxReservationTTL time.Duration
+}
+
+type InventoryService struct {
+	inventoryRepository interfaces.InventoryRepositoryInterface
+	options             InventoryOptions
+	now                 func() time.Time
+}
+
+func NewInventoryService(inventoryRepository interfaces.InventoryRepositoryInterface, options InventoryOptions) *InventoryService {
+	return &InventoryService{inventoryRepository: inventoryRepository, options: options, now: time.Now}
+}
+
+func (s *InventoryService) GetStock(productID int) (*models.Stock, error) {
+	return s.inventoryRepository.GetStock(productID, s.now())
+}
+
+// AdjustStock changes the stock of a product in the warehouse of the
+// adjustment, the default one when it names none.
+func (s *InventoryService) AdjustStock(productID int, adjustment models.StockAdjustment) (*models.StockLevel, error) {
+	warehouse, err := models.NormalizeWarehouse(adjustment.Warehouse)
+	if err != nil {
+		return nil, err
+	}
+	adjustment.Warehouse = warehouse
+	return s.inventoryRepository.Adjust(productID, adjustment, s.now())
+}
+
+// Reserve holds stock of a product until the reservation is committed,
+// released or expires.
+func (s *InventoryService) Reserve(productID int, request models.ReservationRequest) (*models.Reservation, error) {
+	warehouse, err := models.NormalizeWarehouse(request.Warehouse)
+	if err != nil {
+		return nil, err
+	}
+	ttl := s.options.ReservationTTL
+	if request.ExpiresIn > 0 {
+		ttl = time.Duration(request.ExpiresIn) * time.Second
+	}
+	if s.options.MaxReservationTTL > 0 && ttl > s.options.MaxReservationTTL {
+		return nil, fmt.Errorf("%w: reservations last at most %s", models.ErrInvalidExpiry, s.options.MaxReservationTTL)
+	}
+
+	now := s.now()
+	reservation := &models.Reservation{
+		ProductID: uint(productID),
+		VariantID: request.VariantID,
+		Warehouse: warehouse,
+		Quantity:  request.Quantity,
+		ExpiresAt: now.Add(ttl),
+	}
+	return s.inventoryRepository.Reserve(reservation, now)
+}
+
+// GetReservation returns a reservation, shown as expired once past its
+// expiry.
+func (s *InventoryService) GetReservation(id int) (*models.Reservation, error) {
+	reservation, err := s.inventoryRepository.GetReservation(id)
+	if err != nil {
+		return nil, err
+	}
+	reservation.ShowExpiry(s.now())
+	return reservation, nil
+}
+
+// CommitReservation records the reserved stock as sold.
+func (s *InventoryService) CommitReservation(id int) (*models.Reservation, error) {
+	return s.inventoryRepository.CommitReservation(id, s.now())
+}
+
+// ReleaseReservation gives the reserved stock back.
+func (s *InventoryService) ReleaseReservation(id int) (*models.Reservation, error) {
+	return s.inventoryRepository.ReleaseReservation(id, s.now())
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/repositories"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/mocks"
+	"github.com/adrianosiqe/eulabs-challenge-api/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newInventoryService(inventoryRepository *mocks.MockInventoryRepository, now time.Time) *InventoryService {
+	inventoryService := NewInventoryService(inventoryRepository, InventoryOptions{ReservationTTL: 15 * time.Minute, MaxReservationTTL: time.Hour})
+	inventoryService.now = func() time.Time { return now }
+	return inventoryService
+}
+
+// createVariant creates a product with a size and one of its variants.
+func createVariant(t *testing.T, products *repositories.MemoryProductRepository) *models.ProductVariant {
+	product, err := products.Create(&models.Product{Title: "T-shirt", Description: "Cotton", Price: money.MustParseAmount("10"), Currency: money.DefaultCurrency})
+	require.NoError(t, err)
+	require.NoError(t, products.SetOptions(int(product.ID), []models.ProductOption{{Name: "Size", Values: models.OptionValues{"S", "M"}}}))
+	variant, err := repositories.NewMemoryVariantRepository(products).Create(&models.ProductVariant{ProductID: product.ID, Options: models.VariantOptions{"Size": "M"}})
+	require.NoError(t, err)
+	return variant
+}
+
+func TestAdjustStock(t *testing.T) {
+	now := time.Date(2024, 11, 29, 0, 0, 0, 0, time.UTC)
+
+	t.Run("should adjust the default warehouse when none is named", func(t *testing.T) {
+		adjustment := models.StockAdjustment{Warehouse: models.DefaultWarehouse, Delta: 5, Reason: models.StockReceived}
+		level := &models.StockLevel{Warehouse: models.DefaultWarehouse, OnHand: 5, Available: 5}
+		mockInventoryRepository := &mocks.MockInventoryRepository{}
+		mockInventoryRepository.On("Adjust", 1, adjustment, now).Return(level, nil)
+
+		adjusted, err := newInventoryService(mockInventoryRepository, now).AdjustStock(1, models.StockAdjustment{Delta: 5, Reason: models.StockReceived})
+
+		assert.NoError(t, err)
+		assert.Equal(t, level, adjusted)
+		mockInventoryRepository.AssertExpectations(t)
+	})
+
+	t.Run("should reject an invalid warehouse", func(t *testing.T) {
+		mockInventoryRepository := &mocks.MockInventoryRepository{}
+
+		_, err := newInventoryService(mockInventoryRepository, now).AdjustStock(1, models.StockAdjustment{Warehouse: "north/1", Delta: 5, Reason: models.StockReceived})
+
+		assert.ErrorIs(t, err, models.ErrInvalidWarehouse)
+		mockInventoryRepository.AssertNotCalled(t, "Adjust", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestReserve(t *testing.T) {
+	now := time.Date(2024, 11, 29, 0, 0, 0, 0, time.UTC)
+
+	t.Run("should reserve for the configured time by default", func(t *testing.T) {
+		reservation := &models.Reservation{ProductID: 1, Warehouse: "north", Quantity: 2, ExpiresAt: now.Add(15 * time.Minute)}
+		mockInventoryRepository := &mocks.MockInventoryRepository{}
+		mockInventoryRepository.On("Reserve", reservation, now).Return(mocks.MockReservation, nil)
+
+		reserved, err := newInventoryService(mockInventoryRepository, now).Reserve(1, models.ReservationRequest{Warehouse: "North", Quantity: 2})
+
+		assert.NoError(t, err)
+		assert.Equal(t, mocks.MockReservation, reserved)
+		mockInventoryRepository.AssertExpectations(t)
+	})
+
+	t.Run("should reserve for the requested time", func(t *testing.T) {
+		reservation := &models.Reservation{ProductID: 1, Warehouse: models.DefaultWarehouse, Quantity: 2, ExpiresAt: now.Add(time.Minute)}
+		mockInventoryRepository := &mocks.MockInventoryRepository{}
+		mockInventoryRepository.On("Reserve", reservation, now).Return(mocks.MockReservation, nil)
+
+		_, err := newInventoryService(mockInventoryRepository, now).Reserve(1, models.ReservationRequest{Quantity: 2, ExpiresIn: 60})
+
+		assert.NoError(t, err)
+		mockInventoryRepository.AssertExpectations(t)
+	})
+
+	t.Run("should reserve the stock of a variant", func(t *testing.T) {
+		products := repositories.NewMemoryProductRepository()
+		variant := createVariant(t, products)
+		inventoryService := NewInventoryService(repositories.NewMemoryInventoryRepository(products), InventoryOptions{ReservationTTL: 15 * time.Minute})
+		_, err := inventoryService.AdjustStock(int(variant.ProductID), models.StockAdjustment{VariantID: variant.ID, Delta: 5, Reason: models.StockReceived})
+		require.NoError(t, err)
+
+		reservation, err := inventoryService.Reserve(int(variant.ProductID), models.ReservationRequest{VariantID: variant.ID, Quantity: 2})
+
+		require.NoError(t, err)
+		assert.Equal(t, variant.ID, reservation.VariantID)
+		stock, err := inventoryService.GetStock(int(variant.ProductID))
+		require.NoError(t, err)
+		require.Len(t, stock.Warehouses, 1)
+		assert.Equal(t, variant.ID, stock.Warehouses[0].VariantID)
+		assert.Equal(t, int64(2), stock.Warehouses[0].Reserved)
+		assert.Equal(t, int64(3), stock.Warehouses[0].Available)
+	})
+
+	t.Run("should not reserve for longer than allowed", func(t *testing.T) {
+		mockInventoryRepository := &mocks.MockInventoryRepository{}
+
+		_, err := newInventoryService(mockInventoryRepository, now).Reserve(1, models.ReservationRequest{Quantity: 2, ExpiresIn: 7200})
+
+		assert.ErrorIs(t, err, models.ErrInvalidExpiry)
+		mockInventoryRepository.AssertNotCalled(t, "Reserve", mock.Anything, mock.Anything)
+	})
+}
+
+func TestGetReservation(t *testing.T) {
+	t.Run("should show an active reservation past its expiry as expired", func(t *testing.T) {
+		now := time.Date(2024, 11, 29, 0, 0, 0, 0, time.UTC)
+		mockInventoryRepository := &mocks.MockInventoryRepository{}
+		mockInventoryRepository.On("GetReservation", 1).Return(&models.Reservation{ID: 1, Status: models.ReservationActive, ExpiresAt: now.Add(-time.Second)}, nil)
+
+		reservation, err := newInventoryService(mockInventoryRepository, now).GetReservation(1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.ReservationExpired, reservation.Status)
+	})
+}
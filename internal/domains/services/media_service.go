@@ -0,0 +1,185 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"net/http"
+
+	// The decoders of the accepted media types register with image.
+	_ "image/gif"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/core/interfaces"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+)
+
+// MediaOptions configures the uploads of the media service.
+type MediaOptions struct {
+	// MaxSize is the largest file, in bytes, that can be uploaded.
+	MaxSize int64
+	// ThumbnailSize is the side of the square the thumbnails fit in.
+	ThumbnailSize int
+}
+
+type MediaService struct {
+	mediaRepository   interfaces.MediaRepositoryInterface
+	productRepository interfaces.ProductRespositoryInterface
+	storage           interfaces.MediaStorageInterface
+	options           MediaOptions
+}
+
+// NewMediaService returns a service that keeps the files of the media in
+// storage and their metadata in mediaRepository.
+func NewMediaService(mediaRepository interfaces.MediaRepositoryInterface, productRepository interfaces.ProductRespositoryInterface, storage interfaces.MediaStorageInterface, options MediaOptions) *MediaService {
+	return &MediaService{mediaRepository: mediaRepository, productRepository: productRepository, storage: storage, options: options}
+}
+
+func (s *MediaService) GetAllMedia(productID int) ([]*models.ProductMedia, error) {
+	return s.mediaRepository.GetAll(productID)
+}
+
+func (s *MediaService) GetMedia(productID, id int) (*models.ProductMedia, error) {
+	return s.mediaRepository.GetByID(productID, id)
+}
+
+// UploadMedia stores an image, with a thumbnail of it, and adds it to the
+// media of a product. The content type is sniffed from the content, whatever
+// the client said it was. It returns models.ErrMediaTooLarge for content over
+// the size limit and models.ErrUnsupportedMedia for content that isn't an
+// image it can decode.
+func (s *MediaService) UploadMedia(productID int, filename string, content io.Reader) (*models.ProductMedia, error) {
+	if _, err := s.productRepository.GetByIDFromPrimary(productID); err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(content, s.options.MaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.options.MaxSize {
+		return nil, fmt.Errorf("%w: files can have up to %d bytes", models.ErrMediaTooLarge, s.options.MaxSize)
+	}
+
+	contentType := http.DetectContentType(data)
+	extension, ok := models.MediaTypes[contentType]
+	if !ok {
+		return nil, fmt.Errorf("%w %s: it must be a JPEG, PNG or GIF image", models.ErrUnsupportedMedia, contentType)
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrUnsupportedMedia, err)
+	}
+	if config.Width*config.Height > models.MaxMediaPixels {
+		return nil, fmt.Errorf("%w: images can have up to %d pixels", models.ErrMediaTooLarge, models.MaxMediaPixels)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrUnsupportedMedia, err)
+	}
+
+	media := &models.ProductMedia{
+		ProductID:   uint(productID),
+		Filename:    models.CleanFilename(filename),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Width:       config.Width,
+		Height:      config.Height,
+	}
+
+	var thumb bytes.Buffer
+	if media.ThumbnailType() == "image/jpeg" {
+		err = jpeg.Encode(&thumb, thumbnail(img, s.options.ThumbnailSize), &jpeg.Options{Quality: 85})
+	} else {
+		err = png.Encode(&thumb, thumbnail(img, s.options.ThumbnailSize))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+	media.FileKey = fmt.Sprintf("products/%d/%s%s", productID, name, extension)
+	media.ThumbnailKey = fmt.Sprintf("products/%d/%s_thumb%s", productID, name, models.MediaTypes[media.ThumbnailType()])
+
+	if err := s.storage.Put(media.FileKey, bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	if err := s.storage.Put(media.ThumbnailKey, &thumb); err != nil {
+		s.removeFiles(media)
+		return nil, err
+	}
+
+	created, err := s.mediaRepository.Create(media)
+	if err != nil {
+		s.removeFiles(media)
+		return nil, err
+	}
+	return created, nil
+}
+
+// OpenMedia returns a media of a product with its file, or its thumbnail,
+// which the caller must close.
+func (s *MediaService) OpenMedia(productID, id int, thumbnail bool) (*models.ProductMedia, io.ReadCloser, error) {
+	media, err := s.mediaRepository.GetByID(productID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key := media.FileKey
+	if thumbnail {
+		key = media.ThumbnailKey
+	}
+	file, err := s.storage.Open(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return media, file, nil
+}
+
+// SetPrimaryMedia makes a media the primary image of its product and returns
+// the media of the product.
+func (s *MediaService) SetPrimaryMedia(productID, id int) ([]*models.ProductMedia, error) {
+	if err := s.mediaRepository.SetPrimary(productID, id); err != nil {
+		return nil, err
+	}
+	return s.mediaRepository.GetAll(productID)
+}
+
+// ReorderMedia puts the media of a product in the order of ids and returns
+// them.
+func (s *MediaService) ReorderMedia(productID int, ids []uint) ([]*models.ProductMedia, error) {
+	if err := s.mediaRepository.Reorder(productID, ids); err != nil {
+		return nil, err
+	}
+	return s.mediaRepository.GetAll(productID)
+}
+
+// DeleteMedia removes a media of a product, then its files.
+func (s *MediaService) DeleteMedia(productID, id int) error {
+	media, err := s.mediaRepository.GetByID(productID, id)
+	if err != nil {
+		return err
+	}
+	if err := s.mediaRepository.Delete(productID, id); err != nil {
+		return err
+	}
+	s.removeFiles(media)
+	return nil
+}
+
+// removeFiles deletes the files of a media. A file left behind is only
+// wasted space, so failures are logged rather than returned.
+func (s *MediaService) removeFiles(media *models.ProductMedia) {
+	for _, key := range []string{media.FileKey, media.ThumbnailKey} {
+		if err := s.storage.Delete(key); err != nil {
+			log.Printf("Failed to delete the media file %s: %v", key, err)
+		}
+	}
+}
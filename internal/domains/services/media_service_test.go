@@ -0,0 +1,235 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// solidImage returns an image of the given size filled with c.
+func solidImage(width, height int, c color.Color) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	var buffer bytes.Buffer
+	require.NoError(t, png.Encode(&buffer, img))
+	return buffer.Bytes()
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	var buffer bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buffer, img, nil))
+	return buffer.Bytes()
+}
+
+// putContent returns what was put in the storage under the key matching.
+func putContent(t *testing.T, storage *mocks.MockMediaStorage, match func(key string) bool) []byte {
+	for _, call := range storage.Calls {
+		if call.Method == "Put" && match(call.Arguments.String(0)) {
+			content, err := io.ReadAll(call.Arguments.Get(1).(io.Reader))
+			require.NoError(t, err)
+			return content
+		}
+	}
+	t.Fatal("nothing was put")
+	return nil
+}
+
+func TestUploadMedia(t *testing.T) {
+	options := MediaOptions{MaxSize: 1 << 20, ThumbnailSize: 256}
+
+	t.Run("should store the image with a thumbnail and add it to the product", func(t *testing.T) {
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("GetByIDFromPrimary", 1).Return(mocks.MockProducts[0], nil)
+		mockMediaStorage := &mocks.MockMediaStorage{}
+		mockMediaStorage.On("Put", mock.Anything, mock.Anything).Return(nil)
+		mockMediaRepository := &mocks.MockMediaRepository{}
+		mockMediaRepository.On("Create", mock.Anything).Return(mocks.MockMedia, nil)
+		content := encodePNG(t, solidImage(600, 300, color.NRGBA{R: 255, A: 128}))
+
+		mediaService := NewMediaService(mockMediaRepository, mockProductRepository, mockMediaStorage, options)
+		_, err := mediaService.UploadMedia(1, `C:\photos\front.png`, bytes.NewReader(content))
+
+		assert.NoError(t, err)
+		created := mockMediaRepository.Calls[0].Arguments.Get(0).(*models.ProductMedia)
+		assert.Equal(t, uint(1), created.ProductID)
+		assert.Equal(t, "front.png", created.Filename)
+		assert.Equal(t, "image/png", created.ContentType)
+		assert.Equal(t, int64(len(content)), created.Size)
+		assert.Equal(t, 600, created.Width)
+		assert.Equal(t, 300, created.Height)
+		assert.Regexp(t, `^products/1/[0-9a-f]{32}\.png$`, created.FileKey)
+		assert.Equal(t, strings.TrimSuffix(created.FileKey, ".png")+"_thumb.png", created.ThumbnailKey)
+
+		assert.Equal(t, content, putContent(t, mockMediaStorage, func(key string) bool { return key == created.FileKey }))
+		thumb, err := png.Decode(bytes.NewReader(putContent(t, mockMediaStorage, func(key string) bool { return key == created.ThumbnailKey })))
+		require.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 256, 128), thumb.Bounds())
+		assert.Equal(t, color.NRGBA{R: 255, A: 128}, color.NRGBAModel.Convert(thumb.At(10, 10)))
+	})
+
+	t.Run("should sniff the content type instead of trusting the name", func(t *testing.T) {
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("GetByIDFromPrimary", 1).Return(mocks.MockProducts[0], nil)
+		mockMediaStorage := &mocks.MockMediaStorage{}
+		mockMediaStorage.On("Put", mock.Anything, mock.Anything).Return(nil)
+		mockMediaRepository := &mocks.MockMediaRepository{}
+		mockMediaRepository.On("Create", mock.Anything).Return(mocks.MockMedia, nil)
+
+		mediaService := NewMediaService(mockMediaRepository, mockProductRepository, mockMediaStorage, options)
+		_, err := mediaService.UploadMedia(1, "front.png", bytes.NewReader(encodeJPEG(t, solidImage(100, 50, color.White))))
+
+		assert.NoError(t, err)
+		created := mockMediaRepository.Calls[0].Arguments.Get(0).(*models.ProductMedia)
+		assert.Equal(t, "image/jpeg", created.ContentType)
+		assert.Regexp(t, `\.jpg$`, created.FileKey)
+		assert.Regexp(t, `_thumb\.jpg$`, created.ThumbnailKey)
+		thumb, err := jpeg.Decode(bytes.NewReader(putContent(t, mockMediaStorage, func(key string) bool { return key == created.ThumbnailKey })))
+		require.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 100, 50), thumb.Bounds())
+	})
+
+	t.Run("should reject content that isn't an image", func(t *testing.T) {
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("GetByIDFromPrimary", 1).Return(mocks.MockProducts[0], nil)
+		mockMediaStorage := &mocks.MockMediaStorage{}
+
+		mediaService := NewMediaService(&mocks.MockMediaRepository{}, mockProductRepository, mockMediaStorage, options)
+		_, err := mediaService.UploadMedia(1, "front.png", strings.NewReader("<html><body>hello</body></html>"))
+
+		assert.ErrorIs(t, err, models.ErrUnsupportedMedia)
+		assert.EqualError(t, err, "unsupported media text/html; charset=utf-8: it must be a JPEG, PNG or GIF image")
+		mockMediaStorage.AssertNotCalled(t, "Put", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should reject a truncated image", func(t *testing.T) {
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("GetByIDFromPrimary", 1).Return(mocks.MockProducts[0], nil)
+		content := encodePNG(t, solidImage(100, 100, color.White))
+
+		mediaService := NewMediaService(&mocks.MockMediaRepository{}, mockProductRepository, &mocks.MockMediaStorage{}, options)
+		_, err := mediaService.UploadMedia(1, "front.png", bytes.NewReader(content[:len(content)/2]))
+
+		assert.ErrorIs(t, err, models.ErrUnsupportedMedia)
+	})
+
+	t.Run("should reject files over the size limit", func(t *testing.T) {
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("GetByIDFromPrimary", 1).Return(mocks.MockProducts[0], nil)
+		content := encodePNG(t, solidImage(10, 10, color.White))
+
+		mediaService := NewMediaService(&mocks.MockMediaRepository{}, mockProductRepository, &mocks.MockMediaStorage{}, MediaOptions{MaxSize: int64(len(content) - 1), ThumbnailSize: 256})
+		_, err := mediaService.UploadMedia(1, "front.png", bytes.NewReader(content))
+
+		assert.ErrorIs(t, err, models.ErrMediaTooLarge)
+	})
+
+	t.Run("should not read the upload of a missing product", func(t *testing.T) {
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("GetByIDFromPrimary", 9).Return(nil, gorm.ErrRecordNotFound)
+
+		mediaService := NewMediaService(&mocks.MockMediaRepository{}, mockProductRepository, &mocks.MockMediaStorage{}, options)
+		_, err := mediaService.UploadMedia(9, "front.png", strings.NewReader("image"))
+
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+
+	t.Run("should delete the files when the media can't be added", func(t *testing.T) {
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("GetByIDFromPrimary", 1).Return(mocks.MockProducts[0], nil)
+		mockMediaStorage := &mocks.MockMediaStorage{}
+		mockMediaStorage.On("Put", mock.Anything, mock.Anything).Return(nil)
+		mockMediaStorage.On("Delete", mock.Anything).Return(nil)
+		mockMediaRepository := &mocks.MockMediaRepository{}
+		mockMediaRepository.On("Create", mock.Anything).Return(nil, errors.New("some error"))
+
+		mediaService := NewMediaService(mockMediaRepository, mockProductRepository, mockMediaStorage, options)
+		_, err := mediaService.UploadMedia(1, "front.png", bytes.NewReader(encodePNG(t, solidImage(10, 10, color.White))))
+
+		assert.Error(t, err)
+		created := mockMediaRepository.Calls[0].Arguments.Get(0).(*models.ProductMedia)
+		mockMediaStorage.AssertCalled(t, "Delete", created.FileKey)
+		mockMediaStorage.AssertCalled(t, "Delete", created.ThumbnailKey)
+	})
+}
+
+func TestDeleteMedia(t *testing.T) {
+	t.Run("should delete the media, then its files", func(t *testing.T) {
+		mockMediaRepository := &mocks.MockMediaRepository{}
+		mockMediaRepository.On("GetByID", 1, 1).Return(mocks.MockMedia, nil)
+		mockMediaRepository.On("Delete", 1, 1).Return(nil)
+		mockMediaStorage := &mocks.MockMediaStorage{}
+		mockMediaStorage.On("Delete", mock.Anything).Return(nil)
+
+		mediaService := NewMediaService(mockMediaRepository, &mocks.MockProductRepository{}, mockMediaStorage, MediaOptions{})
+		err := mediaService.DeleteMedia(1, 1)
+
+		assert.NoError(t, err)
+		mockMediaStorage.AssertCalled(t, "Delete", mocks.MockMedia.FileKey)
+		mockMediaStorage.AssertCalled(t, "Delete", mocks.MockMedia.ThumbnailKey)
+	})
+
+	t.Run("should keep the files when the media can't be deleted", func(t *testing.T) {
+		mockMediaRepository := &mocks.MockMediaRepository{}
+		mockMediaRepository.On("GetByID", 1, 1).Return(mocks.MockMedia, nil)
+		mockMediaRepository.On("Delete", 1, 1).Return(errors.New("some error"))
+		mockMediaStorage := &mocks.MockMediaStorage{}
+
+		mediaService := NewMediaService(mockMediaRepository, &mocks.MockProductRepository{}, mockMediaStorage, MediaOptions{})
+		err := mediaService.DeleteMedia(1, 1)
+
+		assert.Error(t, err)
+		mockMediaStorage.AssertNotCalled(t, "Delete", mock.Anything)
+	})
+}
+
+func TestThumbnail(t *testing.T) {
+	t.Run("should fit the image in the square, keeping its aspect ratio", func(t *testing.T) {
+		assert.Equal(t, image.Rect(0, 0, 128, 256), thumbnail(solidImage(300, 600, color.White), 256).Bounds())
+		assert.Equal(t, image.Rect(0, 0, 256, 1), thumbnail(solidImage(1000, 2, color.White), 256).Bounds())
+	})
+
+	t.Run("should keep smaller images as they are", func(t *testing.T) {
+		img := solidImage(20, 10, color.White)
+		img.Set(3, 4, color.Black)
+
+		thumb := thumbnail(img, 256)
+
+		assert.Equal(t, img.Bounds(), thumb.Bounds())
+		assert.Equal(t, img.Pix, thumb.Pix)
+	})
+
+	t.Run("should average the pixels it scales down", func(t *testing.T) {
+		img := solidImage(4, 2, color.White)
+		img.Set(0, 0, color.Black)
+		img.Set(1, 0, color.Black)
+		img.Set(0, 1, color.Black)
+		img.Set(1, 1, color.Black)
+
+		thumb := thumbnail(img, 2)
+
+		assert.Equal(t, image.Rect(0, 0, 2, 1), thumb.Bounds())
+		assert.Equal(t, color.NRGBA{A: 255}, thumb.NRGBAAt(0, 0))
+		assert.Equal(t, color.NRGBA{R: 255, G: 255, B: 255, A: 255}, thumb.NRGBAAt(1, 0))
+	})
+}
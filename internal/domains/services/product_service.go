@@ -1,23 +1,72 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
 	"github.com/adrianosiqe/eulabs-challenge-api/internal/core/interfaces"
 	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"gorm.io/gorm"
 )
 
+// ProductOptions configures the rules of the product service.
+type ProductOptions struct {
+	// SKUPattern is matched by every SKU; nil accepts any SKU.
+	SKUPattern *regexp.Regexp
+}
+
 type ProductService struct {
-	productRepository interfaces.ProductRespositoryInterface
+	productRepository   interfaces.ProductRespositoryInterface
+	categoryRepository  interfaces.CategoryRepositoryInterface
+	attributeRepository interfaces.AttributeRepositoryInterface
+	mediaStorage        interfaces.MediaStorageInterface
+	options             ProductOptions
 }
 
-func NewProductService(productRepository interfaces.ProductRespositoryInterface) *ProductService {
-	return &ProductService{productRepository: productRepository}
+func NewProductService(productRepository interfaces.ProductRespositoryInterface, categoryRepository interfaces.CategoryRepositoryInterface, attributeRepository interfaces.AttributeRepositoryInterface, options ProductOptions) *ProductService {
+	return &ProductService{productRepository: productRepository, categoryRepository: categoryRepository, attributeRepository: attributeRepository, options: options}
 }
 
-func (s *ProductService) GetAllProducts() ([]*models.Product, error) {
-	return s.productRepository.GetAll()
+// GetAllProducts lists the products. Filtering by a category includes its
+// descendants, and an unknown category has no products. The attribute filters
+// are parsed against the attribute definitions.
+func (s *ProductService) GetAllProducts(filter models.ProductFilter) ([]*models.Product, error) {
+	if len(filter.AttributeQuery) > 0 {
+		definitions, err := s.attributeDefinitions()
+		if err != nil {
+			return nil, err
+		}
+		filter.Attributes, err = models.ParseAttributeConditions(filter.AttributeQuery, definitions)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if filter.Category > 0 {
+		ids, err := s.categoryRepository.DescendantIDs(filter.Category)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return []*models.Product{}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		filter.CategoryIDs = ids
+	}
+	return s.productRepository.GetAll(filter)
 }
 
+// CreateProduct creates the product as a draft, checking its attributes
+// against their definitions.
 func (s *ProductService) CreateProduct(product *models.Product) (*models.Product, error) {
+	product.Status, product.PublishedAt = models.ProductDraft, nil
+	if err := s.checkSKU(product); err != nil {
+		return nil, err
+	}
+	if err := s.checkAttributes(product); err != nil {
+		return nil, err
+	}
 	return s.productRepository.Create(product)
 }
 
@@ -25,10 +74,250 @@ func (s *ProductService) GetProductByID(id int) (*models.Product, error) {
 	return s.productRepository.GetByID(id)
 }
 
-func (s *ProductService) UpdateProduct(product *models.Product) (*models.Product, error) {
-	return s.productRepository.Update(product)
+// GetProductForUpdate returns the product from the primary, for the reads
+// whose result is written back with UpdateProduct.
+func (s *ProductService) GetProductForUpdate(id int) (*models.Product, error) {
+	return s.productRepository.GetByIDFromPrimary(id)
+}
+
+func (s *ProductService) GetProductBySKU(sku string) (*models.Product, error) {
+	return s.productRepository.GetBySKU(sku)
+}
+
+func (s *ProductService) GetProductBySlug(slug string) (*models.Product, error) {
+	return s.productRepository.GetBySlug(slug)
+}
+
+// UpdateProduct saves the product, recording a change of its price with
+// audit. Its attributes replace the stored ones, unless they are nil; the
+// ones decoded from the request are checked against their definitions.
+func (s *ProductService) UpdateProduct(product *models.Product, audit models.Audit) (*models.Product, error) {
+	if err := s.checkSKU(product); err != nil {
+		return nil, err
+	}
+	if err := s.checkAttributes(product); err != nil {
+		return nil, err
+	}
+	audit.Reason = strings.TrimSpace(audit.Reason)
+	return s.productRepository.Update(product, audit)
+}
+
+// GetPriceHistory returns the price of a product with its changes in the range
+// of filter.
+func (s *ProductService) GetPriceHistory(id int, filter models.PriceHistoryFilter) (*models.PriceHistory, error) {
+	product, err := s.productRepository.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := s.productRepository.GetPriceHistory(id, filter)
+	if err != nil {
+		return nil, err
+	}
+	return &models.PriceHistory{
+		Price:          product.Price,
+		Currency:       product.Money().Currency,
+		LowestPrice30d: product.LowestPrice30d,
+		Changes:        changes,
+	}, nil
+}
+
+// checkSKU trims the SKU of product, dropping it when blank, and matches it
+// against the configured pattern.
+func (s *ProductService) checkSKU(product *models.Product) error {
+	sku, err := normalizeSKU(product.SKU, s.options.SKUPattern)
+	product.SKU = sku
+	return err
+}
+
+// checkAttributes checks the attributes of product decoded from a request
+// against their definitions, which are only read when there are some, and
+// normalizes their values.
+func (s *ProductService) checkAttributes(product *models.Product) error {
+	if product.Attributes == nil {
+		return nil
+	}
+	var definitions map[string]models.AttributeDefinition
+	if product.Attributes.Decoded() {
+		var err error
+		if definitions, err = s.attributeDefinitions(); err != nil {
+			return err
+		}
+	}
+	attributes, err := models.NormalizeAttributes(product.Attributes, definitions)
+	if err != nil {
+		return err
+	}
+	product.Attributes = attributes
+	return nil
+}
+
+// attributeDefinitions returns the attribute definitions keyed by attribute.
+func (s *ProductService) attributeDefinitions() (map[string]models.AttributeDefinition, error) {
+	all, err := s.attributeRepository.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	definitions := make(map[string]models.AttributeDefinition, len(all))
+	for _, definition := range all {
+		definitions[definition.Key] = *definition
+	}
+	return definitions, nil
+}
+
+// normalizeSKU trims sku, returning nil when it is blank, and matches it
+// against pattern unless nil.
+func normalizeSKU(sku *string, pattern *regexp.Regexp) (*string, error) {
+	if sku == nil {
+		return nil, nil
+	}
+
+	trimmed := strings.TrimSpace(*sku)
+	if trimmed == "" {
+		return nil, nil
+	}
+	if pattern != nil && !pattern.MatchString(trimmed) {
+		return &trimmed, fmt.Errorf("%w %q: it must match %s", models.ErrInvalidSKU, trimmed, pattern)
+	}
+	return &trimmed, nil
 }
 
 func (s *ProductService) DeleteProduct(id int) error {
 	return s.productRepository.Delete(id)
 }
+
+// WithMedia makes PurgeProduct delete the files of the media of the product,
+// whose metadata goes with it.
+func (s *ProductService) WithMedia(mediaStorage interfaces.MediaStorageInterface) *ProductService {
+	s.mediaStorage = mediaStorage
+	return s
+}
+
+// PurgeProduct permanently removes a product, deleted or not, with everything
+// linked to it.
+func (s *ProductService) PurgeProduct(id int) error {
+	media, err := s.productRepository.Purge(id)
+	if err != nil || s.mediaStorage == nil {
+		return err
+	}
+
+	// The files are only deleted once the product is gone, so that a
+	// failure doesn't leave media without them.
+	for _, m := range media {
+		for _, key := range []string{m.FileKey, m.ThumbnailKey} {
+			if err := s.mediaStorage.Delete(key); err != nil {
+				return fmt.Errorf("deleting the media file %s: %w", key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// RestoreProduct brings back a deleted product and returns it.
+func (s *ProductService) RestoreProduct(id int) (*models.Product, error) {
+	if err := s.productRepository.Restore(id); err != nil {
+		return nil, err
+	}
+	return s.productRepository.GetByIDFromPrimary(id)
+}
+
+// TransitionProduct moves a product to status and returns it. It returns
+// models.ErrInvalidTransition when the product can't go to status from the
+// one it has.
+func (s *ProductService) TransitionProduct(id int, status models.ProductStatus) (*models.Product, error) {
+	product, err := s.productRepository.GetByIDFromPrimary(id)
+	if err != nil {
+		return nil, err
+	}
+
+	from := product.Status
+	if err := product.Transition(status, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := s.productRepository.UpdatePublication(product, from); err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// ScheduleProduct replaces the scheduled transitions of a product and returns
+// it.
+func (s *ProductService) ScheduleProduct(id int, schedule models.ProductSchedule) (*models.Product, error) {
+	if err := schedule.Validate(); err != nil {
+		return nil, err
+	}
+
+	product, err := s.productRepository.GetByIDFromPrimary(id)
+	if err != nil {
+		return nil, err
+	}
+
+	product.PublishAt, product.UnpublishAt = schedule.PublishAt, schedule.UnpublishAt
+	if err := s.productRepository.UpdatePublication(product, product.Status); err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// SetProductCategories replaces the categories of a product and returns it.
+func (s *ProductService) SetProductCategories(id int, categoryIDs []uint) (*models.Product, error) {
+	categories := []models.Category{}
+	seen := map[uint]bool{}
+	for _, categoryID := range categoryIDs {
+		if seen[categoryID] {
+			continue
+		}
+		seen[categoryID] = true
+
+		category, err := s.categoryRepository.GetByID(int(categoryID))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUnknownCategory
+		}
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, *category)
+	}
+
+	if err := s.productRepository.SetCategories(id, categories); err != nil {
+		return nil, err
+	}
+	return s.productRepository.GetByID(id)
+}
+
+// SetProductOptions normalizes the options, replaces the ones of a product
+// with them and returns it.
+func (s *ProductService) SetProductOptions(id int, options []models.ProductOption) (*models.Product, error) {
+	options, err := models.NormalizeOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.productRepository.SetOptions(id, options); err != nil {
+		return nil, err
+	}
+	return s.productRepository.GetByID(id)
+}
+
+// AddProductTags normalizes the tags, adds them to a product and returns it.
+func (s *ProductService) AddProductTags(id int, names []string) (*models.Product, error) {
+	names, err := models.NormalizeTags(names)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.productRepository.AddTags(id, names); err != nil {
+		return nil, err
+	}
+	return s.productRepository.GetByID(id)
+}
+
+// RemoveProductTag removes a tag from a product and returns it. Removing a tag
+// the product doesn't have is not an error.
+func (s *ProductService) RemoveProductTag(id int, name string) (*models.Product, error) {
+	name, err := models.NormalizeTag(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.productRepository.RemoveTags(id, []string{name}); err != nil {
+		return nil, err
+	}
+	return s.productRepository.GetByID(id)
+}
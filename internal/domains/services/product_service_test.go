@@ -2,20 +2,25 @@ package services
 
 import (
 	"fmt"
+	"regexp"
 	"testing"
+	"time"
 
 	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
 	"github.com/adrianosiqe/eulabs-challenge-api/internal/mocks"
+	"github.com/adrianosiqe/eulabs-challenge-api/pkg/money"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
 )
 
 func TestGetAllProducts(t *testing.T) {
 	t.Run("should return a list the products", func(t *testing.T) {
 		mockProductRepository := &mocks.MockProductRepository{}
-		mockProductRepository.On("GetAll").Return(mocks.MockProducts, nil)
+		mockProductRepository.On("GetAll", models.ProductFilter{}).Return(mocks.MockProducts, nil)
 
-		productService := NewProductService(mockProductRepository)
-		products, err := productService.GetAllProducts()
+		productService := NewProductService(mockProductRepository, &mocks.MockCategoryRepository{}, &mocks.MockAttributeRepository{}, ProductOptions{})
+		products, err := productService.GetAllProducts(models.ProductFilter{})
 
 		assert.NoError(t, err)
 		assert.True(t, len(products) == 2)
@@ -33,10 +38,10 @@ func TestGetAllProducts(t *testing.T) {
 	t.Run("should return an empty list", func(t *testing.T) {
 		var mockEmptyProducts []*models.Product
 		mockProductRepository := &mocks.MockProductRepository{}
-		mockProductRepository.On("GetAll").Return(mockEmptyProducts, nil)
+		mockProductRepository.On("GetAll", models.ProductFilter{}).Return(mockEmptyProducts, nil)
 
-		productService := NewProductService(mockProductRepository)
-		products, err := productService.GetAllProducts()
+		productService := NewProductService(mockProductRepository, &mocks.MockCategoryRepository{}, &mocks.MockAttributeRepository{}, ProductOptions{})
+		products, err := productService.GetAllProducts(models.ProductFilter{})
 
 		assert.NoError(t, err)
 		assert.Empty(t, products)
@@ -45,10 +50,10 @@ func TestGetAllProducts(t *testing.T) {
 
 	t.Run("should return an error", func(t *testing.T) {
 		mockProductRepository := &mocks.MockProductRepository{}
-		mockProductRepository.On("GetAll").Return(nil, fmt.Errorf("some error"))
+		mockProductRepository.On("GetAll", models.ProductFilter{}).Return(nil, fmt.Errorf("some error"))
 
-		productService := NewProductService(mockProductRepository)
-		_, err := productService.GetAllProducts()
+		productService := NewProductService(mockProductRepository, &mocks.MockCategoryRepository{}, &mocks.MockAttributeRepository{}, ProductOptions{})
+		_, err := productService.GetAllProducts(models.ProductFilter{})
 
 		assert.Error(t, err)
 		mockProductRepository.AssertExpectations(t)
@@ -62,7 +67,7 @@ func TestCreateProducts(t *testing.T) {
 		mockProductRepository := &mocks.MockProductRepository{}
 		mockProductRepository.On("Create", &mockCreateProduct).Return(mocks.MockProducts[0], nil)
 
-		productService := NewProductService(mockProductRepository)
+		productService := NewProductService(mockProductRepository, &mocks.MockCategoryRepository{}, &mocks.MockAttributeRepository{}, ProductOptions{})
 		product, err := productService.CreateProduct(&mockCreateProduct)
 
 		assert.NoError(t, err)
@@ -73,11 +78,26 @@ func TestCreateProducts(t *testing.T) {
 		mockProductRepository.AssertExpectations(t)
 	})
 
+	t.Run("should create a draft whatever the status", func(t *testing.T) {
+		publishedAt := time.Now()
+		product := models.Product{Title: "Bulbasaur", Status: models.ProductPublished, PublishedAt: &publishedAt}
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("Create", mock.MatchedBy(func(p *models.Product) bool {
+			return p.Status == models.ProductDraft && p.PublishedAt == nil
+		})).Return(&product, nil)
+
+		productService := NewProductService(mockProductRepository, &mocks.MockCategoryRepository{}, &mocks.MockAttributeRepository{}, ProductOptions{})
+		_, err := productService.CreateProduct(&product)
+
+		assert.NoError(t, err)
+		mockProductRepository.AssertExpectations(t)
+	})
+
 	t.Run("should return an error", func(t *testing.T) {
 		mockProductRepository := &mocks.MockProductRepository{}
 		mockProductRepository.On("Create", &mockCreateProduct).Return(nil, fmt.Errorf("some error"))
 
-		productService := NewProductService(mockProductRepository)
+		productService := NewProductService(mockProductRepository, &mocks.MockCategoryRepository{}, &mocks.MockAttributeRepository{}, ProductOptions{})
 		_, err := productService.CreateProduct(&mockCreateProduct)
 
 		assert.Error(t, err)
@@ -90,7 +110,7 @@ func TestGetProductByID(t *testing.T) {
 		mockProductRepository := &mocks.MockProductRepository{}
 		mockProductRepository.On("GetByID", 1).Return(mocks.MockProducts[0], nil)
 
-		productService := NewProductService(mockProductRepository)
+		productService := NewProductService(mockProductRepository, &mocks.MockCategoryRepository{}, &mocks.MockAttributeRepository{}, ProductOptions{})
 		product, err := productService.GetProductByID(1)
 
 		assert.NoError(t, err)
@@ -105,7 +125,7 @@ func TestGetProductByID(t *testing.T) {
 		mockProductRepository := &mocks.MockProductRepository{}
 		mockProductRepository.On("GetByID", 1).Return(nil, fmt.Errorf("some error"))
 
-		productService := NewProductService(mockProductRepository)
+		productService := NewProductService(mockProductRepository, &mocks.MockCategoryRepository{}, &mocks.MockAttributeRepository{}, ProductOptions{})
 		_, err := productService.GetProductByID(1)
 
 		assert.Error(t, err)
@@ -116,10 +136,10 @@ func TestGetProductByID(t *testing.T) {
 func TestUpdateProduct(t *testing.T) {
 	t.Run("should return the product", func(t *testing.T) {
 		mockProductRepository := &mocks.MockProductRepository{}
-		mockProductRepository.On("Update", mocks.MockProducts[0]).Return(mocks.MockProducts[0], nil)
+		mockProductRepository.On("Update", mocks.MockProducts[0], models.Audit{}).Return(mocks.MockProducts[0], nil)
 
-		productService := NewProductService(mockProductRepository)
-		product, err := productService.UpdateProduct(mocks.MockProducts[0])
+		productService := NewProductService(mockProductRepository, &mocks.MockCategoryRepository{}, &mocks.MockAttributeRepository{}, ProductOptions{})
+		product, err := productService.UpdateProduct(mocks.MockProducts[0], models.Audit{})
 
 		assert.NoError(t, err)
 		assert.Equal(t, mocks.MockProducts[0].ID, product.ID)
@@ -131,22 +151,56 @@ func TestUpdateProduct(t *testing.T) {
 
 	t.Run("should return an error", func(t *testing.T) {
 		mockProductRepository := &mocks.MockProductRepository{}
-		mockProductRepository.On("Update", mocks.MockProducts[0]).Return(nil, fmt.Errorf("some error"))
+		mockProductRepository.On("Update", mocks.MockProducts[0], models.Audit{}).Return(nil, fmt.Errorf("some error"))
 
-		productService := NewProductService(mockProductRepository)
-		_, err := productService.UpdateProduct(mocks.MockProducts[0])
+		productService := NewProductService(mockProductRepository, &mocks.MockCategoryRepository{}, &mocks.MockAttributeRepository{}, ProductOptions{})
+		_, err := productService.UpdateProduct(mocks.MockProducts[0], models.Audit{})
 
 		assert.Error(t, err)
 		mockProductRepository.AssertExpectations(t)
 	})
 }
 
+func TestGetPriceHistory(t *testing.T) {
+	t.Run("should return the price of the product with its changes", func(t *testing.T) {
+		product := &models.Product{ID: 1, Price: money.MustParseAmount("80"), LowestPrice30d: money.MustParseAmount("75")}
+		changes := []*models.PriceChange{{ID: 1, ProductID: 1, OldPrice: money.MustParseAmount("100"), NewPrice: money.MustParseAmount("80")}}
+		since := time.Now().Add(-time.Hour)
+		filter := models.PriceHistoryFilter{Since: &since}
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("GetByID", 1).Return(product, nil)
+		mockProductRepository.On("GetPriceHistory", 1, filter).Return(changes, nil)
+
+		productService := NewProductService(mockProductRepository, &mocks.MockCategoryRepository{}, &mocks.MockAttributeRepository{}, ProductOptions{})
+		history, err := productService.GetPriceHistory(1, filter)
+
+		assert.NoError(t, err)
+		assert.Equal(t, &models.PriceHistory{
+			Price:          money.MustParseAmount("80"),
+			Currency:       money.DefaultCurrency,
+			LowestPrice30d: money.MustParseAmount("75"),
+			Changes:        changes,
+		}, history)
+	})
+
+	t.Run("should return an error for a missing product", func(t *testing.T) {
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("GetByID", 9).Return(nil, gorm.ErrRecordNotFound)
+
+		productService := NewProductService(mockProductRepository, &mocks.MockCategoryRepository{}, &mocks.MockAttributeRepository{}, ProductOptions{})
+		_, err := productService.GetPriceHistory(9, models.PriceHistoryFilter{})
+
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		mockProductRepository.AssertNotCalled(t, "GetPriceHistory", mock.Anything, mock.Anything)
+	})
+}
+
 func TestDeleteProduct(t *testing.T) {
 	t.Run("should return nil", func(t *testing.T) {
 		mockProductRepository := &mocks.MockProductRepository{}
 		mockProductRepository.On("Delete", 1).Return(nil)
 
-		productService := NewProductService(mockProductRepository)
+		productService := NewProductService(mockProductRepository, &mocks.MockCategoryRepository{}, &mocks.MockAttributeRepository{}, ProductOptions{})
 		err := productService.DeleteProduct(1)
 
 		assert.NoError(t, err)
@@ -157,10 +211,424 @@ func TestDeleteProduct(t *testing.T) {
 		mockProductRepository := &mocks.MockProductRepository{}
 		mockProductRepository.On("Delete", 1).Return(fmt.Errorf("some error"))
 
-		productService := NewProductService(mockProductRepository)
+		productService := NewProductService(mockProductRepository, &mocks.MockCategoryRepository{}, &mocks.MockAttributeRepository{}, ProductOptions{})
 		err := productService.DeleteProduct(1)
 
 		assert.Error(t, err)
 		mockProductRepository.AssertExpectations(t)
 	})
 }
+
+func TestPurgeProduct(t *testing.T) {
+	t.Run("should delete the files of the media", func(t *testing.T) {
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("Purge", 1).Return([]*models.ProductMedia{{ID: 1, ProductID: 1, FileKey: "products/1/a.png", ThumbnailKey: "products/1/a_thumb.png"}}, nil)
+		mockMediaStorage := &mocks.MockMediaStorage{}
+		mockMediaStorage.On("Delete", "products/1/a.png").Return(nil)
+		mockMediaStorage.On("Delete", "products/1/a_thumb.png").Return(nil)
+
+		productService := NewProductService(mockProductRepository, &mocks.MockCategoryRepository{}, &mocks.MockAttributeRepository{}, ProductOptions{}).WithMedia(mockMediaStorage)
+		err := productService.PurgeProduct(1)
+
+		assert.NoError(t, err)
+		mockProductRepository.AssertExpectations(t)
+		mockMediaStorage.AssertExpectations(t)
+	})
+
+	t.Run("should not delete files when the product is not purged", func(t *testing.T) {
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("Purge", 1).Return(nil, gorm.ErrRecordNotFound)
+		mockMediaStorage := &mocks.MockMediaStorage{}
+
+		productService := NewProductService(mockProductRepository, &mocks.MockCategoryRepository{}, &mocks.MockAttributeRepository{}, ProductOptions{}).WithMedia(mockMediaStorage)
+		err := productService.PurgeProduct(1)
+
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		mockMediaStorage.AssertNotCalled(t, "Delete", mock.Anything)
+	})
+}
+
+func TestRestoreProduct(t *testing.T) {
+	t.Run("should return the restored product", func(t *testing.T) {
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("Restore", 1).Return(nil)
+		mockProductRepository.On("GetByIDFromPrimary", 1).Return(mocks.MockProducts[0], nil)
+
+		productService := NewProductService(mockProductRepository, &mocks.MockCategoryRepository{}, &mocks.MockAttributeRepository{}, ProductOptions{})
+		product, err := productService.RestoreProduct(1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, mocks.MockProducts[0], product)
+		mockProductRepository.AssertExpectations(t)
+	})
+
+	t.Run("should return an error", func(t *testing.T) {
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("Restore", 1).Return(gorm.ErrRecordNotFound)
+
+		productService := NewProductService(mockProductRepository, &mocks.MockCategoryRepository{}, &mocks.MockAttributeRepository{}, ProductOptions{})
+		_, err := productService.RestoreProduct(1)
+
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		mockProductRepository.AssertExpectations(t)
+	})
+}
+
+func TestGetAllProductsByCategory(t *testing.T) {
+	t.Run("should include the subcategories", func(t *testing.T) {
+		mockCategoryRepository := &mocks.MockCategoryRepository{}
+		mockCategoryRepository.On("DescendantIDs", 1).Return([]uint{1, 2}, nil)
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("GetAll", models.ProductFilter{Category: 1, CategoryIDs: []uint{1, 2}}).Return(mocks.MockProducts, nil)
+
+		productService := NewProductService(mockProductRepository, mockCategoryRepository, &mocks.MockAttributeRepository{}, ProductOptions{})
+		products, err := productService.GetAllProducts(models.ProductFilter{Category: 1})
+
+		assert.NoError(t, err)
+		assert.Len(t, products, 2)
+		mockProductRepository.AssertExpectations(t)
+	})
+
+	t.Run("should return an empty list for an unknown category", func(t *testing.T) {
+		mockCategoryRepository := &mocks.MockCategoryRepository{}
+		mockCategoryRepository.On("DescendantIDs", 42).Return(nil, gorm.ErrRecordNotFound)
+		mockProductRepository := &mocks.MockProductRepository{}
+
+		productService := NewProductService(mockProductRepository, mockCategoryRepository, &mocks.MockAttributeRepository{}, ProductOptions{})
+		products, err := productService.GetAllProducts(models.ProductFilter{Category: 42})
+
+		assert.NoError(t, err)
+		assert.Empty(t, products)
+		mockProductRepository.AssertNotCalled(t, "GetAll", mock.Anything)
+	})
+}
+
+func TestSetProductCategories(t *testing.T) {
+	t.Run("should replace the categories once each", func(t *testing.T) {
+		mockCategoryRepository := &mocks.MockCategoryRepository{}
+		mockCategoryRepository.On("GetByID", 2).Return(mocks.MockCategories[1], nil).Once()
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("SetCategories", 1, []models.Category{*mocks.MockCategories[1]}).Return(nil)
+		mockProductRepository.On("GetByID", 1).Return(mocks.MockProducts[0], nil)
+
+		productService := NewProductService(mockProductRepository, mockCategoryRepository, &mocks.MockAttributeRepository{}, ProductOptions{})
+		product, err := productService.SetProductCategories(1, []uint{2, 2})
+
+		assert.NoError(t, err)
+		assert.Equal(t, mocks.MockProducts[0].ID, product.ID)
+		mockCategoryRepository.AssertExpectations(t)
+		mockProductRepository.AssertExpectations(t)
+	})
+
+	t.Run("should return an error for an unknown category", func(t *testing.T) {
+		mockCategoryRepository := &mocks.MockCategoryRepository{}
+		mockCategoryRepository.On("GetByID", 42).Return(nil, gorm.ErrRecordNotFound)
+		mockProductRepository := &mocks.MockProductRepository{}
+
+		productService := NewProductService(mockProductRepository, mockCategoryRepository, &mocks.MockAttributeRepository{}, ProductOptions{})
+		_, err := productService.SetProductCategories(1, []uint{42})
+
+		assert.ErrorIs(t, err, ErrUnknownCategory)
+		mockProductRepository.AssertNotCalled(t, "SetCategories", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAddProductTags(t *testing.T) {
+	t.Run("should add the normalized tags", func(t *testing.T) {
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("AddTags", 1, []string{"fire-type", "new"}).Return(nil)
+		mockProductRepository.On("GetByID", 1).Return(mocks.MockProducts[0], nil)
+
+		productService := NewProductService(mockProductRepository, &mocks.MockCategoryRepository{}, &mocks.MockAttributeRepository{}, ProductOptions{})
+		product, err := productService.AddProductTags(1, []string{" Fire Type", "fire_type", "NEW"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, mocks.MockProducts[0].ID, product.ID)
+		mockProductRepository.AssertExpectations(t)
+	})
+
+	t.Run("should return an error for an invalid tag", func(t *testing.T) {
+		mockProductRepository := &mocks.MockProductRepository{}
+
+		productService := NewProductService(mockProductRepository, &mocks.MockCategoryRepository{}, &mocks.MockAttributeRepository{}, ProductOptions{})
+		_, err := productService.AddProductTags(1, []string{"new", "50% off"})
+
+		assert.ErrorIs(t, err, models.ErrInvalidTag)
+		mockProductRepository.AssertNotCalled(t, "AddTags", mock.Anything, mock.Anything)
+	})
+}
+
+func TestSetProductOptions(t *testing.T) {
+	t.Run("should set the trimmed options in order", func(t *testing.T) {
+		normalized := []models.ProductOption{
+			{Name: "Size", Values: models.OptionValues{"S", "M"}, Position: 0},
+			{Name: "Color", Values: models.OptionValues{"Red"}, Position: 1},
+		}
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("SetOptions", 1, normalized).Return(nil)
+		mockProductRepository.On("GetByID", 1).Return(mocks.MockProducts[0], nil)
+
+		productService := NewProductService(mockProductRepository, &mocks.MockCategoryRepository{}, &mocks.MockAttributeRepository{}, ProductOptions{})
+		_, err := productService.SetProductOptions(1, []models.ProductOption{
+			{Name: " Size ", Values: models.OptionValues{"S", " M"}},
+			{Name: "Color", Values: models.OptionValues{"Red"}},
+		})
+
+		assert.NoError(t, err)
+		mockProductRepository.AssertExpectations(t)
+	})
+
+	t.Run("should reject repeated values", func(t *testing.T) {
+		mockProductRepository := &mocks.MockProductRepository{}
+
+		productService := NewProductService(mockProductRepository, &mocks.MockCategoryRepository{}, &mocks.MockAttributeRepository{}, ProductOptions{})
+		_, err := productService.SetProductOptions(1, []models.ProductOption{{Name: "Size", Values: models.OptionValues{"S", "s"}}})
+
+		assert.ErrorIs(t, err, models.ErrInvalidOptions)
+		mockProductRepository.AssertNotCalled(t, "SetOptions", mock.Anything, mock.Anything)
+	})
+}
+
+func TestRemoveProductTag(t *testing.T) {
+	t.Run("should remove the normalized tag", func(t *testing.T) {
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("RemoveTags", 1, []string{"fire-type"}).Return(nil)
+		mockProductRepository.On("GetByID", 1).Return(mocks.MockProducts[0], nil)
+
+		productService := NewProductService(mockProductRepository, &mocks.MockCategoryRepository{}, &mocks.MockAttributeRepository{}, ProductOptions{})
+		_, err := productService.RemoveProductTag(1, "Fire Type")
+
+		assert.NoError(t, err)
+		mockProductRepository.AssertExpectations(t)
+	})
+
+	t.Run("should return not found for a missing product", func(t *testing.T) {
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("RemoveTags", 42, []string{"new"}).Return(gorm.ErrRecordNotFound)
+
+		productService := NewProductService(mockProductRepository, &mocks.MockCategoryRepository{}, &mocks.MockAttributeRepository{}, ProductOptions{})
+		_, err := productService.RemoveProductTag(42, "new")
+
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+}
+
+func TestProductSKU(t *testing.T) {
+	options := ProductOptions{SKUPattern: regexp.MustCompile(`^[A-Z0-9][A-Z0-9-]{2,63}$`)}
+
+	t.Run("should trim the sku before creating the product", func(t *testing.T) {
+		sku := " BULB-001 "
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("Create", mock.Anything).Return(mocks.MockProducts[0], nil)
+
+		productService := NewProductService(mockProductRepository, &mocks.MockCategoryRepository{}, &mocks.MockAttributeRepository{}, options)
+		_, err := productService.CreateProduct(&models.Product{Title: "Bulbasaur", SKU: &sku})
+
+		assert.NoError(t, err)
+		created := mockProductRepository.Calls[0].Arguments.Get(0).(*models.Product)
+		assert.Equal(t, "BULB-001", *created.SKU)
+	})
+
+	t.Run("should drop a blank sku", func(t *testing.T) {
+		sku := "  "
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("Update", mock.Anything, models.Audit{}).Return(mocks.MockProducts[0], nil)
+
+		productService := NewProductService(mockProductRepository, &mocks.MockCategoryRepository{}, &mocks.MockAttributeRepository{}, options)
+		_, err := productService.UpdateProduct(&models.Product{ID: 1, Title: "Bulbasaur", SKU: &sku}, models.Audit{})
+
+		assert.NoError(t, err)
+		updated := mockProductRepository.Calls[0].Arguments.Get(0).(*models.Product)
+		assert.Nil(t, updated.SKU)
+	})
+
+	t.Run("should reject a sku that doesn't match the pattern", func(t *testing.T) {
+		sku := "bulb 001"
+		mockProductRepository := &mocks.MockProductRepository{}
+
+		productService := NewProductService(mockProductRepository, &mocks.MockCategoryRepository{}, &mocks.MockAttributeRepository{}, options)
+		_, err := productService.CreateProduct(&models.Product{Title: "Bulbasaur", SKU: &sku})
+
+		assert.ErrorIs(t, err, models.ErrInvalidSKU)
+		mockProductRepository.AssertNotCalled(t, "Create", mock.Anything)
+	})
+}
+
+func TestTransitionProduct(t *testing.T) {
+	t.Run("should publish a draft", func(t *testing.T) {
+		draft := &models.Product{ID: 1, Title: "Bulbasaur", Status: models.ProductDraft}
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("GetByIDFromPrimary", 1).Return(draft, nil)
+		mockProductRepository.On("UpdatePublication", draft, models.ProductDraft).Return(nil)
+
+		productService := NewProductService(mockProductRepository, &mocks.MockCategoryRepository{}, &mocks.MockAttributeRepository{}, ProductOptions{})
+		product, err := productService.TransitionProduct(1, models.ProductPublished)
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.ProductPublished, product.Status)
+		assert.NotNil(t, product.PublishedAt)
+		mockProductRepository.AssertExpectations(t)
+	})
+
+	t.Run("should clear the publication time of a product back to draft", func(t *testing.T) {
+		publishedAt := time.Now()
+		archived := &models.Product{ID: 1, Status: models.ProductArchived, PublishedAt: &publishedAt}
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("GetByIDFromPrimary", 1).Return(archived, nil)
+		mockProductRepository.On("UpdatePublication", archived, models.ProductArchived).Return(nil)
+
+		productService := NewProductService(mockProductRepository, &mocks.MockCategoryRepository{}, &mocks.MockAttributeRepository{}, ProductOptions{})
+		product, err := productService.TransitionProduct(1, models.ProductDraft)
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.ProductDraft, product.Status)
+		assert.Nil(t, product.PublishedAt)
+	})
+
+	t.Run("should return an invalid transition", func(t *testing.T) {
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("GetByIDFromPrimary", 1).Return(&models.Product{ID: 1, Status: models.ProductDraft}, nil)
+
+		productService := NewProductService(mockProductRepository, &mocks.MockCategoryRepository{}, &mocks.MockAttributeRepository{}, ProductOptions{})
+		_, err := productService.TransitionProduct(1, models.ProductArchived)
+
+		assert.ErrorIs(t, err, models.ErrInvalidTransition)
+		mockProductRepository.AssertNotCalled(t, "UpdatePublication", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should return not found for a missing product", func(t *testing.T) {
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("GetByIDFromPrimary", 42).Return(nil, gorm.ErrRecordNotFound)
+
+		productService := NewProductService(mockProductRepository, &mocks.MockCategoryRepository{}, &mocks.MockAttributeRepository{}, ProductOptions{})
+		_, err := productService.TransitionProduct(42, models.ProductPublished)
+
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+}
+
+func TestScheduleProduct(t *testing.T) {
+	publishAt := time.Now().Add(time.Hour)
+	unpublishAt := publishAt.Add(24 * time.Hour)
+
+	t.Run("should replace the schedule while the status is unchanged", func(t *testing.T) {
+		product := &models.Product{ID: 1, Status: models.ProductDraft, UnpublishAt: &publishAt}
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("GetByIDFromPrimary", 1).Return(product, nil)
+		mockProductRepository.On("UpdatePublication", product, models.ProductDraft).Return(nil)
+
+		productService := NewProductService(mockProductRepository, &mocks.MockCategoryRepository{}, &mocks.MockAttributeRepository{}, ProductOptions{})
+		scheduled, err := productService.ScheduleProduct(1, models.ProductSchedule{PublishAt: &publishAt})
+
+		assert.NoError(t, err)
+		assert.Equal(t, &publishAt, scheduled.PublishAt)
+		assert.Nil(t, scheduled.UnpublishAt)
+		mockProductRepository.AssertExpectations(t)
+	})
+
+	t.Run("should return an error for an archiving before the publication", func(t *testing.T) {
+		mockProductRepository := &mocks.MockProductRepository{}
+
+		productService := NewProductService(mockProductRepository, &mocks.MockCategoryRepository{}, &mocks.MockAttributeRepository{}, ProductOptions{})
+		_, err := productService.ScheduleProduct(1, models.ProductSchedule{PublishAt: &unpublishAt, UnpublishAt: &publishAt})
+
+		assert.ErrorIs(t, err, models.ErrInvalidSchedule)
+		mockProductRepository.AssertNotCalled(t, "GetByID", mock.Anything)
+	})
+}
+
+func TestProductAttributes(t *testing.T) {
+	t.Run("should check and normalize the attributes on create", func(t *testing.T) {
+		product := &models.Product{Title: "Drill", Attributes: models.ProductAttributes{
+			{Key: "material", Value: " steel "},
+			{Key: "weight", Value: 2.5},
+			{Key: "wireless", Value: nil},
+		}}
+		mockAttributeRepository := &mocks.MockAttributeRepository{}
+		mockAttributeRepository.On("GetAll").Return(mocks.MockAttributeDefinitions, nil)
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("Create", product).Return(product, nil)
+
+		productService := NewProductService(mockProductRepository, &mocks.MockCategoryRepository{}, mockAttributeRepository, ProductOptions{})
+		created, err := productService.CreateProduct(product)
+
+		assert.NoError(t, err)
+		assert.Len(t, created.Attributes, 2)
+		assert.Equal(t, "Steel", *created.Attributes[0].StringValue)
+		assert.Equal(t, 2.5, *created.Attributes[1].NumberValue)
+		mockProductRepository.AssertExpectations(t)
+	})
+
+	t.Run("should reject values that don't match their definitions", func(t *testing.T) {
+		for name, attribute := range map[string]models.ProductAttribute{
+			"unknown attribute": {Key: "voltage", Value: 220.0},
+			"wrong type":        {Key: "weight", Value: "heavy"},
+			"out of range":      {Key: "weight", Value: -1.0},
+			"unknown value":     {Key: "material", Value: "Plastic"},
+		} {
+			t.Run(name, func(t *testing.T) {
+				mockAttributeRepository := &mocks.MockAttributeRepository{}
+				mockAttributeRepository.On("GetAll").Return(mocks.MockAttributeDefinitions, nil)
+				mockProductRepository := &mocks.MockProductRepository{}
+
+				productService := NewProductService(mockProductRepository, &mocks.MockCategoryRepository{}, mockAttributeRepository, ProductOptions{})
+				_, err := productService.CreateProduct(&models.Product{Title: "Drill", Attributes: models.ProductAttributes{attribute}})
+
+				assert.ErrorIs(t, err, models.ErrInvalidAttribute)
+				mockProductRepository.AssertNotCalled(t, "Create", mock.Anything)
+			})
+		}
+	})
+
+	t.Run("should keep the stored attributes without reading the definitions", func(t *testing.T) {
+		weight := 2.5
+		product := &models.Product{ID: 1, Title: "Drill", Attributes: models.ProductAttributes{{ProductID: 1, Key: "weight", NumberValue: &weight}}}
+		mockAttributeRepository := &mocks.MockAttributeRepository{}
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("Update", product, models.Audit{}).Return(product, nil)
+
+		productService := NewProductService(mockProductRepository, &mocks.MockCategoryRepository{}, mockAttributeRepository, ProductOptions{})
+		updated, err := productService.UpdateProduct(product, models.Audit{})
+
+		assert.NoError(t, err)
+		assert.Len(t, updated.Attributes, 1)
+		mockAttributeRepository.AssertNotCalled(t, "GetAll")
+	})
+
+	t.Run("should parse the attribute filters", func(t *testing.T) {
+		mockAttributeRepository := &mocks.MockAttributeRepository{}
+		mockAttributeRepository.On("GetAll").Return(mocks.MockAttributeDefinitions, nil)
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("GetAll", mock.MatchedBy(func(filter models.ProductFilter) bool {
+			return len(filter.Attributes) == 2 &&
+				filter.Attributes[0].Attribute.Key == "material" && *filter.Attributes[0].Attribute.StringValue == "Wood" &&
+				filter.Attributes[1].Attribute.Key == "weight" && filter.Attributes[1].Operator == models.AttributeGt && *filter.Attributes[1].Attribute.NumberValue == 2
+		})).Return(mocks.MockProducts, nil)
+
+		productService := NewProductService(mockProductRepository, &mocks.MockCategoryRepository{}, mockAttributeRepository, ProductOptions{})
+		_, err := productService.GetAllProducts(models.ProductFilter{AttributeQuery: map[string]string{"weight_gt": "2", "material": "wood"}})
+
+		assert.NoError(t, err)
+		mockProductRepository.AssertExpectations(t)
+	})
+
+	t.Run("should reject invalid attribute filters", func(t *testing.T) {
+		for _, query := range []map[string]string{
+			{"voltage": "220"},
+			{"weight_gt": "heavy"},
+			{"material_gt": "Steel"},
+			{"weight_between": "2"},
+			{"wireless": "maybe"},
+		} {
+			mockAttributeRepository := &mocks.MockAttributeRepository{}
+			mockAttributeRepository.On("GetAll").Return(mocks.MockAttributeDefinitions, nil)
+			mockProductRepository := &mocks.MockProductRepository{}
+
+			productService := NewProductService(mockProductRepository, &mocks.MockCategoryRepository{}, mockAttributeRepository, ProductOptions{})
+			_, err := productService.GetAllProducts(models.ProductFilter{AttributeQuery: query})
+
+			assert.ErrorIs(t, err, models.ErrInvalidAttribute, query)
+			mockProductRepository.AssertNotCalled(t, "GetAll", mock.Anything)
+		}
+	})
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/core/interfaces"
+)
+
+// SchedulerOptions configures the PublicationScheduler.
+type SchedulerOptions struct {
+	// Interval is how often the due schedules are applied.
+	Interval time.Duration
+	// BatchSize bounds how many products are changed in each transaction.
+	BatchSize int
+}
+
+// PublicationScheduler publishes and archives the products whose publish_at
+// and unpublish_at are due. Every instance of the API can run one: the
+// repository locks the products it changes, so each schedule is applied once.
+type PublicationScheduler struct {
+	productRepository interfaces.ProductRespositoryInterface
+	options           SchedulerOptions
+	now               func() time.Time
+}
+
+func NewPublicationScheduler(productRepository interfaces.ProductRespositoryInterface, options SchedulerOptions) *PublicationScheduler {
+	return &PublicationScheduler{productRepository: productRepository, options: options, now: time.Now}
+}
+
+// Run applies the due schedules right away, catching up on the ones missed
+// while no instance was running, and then every interval until ctx is done.
+func (s *PublicationScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.options.Interval)
+	defer ticker.Stop()
+
+	for {
+		if applied, err := s.ApplyDue(ctx); err != nil {
+			log.Printf("Failed to apply the product schedules: %v", err)
+		} else if applied > 0 {
+			log.Printf("Applied the schedules of %d products", applied)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ApplyDue applies every schedule due by now, a batch at a time, and returns
+// how many products changed. It stops between batches when ctx is done.
+func (s *PublicationScheduler) ApplyDue(ctx context.Context) (int, error) {
+	now := s.now()
+	applied := 0
+	for ctx.Err() == nil {
+		changed, err := s.productRepository.ApplySchedules(now, s.options.BatchSize)
+		applied += len(changed)
+		if err != nil || len(changed) < s.options.BatchSize {
+			return applied, err
+		}
+	}
+	return applied, nil
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPublicationScheduler(t *testing.T) {
+	now := time.Date(2024, 11, 29, 0, 0, 0, 0, time.UTC)
+	newScheduler := func(productRepository *mocks.MockProductRepository) *PublicationScheduler {
+		scheduler := NewPublicationScheduler(productRepository, SchedulerOptions{Interval: time.Hour, BatchSize: 2})
+		scheduler.now = func() time.Time { return now }
+		return scheduler
+	}
+
+	t.Run("should apply the due schedules a batch at a time", func(t *testing.T) {
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("ApplySchedules", now, 2).Return([]uint{1, 2}, nil).Twice()
+		mockProductRepository.On("ApplySchedules", now, 2).Return([]uint{5}, nil).Once()
+
+		applied, err := newScheduler(mockProductRepository).ApplyDue(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, 5, applied)
+		mockProductRepository.AssertExpectations(t)
+	})
+
+	t.Run("should return an error", func(t *testing.T) {
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("ApplySchedules", now, 2).Return(nil, fmt.Errorf("some error"))
+
+		_, err := newScheduler(mockProductRepository).ApplyDue(context.Background())
+
+		assert.Error(t, err)
+	})
+
+	t.Run("should catch up when started and stop when the context is done", func(t *testing.T) {
+		applied := make(chan struct{})
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("ApplySchedules", now, 2).Return([]uint{}, nil).Once().Run(func(mock.Arguments) { close(applied) })
+		scheduler := newScheduler(mockProductRepository)
+		ctx, cancel := context.WithCancel(context.Background())
+
+		done := make(chan struct{})
+		go func() {
+			scheduler.Run(ctx)
+			close(done)
+		}()
+		<-applied
+		cancel()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("the scheduler didn't stop")
+		}
+		mockProductRepository.AssertExpectations(t)
+	})
+}
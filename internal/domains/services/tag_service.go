@@ -0,0 +1,18 @@
+package services
+
+import (
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/core/interfaces"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+)
+
+type TagService struct {
+	tagRepository interfaces.TagRepositoryInterface
+}
+
+func NewTagService(tagRepository interfaces.TagRepositoryInterface) *TagService {
+	return &TagService{tagRepository: tagRepository}
+}
+
+func (s *TagService) GetAllTags() ([]*models.TagCount, error) {
+	return s.tagRepository.GetAll()
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"image"
+	"image/color"
+)
+
+// thumbnail scales img down to fit in a size by size square, keeping its
+// aspect ratio. Each pixel of the thumbnail is the average of the ones it
+// covers, which is slower than picking one but doesn't alias. Images that fit
+// already are only copied.
+func thumbnail(img image.Image, size int) *image.NRGBA {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > size || height > size {
+		if width >= height {
+			width, height = size, height*size/width
+		} else {
+			width, height = width*size/height, size
+		}
+		if width < 1 {
+			width = 1
+		}
+		if height < 1 {
+			height = 1
+		}
+	}
+
+	thumb := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		top := bounds.Min.Y + y*bounds.Dy()/height
+		bottom := bounds.Min.Y + (y+1)*bounds.Dy()/height
+		for x := 0; x < width; x++ {
+			left := bounds.Min.X + x*bounds.Dx()/width
+			right := bounds.Min.X + (x+1)*bounds.Dx()/width
+
+			var r, g, b, a, n uint64
+			for sy := top; sy < bottom; sy++ {
+				for sx := left; sx < right; sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r, g, b, a, n = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca), n+1
+				}
+			}
+			// The colors are premultiplied by their alpha, so averaging them
+			// weighs the transparent pixels less, and NRGBA undoes it.
+			thumb.Set(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(b / n), A: uint16(a / n)})
+		}
+	}
+	return thumb
+}
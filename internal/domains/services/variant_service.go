@@ -0,0 +1,80 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/core/interfaces"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+)
+
+type VariantService struct {
+	variantRepository interfaces.VariantRepositoryInterface
+	productRepository interfaces.ProductRespositoryInterface
+	options           ProductOptions
+}
+
+// NewVariantService returns a service that checks the SKUs of the variants
+// with the same options as the products'.
+func NewVariantService(variantRepository interfaces.VariantRepositoryInterface, productRepository interfaces.ProductRespositoryInterface, options ProductOptions) *VariantService {
+	return &VariantService{variantRepository: variantRepository, productRepository: productRepository, options: options}
+}
+
+func (s *VariantService) GetAllVariants(productID int) ([]*models.ProductVariant, error) {
+	return s.variantRepository.GetAll(productID)
+}
+
+func (s *VariantService) GetVariant(productID, id int) (*models.ProductVariant, error) {
+	return s.variantRepository.GetByID(productID, id)
+}
+
+// CreateVariant checks the SKU and the price of a variant and adds it to a
+// product.
+func (s *VariantService) CreateVariant(productID int, variant *models.ProductVariant) (*models.ProductVariant, error) {
+	variant.ID, variant.ProductID = 0, uint(productID)
+	if err := s.check(variant); err != nil {
+		return nil, err
+	}
+	return s.variantRepository.Create(variant)
+}
+
+// UpdateVariant checks the SKU and the price of a variant and saves it.
+func (s *VariantService) UpdateVariant(variant *models.ProductVariant) (*models.ProductVariant, error) {
+	if err := s.check(variant); err != nil {
+		return nil, err
+	}
+	return s.variantRepository.Update(variant)
+}
+
+func (s *VariantService) DeleteVariant(productID, id int) error {
+	return s.variantRepository.Delete(productID, id)
+}
+
+// GenerateVariants adds a variant for every combination of the options of a
+// product that has none, and returns the ones added.
+func (s *VariantService) GenerateVariants(productID int) ([]*models.ProductVariant, error) {
+	return s.variantRepository.Generate(productID)
+}
+
+// check normalizes the SKU of variant and checks that its price, when it
+// overrides the product's, suits the currency of the product.
+func (s *VariantService) check(variant *models.ProductVariant) error {
+	sku, err := normalizeSKU(variant.SKU, s.options.SKUPattern)
+	variant.SKU = sku
+	if err != nil {
+		return err
+	}
+	if variant.Price == nil {
+		return nil
+	}
+
+	product, err := s.productRepository.GetByIDFromPrimary(int(variant.ProductID))
+	if err != nil {
+		return err
+	}
+	price := product.Money()
+	price.Amount = *variant.Price
+	if err := price.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", models.ErrInvalidVariant, err)
+	}
+	return nil
+}
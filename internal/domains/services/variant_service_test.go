@@ -0,0 +1,55 @@
+package services
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/mocks"
+	"github.com/adrianosiqe/eulabs-challenge-api/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreateVariant(t *testing.T) {
+	options := ProductOptions{SKUPattern: regexp.MustCompile(`^[A-Z0-9][A-Z0-9-]{2,63}$`)}
+
+	t.Run("should create the variant of the product with a trimmed sku", func(t *testing.T) {
+		sku := " TSHIRT-S "
+		mockVariantRepository := &mocks.MockVariantRepository{}
+		mockVariantRepository.On("Create", mock.Anything).Return(mocks.MockVariant, nil)
+
+		variantService := NewVariantService(mockVariantRepository, &mocks.MockProductRepository{}, options)
+		_, err := variantService.CreateVariant(1, &models.ProductVariant{ID: 9, Options: models.VariantOptions{"Size": "S"}, SKU: &sku})
+
+		assert.NoError(t, err)
+		created := mockVariantRepository.Calls[0].Arguments.Get(0).(*models.ProductVariant)
+		assert.Equal(t, uint(0), created.ID)
+		assert.Equal(t, uint(1), created.ProductID)
+		assert.Equal(t, "TSHIRT-S", *created.SKU)
+	})
+
+	t.Run("should reject a sku that doesn't match the pattern", func(t *testing.T) {
+		sku := "tshirt s"
+		mockVariantRepository := &mocks.MockVariantRepository{}
+
+		variantService := NewVariantService(mockVariantRepository, &mocks.MockProductRepository{}, options)
+		_, err := variantService.CreateVariant(1, &models.ProductVariant{Options: models.VariantOptions{"Size": "S"}, SKU: &sku})
+
+		assert.ErrorIs(t, err, models.ErrInvalidSKU)
+		mockVariantRepository.AssertNotCalled(t, "Create", mock.Anything)
+	})
+
+	t.Run("should reject a price the currency of the product can't have", func(t *testing.T) {
+		price := money.MustParseAmount("9.999")
+		mockVariantRepository := &mocks.MockVariantRepository{}
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("GetByIDFromPrimary", 1).Return(&models.Product{ID: 1, Currency: "BRL"}, nil)
+
+		variantService := NewVariantService(mockVariantRepository, mockProductRepository, options)
+		_, err := variantService.CreateVariant(1, &models.ProductVariant{Options: models.VariantOptions{"Size": "S"}, Price: &price})
+
+		assert.ErrorIs(t, err, models.ErrInvalidVariant)
+		mockVariantRepository.AssertNotCalled(t, "Create", mock.Anything)
+	})
+}
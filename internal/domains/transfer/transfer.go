@@ -0,0 +1,161 @@
+// Package transfer reads and writes products in the file formats used by the
+// import and export commands.
+package transfer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/adrianosiqe/eulabs-challenge-api/pkg/money"
+	"github.com/go-playground/validator"
+)
+
+const (
+	FormatCSV    = "csv"
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+)
+
+var csvHeader = []string{"id", "title", "description", "price", "currency"}
+
+// ReadCSV parses products from a CSV file with a title, description and price
+// header, and optionally a currency (money.DefaultCurrency when missing).
+// Columns may come in any order and an id column is ignored. Every row is
+// validated before any product is returned.
+func ReadCSV(r io.Reader) ([]*models.Product, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the CSV header: %w", err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"title", "description", "price"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing %q column", name)
+		}
+	}
+
+	validate := validator.New()
+	var products []*models.Product
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		price, err := money.ParseAmount(record[columns["price"]])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid price %q", line, record[columns["price"]])
+		}
+
+		currency := money.DefaultCurrency
+		if i, ok := columns["currency"]; ok && strings.TrimSpace(record[i]) != "" {
+			if currency, err = money.ParseCurrency(record[i]); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+
+		product := &models.Product{
+			Title:       strings.TrimSpace(record[columns["title"]]),
+			Description: strings.TrimSpace(record[columns["description"]]),
+			Price:       price,
+			Currency:    currency,
+		}
+		if err := validate.Struct(product); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if err := product.Validate(); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		products = append(products, product)
+	}
+
+	return products, nil
+}
+
+// Writer streams products in one of the supported formats. Close must be
+// called once every product has been written.
+type Writer struct {
+	format string
+	w      io.Writer
+	csv    *csv.Writer
+	count  int
+}
+
+func NewWriter(w io.Writer, format string) (*Writer, error) {
+	writer := &Writer{format: format, w: w}
+	switch format {
+	case FormatNDJSON:
+	case FormatJSON:
+		if _, err := io.WriteString(w, "["); err != nil {
+			return nil, err
+		}
+	case FormatCSV:
+		writer.csv = csv.NewWriter(w)
+		if err := writer.csv.Write(csvHeader); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+	return writer, nil
+}
+
+func (w *Writer) Write(product *models.Product) error {
+	defer func() { w.count++ }()
+
+	switch w.format {
+	case FormatCSV:
+		return w.csv.Write([]string{
+			strconv.FormatUint(uint64(product.ID), 10),
+			product.Title,
+			product.Description,
+			product.Price.StringFixed(product.Money().Currency.MinorUnits()),
+			string(product.Money().Currency),
+		})
+	case FormatJSON:
+		if w.count > 0 {
+			if _, err := io.WriteString(w.w, ","); err != nil {
+				return err
+			}
+		}
+	}
+
+	data, err := json.Marshal(product)
+	if err != nil {
+		return err
+	}
+	if w.format == FormatNDJSON {
+		data = append(data, '\n')
+	}
+	_, err = w.w.Write(data)
+	return err
+}
+
+func (w *Writer) Close() error {
+	switch w.format {
+	case FormatJSON:
+		_, err := io.WriteString(w.w, "]\n")
+		return err
+	case FormatCSV:
+		w.csv.Flush()
+		return w.csv.Error()
+	}
+	return nil
+}
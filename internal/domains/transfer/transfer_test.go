@@ -0,0 +1,95 @@
+package transfer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/mocks"
+	"github.com/adrianosiqe/eulabs-challenge-api/pkg/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadCSV(t *testing.T) {
+	t.Run("should return the products", func(t *testing.T) {
+		products, err := ReadCSV(strings.NewReader("price,title,description\n99.99,Bulbasaur,\"A seed, on its back.\"\n1093.45,Charmander,Likes hot things.\n"))
+
+		assert.NoError(t, err)
+		assert.Len(t, products, 2)
+		assert.Equal(t, "Bulbasaur", products[0].Title)
+		assert.Equal(t, "A seed, on its back.", products[0].Description)
+		assert.Equal(t, money.MustParseAmount("1093.45"), products[1].Price)
+	})
+
+	t.Run("should return an error for a missing column", func(t *testing.T) {
+		_, err := ReadCSV(strings.NewReader("title,price\nBulbasaur,99.99\n"))
+
+		assert.EqualError(t, err, `missing "description" column`)
+	})
+
+	t.Run("should return an error with the line number", func(t *testing.T) {
+		_, err := ReadCSV(strings.NewReader("title,description,price\nBulbasaur,A seed.,99.99\nCharmander,Likes hot things.,free\n"))
+
+		assert.EqualError(t, err, `line 3: invalid price "free"`)
+	})
+
+	t.Run("should read the currency column", func(t *testing.T) {
+		products, err := ReadCSV(strings.NewReader("title,description,price,currency\nBulbasaur,A seed.,99.99,usd\nCharmander,Likes hot things.,1093.45,\n"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, money.Currency("USD"), products[0].Currency)
+		assert.Equal(t, money.DefaultCurrency, products[1].Currency)
+	})
+
+	t.Run("should reject prices with too many decimal places for the currency", func(t *testing.T) {
+		_, err := ReadCSV(strings.NewReader("title,description,price,currency\nBulbasaur,A seed.,99.99,JPY\n"))
+
+		assert.ErrorIs(t, err, money.ErrPrecision)
+	})
+
+	t.Run("should validate the products", func(t *testing.T) {
+		_, err := ReadCSV(strings.NewReader("title,description,price\nBulbasaur,,99.99\n"))
+
+		assert.ErrorContains(t, err, "line 2")
+	})
+}
+
+func TestWriter(t *testing.T) {
+	write := func(format string) string {
+		var buf bytes.Buffer
+		w, err := NewWriter(&buf, format)
+		assert.NoError(t, err)
+		for _, product := range mocks.MockProducts {
+			assert.NoError(t, w.Write(product))
+		}
+		assert.NoError(t, w.Close())
+		return buf.String()
+	}
+
+	t.Run("should write ndjson", func(t *testing.T) {
+		lines := strings.Split(strings.TrimSpace(write(FormatNDJSON)), "\n")
+
+		assert.Len(t, lines, 2)
+		assert.Contains(t, lines[1], `"title":"Charmander"`)
+	})
+
+	t.Run("should write json", func(t *testing.T) {
+		output := write(FormatJSON)
+
+		assert.True(t, strings.HasPrefix(output, `[{"id":1`))
+		assert.Contains(t, output, `},{"id":2`)
+	})
+
+	t.Run("should write csv", func(t *testing.T) {
+		output := write(FormatCSV)
+
+		assert.True(t, strings.HasPrefix(output, "id,title,description,price,currency\n1,Bulbasaur,"))
+		assert.Contains(t, output, ",1093.45,BRL\n")
+	})
+
+	t.Run("should return an error for an unknown format", func(t *testing.T) {
+		_, err := NewWriter(&bytes.Buffer{}, "xml")
+
+		assert.Error(t, err)
+	})
+}
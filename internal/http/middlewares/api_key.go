@@ -0,0 +1,36 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/core/interfaces"
+	"github.com/labstack/echo"
+)
+
+const HeaderAPIKey = "X-API-Key"
+
+// APIKey authenticates requests carrying an X-API-Key header. Requests without
+// the header are left to the JWT middleware.
+func APIKey(apiKeyService interfaces.APIKeyServiceInterface) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key := c.Request().Header.Get(HeaderAPIKey)
+			if key == "" {
+				return next(c)
+			}
+
+			apiKey, err := apiKeyService.AuthenticateAPIKey(key)
+			if err != nil {
+				return &echo.HTTPError{Code: http.StatusUnauthorized, Message: "Invalid or missing credentials", Internal: err}
+			}
+
+			permissions := make([]Permission, len(apiKey.Scopes))
+			for i, scope := range apiKey.Scopes {
+				permissions[i] = Permission(scope)
+			}
+
+			c.Set(principalContextKey, &Principal{Subject: "api-key:" + apiKey.Prefix, Permissions: permissions})
+			return next(c)
+		}
+	}
+}
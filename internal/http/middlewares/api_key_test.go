@@ -0,0 +1,73 @@
+package middlewares
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/mocks"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+)
+
+func newAPIKeyServer(mockAPIKeyService *mocks.MockAPIKeyService) *echo.Echo {
+	e := echo.New()
+	ok := func(c echo.Context) error { return c.NoContent(http.StatusOK) }
+	g := e.Group("", APIKey(mockAPIKeyService), JWT("secret"))
+	g.GET("/products", ok, Authorize(DefaultPolicy(), PermissionProductsRead))
+	g.DELETE("/products", ok, Authorize(DefaultPolicy(), PermissionProductsDelete))
+	return e
+}
+
+func TestAPIKey(t *testing.T) {
+	apiKey := &models.APIKey{ID: 1, Prefix: "a1b2c3d4e5f6", Scopes: models.Scopes{"products:read"}}
+
+	t.Run("should returns 200 for a granted scope", func(t *testing.T) {
+		mockAPIKeyService := &mocks.MockAPIKeyService{}
+		mockAPIKeyService.On("AuthenticateAPIKey", "eul_a1b2c3d4e5f6_secret").Return(apiKey, nil)
+		req := httptest.NewRequest(http.MethodGet, "/products", nil)
+		req.Header.Set(HeaderAPIKey, "eul_a1b2c3d4e5f6_secret")
+		rec := httptest.NewRecorder()
+
+		newAPIKeyServer(mockAPIKeyService).ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		mockAPIKeyService.AssertExpectations(t)
+	})
+
+	t.Run("should returns 403 for a missing scope", func(t *testing.T) {
+		mockAPIKeyService := &mocks.MockAPIKeyService{}
+		mockAPIKeyService.On("AuthenticateAPIKey", "eul_a1b2c3d4e5f6_secret").Return(apiKey, nil)
+		req := httptest.NewRequest(http.MethodDelete, "/products", nil)
+		req.Header.Set(HeaderAPIKey, "eul_a1b2c3d4e5f6_secret")
+		rec := httptest.NewRecorder()
+
+		newAPIKeyServer(mockAPIKeyService).ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("should returns 401 for an invalid key", func(t *testing.T) {
+		mockAPIKeyService := &mocks.MockAPIKeyService{}
+		mockAPIKeyService.On("AuthenticateAPIKey", "eul_a1b2c3d4e5f6_wrong").Return(nil, fmt.Errorf("some error"))
+		req := httptest.NewRequest(http.MethodGet, "/products", nil)
+		req.Header.Set(HeaderAPIKey, "eul_a1b2c3d4e5f6_wrong")
+		rec := httptest.NewRecorder()
+
+		newAPIKeyServer(mockAPIKeyService).ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("should fall back to jwt", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/products", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+newToken("secret", RoleViewer))
+		rec := httptest.NewRecorder()
+
+		newAPIKeyServer(&mocks.MockAPIKeyService{}).ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+}
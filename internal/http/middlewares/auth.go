@@ -0,0 +1,99 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo"
+	"github.com/labstack/echo/middleware"
+)
+
+const principalContextKey = "principal"
+
+// Principal is the authenticated caller of a request. Users are granted
+// permissions through their role, machine clients through their key scopes.
+type Principal struct {
+	Subject     string
+	Role        string
+	Permissions []Permission
+}
+
+func (p *Principal) Can(policy *Policy, permission Permission) bool {
+	if p.Role != "" {
+		return policy.Allows(p.Role, permission)
+	}
+
+	for _, granted := range p.Permissions {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+type JWTClaims struct {
+	Role string `json:"role"`
+	jwt.StandardClaims
+}
+
+// JWT authenticates requests carrying an HS256 bearer token and stores the
+// resulting Principal in the context. It is skipped when a previous middleware
+// already authenticated the request.
+func JWT(secret string) echo.MiddlewareFunc {
+	return middleware.JWTWithConfig(middleware.JWTConfig{
+		Skipper: func(c echo.Context) bool {
+			return GetPrincipal(c) != nil
+		},
+		SigningKey: []byte(secret),
+		Claims:     &JWTClaims{},
+		SuccessHandler: func(c echo.Context) {
+			token := c.Get("user").(*jwt.Token)
+			claims := token.Claims.(*JWTClaims)
+			c.Set(principalContextKey, &Principal{Subject: claims.Subject, Role: claims.Role})
+		},
+		ErrorHandler: func(err error) error {
+			return &echo.HTTPError{Code: http.StatusUnauthorized, Message: "Invalid or missing credentials", Internal: err}
+		},
+	})
+}
+
+func GetPrincipal(c echo.Context) *Principal {
+	principal, _ := c.Get(principalContextKey).(*Principal)
+	return principal
+}
+
+// Authorize rejects requests whose principal is not granted the permission.
+func Authorize(policy *Policy, permission Permission) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			principal := GetPrincipal(c)
+			if principal == nil {
+				return echo.NewHTTPError(http.StatusUnauthorized, "Invalid or missing credentials")
+			}
+
+			if !principal.Can(policy, permission) {
+				return echo.NewHTTPError(http.StatusForbidden, "Forbidden")
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// Granted returns a check of whether the principal of a request is granted the
+// permission, for handlers whose response depends on it.
+func Granted(policy *Policy, permission Permission) func(c echo.Context) bool {
+	return func(c echo.Context) bool {
+		principal := GetPrincipal(c)
+		return principal != nil && principal.Can(policy, permission)
+	}
+}
+
+// Subject returns the subject of the principal of a request, or "" when there
+// is none, for handlers that record who made a change.
+func Subject(c echo.Context) string {
+	if principal := GetPrincipal(c); principal != nil {
+		return principal.Subject
+	}
+	return ""
+}
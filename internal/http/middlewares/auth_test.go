@@ -0,0 +1,103 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+)
+
+func newToken(secret string, role string) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &JWTClaims{
+		Role:           role,
+		StandardClaims: jwt.StandardClaims{Subject: "ash"},
+	})
+	signed, _ := token.SignedString([]byte(secret))
+	return signed
+}
+
+func newAuthServer() *echo.Echo {
+	e := echo.New()
+	ok := func(c echo.Context) error { return c.NoContent(http.StatusOK) }
+	g := e.Group("", JWT("secret"))
+	g.GET("/products", ok, Authorize(DefaultPolicy(), PermissionProductsRead))
+	g.DELETE("/products", ok, Authorize(DefaultPolicy(), PermissionProductsDelete))
+	return e
+}
+
+func TestAuthorize(t *testing.T) {
+	t.Run("should returns 200", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/products", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+newToken("secret", RoleViewer))
+		rec := httptest.NewRecorder()
+
+		newAuthServer().ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("should returns 401 without a token", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/products", nil)
+		rec := httptest.NewRecorder()
+
+		newAuthServer().ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("should returns 401 with a token signed by another key", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/products", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+newToken("other", RoleAdmin))
+		rec := httptest.NewRecorder()
+
+		newAuthServer().ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("should returns 403", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, "/products", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+newToken("secret", RoleEditor))
+		rec := httptest.NewRecorder()
+
+		newAuthServer().ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.JSONEq(t, `{"message":"Forbidden"}`, rec.Body.String())
+	})
+}
+
+func TestGranted(t *testing.T) {
+	isEditor := Granted(DefaultPolicy(), PermissionProductsWrite)
+	newContext := func(principal *Principal) echo.Context {
+		c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/products", nil), httptest.NewRecorder())
+		if principal != nil {
+			c.Set(principalContextKey, principal)
+		}
+		return c
+	}
+
+	t.Run("should grant the permission through the role or the scopes", func(t *testing.T) {
+		assert.True(t, isEditor(newContext(&Principal{Role: RoleEditor})))
+		assert.True(t, isEditor(newContext(&Principal{Permissions: []Permission{PermissionProductsWrite}})))
+	})
+
+	t.Run("should not grant the permission", func(t *testing.T) {
+		assert.False(t, isEditor(newContext(&Principal{Role: RoleViewer})))
+		assert.False(t, isEditor(newContext(&Principal{Permissions: []Permission{PermissionProductsRead}})))
+		assert.False(t, isEditor(newContext(nil)))
+	})
+}
+
+func TestSubject(t *testing.T) {
+	t.Run("should return the subject of the principal, if any", func(t *testing.T) {
+		c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/products", nil), httptest.NewRecorder())
+		assert.Equal(t, "", Subject(c))
+
+		c.Set(principalContextKey, &Principal{Subject: "ash@example.com"})
+		assert.Equal(t, "ash@example.com", Subject(c))
+	})
+}
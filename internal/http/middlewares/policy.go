@@ -0,0 +1,89 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+type Permission string
+
+const (
+	PermissionProductsRead   Permission = "products:read"
+	PermissionProductsWrite  Permission = "products:write"
+	PermissionProductsDelete Permission = "products:delete"
+	// PermissionProductsPurge removes products for good, which a soft delete
+	// doesn't, so it is granted apart from PermissionProductsDelete.
+	PermissionProductsPurge   Permission = "products:purge"
+	PermissionProductsRestore Permission = "products:restore"
+	PermissionAPIKeysManage   Permission = "api_keys:manage"
+	PermissionMetricsRead     Permission = "metrics:read"
+)
+
+// Permissions lists every permission the routes check, which are also the
+// scopes an API key can be granted.
+func Permissions() []Permission {
+	return []Permission{
+		PermissionProductsRead,
+		PermissionProductsWrite,
+		PermissionProductsDelete,
+		PermissionProductsPurge,
+		PermissionProductsRestore,
+		PermissionAPIKeysManage,
+		PermissionMetricsRead,
+	}
+}
+
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+// Policy maps each role to the permissions it is granted.
+type Policy struct {
+	Roles map[string][]Permission `json:"roles"`
+}
+
+func DefaultPolicy() *Policy {
+	return &Policy{
+		Roles: map[string][]Permission{
+			RoleViewer: {PermissionProductsRead},
+			RoleEditor: {PermissionProductsRead, PermissionProductsWrite},
+			RoleAdmin:  {PermissionProductsRead, PermissionProductsWrite, PermissionProductsDelete, PermissionProductsPurge, PermissionProductsRestore, PermissionAPIKeysManage, PermissionMetricsRead},
+		},
+	}
+}
+
+// LoadPolicy reads a JSON policy file, falling back to the default policy when
+// no path is given.
+func LoadPolicy(path string) (*Policy, error) {
+	if path == "" {
+		return DefaultPolicy(), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var policy Policy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("invalid policy file %s: %w", path, err)
+	}
+
+	if len(policy.Roles) == 0 {
+		return nil, fmt.Errorf("invalid policy file %s: no roles defined", path)
+	}
+
+	return &policy, nil
+}
+
+func (p *Policy) Allows(role string, permission Permission) bool {
+	for _, granted := range p.Roles[role] {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
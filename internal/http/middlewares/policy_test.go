@@ -0,0 +1,77 @@
+package middlewares
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultPolicy(t *testing.T) {
+	policy := DefaultPolicy()
+
+	t.Run("should allow viewers to read only", func(t *testing.T) {
+		assert.True(t, policy.Allows(RoleViewer, PermissionProductsRead))
+		assert.False(t, policy.Allows(RoleViewer, PermissionProductsWrite))
+		assert.False(t, policy.Allows(RoleViewer, PermissionProductsDelete))
+	})
+
+	t.Run("should allow editors to read and write", func(t *testing.T) {
+		assert.True(t, policy.Allows(RoleEditor, PermissionProductsRead))
+		assert.True(t, policy.Allows(RoleEditor, PermissionProductsWrite))
+		assert.False(t, policy.Allows(RoleEditor, PermissionProductsDelete))
+		assert.False(t, policy.Allows(RoleEditor, PermissionProductsPurge))
+		assert.False(t, policy.Allows(RoleEditor, PermissionProductsRestore))
+	})
+
+	t.Run("should allow admins everything", func(t *testing.T) {
+		assert.True(t, policy.Allows(RoleAdmin, PermissionProductsRead))
+		assert.True(t, policy.Allows(RoleAdmin, PermissionProductsWrite))
+		assert.True(t, policy.Allows(RoleAdmin, PermissionProductsDelete))
+		assert.True(t, policy.Allows(RoleAdmin, PermissionProductsPurge))
+		assert.True(t, policy.Allows(RoleAdmin, PermissionProductsRestore))
+		assert.True(t, policy.Allows(RoleAdmin, PermissionMetricsRead))
+	})
+
+	t.Run("should deny unknown roles", func(t *testing.T) {
+		assert.False(t, policy.Allows("guest", PermissionProductsRead))
+	})
+
+	t.Run("should only grant known permissions", func(t *testing.T) {
+		for role, permissions := range policy.Roles {
+			for _, permission := range permissions {
+				assert.Contains(t, Permissions(), permission, role)
+			}
+		}
+	})
+}
+
+func TestLoadPolicy(t *testing.T) {
+	t.Run("should return the default policy", func(t *testing.T) {
+		policy, err := LoadPolicy("")
+
+		assert.NoError(t, err)
+		assert.Equal(t, DefaultPolicy(), policy)
+	})
+
+	t.Run("should load the policy file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "policy.json")
+		os.WriteFile(path, []byte(`{"roles":{"auditor":["products:read"]}}`), 0o600)
+
+		policy, err := LoadPolicy(path)
+
+		assert.NoError(t, err)
+		assert.True(t, policy.Allows("auditor", PermissionProductsRead))
+		assert.False(t, policy.Allows(RoleAdmin, PermissionProductsRead))
+	})
+
+	t.Run("should return an error", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "policy.json")
+		os.WriteFile(path, []byte(`{"roles":{}}`), 0o600)
+
+		_, err := LoadPolicy(path)
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,288 @@
+package middlewares
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo"
+)
+
+const (
+	HeaderRateLimitLimit     = "RateLimit-Limit"
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	HeaderRateLimitReset     = "RateLimit-Reset"
+	HeaderRetryAfter         = "Retry-After"
+)
+
+// RateLimit allows bursts of up to Requests, refilled evenly over Period.
+type RateLimit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// ParseRateLimit parses limits written as "<requests>/<period>", e.g. "100/1m".
+func ParseRateLimit(value string) (RateLimit, error) {
+	parts := strings.SplitN(value, "/", 2)
+	if len(parts) != 2 {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q: expected <requests>/<period>", value)
+	}
+
+	requests, err := strconv.Atoi(parts[0])
+	if err != nil || requests <= 0 {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q: requests must be a positive integer", value)
+	}
+
+	period, err := time.ParseDuration(parts[1])
+	if err != nil || period <= 0 {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q: period must be a positive duration", value)
+	}
+
+	return RateLimit{Requests: requests, Period: period}, nil
+}
+
+// ParseRateLimits parses a comma separated list of per group limits, e.g.
+// "products=100/1m,api-keys=20/1m".
+func ParseRateLimits(value string) (map[string]RateLimit, error) {
+	limits := map[string]RateLimit{}
+	if value == "" {
+		return limits, nil
+	}
+
+	for _, entry := range strings.Split(value, ",") {
+		group, raw, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || group == "" {
+			return nil, fmt.Errorf("invalid rate limit %q: expected <group>=<requests>/<period>", entry)
+		}
+
+		limit, err := ParseRateLimit(raw)
+		if err != nil {
+			return nil, err
+		}
+		limits[group] = limit
+	}
+
+	return limits, nil
+}
+
+type RateLimitResult struct {
+	Allowed    bool
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// RateLimitStore keeps the token buckets. The in-memory store only limits a
+// single instance; a shared store can be plugged in for several replicas.
+type RateLimitStore interface {
+	Take(key string, limit RateLimit, now time.Time) (RateLimitResult, error)
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	period time.Duration
+}
+
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{buckets: map[string]*bucket{}}
+}
+
+func (s *MemoryRateLimitStore) Take(key string, limit RateLimit, now time.Time) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	capacity := float64(limit.Requests)
+	rate := capacity / limit.Period.Seconds()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, last: now}
+		s.buckets[key] = b
+	}
+	b.period = limit.Period
+	b.tokens = math.Min(capacity, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+
+	result := RateLimitResult{Allowed: b.tokens >= 1}
+	if result.Allowed {
+		b.tokens--
+	} else {
+		result.RetryAfter = seconds((1 - b.tokens) / rate)
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = seconds((capacity - b.tokens) / rate)
+
+	return result, nil
+}
+
+// sweep drops buckets that have refilled completely, as they are equivalent to
+// a new bucket.
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+
+	for key, b := range s.buckets {
+		if now.Sub(b.last) >= b.period {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// RateLimiter limits each client of a route group. Clients are identified by
+// their principal once authenticated, or by their IP address otherwise.
+func RateLimiter(store RateLimitStore, group string, limit RateLimit) echo.MiddlewareFunc {
+	return rateLimiter(store, group, limit, rateLimitKey)
+}
+
+// IPRateLimiter limits each IP address, as resolved by TrustedProxies. It goes
+// before the authentication, so that requests with bad or missing credentials
+// are limited too.
+func IPRateLimiter(store RateLimitStore, group string, limit RateLimit) echo.MiddlewareFunc {
+	return rateLimiter(store, group, limit, ipKey)
+}
+
+func rateLimiter(store RateLimitStore, group string, limit RateLimit, key func(c echo.Context) string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			result, err := store.Take(group+":"+key(c), limit, time.Now())
+			if err != nil {
+				c.Logger().Errorf("rate limit store failed: %v", err)
+				return next(c)
+			}
+
+			header := c.Response().Header()
+			header.Set(HeaderRateLimitLimit, strconv.Itoa(limit.Requests))
+			header.Set(HeaderRateLimitRemaining, strconv.Itoa(result.Remaining))
+			header.Set(HeaderRateLimitReset, ceilSeconds(result.Reset))
+
+			if !result.Allowed {
+				header.Set(HeaderRetryAfter, ceilSeconds(result.RetryAfter))
+				return echo.NewHTTPError(http.StatusTooManyRequests, "Too many requests")
+			}
+
+			return next(c)
+		}
+	}
+}
+
+func rateLimitKey(c echo.Context) string {
+	if principal := GetPrincipal(c); principal != nil && principal.Subject != "" {
+		return principal.Subject
+	}
+	return ipKey(c)
+}
+
+func ipKey(c echo.Context) string {
+	return "ip:" + ClientIP(c)
+}
+
+const clientIPContextKey = "client_ip"
+
+// ParseTrustedProxies parses the addresses of the trusted proxies, each an IP
+// address or a CIDR block such as "10.0.0.0/8".
+func ParseTrustedProxies(values []string) ([]*net.IPNet, error) {
+	proxies := make([]*net.IPNet, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: expected an IP address or a CIDR block", value)
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+			continue
+		}
+
+		_, block, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: expected an IP address or a CIDR block", value)
+		}
+		proxies = append(proxies, block)
+	}
+	return proxies, nil
+}
+
+// TrustedProxies resolves the IP address of the client. It is the address of
+// the connection, unless that is one of proxies: the X-Forwarded-For header is
+// then read from the right, skipping the proxies, up to the first address
+// that isn't one. The headers of the other connections, and X-Real-IP, are
+// ignored, as the clients could set them to anything.
+func TrustedProxies(proxies []*net.IPNet) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set(clientIPContextKey, clientIP(c.Request(), proxies))
+			return next(c)
+		}
+	}
+}
+
+// ClientIP returns the IP address of the client resolved by TrustedProxies, or
+// the address of the connection without it.
+func ClientIP(c echo.Context) string {
+	if ip, ok := c.Get(clientIPContextKey).(string); ok {
+		return ip
+	}
+	return remoteIP(c.Request())
+}
+
+func clientIP(r *http.Request, proxies []*net.IPNet) string {
+	ip := remoteIP(r)
+	if !trusted(ip, proxies) {
+		return ip
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header.Values(echo.HeaderXForwardedFor), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(forwarded[i])
+		if net.ParseIP(hop) == nil {
+			break
+		}
+		ip = hop
+		if !trusted(ip, proxies) {
+			break
+		}
+	}
+	return ip
+}
+
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func trusted(ip string, proxies []*net.IPNet) bool {
+	parsed := net.ParseIP(ip)
+	for _, proxy := range proxies {
+		if parsed != nil && proxy.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,182 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRateLimits(t *testing.T) {
+	t.Run("should parse each group", func(t *testing.T) {
+		limits, err := ParseRateLimits("products=100/1m, api-keys=5/10s")
+
+		assert.NoError(t, err)
+		assert.Equal(t, RateLimit{Requests: 100, Period: time.Minute}, limits["products"])
+		assert.Equal(t, RateLimit{Requests: 5, Period: 10 * time.Second}, limits["api-keys"])
+	})
+
+	t.Run("should return an empty map", func(t *testing.T) {
+		limits, err := ParseRateLimits("")
+
+		assert.NoError(t, err)
+		assert.Empty(t, limits)
+	})
+
+	t.Run("should return an error", func(t *testing.T) {
+		for _, value := range []string{"products", "products=100", "products=0/1m", "products=100/forever"} {
+			_, err := ParseRateLimits(value)
+			assert.Error(t, err, value)
+		}
+	})
+}
+
+func TestMemoryRateLimitStore(t *testing.T) {
+	limit := RateLimit{Requests: 2, Period: 10 * time.Second}
+	now := time.Now()
+
+	t.Run("should allow a burst up to the limit", func(t *testing.T) {
+		store := NewMemoryRateLimitStore()
+
+		first, _ := store.Take("client", limit, now)
+		second, _ := store.Take("client", limit, now)
+		third, _ := store.Take("client", limit, now)
+
+		assert.True(t, first.Allowed)
+		assert.Equal(t, 1, first.Remaining)
+		assert.True(t, second.Allowed)
+		assert.Equal(t, 0, second.Remaining)
+		assert.False(t, third.Allowed)
+		assert.Equal(t, 5*time.Second, third.RetryAfter)
+		assert.Equal(t, 10*time.Second, third.Reset)
+	})
+
+	t.Run("should refill over time", func(t *testing.T) {
+		store := NewMemoryRateLimitStore()
+		store.Take("client", limit, now)
+		store.Take("client", limit, now)
+
+		result, _ := store.Take("client", limit, now.Add(5*time.Second))
+
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 0, result.Remaining)
+	})
+
+	t.Run("should keep clients apart", func(t *testing.T) {
+		store := NewMemoryRateLimitStore()
+		store.Take("client", limit, now)
+		store.Take("client", limit, now)
+
+		result, _ := store.Take("other", limit, now)
+
+		assert.True(t, result.Allowed)
+	})
+}
+
+func TestRateLimiter(t *testing.T) {
+	newServer := func() *echo.Echo {
+		e := echo.New()
+		e.GET("/products", func(c echo.Context) error { return c.NoContent(http.StatusOK) },
+			RateLimiter(NewMemoryRateLimitStore(), "products", RateLimit{Requests: 1, Period: time.Minute}))
+		return e
+	}
+
+	t.Run("should set the rate limit headers", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/products", nil)
+		rec := httptest.NewRecorder()
+
+		newServer().ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "1", rec.Header().Get(HeaderRateLimitLimit))
+		assert.Equal(t, "0", rec.Header().Get(HeaderRateLimitRemaining))
+		assert.Equal(t, "60", rec.Header().Get(HeaderRateLimitReset))
+	})
+
+	t.Run("should returns 429", func(t *testing.T) {
+		e := newServer()
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/products", nil))
+
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/products", nil))
+
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		assert.Equal(t, "60", rec.Header().Get(HeaderRetryAfter))
+		assert.JSONEq(t, `{"message":"Too many requests"}`, rec.Body.String())
+	})
+}
+
+func TestIPRateLimiter(t *testing.T) {
+	t.Run("should limit the requests that fail to authenticate", func(t *testing.T) {
+		e := echo.New()
+		unauthorized := func(next echo.HandlerFunc) echo.HandlerFunc {
+			return func(c echo.Context) error { return echo.ErrUnauthorized }
+		}
+		e.Use(IPRateLimiter(NewMemoryRateLimitStore(), "ip", RateLimit{Requests: 1, Period: time.Minute}), unauthorized)
+		e.GET("/products", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+
+		first := httptest.NewRecorder()
+		e.ServeHTTP(first, httptest.NewRequest(http.MethodGet, "/products", nil))
+		second := httptest.NewRecorder()
+		e.ServeHTTP(second, httptest.NewRequest(http.MethodGet, "/products", nil))
+
+		assert.Equal(t, http.StatusUnauthorized, first.Code)
+		assert.Equal(t, http.StatusTooManyRequests, second.Code)
+	})
+
+	t.Run("should not reset the limit for a spoofed forwarded address", func(t *testing.T) {
+		e := echo.New()
+		e.Use(TrustedProxies(nil), IPRateLimiter(NewMemoryRateLimitStore(), "ip", RateLimit{Requests: 1, Period: time.Minute}))
+		e.GET("/products", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+
+		codes := []int{}
+		for _, spoofed := range []string{"203.0.113.1", "203.0.113.2"} {
+			req := httptest.NewRequest(http.MethodGet, "/products", nil)
+			req.Header.Set(echo.HeaderXForwardedFor, spoofed)
+			req.Header.Set(echo.HeaderXRealIP, spoofed)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			codes = append(codes, rec.Code)
+		}
+
+		assert.Equal(t, []int{http.StatusOK, http.StatusTooManyRequests}, codes)
+	})
+}
+
+func TestTrustedProxies(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.0.2.1"})
+	require.NoError(t, err)
+	clientIPOf := func(remoteAddr, forwardedFor string) string {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			req.Header.Set(echo.HeaderXForwardedFor, forwardedFor)
+		}
+		c := echo.New().NewContext(req, httptest.NewRecorder())
+		TrustedProxies(proxies)(func(echo.Context) error { return nil })(c)
+		return ClientIP(c)
+	}
+
+	t.Run("should use the address of the connection without a proxy", func(t *testing.T) {
+		assert.Equal(t, "198.51.100.7", clientIPOf("198.51.100.7:1234", "203.0.113.1"))
+	})
+
+	t.Run("should read the forwarded address from a trusted proxy", func(t *testing.T) {
+		assert.Equal(t, "198.51.100.7", clientIPOf("192.0.2.1:1234", "198.51.100.7"))
+	})
+
+	t.Run("should skip the trusted proxies and ignore what the client prepends", func(t *testing.T) {
+		assert.Equal(t, "198.51.100.7", clientIPOf("192.0.2.1:1234", "203.0.113.1, 198.51.100.7, 10.1.2.3"))
+	})
+
+	t.Run("should reject invalid proxies", func(t *testing.T) {
+		_, err := ParseTrustedProxies([]string{"10.0.0.0/33"})
+		assert.Error(t, err)
+		_, err = ParseTrustedProxies([]string{"proxy"})
+		assert.Error(t, err)
+	})
+}
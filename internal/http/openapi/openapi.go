@@ -0,0 +1,222 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const Version = "3.1.0"
+
+// Operation documents a route. Request and Response are example values of the
+// body types, e.g. models.Product{} or []models.Product{}. Query is an example
+// value of a struct whose `query` tagged fields are the query parameters.
+type Operation struct {
+	Summary string
+	Tags    []string
+	Query   interface{}
+	Request interface{}
+	// PartialRequest documents a request body whose fields are all optional.
+	PartialRequest bool
+	Response       interface{}
+	Status         int
+	Errors         []int
+}
+
+type Document struct {
+	OpenAPI    string                               `json:"openapi"`
+	Info       Info                                 `json:"info"`
+	Paths      map[string]map[string]*PathOperation `json:"paths"`
+	Components Components                           `json:"components"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes"`
+}
+
+type SecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme,omitempty"`
+	Format string `json:"bearerFormat,omitempty"`
+	In     string `json:"in,omitempty"`
+	Name   string `json:"name,omitempty"`
+}
+
+type PathOperation struct {
+	Summary     string                `json:"summary"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []*Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+const (
+	errorSchemaName = "Error"
+	bearerAuth      = "bearerAuth"
+	apiKeyAuth      = "apiKeyAuth"
+)
+
+var pathParam = regexp.MustCompile(`:([^/]+)`)
+
+type Builder struct {
+	document *Document
+}
+
+func NewBuilder(title, version string) *Builder {
+	return &Builder{document: &Document{
+		OpenAPI: Version,
+		Info:    Info{Title: title, Version: version},
+		Paths:   map[string]map[string]*PathOperation{},
+		Components: Components{
+			Schemas: map[string]*Schema{
+				errorSchemaName: {
+					Type:       "object",
+					Properties: map[string]*Schema{"message": {Type: "string"}},
+					Required:   []string{"message"},
+				},
+			},
+			SecuritySchemes: map[string]*SecurityScheme{
+				bearerAuth: {Type: "http", Scheme: "bearer", Format: "JWT"},
+				apiKeyAuth: {Type: "apiKey", In: "header", Name: "X-API-Key"},
+			},
+		},
+	}}
+}
+
+// Add documents an echo route. Routes with a permission require
+// authentication and may also fail with 401, 403 and 429.
+func (b *Builder) Add(method, path, permission string, operation Operation) {
+	item := &PathOperation{
+		Summary:   operation.Summary,
+		Tags:      operation.Tags,
+		Responses: map[string]*Response{},
+	}
+
+	for _, match := range pathParam.FindAllStringSubmatch(path, -1) {
+		// Ids, such as id or variant_id, are integers; other parameters such
+		// as a tag name are strings.
+		schema := &Schema{Type: "string"}
+		if match[1] == "id" || strings.HasSuffix(match[1], "_id") {
+			schema.Type = "integer"
+		}
+		item.Parameters = append(item.Parameters, &Parameter{
+			Name:     match[1],
+			In:       "path",
+			Required: true,
+			Schema:   schema,
+		})
+	}
+
+	if operation.Query != nil {
+		item.Parameters = append(item.Parameters, b.queryParameters(reflect.TypeOf(operation.Query))...)
+	}
+
+	if operation.Request != nil {
+		schema := b.schemaFor(reflect.TypeOf(operation.Request))
+		if operation.PartialRequest {
+			schema = b.partial(schema)
+		}
+		item.RequestBody = &RequestBody{Required: true, Content: jsonContent(schema)}
+	}
+
+	status := operation.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := &Response{Description: http.StatusText(status)}
+	if operation.Response != nil {
+		success.Content = jsonContent(b.schemaFor(reflect.TypeOf(operation.Response)))
+	}
+	item.Responses[strconv.Itoa(status)] = success
+
+	errors := append([]int{}, operation.Errors...)
+	if permission != "" {
+		item.Description = "Requires the `" + permission + "` permission."
+		item.Security = []map[string][]string{{bearerAuth: {}}, {apiKeyAuth: {}}}
+		errors = append(errors, http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests)
+	}
+	for _, code := range errors {
+		item.Responses[strconv.Itoa(code)] = &Response{
+			Description: http.StatusText(code),
+			Content:     jsonContent(&Schema{Ref: "#/components/schemas/" + errorSchemaName}),
+		}
+	}
+
+	path = Path(path)
+	if b.document.Paths[path] == nil {
+		b.document.Paths[path] = map[string]*PathOperation{}
+	}
+	b.document.Paths[path][strings.ToLower(method)] = item
+}
+
+func (b *Builder) Document() *Document {
+	return b.document
+}
+
+// Path converts an echo route path to an OpenAPI path template.
+func Path(path string) string {
+	return pathParam.ReplaceAllString(path, "{$1}")
+}
+
+func (b *Builder) queryParameters(t reflect.Type) []*Parameter {
+	var parameters []*Parameter
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get("query")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		schema := b.schemaFor(field.Type)
+		applyValidation(schema, field.Tag.Get("validate"))
+		parameters = append(parameters, &Parameter{Name: name, In: "query", Schema: schema})
+	}
+	return parameters
+}
+
+// partial inlines a component schema without its required fields.
+func (b *Builder) partial(schema *Schema) *Schema {
+	if schema.Ref == "" {
+		return schema
+	}
+
+	component := *b.document.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")]
+	component.Required = nil
+
+	return &component
+}
+
+func jsonContent(schema *Schema) map[string]*MediaType {
+	return map[string]*MediaType{"application/json": {Schema: schema}}
+}
@@ -0,0 +1,86 @@
+package openapi
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilder(t *testing.T) {
+	t.Run("should translate validate tags into constraints", func(t *testing.T) {
+		b := NewBuilder("test", "1")
+		b.Add(http.MethodPost, "/products", "products:write", Operation{Request: models.Product{}})
+
+		product := b.Document().Components.Schemas["Product"]
+
+		assert.ElementsMatch(t, []string{"title", "description", "price"}, product.Required)
+		assert.Equal(t, 0.0, *product.Properties["price"].ExclusiveMinimum)
+		assert.Equal(t, "number", product.Properties["price"].Type)
+		assert.Equal(t, "^[A-Z]{3}$", product.Properties["currency"].Pattern)
+		assert.True(t, product.Properties["id"].ReadOnly)
+		assert.Equal(t, []string{"string", "null"}, product.Properties["deleted_at"].Type)
+		assert.Equal(t, "object", product.Properties["attributes"].Type)
+	})
+
+	t.Run("should apply dive rules to items", func(t *testing.T) {
+		type Sizes struct {
+			Sizes []string `json:"sizes" validate:"required,dive,oneof=S M L"`
+		}
+		b := NewBuilder("test", "1")
+		b.Add(http.MethodPost, "/sizes", "products:write", Operation{Request: Sizes{}})
+
+		sizes := b.Document().Components.Schemas["Sizes"]
+
+		assert.Contains(t, sizes.Required, "sizes")
+		assert.Equal(t, []interface{}{"S", "M", "L"}, sizes.Properties["sizes"].Items.Enum)
+	})
+
+	t.Run("should leave out the fields hidden from json", func(t *testing.T) {
+		b := NewBuilder("test", "1")
+		b.Add(http.MethodPost, "/api-keys", "api_keys:manage", Operation{Request: models.APIKey{}})
+
+		apiKey := b.Document().Components.Schemas["APIKey"]
+
+		assert.Contains(t, apiKey.Required, "scopes")
+		assert.NotContains(t, apiKey.Properties, "hash")
+	})
+
+	t.Run("should document path parameters, security and errors", func(t *testing.T) {
+		b := NewBuilder("test", "1")
+		b.Add(http.MethodGet, "/products/:id", "products:read", Operation{Response: models.Product{}, Errors: []int{http.StatusNotFound}})
+
+		operation := b.Document().Paths["/products/{id}"]["get"]
+
+		assert.Equal(t, "id", operation.Parameters[0].Name)
+		assert.Len(t, operation.Security, 2)
+		assert.Equal(t, "#/components/schemas/Product", operation.Responses["200"].Content["application/json"].Schema.Ref)
+		for _, code := range []string{"401", "403", "404", "429"} {
+			assert.Equal(t, "#/components/schemas/Error", operation.Responses[code].Content["application/json"].Schema.Ref)
+		}
+	})
+
+	t.Run("should type the ids as integers", func(t *testing.T) {
+		b := NewBuilder("test", "1")
+		b.Add(http.MethodGet, "/products/:id/variants/:variant_id", "products:read", Operation{})
+		b.Add(http.MethodDelete, "/products/:id/tags/:tag", "products:write", Operation{})
+
+		variant := b.Document().Paths["/products/{id}/variants/{variant_id}"]["get"]
+		tag := b.Document().Paths["/products/{id}/tags/{tag}"]["delete"]
+
+		assert.Equal(t, "integer", variant.Parameters[0].Schema.Type)
+		assert.Equal(t, "integer", variant.Parameters[1].Schema.Type)
+		assert.Equal(t, "string", tag.Parameters[1].Schema.Type)
+	})
+
+	t.Run("should drop required fields from partial requests", func(t *testing.T) {
+		b := NewBuilder("test", "1")
+		b.Add(http.MethodPut, "/products/:id", "products:write", Operation{Request: models.Product{}, PartialRequest: true})
+
+		schema := b.Document().Paths["/products/{id}"]["put"].RequestBody.Content["application/json"].Schema
+
+		assert.Empty(t, schema.Required)
+		assert.NotEmpty(t, b.Document().Components.Schemas["Product"].Required)
+	})
+}
@@ -0,0 +1,266 @@
+package openapi
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/pkg/money"
+	"gorm.io/gorm"
+)
+
+type Schema struct {
+	Ref              string             `json:"$ref,omitempty"`
+	Type             interface{}        `json:"type,omitempty"`
+	Format           string             `json:"format,omitempty"`
+	Properties       map[string]*Schema `json:"properties,omitempty"`
+	Required         []string           `json:"required,omitempty"`
+	Items            *Schema            `json:"items,omitempty"`
+	Enum             []interface{}      `json:"enum,omitempty"`
+	Pattern          string             `json:"pattern,omitempty"`
+	Minimum          *float64           `json:"minimum,omitempty"`
+	ExclusiveMinimum *float64           `json:"exclusiveMinimum,omitempty"`
+	Maximum          *float64           `json:"maximum,omitempty"`
+	ExclusiveMaximum *float64           `json:"exclusiveMaximum,omitempty"`
+	MinLength        *int               `json:"minLength,omitempty"`
+	MaxLength        *int               `json:"maxLength,omitempty"`
+	MinItems         *int               `json:"minItems,omitempty"`
+	MaxItems         *int               `json:"maxItems,omitempty"`
+	ReadOnly         bool               `json:"readOnly,omitempty"`
+}
+
+// typer is implemented by the types whose JSON encoding isn't the one of their
+// kind, such as slices encoded as objects.
+type typer interface {
+	OpenAPIType() string
+}
+
+var (
+	typerType     = reflect.TypeOf((*typer)(nil)).Elem()
+	timeType      = reflect.TypeOf(time.Time{})
+	deletedAtType = reflect.TypeOf(gorm.DeletedAt{})
+	amountType    = reflect.TypeOf(money.Amount(0))
+	currencyType  = reflect.TypeOf(money.Currency(""))
+)
+
+// Fields managed by GORM are never accepted from clients.
+var readOnlyFields = map[string]bool{"CreatedAt": true, "UpdatedAt": true, "DeletedAt": true}
+
+// schemaFor returns the schema of t, registering structs as components and
+// referencing them.
+func (b *Builder) schemaFor(t reflect.Type) *Schema {
+	nullable := false
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		nullable = true
+	}
+
+	var schema *Schema
+	switch {
+	case t.Implements(typerType):
+		schema = &Schema{Type: reflect.Zero(t).Interface().(typer).OpenAPIType()}
+	case t == timeType:
+		schema = &Schema{Type: "string", Format: "date-time"}
+	case t == deletedAtType:
+		schema = &Schema{Type: "string", Format: "date-time"}
+		nullable = true
+	case t == amountType:
+		schema = &Schema{Type: "number"}
+	case t == currencyType:
+		schema = &Schema{Type: "string", Pattern: "^[A-Z]{3}$"}
+	case t.Kind() == reflect.Struct:
+		return &Schema{Ref: b.component(t)}
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		schema = &Schema{Type: "array", Items: b.schemaFor(t.Elem())}
+	case t.Kind() == reflect.Map:
+		schema = &Schema{Type: "object"}
+	case t.Kind() == reflect.Bool:
+		schema = &Schema{Type: "boolean"}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		schema = &Schema{Type: "number"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Int64:
+		schema = &Schema{Type: "integer"}
+	case t.Kind() >= reflect.Uint && t.Kind() <= reflect.Uint64:
+		schema = &Schema{Type: "integer", Minimum: float(0)}
+	default:
+		schema = &Schema{Type: "string"}
+	}
+
+	if nullable {
+		schema.Type = []string{schema.Type.(string), "null"}
+	}
+	return schema
+}
+
+func (b *Builder) component(t reflect.Type) string {
+	ref := "#/components/schemas/" + t.Name()
+	if _, ok := b.document.Components.Schemas[t.Name()]; ok {
+		return ref
+	}
+
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	b.document.Components.Schemas[t.Name()] = schema
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, omitted := jsonName(field)
+		if omitted {
+			continue
+		}
+
+		property := b.schemaFor(field.Type)
+		if property.Ref != "" {
+			schema.Properties[name] = property
+			continue
+		}
+
+		if readOnlyFields[field.Name] || strings.Contains(field.Tag.Get("gorm"), "primaryKey") || field.Tag.Get("openapi") == "readOnly" {
+			property.ReadOnly = true
+		}
+
+		if applyValidation(property, field.Tag.Get("validate")) {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = property
+	}
+
+	return ref
+}
+
+func jsonName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", true
+	}
+
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", true
+	}
+
+	name := strings.Split(tag, ",")[0]
+	if name == "" {
+		name = field.Name
+	}
+	return name, false
+}
+
+// applyValidation translates go-playground/validator rules into schema
+// constraints and reports whether the field is required. Rules after "dive"
+// apply to the items of a slice.
+func applyValidation(schema *Schema, tag string) bool {
+	required := false
+	target := schema
+
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		kind := schemaType(target)
+
+		switch name {
+		case "required":
+			required = required || target == schema
+		case "dive":
+			if target.Items != nil {
+				target = target.Items
+			}
+		case "gt", "gte", "min", "lt", "lte", "max", "len":
+			applyBound(target, kind, name, param)
+		case "oneof":
+			for _, value := range strings.Fields(param) {
+				target.Enum = append(target.Enum, enumValue(kind, value))
+			}
+		case "email":
+			target.Format = "email"
+		case "url", "uri":
+			target.Format = "uri"
+		case "uuid", "uuid4":
+			target.Format = "uuid"
+		case "alphanum":
+			target.Pattern = "^[a-zA-Z0-9]+$"
+		case "numeric":
+			target.Pattern = "^[-+]?[0-9]+(\\.[0-9]+)?$"
+		}
+	}
+
+	return required
+}
+
+func applyBound(schema *Schema, kind, rule, param string) {
+	value, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+
+	if kind == "number" || kind == "integer" {
+		switch rule {
+		case "gt":
+			schema.ExclusiveMinimum = float(value)
+		case "gte", "min":
+			schema.Minimum = float(value)
+		case "lt":
+			schema.ExclusiveMaximum = float(value)
+		case "lte", "max":
+			schema.Maximum = float(value)
+		case "len":
+			schema.Minimum, schema.Maximum = float(value), float(value)
+		}
+		return
+	}
+
+	// For strings and arrays the bounds apply to the length. "gt" and "lt"
+	// are exclusive, so they move the bound by one.
+	n := int(value)
+	lower, upper := (*int)(nil), (*int)(nil)
+	switch rule {
+	case "gt":
+		lower = integer(n + 1)
+	case "gte", "min":
+		lower = integer(n)
+	case "lt":
+		upper = integer(n - 1)
+	case "lte", "max":
+		upper = integer(n)
+	case "len":
+		lower, upper = integer(n), integer(n)
+	}
+
+	if kind == "array" {
+		schema.MinItems, schema.MaxItems = pick(schema.MinItems, lower), pick(schema.MaxItems, upper)
+		return
+	}
+	schema.MinLength, schema.MaxLength = pick(schema.MinLength, lower), pick(schema.MaxLength, upper)
+}
+
+func schemaType(schema *Schema) string {
+	switch t := schema.Type.(type) {
+	case string:
+		return t
+	case []string:
+		return t[0]
+	}
+	return ""
+}
+
+func enumValue(kind, value string) interface{} {
+	if kind == "number" || kind == "integer" {
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return n
+		}
+	}
+	return value
+}
+
+func pick(current, next *int) *int {
+	if next != nil {
+		return next
+	}
+	return current
+}
+
+func float(v float64) *float64 {
+	return &v
+}
+
+func integer(v int) *int {
+	return &v
+}
@@ -0,0 +1,20 @@
+package openapi
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/labstack/echo"
+	swaggerFiles "github.com/swaggo/files"
+)
+
+//go:embed swagger/index.html
+var swaggerIndex []byte
+
+// SwaggerUI serves the page at /docs. The Swagger UI assets are bundled in the
+// binary and served by SwaggerAssets.
+func SwaggerUI(c echo.Context) error {
+	return c.HTMLBlob(http.StatusOK, swaggerIndex)
+}
+
+var SwaggerAssets = echo.WrapHandler(http.StripPrefix("/docs", http.FileServer(swaggerFiles.HTTP)))
@@ -1,21 +1,92 @@
 package http
 
 import (
+	"context"
+	"encoding/json"
+	"expvar"
 	"log"
+	"net"
 	"net/http"
+	"time"
 
 	"github.com/adrianosiqe/eulabs-challenge-api/internal/core/interfaces"
 	"github.com/adrianosiqe/eulabs-challenge-api/internal/core/utils"
 	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/handlers"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
 	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/services"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/http/middlewares"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/http/openapi"
 	"github.com/go-playground/validator"
 	"github.com/labstack/echo"
 	"github.com/labstack/echo/middleware"
 )
 
 type Server struct {
-	echo           *echo.Echo
-	productHandler *handlers.ProductHandler
+	echo             *echo.Echo
+	productHandler   *handlers.ProductHandler
+	categoryHandler  *handlers.CategoryHandler
+	attributeHandler *handlers.AttributeHandler
+	tagHandler       *handlers.TagHandler
+	variantHandler   *handlers.VariantHandler
+	mediaHandler     *handlers.MediaHandler
+	inventoryHandler *handlers.InventoryHandler
+	apiKeyHandler    *handlers.APIKeyHandler
+	apiKeyService    interfaces.APIKeyServiceInterface
+	options          Options
+	openapi          *openapi.Builder
+}
+
+// Repositories holds the storage the server is built on.
+type Repositories struct {
+	Products   interfaces.ProductRespositoryInterface
+	Categories interfaces.CategoryRepositoryInterface
+	Attributes interfaces.AttributeRepositoryInterface
+	Tags       interfaces.TagRepositoryInterface
+	Variants   interfaces.VariantRepositoryInterface
+	Media      interfaces.MediaRepositoryInterface
+	Inventory  interfaces.InventoryRepositoryInterface
+	APIKeys    interfaces.APIKeyRepositoryInterface
+	// MediaStorage keeps the files of the media, whose metadata is in Media.
+	MediaStorage interfaces.MediaStorageInterface
+}
+
+// Options configures authentication, authorization, rate limiting, the
+// product rules, the reservations and the media uploads.
+type Options struct {
+	Policy         *middlewares.Policy
+	JWTSecret      string
+	RateLimitStore middlewares.RateLimitStore
+	// RateLimits holds the per-client limit of each route group, keyed by the
+	// group name. Groups without an entry use DefaultRateLimit. The "ip" entry
+	// limits each IP address across the whole API before the authentication,
+	// and defaults to DefaultIPRateLimit.
+	RateLimits map[string]middlewares.RateLimit
+	// TrustedProxies are the proxies whose X-Forwarded-For tells the IP
+	// address of the client; without them, the address of the connection is
+	// used.
+	TrustedProxies []*net.IPNet
+	Products       services.ProductOptions
+	Inventory      services.InventoryOptions
+	Media          services.MediaOptions
+}
+
+var DefaultRateLimit = middlewares.RateLimit{Requests: 100, Period: time.Minute}
+
+// DefaultIPRateLimit is higher than DefaultRateLimit, as it is shared by every
+// route group and by the clients behind the same address.
+var DefaultIPRateLimit = middlewares.RateLimit{Requests: 300, Period: time.Minute}
+
+// DefaultMediaOptions is used when Options.Media is left empty.
+var DefaultMediaOptions = services.MediaOptions{MaxSize: 10 << 20, ThumbnailSize: 256}
+
+// route declares an endpoint together with the permission it requires and its
+// OpenAPI documentation.
+type route struct {
+	method     string
+	path       string
+	handler    echo.HandlerFunc
+	permission middlewares.Permission
+	doc        openapi.Operation
 }
 
 type CustomValidator struct {
@@ -29,7 +100,7 @@ func (cv *CustomValidator) Validate(i interface{}) error {
 	return nil
 }
 
-func NewServer(productRepository interfaces.ProductRespositoryInterface) *Server {
+func NewServer(repositories Repositories, options Options) *Server {
 	e := echo.New()
 	e.Validator = &CustomValidator{validator: validator.New()}
 
@@ -41,38 +112,393 @@ func NewServer(productRepository interfaces.ProductRespositoryInterface) *Server
 
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
 		AllowOrigins:     []string{"*"},
-		AllowHeaders:     []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization, echo.HeaderContentLength},
-		AllowMethods:     []string{http.MethodGet, http.MethodPut, http.MethodPost, http.MethodDelete},
+		AllowHeaders:     []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization, echo.HeaderContentLength, middlewares.HeaderAPIKey},
+		AllowMethods:     []string{http.MethodGet, http.MethodPut, http.MethodPatch, http.MethodPost, http.MethodDelete},
+		ExposeHeaders:    []string{middlewares.HeaderRateLimitLimit, middlewares.HeaderRateLimitRemaining, middlewares.HeaderRateLimitReset, middlewares.HeaderRetryAfter},
 		AllowCredentials: true,
 	}))
 	e.Use(middleware.LoggerWithConfig(loggerConfig))
 	e.Use(middleware.Recover())
 
-	productService := services.NewProductService(productRepository)
-	productHandler := handlers.NewProductHandler(productService)
+	if options.Policy == nil {
+		options.Policy = middlewares.DefaultPolicy()
+	}
+	if options.RateLimitStore == nil {
+		options.RateLimitStore = middlewares.NewMemoryRateLimitStore()
+	}
+	if options.Media == (services.MediaOptions{}) {
+		options.Media = DefaultMediaOptions
+	}
+
+	productService := services.NewProductService(repositories.Products, repositories.Categories, repositories.Attributes, options.Products).WithMedia(repositories.MediaStorage)
+	isEditor := middlewares.Granted(options.Policy, middlewares.PermissionProductsWrite)
+	productHandler := handlers.NewProductHandler(productService, isEditor, middlewares.Subject)
+	visibility := handlers.NewProductVisibility(productService, isEditor)
+	categoryHandler := handlers.NewCategoryHandler(services.NewCategoryService(repositories.Categories))
+	attributeHandler := handlers.NewAttributeHandler(services.NewAttributeService(repositories.Attributes))
+	tagHandler := handlers.NewTagHandler(services.NewTagService(repositories.Tags))
+	variantHandler := handlers.NewVariantHandler(services.NewVariantService(repositories.Variants, repositories.Products, options.Products), visibility)
+	mediaService := services.NewMediaService(repositories.Media, repositories.Products, repositories.MediaStorage, options.Media)
+	mediaHandler := handlers.NewMediaHandler(mediaService, visibility, options.Media.MaxSize)
+	inventoryHandler := handlers.NewInventoryHandler(services.NewInventoryService(repositories.Inventory, options.Inventory), visibility)
+	apiKeyService := services.NewAPIKeyService(repositories.APIKeys)
+	scopes := make([]string, 0, len(middlewares.Permissions()))
+	for _, permission := range middlewares.Permissions() {
+		scopes = append(scopes, string(permission))
+	}
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService, scopes)
 
 	return &Server{
-		echo:           e,
-		productHandler: productHandler,
+		echo:             e,
+		productHandler:   productHandler,
+		categoryHandler:  categoryHandler,
+		attributeHandler: attributeHandler,
+		tagHandler:       tagHandler,
+		variantHandler:   variantHandler,
+		mediaHandler:     mediaHandler,
+		inventoryHandler: inventoryHandler,
+		apiKeyHandler:    apiKeyHandler,
+		apiKeyService:    apiKeyService,
+		options:          options,
+		openapi:          openapi.NewBuilder("EuLabs API", "1.0.0"),
 	}
 }
 
 func (s *Server) RouteInit(address string) {
-	s.routeConfig()
-
-	err := s.echo.Start(address)
+	err := s.Serve(context.Background(), address)
 	if err != nil {
 		log.Fatalf("Failed To Start The Server: %v", err)
 	}
 }
 
+// ShutdownTimeout is how long Serve waits for the requests in flight once its
+// context is done.
+var ShutdownTimeout = 10 * time.Second
+
+// Serve configures the routes and serves address until ctx is done, then shuts
+// the server down gracefully.
+func (s *Server) Serve(ctx context.Context, address string) error {
+	s.routeConfig()
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- s.echo.Start(address)
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	defer cancel()
+	return s.echo.Shutdown(shutdownCtx)
+}
+
+// Handler configures the routes and returns the server as an http.Handler, to
+// be served by something other than RouteInit such as an httptest.Server.
+func (s *Server) Handler() http.Handler {
+	s.routeConfig()
+	return s.echo
+}
+
 func (s *Server) routeConfig() {
+	s.echo.GET("/openapi.json", s.openAPI)
+	s.echo.GET("/docs", openapi.SwaggerUI)
+	s.echo.GET("/docs/*", openapi.SwaggerAssets)
+
 	api := s.echo.Group("/api/v1")
 
-	products := api.Group("/products")
-	products.GET("", s.productHandler.Index)
-	products.POST("", s.productHandler.Create)
-	products.GET("/:id", s.productHandler.Show)
-	products.DELETE("/:id", s.productHandler.Delete)
-	products.PUT("/:id", s.productHandler.Update)
+	api.Use(middlewares.TrustedProxies(s.options.TrustedProxies), s.ipRateLimiter(), middlewares.APIKey(s.apiKeyService), middlewares.JWT(s.options.JWTSecret))
+
+	products := api.Group("/products", s.rateLimiter("products"))
+	s.register(products, []route{
+		{http.MethodGet, "", s.productHandler.Index, middlewares.PermissionProductsRead, openapi.Operation{
+			Summary: "List products, optionally of a category and its subcategories, with some tags or with attribute values, as in attr.weight_gt=2; only editors see the products that aren't published", Tags: []string{"products"}, Query: models.ProductFilter{}, Response: []models.Product{},
+			Errors: []int{http.StatusBadRequest, http.StatusInternalServerError},
+		}},
+		{http.MethodPost, "", s.productHandler.Create, middlewares.PermissionProductsWrite, openapi.Operation{
+			Summary: "Create a product", Tags: []string{"products"}, Request: models.Product{}, Response: models.Product{}, Status: http.StatusCreated,
+			Errors: []int{http.StatusBadRequest, http.StatusConflict, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+		}},
+		{http.MethodGet, "/:id", s.productHandler.Show, middlewares.PermissionProductsRead, openapi.Operation{
+			Summary: "Get a product", Tags: []string{"products"}, Response: models.Product{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound},
+		}},
+		{http.MethodGet, "/by-sku/:sku", s.productHandler.ShowBySKU, middlewares.PermissionProductsRead, openapi.Operation{
+			Summary: "Get a product by its SKU", Tags: []string{"products"}, Response: models.Product{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound},
+		}},
+		{http.MethodGet, "/by-slug/:slug", s.productHandler.ShowBySlug, middlewares.PermissionProductsRead, openapi.Operation{
+			Summary: "Get a product by its slug", Tags: []string{"products"}, Response: models.Product{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound},
+		}},
+		{http.MethodDelete, "/:id", s.productHandler.Delete, middlewares.PermissionProductsDelete, openapi.Operation{
+			Summary: "Delete a product", Tags: []string{"products"}, Status: http.StatusNoContent,
+			Errors: []int{http.StatusBadRequest, http.StatusInternalServerError},
+		}},
+		{http.MethodDelete, "/:id/purge", s.productHandler.Purge, middlewares.PermissionProductsPurge, openapi.Operation{
+			Summary: "Permanently remove a product, deleted or not, with its variants, media, stock and price history", Tags: []string{"products"}, Status: http.StatusNoContent,
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+		}},
+		{http.MethodPost, "/:id/restore", s.productHandler.Restore, middlewares.PermissionProductsRestore, openapi.Operation{
+			Summary: "Restore a deleted product", Tags: []string{"products"}, Response: models.Product{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+		}},
+		{http.MethodPut, "/:id", s.productHandler.Update, middlewares.PermissionProductsWrite, openapi.Operation{
+			Summary: "Update a product", Tags: []string{"products"}, Request: models.Product{}, PartialRequest: true, Response: models.Product{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+		}},
+		{http.MethodPatch, "/:id", s.productHandler.Update, middlewares.PermissionProductsWrite, openapi.Operation{
+			Summary: "Partially update a product", Tags: []string{"products"}, Request: models.Product{}, PartialRequest: true, Response: models.Product{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+		}},
+		{http.MethodGet, "/:id/price-history", s.productHandler.PriceHistory, middlewares.PermissionProductsRead, openapi.Operation{
+			Summary: "Get the price of a product, its lowest price in the last 30 days and the changes of its price, optionally between two dates", Tags: []string{"products"}, Query: models.PriceHistoryFilter{}, Response: models.PriceHistory{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+		}},
+		{http.MethodPost, "/:id/publish", s.productHandler.Publish, middlewares.PermissionProductsWrite, openapi.Operation{
+			Summary: "Publish a draft product", Tags: []string{"products"}, Response: models.Product{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError},
+		}},
+		{http.MethodPost, "/:id/archive", s.productHandler.Archive, middlewares.PermissionProductsWrite, openapi.Operation{
+			Summary: "Archive a published product", Tags: []string{"products"}, Response: models.Product{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError},
+		}},
+		{http.MethodPost, "/:id/unarchive", s.productHandler.Unarchive, middlewares.PermissionProductsWrite, openapi.Operation{
+			Summary: "Turn an archived product back into a draft", Tags: []string{"products"}, Response: models.Product{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError},
+		}},
+		{http.MethodPut, "/:id/schedule", s.productHandler.Schedule, middlewares.PermissionProductsWrite, openapi.Operation{
+			Summary: "Schedule the publication and the archiving of a product", Tags: []string{"products"}, Request: models.ProductSchedule{}, Response: models.Product{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+		}},
+		{http.MethodPut, "/:id/options", s.productHandler.SetOptions, middlewares.PermissionProductsWrite, openapi.Operation{
+			Summary: "Replace the options the variants of a product are made of", Tags: []string{"variants"}, Request: models.ProductOptions{}, Response: models.Product{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+		}},
+		{http.MethodGet, "/:id/variants", s.variantHandler.Index, middlewares.PermissionProductsRead, openapi.Operation{
+			Summary: "List the variants of a product", Tags: []string{"variants"}, Response: []models.ProductVariant{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+		}},
+		{http.MethodPost, "/:id/variants", s.variantHandler.Create, middlewares.PermissionProductsWrite, openapi.Operation{
+			Summary: "Add a variant to a product, picking a value of each of its options", Tags: []string{"variants"}, Request: models.ProductVariant{}, Response: models.ProductVariant{}, Status: http.StatusCreated,
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+		}},
+		{http.MethodPost, "/:id/variants/generate", s.variantHandler.Generate, middlewares.PermissionProductsWrite, openapi.Operation{
+			Summary: "Add a variant for every combination of the options of a product that has none", Tags: []string{"variants"}, Response: []models.ProductVariant{}, Status: http.StatusCreated,
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+		}},
+		{http.MethodGet, "/:id/variants/:variant_id", s.variantHandler.Show, middlewares.PermissionProductsRead, openapi.Operation{
+			Summary: "Get a variant of a product", Tags: []string{"variants"}, Response: models.ProductVariant{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+		}},
+		{http.MethodPut, "/:id/variants/:variant_id", s.variantHandler.Update, middlewares.PermissionProductsWrite, openapi.Operation{
+			Summary: "Update a variant of a product; a price of 0 removes its override", Tags: []string{"variants"}, Request: models.ProductVariant{}, PartialRequest: true, Response: models.ProductVariant{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+		}},
+		{http.MethodDelete, "/:id/variants/:variant_id", s.variantHandler.Delete, middlewares.PermissionProductsWrite, openapi.Operation{
+			Summary: "Delete a variant of a product with its stock", Tags: []string{"variants"}, Status: http.StatusNoContent,
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+		}},
+		{http.MethodGet, "/:id/media", s.mediaHandler.Index, middlewares.PermissionProductsRead, openapi.Operation{
+			Summary: "List the images of a product by position", Tags: []string{"media"}, Response: []models.ProductMedia{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+		}},
+		{http.MethodPost, "/:id/media", s.mediaHandler.Upload, middlewares.PermissionProductsWrite, openapi.Operation{
+			Summary: "Upload a JPEG, PNG or GIF image of a product in the file field of a multipart form; the first one is the primary image", Tags: []string{"media"}, Response: models.ProductMedia{}, Status: http.StatusCreated,
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusRequestEntityTooLarge, http.StatusUnsupportedMediaType, http.StatusInternalServerError},
+		}},
+		{http.MethodPut, "/:id/media/order", s.mediaHandler.Reorder, middlewares.PermissionProductsWrite, openapi.Operation{
+			Summary: "Reorder the images of a product, listing all their ids", Tags: []string{"media"}, Request: models.MediaOrder{}, Response: []models.ProductMedia{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+		}},
+		{http.MethodGet, "/:id/media/:media_id", s.mediaHandler.Show, middlewares.PermissionProductsRead, openapi.Operation{
+			Summary: "Get an image of a product", Tags: []string{"media"}, Response: models.ProductMedia{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+		}},
+		{http.MethodGet, "/:id/media/:media_id/file", s.mediaHandler.File, middlewares.PermissionProductsRead, openapi.Operation{
+			Summary: "Download an image of a product", Tags: []string{"media"},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+		}},
+		{http.MethodGet, "/:id/media/:media_id/thumbnail", s.mediaHandler.Thumbnail, middlewares.PermissionProductsRead, openapi.Operation{
+			Summary: "Download the thumbnail of an image of a product", Tags: []string{"media"},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+		}},
+		{http.MethodPut, "/:id/media/:media_id/primary", s.mediaHandler.SetPrimary, middlewares.PermissionProductsWrite, openapi.Operation{
+			Summary: "Make an image the primary one of its product", Tags: []string{"media"}, Response: []models.ProductMedia{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+		}},
+		{http.MethodDelete, "/:id/media/:media_id", s.mediaHandler.Delete, middlewares.PermissionProductsWrite, openapi.Operation{
+			Summary: "Delete an image of a product with its files; the next one becomes primary if it was", Tags: []string{"media"}, Status: http.StatusNoContent,
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+		}},
+		{http.MethodGet, "/:id/stock", s.inventoryHandler.ShowStock, middlewares.PermissionProductsRead, openapi.Operation{
+			Summary: "Get the stock of a product, in total and per variant and warehouse", Tags: []string{"inventory"}, Response: models.Stock{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+		}},
+		{http.MethodPost, "/:id/stock/adjustments", s.inventoryHandler.Adjust, middlewares.PermissionProductsWrite, openapi.Operation{
+			Summary: "Change the stock on hand of a product, or of one of its variants, in a warehouse", Tags: []string{"inventory"}, Request: models.StockAdjustment{}, Response: models.StockLevel{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+		}},
+		{http.MethodPost, "/:id/reservations", s.inventoryHandler.Reserve, middlewares.PermissionProductsWrite, openapi.Operation{
+			Summary: "Reserve stock of a product, or of one of its variants, until the reservation expires", Tags: []string{"inventory"}, Request: models.ReservationRequest{}, Response: models.Reservation{}, Status: http.StatusCreated,
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+		}},
+		{http.MethodPut, "/:id/categories", s.productHandler.SetCategories, middlewares.PermissionProductsWrite, openapi.Operation{
+			Summary: "Replace the categories of a product", Tags: []string{"products"}, Request: models.ProductCategories{}, Response: models.Product{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+		}},
+		{http.MethodPost, "/:id/tags", s.productHandler.AddTags, middlewares.PermissionProductsWrite, openapi.Operation{
+			Summary: "Add tags to a product", Tags: []string{"products"}, Request: models.ProductTags{}, Response: models.Product{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+		}},
+		{http.MethodDelete, "/:id/tags/:tag", s.productHandler.RemoveTag, middlewares.PermissionProductsWrite, openapi.Operation{
+			Summary: "Remove a tag from a product", Tags: []string{"products"}, Response: models.Product{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+		}},
+	})
+
+	categories := api.Group("/categories", s.rateLimiter("categories"))
+	s.register(categories, []route{
+		{http.MethodGet, "", s.categoryHandler.Index, middlewares.PermissionProductsRead, openapi.Operation{
+			Summary: "List categories, parents first", Tags: []string{"categories"}, Response: []models.Category{},
+			Errors: []int{http.StatusInternalServerError},
+		}},
+		{http.MethodPost, "", s.categoryHandler.Create, middlewares.PermissionProductsWrite, openapi.Operation{
+			Summary: "Create a category", Tags: []string{"categories"}, Request: models.Category{}, Response: models.Category{}, Status: http.StatusCreated,
+			Errors: []int{http.StatusBadRequest, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+		}},
+		{http.MethodGet, "/:id", s.categoryHandler.Show, middlewares.PermissionProductsRead, openapi.Operation{
+			Summary: "Get a category", Tags: []string{"categories"}, Response: models.Category{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound},
+		}},
+		{http.MethodPut, "/:id", s.categoryHandler.Update, middlewares.PermissionProductsWrite, openapi.Operation{
+			Summary: "Rename a category", Tags: []string{"categories"}, Request: models.Category{}, PartialRequest: true, Response: models.Category{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+		}},
+		{http.MethodPost, "/:id/move", s.categoryHandler.Move, middlewares.PermissionProductsWrite, openapi.Operation{
+			Summary: "Move a category under another one, or to the root", Tags: []string{"categories"}, Request: models.CategoryMove{}, Response: models.Category{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+		}},
+		{http.MethodDelete, "/:id", s.categoryHandler.Delete, middlewares.PermissionProductsDelete, openapi.Operation{
+			Summary: "Delete a category without subcategories", Tags: []string{"categories"}, Status: http.StatusNoContent,
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError},
+		}},
+	})
+
+	attributes := api.Group("/attributes", s.rateLimiter("attributes"))
+	s.register(attributes, []route{
+		{http.MethodGet, "", s.attributeHandler.Index, middlewares.PermissionProductsRead, openapi.Operation{
+			Summary: "List the attribute definitions", Tags: []string{"attributes"}, Response: []models.AttributeDefinition{},
+			Errors: []int{http.StatusInternalServerError},
+		}},
+		{http.MethodPost, "", s.attributeHandler.Create, middlewares.PermissionProductsWrite, openapi.Operation{
+			Summary: "Define an attribute of the products", Tags: []string{"attributes"}, Request: models.AttributeDefinition{}, Response: models.AttributeDefinition{}, Status: http.StatusCreated,
+			Errors: []int{http.StatusBadRequest, http.StatusConflict, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+		}},
+		{http.MethodGet, "/:key", s.attributeHandler.Show, middlewares.PermissionProductsRead, openapi.Operation{
+			Summary: "Get an attribute definition", Tags: []string{"attributes"}, Response: models.AttributeDefinition{},
+			Errors: []int{http.StatusNotFound},
+		}},
+		{http.MethodPut, "/:key", s.attributeHandler.Update, middlewares.PermissionProductsWrite, openapi.Operation{
+			Summary: "Replace the name and the rules of an attribute", Tags: []string{"attributes"}, Request: models.AttributeDefinition{}, PartialRequest: true, Response: models.AttributeDefinition{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+		}},
+		{http.MethodDelete, "/:key", s.attributeHandler.Delete, middlewares.PermissionProductsDelete, openapi.Operation{
+			Summary: "Delete an attribute and the values products have for it", Tags: []string{"attributes"}, Status: http.StatusNoContent,
+			Errors: []int{http.StatusNotFound, http.StatusInternalServerError},
+		}},
+	})
+
+	tags := api.Group("/tags", s.rateLimiter("tags"))
+	s.register(tags, []route{
+		{http.MethodGet, "", s.tagHandler.Index, middlewares.PermissionProductsRead, openapi.Operation{
+			Summary: "List the tags in use with their product counts", Tags: []string{"tags"}, Response: []models.TagCount{},
+			Errors: []int{http.StatusInternalServerError},
+		}},
+	})
+
+	reservations := api.Group("/reservations", s.rateLimiter("reservations"))
+	s.register(reservations, []route{
+		{http.MethodGet, "/:id", s.inventoryHandler.ShowReservation, middlewares.PermissionProductsRead, openapi.Operation{
+			Summary: "Get a reservation", Tags: []string{"inventory"}, Response: models.Reservation{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+		}},
+		{http.MethodPost, "/:id/commit", s.inventoryHandler.CommitReservation, middlewares.PermissionProductsWrite, openapi.Operation{
+			Summary: "Take the reserved stock out of the stock on hand, as sold", Tags: []string{"inventory"}, Response: models.Reservation{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError},
+		}},
+		{http.MethodDelete, "/:id", s.inventoryHandler.ReleaseReservation, middlewares.PermissionProductsWrite, openapi.Operation{
+			Summary: "Release a reservation, giving its stock back", Tags: []string{"inventory"}, Response: models.Reservation{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError},
+		}},
+	})
+
+	apiKeys := api.Group("/api-keys", s.rateLimiter("api-keys"))
+	s.register(apiKeys, []route{
+		{http.MethodGet, "", s.apiKeyHandler.Index, middlewares.PermissionAPIKeysManage, openapi.Operation{
+			Summary: "List API keys", Tags: []string{"api-keys"}, Response: []models.APIKey{},
+			Errors: []int{http.StatusInternalServerError},
+		}},
+		{http.MethodPost, "", s.apiKeyHandler.Create, middlewares.PermissionAPIKeysManage, openapi.Operation{
+			Summary: "Create an API key", Tags: []string{"api-keys"}, Request: models.APIKey{}, Response: models.APIKey{}, Status: http.StatusCreated,
+			Errors: []int{http.StatusBadRequest, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+		}},
+		{http.MethodPost, "/:id/rotate", s.apiKeyHandler.Rotate, middlewares.PermissionAPIKeysManage, openapi.Operation{
+			Summary: "Rotate an API key", Tags: []string{"api-keys"}, Response: models.APIKey{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError},
+		}},
+		{http.MethodDelete, "/:id", s.apiKeyHandler.Revoke, middlewares.PermissionAPIKeysManage, openapi.Operation{
+			Summary: "Revoke an API key", Tags: []string{"api-keys"}, Status: http.StatusNoContent,
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+		}},
+	})
+
+	metrics := api.Group("/metrics", s.rateLimiter("metrics"))
+	s.register(metrics, []route{
+		{http.MethodGet, "", s.metrics, middlewares.PermissionMetricsRead, openapi.Operation{
+			Summary: "Get the metrics of the instance", Tags: []string{"metrics"}, Response: map[string]interface{}{},
+		}},
+	})
+}
+
+func (s *Server) register(group *echo.Group, routes []route) {
+	for _, r := range routes {
+		registered := group.Add(r.method, r.path, r.handler, middlewares.Authorize(s.options.Policy, r.permission))
+		s.openapi.Add(registered.Method, registered.Path, string(r.permission), r.doc)
+	}
+}
+
+func (s *Server) openAPI(c echo.Context) error {
+	return c.JSON(http.StatusOK, s.openapi.Document())
+}
+
+// metrics returns the variables published with expvar, such as the product
+// cache counters, except the command line which may hold secrets.
+func (s *Server) metrics(c echo.Context) error {
+	metrics := map[string]json.RawMessage{}
+	expvar.Do(func(kv expvar.KeyValue) {
+		if kv.Key != "cmdline" {
+			metrics[kv.Key] = json.RawMessage(kv.Value.String())
+		}
+	})
+	return c.JSON(http.StatusOK, metrics)
+}
+
+func (s *Server) rateLimiter(group string) echo.MiddlewareFunc {
+	limit, ok := s.options.RateLimits[group]
+	if !ok {
+		limit = DefaultRateLimit
+	}
+	return middlewares.RateLimiter(s.options.RateLimitStore, group, limit)
+}
+
+func (s *Server) ipRateLimiter() echo.MiddlewareFunc {
+	limit, ok := s.options.RateLimits["ip"]
+	if !ok {
+		limit = DefaultIPRateLimit
+	}
+	return middlewares.IPRateLimiter(s.options.RateLimitStore, "ip", limit)
 }
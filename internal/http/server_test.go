@@ -0,0 +1,153 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/http/middlewares"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/http/openapi"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestServer() *Server {
+	s := NewServer(Repositories{Products: &mocks.MockProductRepository{}, Categories: &mocks.MockCategoryRepository{}, Attributes: &mocks.MockAttributeRepository{}, Tags: &mocks.MockTagRepository{}, Variants: &mocks.MockVariantRepository{}, Media: &mocks.MockMediaRepository{}, Inventory: &mocks.MockInventoryRepository{}, APIKeys: &mocks.MockAPIKeyRepository{}, MediaStorage: &mocks.MockMediaStorage{}}, Options{JWTSecret: "secret"})
+	s.routeConfig()
+	return s
+}
+
+func TestOpenAPI(t *testing.T) {
+	t.Run("should document every route", func(t *testing.T) {
+		s := newTestServer()
+		document := s.openapi.Document()
+
+		for _, r := range s.echo.Routes() {
+			// Group middlewares register catch-all routes, and the docs
+			// themselves are not part of the API.
+			if strings.HasSuffix(r.Name, "(*Group).Use.func1") || strings.HasPrefix(r.Path, "/docs") || r.Path == "/openapi.json" {
+				continue
+			}
+
+			operation := document.Paths[openapi.Path(r.Path)][strings.ToLower(r.Method)]
+			if assert.NotNil(t, operation, "%s %s is not documented", r.Method, r.Path) {
+				assert.NotEmpty(t, operation.Summary, "%s %s has no summary", r.Method, r.Path)
+			}
+		}
+	})
+
+	t.Run("should type the ids of the routes as integers", func(t *testing.T) {
+		document := newTestServer().openapi.Document()
+
+		operation := document.Paths["/api/v1/products/{id}/media/{media_id}"]["get"]
+
+		if assert.NotNil(t, operation) {
+			for _, parameter := range operation.Parameters {
+				assert.Equal(t, "integer", parameter.Schema.Type, parameter.Name)
+			}
+		}
+	})
+
+	t.Run("should serve the document", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+		rec := httptest.NewRecorder()
+
+		newTestServer().echo.ServeHTTP(rec, req)
+
+		var document map[string]interface{}
+		json.Unmarshal(rec.Body.Bytes(), &document)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, openapi.Version, document["openapi"])
+		assert.Contains(t, document["paths"], "/api/v1/products/{id}")
+	})
+
+	t.Run("should serve swagger ui", func(t *testing.T) {
+		for _, path := range []string{"/docs", "/docs/swagger-ui-bundle.js"} {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			rec := httptest.NewRecorder()
+
+			newTestServer().echo.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusOK, rec.Code, path)
+		}
+	})
+}
+
+func TestMetrics(t *testing.T) {
+	t.Run("should serve the published variables without the command line", func(t *testing.T) {
+		s := newTestServer()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/metrics", nil)
+		rec := httptest.NewRecorder()
+
+		err := s.metrics(s.echo.NewContext(req, rec))
+
+		var metrics map[string]interface{}
+		json.Unmarshal(rec.Body.Bytes(), &metrics)
+
+		assert.NoError(t, err)
+		assert.Contains(t, metrics, "memstats")
+		assert.NotContains(t, metrics, "cmdline")
+	})
+}
+
+func TestServe(t *testing.T) {
+	t.Run("should shut down when the context is done", func(t *testing.T) {
+		s := NewServer(Repositories{Products: &mocks.MockProductRepository{}, Categories: &mocks.MockCategoryRepository{}, Attributes: &mocks.MockAttributeRepository{}, Tags: &mocks.MockTagRepository{}, Variants: &mocks.MockVariantRepository{}, Media: &mocks.MockMediaRepository{}, Inventory: &mocks.MockInventoryRepository{}, APIKeys: &mocks.MockAPIKeyRepository{}, MediaStorage: &mocks.MockMediaStorage{}}, Options{JWTSecret: "secret"})
+		ctx, cancel := context.WithCancel(context.Background())
+
+		errs := make(chan error, 1)
+		go func() {
+			errs <- s.Serve(ctx, "127.0.0.1:0")
+		}()
+		cancel()
+
+		select {
+		case err := <-errs:
+			assert.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("the server didn't shut down")
+		}
+	})
+}
+
+func TestRateLimit(t *testing.T) {
+	t.Run("should limit the requests without credentials by ip", func(t *testing.T) {
+		s := NewServer(Repositories{Products: &mocks.MockProductRepository{}, Categories: &mocks.MockCategoryRepository{}, Attributes: &mocks.MockAttributeRepository{}, Tags: &mocks.MockTagRepository{}, Variants: &mocks.MockVariantRepository{}, Media: &mocks.MockMediaRepository{}, Inventory: &mocks.MockInventoryRepository{}, APIKeys: &mocks.MockAPIKeyRepository{}, MediaStorage: &mocks.MockMediaStorage{}}, Options{
+			JWTSecret:  "secret",
+			RateLimits: map[string]middlewares.RateLimit{"ip": {Requests: 1, Period: time.Minute}},
+		})
+		handler := s.Handler()
+
+		first := httptest.NewRecorder()
+		handler.ServeHTTP(first, httptest.NewRequest(http.MethodGet, "/api/v1/products", nil))
+		second := httptest.NewRecorder()
+		handler.ServeHTTP(second, httptest.NewRequest(http.MethodGet, "/api/v1/products", nil))
+
+		assert.NotEqual(t, http.StatusTooManyRequests, first.Code)
+		assert.Equal(t, http.StatusTooManyRequests, second.Code)
+		assert.Equal(t, "60", second.Header().Get(middlewares.HeaderRetryAfter))
+	})
+
+	t.Run("should not trust the forwarded address of a client", func(t *testing.T) {
+		s := NewServer(Repositories{Products: &mocks.MockProductRepository{}, Categories: &mocks.MockCategoryRepository{}, Attributes: &mocks.MockAttributeRepository{}, Tags: &mocks.MockTagRepository{}, Variants: &mocks.MockVariantRepository{}, Media: &mocks.MockMediaRepository{}, Inventory: &mocks.MockInventoryRepository{}, APIKeys: &mocks.MockAPIKeyRepository{}, MediaStorage: &mocks.MockMediaStorage{}}, Options{
+			JWTSecret:  "secret",
+			RateLimits: map[string]middlewares.RateLimit{"ip": {Requests: 1, Period: time.Minute}},
+		})
+		handler := s.Handler()
+
+		first := httptest.NewRecorder()
+		handler.ServeHTTP(first, httptest.NewRequest(http.MethodGet, "/api/v1/products", nil))
+		spoofed := httptest.NewRequest(http.MethodGet, "/api/v1/products", nil)
+		spoofed.Header.Set("X-Forwarded-For", "203.0.113.9")
+		second := httptest.NewRecorder()
+		handler.ServeHTTP(second, spoofed)
+
+		assert.NotEqual(t, http.StatusTooManyRequests, first.Code)
+		assert.Equal(t, http.StatusTooManyRequests, second.Code)
+	})
+}
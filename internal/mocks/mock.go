@@ -1,9 +1,11 @@
 package mocks
 
 import (
+	"io"
 	"time"
 
 	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/adrianosiqe/eulabs-challenge-api/pkg/money"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -11,8 +13,8 @@ type MockProductService struct {
 	mock.Mock
 }
 
-func (m *MockProductService) GetAllProducts() ([]*models.Product, error) {
-	args := m.Called()
+func (m *MockProductService) GetAllProducts(filter models.ProductFilter) ([]*models.Product, error) {
+	args := m.Called(filter)
 	if args.Error(1) != nil {
 		return nil, args.Error(1)
 	}
@@ -35,25 +37,118 @@ func (m *MockProductService) GetProductByID(id int) (*models.Product, error) {
 	return args.Get(0).(*models.Product), args.Error(1)
 }
 
-func (m *MockProductService) UpdateProduct(product *models.Product) (*models.Product, error) {
-	args := m.Called(product)
+func (m *MockProductService) GetProductForUpdate(id int) (*models.Product, error) {
+	args := m.Called(id)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Product), args.Error(1)
+}
+
+func (m *MockProductService) GetProductBySKU(sku string) (*models.Product, error) {
+	args := m.Called(sku)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Product), args.Error(1)
+}
+
+func (m *MockProductService) GetProductBySlug(slug string) (*models.Product, error) {
+	args := m.Called(slug)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Product), args.Error(1)
+}
+
+func (m *MockProductService) UpdateProduct(product *models.Product, audit models.Audit) (*models.Product, error) {
+	args := m.Called(product, audit)
 	if args.Error(1) != nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.Product), args.Error(1)
 }
 
+func (m *MockProductService) GetPriceHistory(id int, filter models.PriceHistoryFilter) (*models.PriceHistory, error) {
+	args := m.Called(id, filter)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PriceHistory), args.Error(1)
+}
+
 func (m *MockProductService) DeleteProduct(id int) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
+func (m *MockProductService) PurgeProduct(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockProductService) RestoreProduct(id int) (*models.Product, error) {
+	args := m.Called(id)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Product), args.Error(1)
+}
+
+func (m *MockProductService) TransitionProduct(id int, status models.ProductStatus) (*models.Product, error) {
+	args := m.Called(id, status)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Product), args.Error(1)
+}
+
+func (m *MockProductService) ScheduleProduct(id int, schedule models.ProductSchedule) (*models.Product, error) {
+	args := m.Called(id, schedule)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Product), args.Error(1)
+}
+
+func (m *MockProductService) SetProductCategories(id int, categoryIDs []uint) (*models.Product, error) {
+	args := m.Called(id, categoryIDs)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Product), args.Error(1)
+}
+
+func (m *MockProductService) SetProductOptions(id int, options []models.ProductOption) (*models.Product, error) {
+	args := m.Called(id, options)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Product), args.Error(1)
+}
+
+func (m *MockProductService) AddProductTags(id int, names []string) (*models.Product, error) {
+	args := m.Called(id, names)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Product), args.Error(1)
+}
+
+func (m *MockProductService) RemoveProductTag(id int, name string) (*models.Product, error) {
+	args := m.Called(id, name)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Product), args.Error(1)
+}
+
 type MockProductRepository struct {
 	mock.Mock
 }
 
-func (m *MockProductRepository) GetAll() ([]*models.Product, error) {
-	args := m.Called()
+func (m *MockProductRepository) GetAll(filter models.ProductFilter) ([]*models.Product, error) {
+	args := m.Called(filter)
 	if args.Error(1) != nil {
 		return nil, args.Error(1)
 	}
@@ -76,25 +171,121 @@ func (m *MockProductRepository) GetByID(id int) (*models.Product, error) {
 	return args.Get(0).(*models.Product), args.Error(1)
 }
 
-func (m *MockProductRepository) Update(product *models.Product) (*models.Product, error) {
-	args := m.Called(product)
+func (m *MockProductRepository) GetByIDFromPrimary(id int) (*models.Product, error) {
+	args := m.Called(id)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Product), args.Error(1)
+}
+
+func (m *MockProductRepository) GetBySKU(sku string) (*models.Product, error) {
+	args := m.Called(sku)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Product), args.Error(1)
+}
+
+func (m *MockProductRepository) GetBySlug(slug string) (*models.Product, error) {
+	args := m.Called(slug)
 	if args.Error(1) != nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.Product), args.Error(1)
 }
 
+func (m *MockProductRepository) Update(product *models.Product, audit models.Audit) (*models.Product, error) {
+	args := m.Called(product, audit)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Product), args.Error(1)
+}
+
+func (m *MockProductRepository) GetPriceHistory(productID int, filter models.PriceHistoryFilter) ([]*models.PriceChange, error) {
+	args := m.Called(productID, filter)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.PriceChange), args.Error(1)
+}
+
+func (m *MockProductRepository) UpdatePublication(product *models.Product, from models.ProductStatus) error {
+	args := m.Called(product, from)
+	return args.Error(0)
+}
+
+func (m *MockProductRepository) ApplySchedules(now time.Time, limit int) ([]uint, error) {
+	args := m.Called(now, limit)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]uint), args.Error(1)
+}
+
 func (m *MockProductRepository) Delete(id int) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
+func (m *MockProductRepository) Purge(id int) ([]*models.ProductMedia, error) {
+	args := m.Called(id)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.ProductMedia), args.Error(1)
+}
+
+func (m *MockProductRepository) Restore(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockProductRepository) GetBySeedKey(key string) (*models.Product, error) {
+	args := m.Called(key)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Product), args.Error(1)
+}
+
+func (m *MockProductRepository) DeleteSeeded() (int64, []*models.ProductMedia, error) {
+	args := m.Called()
+	if args.Error(2) != nil {
+		return 0, nil, args.Error(2)
+	}
+	return args.Get(0).(int64), args.Get(1).([]*models.ProductMedia), args.Error(2)
+}
+
+func (m *MockProductRepository) SetCategories(productID int, categories []models.Category) error {
+	args := m.Called(productID, categories)
+	return args.Error(0)
+}
+
+func (m *MockProductRepository) SetOptions(productID int, options []models.ProductOption) error {
+	args := m.Called(productID, options)
+	return args.Error(0)
+}
+
+func (m *MockProductRepository) AddTags(productID int, names []string) error {
+	args := m.Called(productID, names)
+	return args.Error(0)
+}
+
+func (m *MockProductRepository) RemoveTags(productID int, names []string) error {
+	args := m.Called(productID, names)
+	return args.Error(0)
+}
+
 var MockProducts = []*models.Product{
 	{
 		ID:          1,
 		Title:       "Bulbasaur",
 		Description: "There is a plant seed on its back right from the day this Pokémon is born. The seed slowly grows larger.",
-		Price:       99.99,
+		Price:       money.MustParseAmount("99.99"),
+		Currency:    money.DefaultCurrency,
+		Status:      models.ProductPublished,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	},
@@ -102,8 +293,710 @@ var MockProducts = []*models.Product{
 		ID:          2,
 		Title:       "Charmander",
 		Description: "It has a preference for hot things. When it rains, steam is said to spout from the tip of its tail.",
-		Price:       1093.45,
+		Price:       money.MustParseAmount("1093.45"),
+		Currency:    money.DefaultCurrency,
+		Status:      models.ProductPublished,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	},
 }
+
+type MockCategoryRepository struct {
+	mock.Mock
+}
+
+func (m *MockCategoryRepository) GetAll() ([]*models.Category, error) {
+	args := m.Called()
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Category), args.Error(1)
+}
+
+func (m *MockCategoryRepository) Create(category *models.Category) (*models.Category, error) {
+	args := m.Called(category)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Category), args.Error(1)
+}
+
+func (m *MockCategoryRepository) GetByID(id int) (*models.Category, error) {
+	args := m.Called(id)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Category), args.Error(1)
+}
+
+func (m *MockCategoryRepository) Update(category *models.Category) (*models.Category, error) {
+	args := m.Called(category)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Category), args.Error(1)
+}
+
+func (m *MockCategoryRepository) Move(id int, parentID *uint) (*models.Category, error) {
+	args := m.Called(id, parentID)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Category), args.Error(1)
+}
+
+func (m *MockCategoryRepository) Delete(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockCategoryRepository) DescendantIDs(id int) ([]uint, error) {
+	args := m.Called(id)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+type MockCategoryService struct {
+	mock.Mock
+}
+
+func (m *MockCategoryService) GetAllCategories() ([]*models.Category, error) {
+	args := m.Called()
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Category), args.Error(1)
+}
+
+func (m *MockCategoryService) CreateCategory(category *models.Category) (*models.Category, error) {
+	args := m.Called(category)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Category), args.Error(1)
+}
+
+func (m *MockCategoryService) GetCategoryByID(id int) (*models.Category, error) {
+	args := m.Called(id)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Category), args.Error(1)
+}
+
+func (m *MockCategoryService) UpdateCategory(category *models.Category) (*models.Category, error) {
+	args := m.Called(category)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Category), args.Error(1)
+}
+
+func (m *MockCategoryService) MoveCategory(id int, parentID *uint) (*models.Category, error) {
+	args := m.Called(id, parentID)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Category), args.Error(1)
+}
+
+func (m *MockCategoryService) DeleteCategory(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+// MockCategories is a small tree: Pokémon > Plush.
+var MockCategories = []*models.Category{
+	{ID: 1, Name: "Pokémon", Path: "/1/", CreatedAt: time.Now(), UpdatedAt: time.Now()},
+	{ID: 2, Name: "Plush", ParentID: uintPtr(1), Path: "/1/2/", CreatedAt: time.Now(), UpdatedAt: time.Now()},
+}
+
+func uintPtr(v uint) *uint {
+	return &v
+}
+
+type MockAttributeRepository struct {
+	mock.Mock
+}
+
+func (m *MockAttributeRepository) GetAll() ([]*models.AttributeDefinition, error) {
+	args := m.Called()
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.AttributeDefinition), args.Error(1)
+}
+
+func (m *MockAttributeRepository) GetByKey(key string) (*models.AttributeDefinition, error) {
+	args := m.Called(key)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AttributeDefinition), args.Error(1)
+}
+
+func (m *MockAttributeRepository) Create(definition *models.AttributeDefinition) (*models.AttributeDefinition, error) {
+	args := m.Called(definition)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AttributeDefinition), args.Error(1)
+}
+
+func (m *MockAttributeRepository) Update(definition *models.AttributeDefinition) (*models.AttributeDefinition, error) {
+	args := m.Called(definition)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AttributeDefinition), args.Error(1)
+}
+
+func (m *MockAttributeRepository) Delete(key string) error {
+	args := m.Called(key)
+	return args.Error(0)
+}
+
+type MockAttributeService struct {
+	mock.Mock
+}
+
+func (m *MockAttributeService) GetAllAttributes() ([]*models.AttributeDefinition, error) {
+	args := m.Called()
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.AttributeDefinition), args.Error(1)
+}
+
+func (m *MockAttributeService) GetAttributeByKey(key string) (*models.AttributeDefinition, error) {
+	args := m.Called(key)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AttributeDefinition), args.Error(1)
+}
+
+func (m *MockAttributeService) CreateAttribute(definition *models.AttributeDefinition) (*models.AttributeDefinition, error) {
+	args := m.Called(definition)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AttributeDefinition), args.Error(1)
+}
+
+func (m *MockAttributeService) UpdateAttribute(definition *models.AttributeDefinition) (*models.AttributeDefinition, error) {
+	args := m.Called(definition)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AttributeDefinition), args.Error(1)
+}
+
+func (m *MockAttributeService) DeleteAttribute(key string) error {
+	args := m.Called(key)
+	return args.Error(0)
+}
+
+// MockAttributeDefinitions define a weight from 0 kg, a material and whether
+// products are wireless.
+var MockAttributeDefinitions = []*models.AttributeDefinition{
+	{Key: "material", Name: "Material", Type: models.AttributeEnum, Values: models.OptionValues{"Steel", "Wood"}, CreatedAt: time.Now(), UpdatedAt: time.Now()},
+	{Key: "weight", Name: "Weight", Type: models.AttributeNumber, Unit: "kg", Min: float64Ptr(0), CreatedAt: time.Now(), UpdatedAt: time.Now()},
+	{Key: "wireless", Name: "Wireless", Type: models.AttributeBool, CreatedAt: time.Now(), UpdatedAt: time.Now()},
+}
+
+func float64Ptr(v float64) *float64 {
+	return &v
+}
+
+type MockTagRepository struct {
+	mock.Mock
+}
+
+func (m *MockTagRepository) GetAll() ([]*models.TagCount, error) {
+	args := m.Called()
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.TagCount), args.Error(1)
+}
+
+type MockTagService struct {
+	mock.Mock
+}
+
+func (m *MockTagService) GetAllTags() ([]*models.TagCount, error) {
+	args := m.Called()
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.TagCount), args.Error(1)
+}
+
+var MockTagCounts = []*models.TagCount{
+	{Name: "starter", Count: 3},
+	{Name: "fire-type", Count: 1},
+}
+
+type MockAPIKeyRepository struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyRepository) GetAll() ([]*models.APIKey, error) {
+	args := m.Called()
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) Create(apiKey *models.APIKey) (*models.APIKey, error) {
+	args := m.Called(apiKey)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) GetByID(id int) (*models.APIKey, error) {
+	args := m.Called(id)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) GetByPrefix(prefix string) (*models.APIKey, error) {
+	args := m.Called(prefix)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) Rotate(id uint, prefix, hash string) error {
+	args := m.Called(id, prefix, hash)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) Revoke(id uint, at time.Time) error {
+	args := m.Called(id, at)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) TouchLastUsed(id uint, at time.Time) error {
+	args := m.Called(id, at)
+	return args.Error(0)
+}
+
+type MockAPIKeyService struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyService) GetAllAPIKeys() ([]*models.APIKey, error) {
+	args := m.Called()
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyService) CreateAPIKey(apiKey *models.APIKey) (*models.APIKey, error) {
+	args := m.Called(apiKey)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyService) RotateAPIKey(id int) (*models.APIKey, error) {
+	args := m.Called(id)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyService) RevokeAPIKey(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyService) AuthenticateAPIKey(key string) (*models.APIKey, error) {
+	args := m.Called(key)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.APIKey), args.Error(1)
+}
+
+var MockAPIKeys = []*models.APIKey{
+	{
+		ID:        1,
+		Name:      "Pokédex partner",
+		Prefix:    "a1b2c3d4e5f6",
+		Scopes:    models.Scopes{"products:read"},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	},
+}
+
+type MockVariantRepository struct {
+	mock.Mock
+}
+
+func (m *MockVariantRepository) GetAll(productID int) ([]*models.ProductVariant, error) {
+	args := m.Called(productID)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.ProductVariant), args.Error(1)
+}
+
+func (m *MockVariantRepository) GetByID(productID, id int) (*models.ProductVariant, error) {
+	args := m.Called(productID, id)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ProductVariant), args.Error(1)
+}
+
+func (m *MockVariantRepository) Create(variant *models.ProductVariant) (*models.ProductVariant, error) {
+	args := m.Called(variant)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ProductVariant), args.Error(1)
+}
+
+func (m *MockVariantRepository) Update(variant *models.ProductVariant) (*models.ProductVariant, error) {
+	args := m.Called(variant)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ProductVariant), args.Error(1)
+}
+
+func (m *MockVariantRepository) Delete(productID, id int) error {
+	args := m.Called(productID, id)
+	return args.Error(0)
+}
+
+func (m *MockVariantRepository) Generate(productID int) ([]*models.ProductVariant, error) {
+	args := m.Called(productID)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.ProductVariant), args.Error(1)
+}
+
+type MockVariantService struct {
+	mock.Mock
+}
+
+func (m *MockVariantService) GetAllVariants(productID int) ([]*models.ProductVariant, error) {
+	args := m.Called(productID)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.ProductVariant), args.Error(1)
+}
+
+func (m *MockVariantService) GetVariant(productID, id int) (*models.ProductVariant, error) {
+	args := m.Called(productID, id)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ProductVariant), args.Error(1)
+}
+
+func (m *MockVariantService) CreateVariant(productID int, variant *models.ProductVariant) (*models.ProductVariant, error) {
+	args := m.Called(productID, variant)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ProductVariant), args.Error(1)
+}
+
+func (m *MockVariantService) UpdateVariant(variant *models.ProductVariant) (*models.ProductVariant, error) {
+	args := m.Called(variant)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ProductVariant), args.Error(1)
+}
+
+func (m *MockVariantService) DeleteVariant(productID, id int) error {
+	args := m.Called(productID, id)
+	return args.Error(0)
+}
+
+func (m *MockVariantService) GenerateVariants(productID int) ([]*models.ProductVariant, error) {
+	args := m.Called(productID)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.ProductVariant), args.Error(1)
+}
+
+var MockVariant = &models.ProductVariant{
+	ID:         1,
+	ProductID:  1,
+	Options:    models.VariantOptions{"Size": "S"},
+	OptionsKey: "size=s",
+	CreatedAt:  time.Now(),
+	UpdatedAt:  time.Now(),
+}
+
+type MockMediaRepository struct {
+	mock.Mock
+}
+
+func (m *MockMediaRepository) GetAll(productID int) ([]*models.ProductMedia, error) {
+	args := m.Called(productID)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.ProductMedia), args.Error(1)
+}
+
+func (m *MockMediaRepository) GetByID(productID, id int) (*models.ProductMedia, error) {
+	args := m.Called(productID, id)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ProductMedia), args.Error(1)
+}
+
+func (m *MockMediaRepository) Create(media *models.ProductMedia) (*models.ProductMedia, error) {
+	args := m.Called(media)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ProductMedia), args.Error(1)
+}
+
+func (m *MockMediaRepository) SetPrimary(productID, id int) error {
+	args := m.Called(productID, id)
+	return args.Error(0)
+}
+
+func (m *MockMediaRepository) Reorder(productID int, ids []uint) error {
+	args := m.Called(productID, ids)
+	return args.Error(0)
+}
+
+func (m *MockMediaRepository) Delete(productID, id int) error {
+	args := m.Called(productID, id)
+	return args.Error(0)
+}
+
+type MockMediaService struct {
+	mock.Mock
+}
+
+func (m *MockMediaService) GetAllMedia(productID int) ([]*models.ProductMedia, error) {
+	args := m.Called(productID)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.ProductMedia), args.Error(1)
+}
+
+func (m *MockMediaService) GetMedia(productID, id int) (*models.ProductMedia, error) {
+	args := m.Called(productID, id)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ProductMedia), args.Error(1)
+}
+
+func (m *MockMediaService) UploadMedia(productID int, filename string, content io.Reader) (*models.ProductMedia, error) {
+	args := m.Called(productID, filename, content)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ProductMedia), args.Error(1)
+}
+
+func (m *MockMediaService) OpenMedia(productID, id int, thumbnail bool) (*models.ProductMedia, io.ReadCloser, error) {
+	args := m.Called(productID, id, thumbnail)
+	if args.Error(2) != nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).(*models.ProductMedia), args.Get(1).(io.ReadCloser), args.Error(2)
+}
+
+func (m *MockMediaService) SetPrimaryMedia(productID, id int) ([]*models.ProductMedia, error) {
+	args := m.Called(productID, id)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.ProductMedia), args.Error(1)
+}
+
+func (m *MockMediaService) ReorderMedia(productID int, ids []uint) ([]*models.ProductMedia, error) {
+	args := m.Called(productID, ids)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.ProductMedia), args.Error(1)
+}
+
+func (m *MockMediaService) DeleteMedia(productID, id int) error {
+	args := m.Called(productID, id)
+	return args.Error(0)
+}
+
+type MockMediaStorage struct {
+	mock.Mock
+}
+
+func (m *MockMediaStorage) Put(key string, content io.Reader) error {
+	args := m.Called(key, content)
+	return args.Error(0)
+}
+
+func (m *MockMediaStorage) Open(key string) (io.ReadCloser, error) {
+	args := m.Called(key)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(io.ReadCloser), args.Error(1)
+}
+
+func (m *MockMediaStorage) Delete(key string) error {
+	args := m.Called(key)
+	return args.Error(0)
+}
+
+var MockMedia = &models.ProductMedia{
+	ID:           1,
+	ProductID:    1,
+	Filename:     "front.jpg",
+	ContentType:  "image/jpeg",
+	Size:         2048,
+	Width:        800,
+	Height:       600,
+	Primary:      true,
+	FileKey:      "products/1/front.jpg",
+	ThumbnailKey: "products/1/front_thumb.jpg",
+	CreatedAt:    time.Now(),
+}
+
+type MockInventoryRepository struct {
+	mock.Mock
+}
+
+func (m *MockInventoryRepository) GetStock(productID int, now time.Time) (*models.Stock, error) {
+	args := m.Called(productID, now)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Stock), args.Error(1)
+}
+
+func (m *MockInventoryRepository) Adjust(productID int, adjustment models.StockAdjustment, now time.Time) (*models.StockLevel, error) {
+	args := m.Called(productID, adjustment, now)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.StockLevel), args.Error(1)
+}
+
+func (m *MockInventoryRepository) Reserve(reservation *models.Reservation, now time.Time) (*models.Reservation, error) {
+	args := m.Called(reservation, now)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Reservation), args.Error(1)
+}
+
+func (m *MockInventoryRepository) GetReservation(id int) (*models.Reservation, error) {
+	args := m.Called(id)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Reservation), args.Error(1)
+}
+
+func (m *MockInventoryRepository) CommitReservation(id int, now time.Time) (*models.Reservation, error) {
+	args := m.Called(id, now)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Reservation), args.Error(1)
+}
+
+func (m *MockInventoryRepository) ReleaseReservation(id int, now time.Time) (*models.Reservation, error) {
+	args := m.Called(id, now)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Reservation), args.Error(1)
+}
+
+type MockInventoryService struct {
+	mock.Mock
+}
+
+func (m *MockInventoryService) GetStock(productID int) (*models.Stock, error) {
+	args := m.Called(productID)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Stock), args.Error(1)
+}
+
+func (m *MockInventoryService) AdjustStock(productID int, adjustment models.StockAdjustment) (*models.StockLevel, error) {
+	args := m.Called(productID, adjustment)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.StockLevel), args.Error(1)
+}
+
+func (m *MockInventoryService) Reserve(productID int, request models.ReservationRequest) (*models.Reservation, error) {
+	args := m.Called(productID, request)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Reservation), args.Error(1)
+}
+
+func (m *MockInventoryService) GetReservation(id int) (*models.Reservation, error) {
+	args := m.Called(id)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Reservation), args.Error(1)
+}
+
+func (m *MockInventoryService) CommitReservation(id int) (*models.Reservation, error) {
+	args := m.Called(id)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Reservation), args.Error(1)
+}
+
+func (m *MockInventoryService) ReleaseReservation(id int) (*models.Reservation, error) {
+	args := m.Called(id)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Reservation), args.Error(1)
+}
+
+var MockReservation = &models.Reservation{
+	ID:        1,
+	ProductID: 1,
+	Warehouse: models.DefaultWarehouse,
+	Quantity:  2,
+	Status:    models.ReservationActive,
+	ExpiresAt: time.Now().Add(15 * time.Minute),
+	CreatedAt: time.Now(),
+	UpdatedAt: time.Now(),
+}
@@ -0,0 +1,206 @@
+// Package client is a typed Go client for the EuLabs products API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Retry configures how failed requests are retried. Network errors and 502,
+// 503 and 504 responses are only retried for idempotent methods; 429 responses
+// are retried for every method, waiting at least for their Retry-After.
+type Retry struct {
+	MaxAttempts int
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+}
+
+var DefaultRetry = Retry{MaxAttempts: 3, MinBackoff: 100 * time.Millisecond, MaxBackoff: 2 * time.Second}
+
+const DefaultTimeout = 30 * time.Second
+
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	token      string
+	apiKey     string
+	retry      Retry
+
+	Products *ProductsService
+}
+
+type Option func(*Client)
+
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) { c.httpClient = httpClient }
+}
+
+// WithTimeout bounds each attempt of a request.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		httpClient := *c.httpClient
+		httpClient.Timeout = timeout
+		c.httpClient = &httpClient
+	}
+}
+
+// WithBearerToken authenticates requests with a JWT.
+func WithBearerToken(token string) Option {
+	return func(c *Client) { c.token = token }
+}
+
+// WithAPIKey authenticates requests with an API key.
+func WithAPIKey(apiKey string) Option {
+	return func(c *Client) { c.apiKey = apiKey }
+}
+
+func WithRetry(retry Retry) Option {
+	return func(c *Client) { c.retry = retry }
+}
+
+// New returns a client for the API served at baseURL, e.g.
+// "http://localhost:8080".
+func New(baseURL string, options ...Option) (*Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, errors.New("client: base URL must be absolute")
+	}
+
+	c := &Client{
+		baseURL:    u,
+		httpClient: &http.Client{Timeout: DefaultTimeout},
+		retry:      DefaultRetry,
+	}
+	for _, option := range options {
+		option(c)
+	}
+	if c.retry.MaxAttempts < 1 {
+		c.retry.MaxAttempts = 1
+	}
+
+	c.Products = &ProductsService{client: c}
+	return c, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	u := *c.baseURL
+	u.Path += path
+	u.RawQuery = query.Encode()
+
+	for attempt := 1; ; attempt++ {
+		resp, err := c.send(ctx, method, u.String(), payload)
+		if err != nil {
+			if ctx.Err() != nil || attempt >= c.retry.MaxAttempts || !idempotent(method) {
+				return err
+			}
+			if err := c.wait(ctx, attempt, 0); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if resp.StatusCode >= http.StatusBadRequest {
+			apiErr := newError(resp)
+			if attempt < c.retry.MaxAttempts && retryable(method, resp.StatusCode) {
+				if err := c.wait(ctx, attempt, apiErr.RetryAfter); err != nil {
+					return err
+				}
+				continue
+			}
+			return apiErr
+		}
+
+		defer resp.Body.Close()
+		if out == nil || resp.StatusCode == http.StatusNoContent {
+			return nil
+		}
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+}
+
+func (c *Client) send(ctx context.Context, method, url string, payload []byte) (*http.Response, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+
+	return c.httpClient.Do(req)
+}
+
+// wait sleeps before the next attempt using exponential backoff with jitter,
+// and never less than the server asked for.
+func (c *Client) wait(ctx context.Context, attempt int, retryAfter time.Duration) error {
+	backoff := c.retry.MinBackoff << (attempt - 1)
+	if backoff > c.retry.MaxBackoff || backoff <= 0 {
+		backoff = c.retry.MaxBackoff
+	}
+	if backoff > 0 {
+		backoff = backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+	}
+	if retryAfter > backoff {
+		backoff = retryAfter
+	}
+
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func retryable(method string, status int) bool {
+	switch status {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return idempotent(method)
+	}
+	return false
+}
@@ -0,0 +1,294 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	server "github.com/adrianosiqe/eulabs-challenge-api/internal/http"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/http/middlewares"
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/mocks"
+	"github.com/adrianosiqe/eulabs-challenge-api/pkg/money"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const jwtSecret = "secret"
+
+func newToken(role string) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &middlewares.JWTClaims{
+		Role:           role,
+		StandardClaims: jwt.StandardClaims{Subject: "ash"},
+	})
+	signed, _ := token.SignedString([]byte(jwtSecret))
+	return signed
+}
+
+func newTestServer(t *testing.T, mockProductRepository *mocks.MockProductRepository, options server.Options) *httptest.Server {
+	options.JWTSecret = jwtSecret
+	ts := httptest.NewServer(server.NewServer(server.Repositories{Products: mockProductRepository, Categories: &mocks.MockCategoryRepository{}, Tags: &mocks.MockTagRepository{}, Variants: &mocks.MockVariantRepository{}, Media: &mocks.MockMediaRepository{}, Inventory: &mocks.MockInventoryRepository{}, APIKeys: &mocks.MockAPIKeyRepository{}, MediaStorage: &mocks.MockMediaStorage{}}, options).Handler())
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func newTestClient(t *testing.T, url string, options ...Option) *Client {
+	options = append([]Option{WithBearerToken(newToken(middlewares.RoleAdmin)), WithRetry(Retry{MaxAttempts: 1})}, options...)
+	c, err := New(url, options...)
+	assert.NoError(t, err)
+	return c
+}
+
+func product(p *models.Product) *models.Product {
+	copied := *p
+	return &copied
+}
+
+func TestNew(t *testing.T) {
+	t.Run("should return an error for a relative url", func(t *testing.T) {
+		_, err := New("/api")
+
+		assert.Error(t, err)
+	})
+}
+
+func TestProductsList(t *testing.T) {
+	t.Run("should return the products", func(t *testing.T) {
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("GetAll", models.ProductFilter{}).Return(mocks.MockProducts, nil)
+		ts := newTestServer(t, mockProductRepository, server.Options{})
+
+		products, err := newTestClient(t, ts.URL).Products.List(context.Background(), nil)
+
+		assert.NoError(t, err)
+		assert.Len(t, products, 2)
+		assert.Equal(t, mocks.MockProducts[1].Title, products[1].Title)
+	})
+
+	t.Run("should iterate over every page", func(t *testing.T) {
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("GetAll", models.ProductFilter{Page: 1, PerPage: 1}).Return(mocks.MockProducts[:1], nil)
+		mockProductRepository.On("GetAll", models.ProductFilter{Page: 2, PerPage: 1}).Return(mocks.MockProducts[1:], nil)
+		mockProductRepository.On("GetAll", models.ProductFilter{Page: 3, PerPage: 1}).Return([]*models.Product{}, nil)
+		ts := newTestServer(t, mockProductRepository, server.Options{})
+
+		var titles []string
+		it := newTestClient(t, ts.URL).Products.Iterate(context.Background(), 1)
+		for it.Next() {
+			titles = append(titles, it.Product().Title)
+		}
+
+		assert.NoError(t, it.Err())
+		assert.Equal(t, []string{"Bulbasaur", "Charmander"}, titles)
+		mockProductRepository.AssertExpectations(t)
+	})
+}
+
+func TestProductsGet(t *testing.T) {
+	t.Run("should return the product", func(t *testing.T) {
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("GetByID", 1).Return(product(mocks.MockProducts[0]), nil)
+		ts := newTestServer(t, mockProductRepository, server.Options{})
+
+		p, err := newTestClient(t, ts.URL).Products.Get(context.Background(), 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Bulbasaur", p.Title)
+	})
+
+	t.Run("should return a not found error", func(t *testing.T) {
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("GetByID", 3).Return(nil, fmt.Errorf("some error"))
+		ts := newTestServer(t, mockProductRepository, server.Options{})
+
+		_, err := newTestClient(t, ts.URL).Products.Get(context.Background(), 3)
+
+		assert.True(t, IsNotFound(err))
+		assert.Equal(t, "eulabs api: 404 Failed to get product", err.Error())
+	})
+}
+
+func TestProductsCreate(t *testing.T) {
+	t.Run("should return the created product", func(t *testing.T) {
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("Create", mock.Anything).Return(product(mocks.MockProducts[1]), nil)
+		ts := newTestServer(t, mockProductRepository, server.Options{})
+
+		p, err := newTestClient(t, ts.URL).Products.Create(context.Background(), &models.Product{
+			Title:       mocks.MockProducts[1].Title,
+			Description: mocks.MockProducts[1].Description,
+			Price:       mocks.MockProducts[1].Price,
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint(2), p.ID)
+	})
+
+	t.Run("should return a validation error", func(t *testing.T) {
+		ts := newTestServer(t, &mocks.MockProductRepository{}, server.Options{})
+
+		_, err := newTestClient(t, ts.URL).Products.Create(context.Background(), &models.Product{Title: "Charmander"})
+
+		assert.True(t, IsValidation(err))
+	})
+
+	t.Run("should return a forbidden error", func(t *testing.T) {
+		ts := newTestServer(t, &mocks.MockProductRepository{}, server.Options{})
+
+		_, err := newTestClient(t, ts.URL, WithBearerToken(newToken(middlewares.RoleViewer))).Products.Create(context.Background(), &models.Product{})
+
+		assert.True(t, IsForbidden(err))
+	})
+}
+
+func TestProductsUpdate(t *testing.T) {
+	t.Run("should update the product", func(t *testing.T) {
+		updated := product(mocks.MockProducts[0])
+		updated.Title = "Ivysaur"
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("GetByIDFromPrimary", 1).Return(product(mocks.MockProducts[0]), nil)
+		mockProductRepository.On("Update", mock.Anything, mock.Anything).Return(updated, nil)
+		ts := newTestServer(t, mockProductRepository, server.Options{})
+
+		p, err := newTestClient(t, ts.URL).Products.Update(context.Background(), 1, &models.Product{Title: "Ivysaur"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Ivysaur", p.Title)
+	})
+}
+
+func TestProductsPatch(t *testing.T) {
+	t.Run("should only send the given fields", func(t *testing.T) {
+		price := 149.99
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("GetByIDFromPrimary", 1).Return(product(mocks.MockProducts[0]), nil)
+		mockProductRepository.On("Update", mock.MatchedBy(func(p *models.Product) bool {
+			return p.Price == money.MustParseAmount("149.99") && p.Title == "Bulbasaur"
+		}), mock.Anything).Return(product(mocks.MockProducts[0]), nil)
+		ts := newTestServer(t, mockProductRepository, server.Options{})
+
+		_, err := newTestClient(t, ts.URL).Products.Patch(context.Background(), 1, ProductPatch{Price: &price})
+
+		assert.NoError(t, err)
+		mockProductRepository.AssertExpectations(t)
+	})
+}
+
+func TestProductsDelete(t *testing.T) {
+	t.Run("should delete the product", func(t *testing.T) {
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("Delete", 1).Return(nil)
+		ts := newTestServer(t, mockProductRepository, server.Options{})
+
+		err := newTestClient(t, ts.URL).Products.Delete(context.Background(), 1)
+
+		assert.NoError(t, err)
+		mockProductRepository.AssertExpectations(t)
+	})
+}
+
+func TestProductsPurge(t *testing.T) {
+	t.Run("should purge the product and its media files", func(t *testing.T) {
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("Purge", 1).Return([]*models.ProductMedia{}, nil)
+		ts := newTestServer(t, mockProductRepository, server.Options{})
+
+		err := newTestClient(t, ts.URL).Products.Purge(context.Background(), 1)
+
+		assert.NoError(t, err)
+		mockProductRepository.AssertExpectations(t)
+	})
+
+	t.Run("should return a forbidden error for editors", func(t *testing.T) {
+		ts := newTestServer(t, &mocks.MockProductRepository{}, server.Options{})
+
+		err := newTestClient(t, ts.URL, WithBearerToken(newToken(middlewares.RoleEditor))).Products.Purge(context.Background(), 1)
+
+		assert.True(t, IsForbidden(err))
+	})
+}
+
+func TestProductsRestore(t *testing.T) {
+	t.Run("should restore the product", func(t *testing.T) {
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("Restore", 1).Return(nil)
+		mockProductRepository.On("GetByIDFromPrimary", 1).Return(product(mocks.MockProducts[0]), nil)
+		ts := newTestServer(t, mockProductRepository, server.Options{})
+
+		p, err := newTestClient(t, ts.URL).Products.Restore(context.Background(), 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), p.ID)
+		mockProductRepository.AssertExpectations(t)
+	})
+
+	t.Run("should return a forbidden error for editors", func(t *testing.T) {
+		ts := newTestServer(t, &mocks.MockProductRepository{}, server.Options{})
+
+		_, err := newTestClient(t, ts.URL, WithBearerToken(newToken(middlewares.RoleEditor))).Products.Restore(context.Background(), 1)
+
+		assert.True(t, IsForbidden(err))
+	})
+}
+
+func TestRetry(t *testing.T) {
+	t.Run("should retry unavailable responses", func(t *testing.T) {
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("GetByID", 1).Return(product(mocks.MockProducts[0]), nil)
+		handler := server.NewServer(server.Repositories{Products: mockProductRepository, Categories: &mocks.MockCategoryRepository{}, Tags: &mocks.MockTagRepository{}, Variants: &mocks.MockVariantRepository{}, Media: &mocks.MockMediaRepository{}, Inventory: &mocks.MockInventoryRepository{}, APIKeys: &mocks.MockAPIKeyRepository{}, MediaStorage: &mocks.MockMediaStorage{}}, server.Options{JWTSecret: jwtSecret}).Handler()
+
+		var calls int32
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			handler.ServeHTTP(w, r)
+		}))
+		defer ts.Close()
+
+		c := newTestClient(t, ts.URL, WithRetry(Retry{MaxAttempts: 2, MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond}))
+		p, err := c.Products.Get(context.Background(), 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Bulbasaur", p.Title)
+		assert.Equal(t, int32(2), calls)
+	})
+
+	t.Run("should return a rate limit error", func(t *testing.T) {
+		mockProductRepository := &mocks.MockProductRepository{}
+		mockProductRepository.On("GetByID", 1).Return(product(mocks.MockProducts[0]), nil)
+		ts := newTestServer(t, mockProductRepository, server.Options{
+			RateLimits: map[string]middlewares.RateLimit{"products": {Requests: 1, Period: time.Minute}},
+		})
+		c := newTestClient(t, ts.URL)
+
+		_, err := c.Products.Get(context.Background(), 1)
+		assert.NoError(t, err)
+
+		_, err = c.Products.Get(context.Background(), 1)
+		assert.True(t, IsRateLimited(err))
+		assert.Equal(t, time.Minute, err.(*Error).RetryAfter)
+	})
+
+	t.Run("should stop when the context is done", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}))
+		defer ts.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		c := newTestClient(t, ts.URL, WithRetry(DefaultRetry))
+		_, err := c.Products.Get(ctx, 1)
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
@@ -0,0 +1,69 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Error is returned for every non-2xx response. Message mirrors the
+// {"message": "..."} body returned by the API.
+type Error struct {
+	StatusCode int
+	Message    string `json:"message"`
+	// RetryAfter is set from the Retry-After header of 429 responses.
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("eulabs api: %d %s", e.StatusCode, e.Message)
+}
+
+func newError(resp *http.Response) *Error {
+	defer resp.Body.Close()
+
+	apiErr := &Error{StatusCode: resp.StatusCode}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if json.Unmarshal(body, apiErr) != nil || apiErr.Message == "" {
+		apiErr.Message = http.StatusText(resp.StatusCode)
+	}
+
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+
+	return apiErr
+}
+
+func hasStatus(err error, status int) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == status
+}
+
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+func IsUnauthorized(err error) bool {
+	return hasStatus(err, http.StatusUnauthorized)
+}
+
+func IsForbidden(err error) bool {
+	return hasStatus(err, http.StatusForbidden)
+}
+
+func IsConflict(err error) bool {
+	return hasStatus(err, http.StatusConflict)
+}
+
+func IsValidation(err error) bool {
+	return hasStatus(err, http.StatusUnprocessableEntity)
+}
+
+func IsRateLimited(err error) bool {
+	return hasStatus(err, http.StatusTooManyRequests)
+}
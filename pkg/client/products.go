@@ -0,0 +1,159 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/adrianosiqe/eulabs-challenge-api/pkg/money"
+)
+
+const productsPath = "/api/v1/products"
+
+type ProductsService struct {
+	client *Client
+}
+
+// ListOptions selects a page of products. A zero PerPage lists every product.
+type ListOptions struct {
+	Page    int
+	PerPage int
+}
+
+// ProductPatch holds the fields to change; nil fields are left untouched.
+// Price is sent as the shortest decimal that reads back as the float, so 19.99
+// reaches the API as 19.99. Changing Currency requires Price.
+type ProductPatch struct {
+	Title       *string         `json:"title,omitempty"`
+	Description *string         `json:"description,omitempty"`
+	Price       *float64        `json:"price,omitempty"`
+	Currency    *money.Currency `json:"currency,omitempty"`
+}
+
+func (s *ProductsService) List(ctx context.Context, options *ListOptions) ([]models.Product, error) {
+	query := url.Values{}
+	if options != nil {
+		if options.Page > 0 {
+			query.Set("page", strconv.Itoa(options.Page))
+		}
+		if options.PerPage > 0 {
+			query.Set("per_page", strconv.Itoa(options.PerPage))
+		}
+	}
+
+	var products []models.Product
+	err := s.client.do(ctx, http.MethodGet, productsPath, query, nil, &products)
+	return products, err
+}
+
+func (s *ProductsService) Get(ctx context.Context, id uint) (*models.Product, error) {
+	var product models.Product
+	if err := s.client.do(ctx, http.MethodGet, productPath(id), nil, nil, &product); err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+func (s *ProductsService) Create(ctx context.Context, product *models.Product) (*models.Product, error) {
+	var created models.Product
+	if err := s.client.do(ctx, http.MethodPost, productsPath, nil, product, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (s *ProductsService) Update(ctx context.Context, id uint, product *models.Product) (*models.Product, error) {
+	var updated models.Product
+	if err := s.client.do(ctx, http.MethodPut, productPath(id), nil, product, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func (s *ProductsService) Patch(ctx context.Context, id uint, patch ProductPatch) (*models.Product, error) {
+	var updated models.Product
+	if err := s.client.do(ctx, http.MethodPatch, productPath(id), nil, patch, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func (s *ProductsService) Delete(ctx context.Context, id uint) error {
+	return s.client.do(ctx, http.MethodDelete, productPath(id), nil, nil, nil)
+}
+
+// Purge permanently removes a product, deleted or not. Only admins can purge.
+func (s *ProductsService) Purge(ctx context.Context, id uint) error {
+	return s.client.do(ctx, http.MethodDelete, productPath(id)+"/purge", nil, nil, nil)
+}
+
+// Restore brings back a deleted product. Only admins can restore.
+func (s *ProductsService) Restore(ctx context.Context, id uint) (*models.Product, error) {
+	var restored models.Product
+	if err := s.client.do(ctx, http.MethodPost, productPath(id)+"/restore", nil, nil, &restored); err != nil {
+		return nil, err
+	}
+	return &restored, nil
+}
+
+// Iterate walks every product, fetching perPage products per request.
+func (s *ProductsService) Iterate(ctx context.Context, perPage int) *ProductIterator {
+	if perPage <= 0 || perPage > models.MaxPerPage {
+		perPage = models.MaxPerPage
+	}
+	return &ProductIterator{ctx: ctx, service: s, perPage: perPage}
+}
+
+// ProductIterator pages through products:
+//
+//	it := c.Products.Iterate(ctx, 50)
+//	for it.Next() {
+//		product := it.Product()
+//	}
+//	if err := it.Err(); err != nil {
+//	}
+type ProductIterator struct {
+	ctx     context.Context
+	service *ProductsService
+	perPage int
+	page    int
+	buffer  []models.Product
+	current models.Product
+	done    bool
+	err     error
+}
+
+func (it *ProductIterator) Next() bool {
+	if len(it.buffer) == 0 {
+		if it.done || it.err != nil {
+			return false
+		}
+
+		it.page++
+		it.buffer, it.err = it.service.List(it.ctx, &ListOptions{Page: it.page, PerPage: it.perPage})
+		if it.err != nil {
+			return false
+		}
+		it.done = len(it.buffer) < it.perPage
+		if len(it.buffer) == 0 {
+			return false
+		}
+	}
+
+	it.current, it.buffer = it.buffer[0], it.buffer[1:]
+	return true
+}
+
+func (it *ProductIterator) Product() models.Product {
+	return it.current
+}
+
+func (it *ProductIterator) Err() error {
+	return it.err
+}
+
+func productPath(id uint) string {
+	return productsPath + "/" + strconv.FormatUint(uint64(id), 10)
+}
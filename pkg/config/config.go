@@ -1,39 +1,155 @@
+// Package config loads the application configuration. Every setting has a
+// default, which is overridden in turn by the config file, the environment
+// and the command line flags.
 package config
 
 import (
 	"os"
+	"regexp"
+	"time"
 )
 
-var (
-	Cfg *Config
-)
-
+// Config is built by Load and handed to whatever needs it. Each field declares
+// its key in the config file and on the command line (config, nested keys are
+// joined with a dot), its environment variable (env), its default value
+// (default) and its validation rules (validate). Secret fields are masked by
+// Settings.
 type Config struct {
-	DBUser      string
-	DBPassword  string
-	DBHost      string
-	DBPort      string
-	DBName      string
-	DBCharset   string
-	DBParseTime string
-	DBLoc       string
-	PORT        string
-}
-
-func LoadConfig() *Config {
-	config := &Config{
-		DBUser:      os.Getenv("DB_USER"),
-		DBPassword:  os.Getenv("DB_PASSWORD"),
-		DBHost:      os.Getenv("DB_HOST"),
-		DBPort:      os.Getenv("DB_PORT"),
-		DBName:      os.Getenv("DB_NAME"),
-		DBCharset:   os.Getenv("DB_CHARSET"),
-		DBParseTime: os.Getenv("DB_PARSETIME"),
-		DBLoc:       os.Getenv("DB_LOC"),
-		PORT:        os.Getenv("PORT"),
+	Port       int             `config:"port" env:"PORT" default:"8080" validate:"min=1,max=65535" usage:"HTTP port"`
+	Database   DatabaseConfig  `config:"database"`
+	Auth       AuthConfig      `config:"auth"`
+	Cache      CacheConfig     `config:"cache"`
+	Products   ProductsConfig  `config:"products"`
+	Scheduler  SchedulerConfig `config:"scheduler"`
+	Inventory  InventoryConfig `config:"inventory"`
+	Media      MediaConfig     `config:"media"`
+	RateLimits string          `config:"rate_limits" env:"RATE_LIMITS" usage:"per group rate limits, with ip for the per address limit before authentication, e.g. ip=300/1m,products=100/1m,api-keys=20/1m"`
+	// TrustedProxies lists the load balancers in front of the API, whose
+	// X-Forwarded-For is trusted to tell the address of the client.
+	TrustedProxies []string `config:"trusted_proxies" env:"TRUSTED_PROXIES" usage:"comma separated IP addresses or CIDR blocks of the proxies whose X-Forwarded-For is trusted"`
+
+	// Storage "memory" serves the API from in-memory repositories, for demos.
+	// Nothing is persisted and the database settings are ignored.
+	Storage string `config:"storage" env:"STORAGE" default:"database" validate:"oneof=database memory" usage:"where the data is kept: database or memory"`
+}
+
+type DatabaseConfig struct {
+	Driver    string `config:"driver" env:"DB_DRIVER" default:"mysql" validate:"oneof=mysql postgres sqlite" usage:"database driver: mysql, postgres or sqlite"`
+	User      string `config:"user" env:"DB_USER" usage:"database user"`
+	Password  string `config:"password" env:"DB_PASSWORD" secret:"true" usage:"database password"`
+	Host      string `config:"host" env:"DB_HOST" usage:"database host"`
+	Port      int    `config:"port" env:"DB_PORT" validate:"min=0,max=65535" usage:"database port, 0 uses the driver's default"`
+	Name      string `config:"name" env:"DB_NAME" usage:"database name, or file path with sqlite"`
+	Charset   string `config:"charset" env:"DB_CHARSET" default:"utf8mb4" usage:"connection charset (mysql)"`
+	ParseTime bool   `config:"parse_time" env:"DB_PARSETIME" default:"true" usage:"scan DATETIME columns into time.Time (mysql)"`
+	Loc       string `config:"loc" env:"DB_LOC" default:"Local" usage:"time zone of the DATETIME columns (mysql)"`
+	SSLMode   string `config:"ssl_mode" env:"DB_SSLMODE" default:"disable" usage:"sslmode of the connection (postgres)"`
+	// AutoMigrate replaces the versioned migrations with GORM's AutoMigrate.
+	// Only meant for local development.
+	AutoMigrate bool `config:"auto_migrate" env:"DB_AUTO_MIGRATE" usage:"use GORM's AutoMigrate instead of the migrations"`
+
+	MaxOpenConns    int           `config:"max_open_conns" env:"DB_MAX_OPEN_CONNS" default:"25" validate:"min=0" usage:"maximum open connections, 0 means unlimited"`
+	MaxIdleConns    int           `config:"max_idle_conns" env:"DB_MAX_IDLE_CONNS" default:"10" validate:"min=0" usage:"maximum idle connections"`
+	ConnMaxLifetime time.Duration `config:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME" default:"30m" validate:"min=0" usage:"maximum time a connection is reused"`
+	ConnMaxIdleTime time.Duration `config:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME" default:"5m" validate:"min=0" usage:"maximum time a connection stays idle"`
+
+	// The connection is retried with exponential backoff, from RetryInterval
+	// up to RetryMaxInterval, until ConnectTimeout has passed.
+	ConnectTimeout   time.Duration `config:"connect_timeout" env:"DB_CONNECT_TIMEOUT" default:"1m" validate:"min=0" usage:"how long to retry connecting at startup"`
+	DialTimeout      time.Duration `config:"dial_timeout" env:"DB_DIAL_TIMEOUT" default:"5s" validate:"min=0" usage:"timeout of each connection attempt"`
+	RetryInterval    time.Duration `config:"retry_interval" env:"DB_RETRY_INTERVAL" default:"500ms" validate:"min=0" usage:"delay before the first retry"`
+	RetryMaxInterval time.Duration `config:"retry_max_interval" env:"DB_RETRY_MAX_INTERVAL" default:"10s" validate:"min=0" usage:"maximum delay between retries"`
+
+	// Replicas lists the DSNs of the read replicas, e.g.
+	// user:password@tcp(replica:3306)/eulabs_challenge_api?parseTime=true.
+	// Product listings and lookups are spread among the healthy ones.
+	Replicas              []string      `config:"replicas" env:"DB_REPLICAS" secret:"true" usage:"comma separated DSNs of the read replicas"`
+	ReplicaHealthInterval time.Duration `config:"replica_health_interval" env:"DB_REPLICA_HEALTH_INTERVAL" default:"5s" validate:"gt=0" usage:"how often the replicas are pinged"`
+	ReadYourWrites        time.Duration `config:"read_your_writes" env:"DB_READ_YOUR_WRITES" validate:"min=0" usage:"how long reads stay on the primary after a write through this instance, 0 disables it"`
+
+	LogLevel      string        `config:"log_level" env:"DB_LOG_LEVEL" default:"error" validate:"oneof=silent error warn info" usage:"GORM log level: silent, error, warn or info"`
+	SlowThreshold time.Duration `config:"slow_threshold" env:"DB_SLOW_THRESHOLD" default:"1s" validate:"min=0" usage:"queries slower than this are logged as warnings"`
+}
+
+type AuthConfig struct {
+	JWTSecret  string `config:"jwt_secret" env:"JWT_SECRET" secret:"true" usage:"HS256 secret of the JWT tokens"`
+	PolicyFile string `config:"policy_file" env:"AUTH_POLICY_FILE" usage:"JSON file redefining the roles"`
+}
+
+// CacheConfig configures the cache of product lookups, kept by each API
+// instance.
+type CacheConfig struct {
+	Enabled     bool          `config:"enabled" env:"CACHE_ENABLED" usage:"cache product lookups in memory"`
+	Size        int           `config:"size" env:"CACHE_SIZE" default:"1000" validate:"min=1" usage:"maximum number of cached products"`
+	TTL         time.Duration `config:"ttl" env:"CACHE_TTL" default:"1m" validate:"min=0" usage:"how long a product is cached"`
+	NegativeTTL time.Duration `config:"negative_ttl" env:"CACHE_NEGATIVE_TTL" default:"10s" validate:"min=0" usage:"how long a missing product is cached, 0 disables it"`
+}
+
+// ProductsConfig configures the rules the products follow.
+type ProductsConfig struct {
+	SKUPattern string `config:"sku_pattern" env:"SKU_PATTERN" default:"^[A-Z0-9][A-Z0-9-]{2,63}$" usage:"regular expression every SKU must match, empty accepts any"`
+}
+
+// SchedulerConfig configures the scheduler that publishes and archives the
+// products at their publish_at and unpublish_at.
+type SchedulerConfig struct {
+	Enabled   bool          `config:"enabled" env:"SCHEDULER_ENABLED" default:"true" usage:"apply the product schedules in this instance"`
+	Interval  time.Duration `config:"interval" env:"SCHEDULER_INTERVAL" default:"1m" validate:"gt=0" usage:"how often the due schedules are applied"`
+	BatchSize int           `config:"batch_size" env:"SCHEDULER_BATCH_SIZE" default:"100" validate:"min=1" usage:"products changed in each transaction"`
+}
+
+// InventoryConfig configures the stock reservations.
+type InventoryConfig struct {
+	ReservationTTL    time.Duration `config:"reservation_ttl" env:"RESERVATION_TTL" default:"15m" validate:"gt=0" usage:"how long reservations last when they don't ask"`
+	ReservationMaxTTL time.Duration `config:"reservation_max_ttl" env:"RESERVATION_MAX_TTL" default:"24h" validate:"gt=0" usage:"how long reservations can ask to last"`
+}
+
+// MediaConfig configures the uploads of product images.
+type MediaConfig struct {
+	Dir           string `config:"dir" env:"MEDIA_DIR" default:"media" validate:"required" usage:"directory the uploaded images are kept in"`
+	MaxSize       int64  `config:"max_size" env:"MEDIA_MAX_SIZE" default:"10485760" validate:"min=1" usage:"largest image that can be uploaded, in bytes"`
+	ThumbnailSize int    `config:"thumbnail_size" env:"MEDIA_THUMBNAIL_SIZE" default:"256" validate:"min=16,max=2048" usage:"side of the square the thumbnails fit in, in pixels"`
+}
+
+// Load builds the configuration from the defaults, the config file given by
+// --config or CONFIG_FILE, the environment and the flags at the start of args.
+// It returns the arguments left after the flags, and a ValidationError listing
+// every invalid setting.
+func Load(args []string) (*Config, []string, error) {
+	cfg := &Config{}
+	rest, err := load(cfg, args, os.LookupEnv)
+	if err != nil {
+		return nil, nil, err
 	}
+	return cfg, rest, nil
+}
 
-	Cfg = config
+// validate checks the rules that depend on more than one setting.
+func (c *Config) validate() []string {
+	var problems []string
+	if _, err := regexp.Compile(c.Products.SKUPattern); err != nil {
+		problems = append(problems, "products.sku_pattern (SKU_PATTERN) is not a valid regular expression: "+err.Error())
+	}
+
+	if c.Storage == "memory" {
+		return problems
+	}
+
+	if c.Database.Name == "" {
+		problems = append(problems, "database.name (DB_NAME) is required")
+	}
+	if c.Database.Driver != "sqlite" {
+		if c.Database.User == "" {
+			problems = append(problems, "database.user (DB_USER) is required")
+		}
+		if c.Database.Host == "" {
+			problems = append(problems, "database.host (DB_HOST) is required")
+		}
+	}
+	return problems
+}
 
-	return config
+// Settings lists the resolved value of every setting, masking secrets.
+func (c *Config) Settings() []Setting {
+	return settings(c)
 }
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testConfig struct {
+	Name    string        `config:"name" env:"TEST_NAME" default:"api" validate:"required"`
+	Timeout time.Duration `config:"timeout" env:"TEST_TIMEOUT" default:"5s"`
+	Workers int           `config:"workers" env:"TEST_WORKERS" default:"2" validate:"min=1"`
+	Debug   bool          `config:"debug" env:"TEST_DEBUG"`
+	Server  struct {
+		Hosts  []string `config:"hosts" env:"TEST_HOSTS"`
+		Secret string   `config:"secret" env:"TEST_SECRET" secret:"true"`
+	} `config:"server"`
+}
+
+func env(values map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := values[name]
+		return value, ok
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("should use the defaults", func(t *testing.T) {
+		var cfg testConfig
+		rest, err := load(&cfg, []string{"serve"}, env(nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"serve"}, rest)
+		assert.Equal(t, "api", cfg.Name)
+		assert.Equal(t, 5*time.Second, cfg.Timeout)
+		assert.Equal(t, 2, cfg.Workers)
+	})
+
+	t.Run("should apply the file, then the environment, then the flags", func(t *testing.T) {
+		path := writeFile(t, "config.yaml", "name: file\nworkers: 4\ntimeout: 1m\nserver:\n  hosts: [a, b]\n")
+
+		var cfg testConfig
+		rest, err := load(&cfg, []string{"--config", path, "--workers", "8", "--debug", "migrate", "up"}, env(map[string]string{
+			"TEST_NAME":    "env",
+			"TEST_WORKERS": "6",
+		}))
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"migrate", "up"}, rest)
+		assert.Equal(t, "env", cfg.Name)
+		assert.Equal(t, 8, cfg.Workers)
+		assert.Equal(t, time.Minute, cfg.Timeout)
+		assert.True(t, cfg.Debug)
+		assert.Equal(t, []string{"a", "b"}, cfg.Server.Hosts)
+	})
+
+	t.Run("should read a TOML file given by CONFIG_FILE", func(t *testing.T) {
+		path := writeFile(t, "config.toml", "name = \"toml\"\n\n[server]\nhosts = [\"c\"]\n")
+
+		var cfg testConfig
+		_, err := load(&cfg, nil, env(map[string]string{"CONFIG_FILE": path}))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "toml", cfg.Name)
+		assert.Equal(t, []string{"c"}, cfg.Server.Hosts)
+	})
+
+	t.Run("should read secrets from _FILE variables", func(t *testing.T) {
+		path := writeFile(t, "secret", "s3cr3t\n")
+
+		var cfg testConfig
+		_, err := load(&cfg, nil, env(map[string]string{"TEST_SECRET_FILE": path, "TEST_HOSTS": "a, b"}))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "s3cr3t", cfg.Server.Secret)
+		assert.Equal(t, []string{"a", "b"}, cfg.Server.Hosts)
+	})
+
+	t.Run("should list every invalid setting", func(t *testing.T) {
+		path := writeFile(t, "config.yaml", "name: file\ntypo: 1\n")
+
+		var cfg testConfig
+		_, err := load(&cfg, []string{"--config", path}, env(map[string]string{
+			"TEST_TIMEOUT": "soon",
+			"TEST_WORKERS": "0",
+		}))
+
+		var validationError *ValidationError
+		assert.True(t, errors.As(err, &validationError))
+		assert.Equal(t, []string{
+			`timeout: invalid value "soon" from TEST_TIMEOUT: time: invalid duration "soon"`,
+			"typo: unknown setting in " + path,
+			"workers (TEST_WORKERS) must be at least 1",
+		}, validationError.Problems)
+	})
+
+	t.Run("should report the required settings of Config", func(t *testing.T) {
+		_, err := load(&Config{}, nil, env(nil))
+
+		var validationError *ValidationError
+		assert.True(t, errors.As(err, &validationError))
+		assert.Equal(t, []string{
+			"database.name (DB_NAME) is required",
+			"database.user (DB_USER) is required",
+			"database.host (DB_HOST) is required",
+		}, validationError.Problems)
+	})
+
+	t.Run("should not require the database with memory storage", func(t *testing.T) {
+		cfg := &Config{}
+		_, err := load(cfg, []string{"--storage=memory"}, env(nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "memory", cfg.Storage)
+	})
+
+	t.Run("should only require a file name with sqlite", func(t *testing.T) {
+		cfg := &Config{}
+		_, err := load(cfg, []string{"--database.driver", "sqlite", "--database.name", "eulabs.db"}, env(nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "sqlite", cfg.Database.Driver)
+	})
+}
+
+func TestSettings(t *testing.T) {
+	t.Run("should mask the secrets", func(t *testing.T) {
+		var cfg testConfig
+		cfg.Server.Hosts = []string{"a", "b"}
+		cfg.Server.Secret = "s3cr3t"
+
+		assert.Contains(t, settings(&cfg), Setting{Key: "server.hosts", Env: "TEST_HOSTS", Value: "a,b"})
+		assert.Contains(t, settings(&cfg), Setting{Key: "server.secret", Env: "TEST_SECRET", Value: "********"})
+	})
+}
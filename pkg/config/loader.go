@@ -0,0 +1,345 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/go-playground/validator"
+	"gopkg.in/yaml.v3"
+)
+
+const fileEnv = "CONFIG_FILE"
+
+// Setting is the resolved value of a configuration field.
+type Setting struct {
+	Key   string
+	Env   string
+	Value string
+}
+
+// ValidationError lists every setting that could not be parsed or is invalid.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  " + strings.Join(e.Problems, "\n  ")
+}
+
+type field struct {
+	key       string
+	env       string
+	def       string
+	usage     string
+	secret    bool
+	namespace string
+	value     reflect.Value
+}
+
+func (f field) name() string {
+	if f.env != "" {
+		return fmt.Sprintf("%s (%s)", f.key, f.env)
+	}
+	return f.key
+}
+
+// fields walks the tagged fields of the struct pointed by target.
+func fields(target interface{}) []field {
+	var result []field
+	var walk func(value reflect.Value, prefix, namespace string)
+	walk = func(value reflect.Value, prefix, namespace string) {
+		for i := 0; i < value.NumField(); i++ {
+			structField := value.Type().Field(i)
+			key := structField.Tag.Get("config")
+			if key == "" {
+				continue
+			}
+
+			f := field{
+				key:       prefix + key,
+				env:       structField.Tag.Get("env"),
+				def:       structField.Tag.Get("default"),
+				usage:     structField.Tag.Get("usage"),
+				secret:    structField.Tag.Get("secret") == "true",
+				namespace: namespace + "." + structField.Name,
+				value:     value.Field(i),
+			}
+			if f.value.Kind() == reflect.Struct {
+				walk(f.value, f.key+".", f.namespace)
+				continue
+			}
+			result = append(result, f)
+		}
+	}
+
+	value := reflect.ValueOf(target).Elem()
+	walk(value, "", value.Type().Name())
+	return result
+}
+
+func load(target interface{}, args []string, lookupEnv func(string) (string, bool)) ([]string, error) {
+	fields := fields(target)
+	problems := []string{}
+
+	flagValues := map[string]string{}
+	flags := flag.NewFlagSet("config", flag.ContinueOnError)
+	configFile := flags.String("config", "", "YAML or TOML config file, also read from "+fileEnv)
+	for _, f := range fields {
+		f := f
+		flags.Var(&flagValue{values: flagValues, key: f.key, isBool: f.value.Kind() == reflect.Bool}, f.key, f.usage)
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *configFile == "" {
+		*configFile, _ = lookupEnv(fileEnv)
+	}
+	fileValues := map[string]string{}
+	if *configFile != "" {
+		var err error
+		if fileValues, err = readFile(*configFile); err != nil {
+			return nil, err
+		}
+	}
+
+	known := map[string]bool{}
+	for _, f := range fields {
+		known[f.key] = true
+
+		raw, source := f.def, "default"
+		if value, found := fileValues[f.key]; found {
+			raw, source = value, *configFile
+		}
+		if f.env != "" {
+			if value, found, err := lookupEnvOrFile(lookupEnv, f.env); err != nil {
+				problems = append(problems, err.Error())
+				continue
+			} else if found && value != "" {
+				raw, source = value, f.env
+			}
+		}
+		if value, found := flagValues[f.key]; found {
+			raw, source = value, "--"+f.key
+		}
+
+		if raw != "" {
+			if err := set(f.value, raw); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: invalid value %q from %s: %v", f.key, raw, source, err))
+			}
+		}
+	}
+
+	var unknown []string
+	for key := range fileValues {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		problems = append(problems, fmt.Sprintf("%s: unknown setting in %s", key, *configFile))
+	}
+
+	problems = append(problems, validate(target, fields)...)
+	if target, ok := target.(interface{ validate() []string }); ok {
+		problems = append(problems, target.validate()...)
+	}
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+	return flags.Args(), nil
+}
+
+// lookupEnvOrFile reads name from the environment or, when unset, the file
+// pointed by name_FILE, so that secrets can be mounted as files.
+func lookupEnvOrFile(lookupEnv func(string) (string, bool), name string) (string, bool, error) {
+	if value, ok := lookupEnv(name); ok {
+		return value, true, nil
+	}
+
+	path, ok := lookupEnv(name + "_FILE")
+	if !ok {
+		return "", false, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("%s_FILE: %v", name, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), true, nil
+}
+
+// readFile flattens a YAML or TOML file into dotted keys.
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	document := map[string]interface{}{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &document)
+	case ".toml":
+		err = toml.Unmarshal(data, &document)
+	default:
+		return nil, fmt.Errorf("%s: unsupported config format %q", path, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	values := map[string]string{}
+	flatten(values, "", document)
+	return values, nil
+}
+
+func flatten(values map[string]string, prefix string, document map[string]interface{}) {
+	for key, value := range document {
+		switch value := value.(type) {
+		case map[string]interface{}:
+			flatten(values, prefix+key+".", value)
+		case []interface{}:
+			items := make([]string, len(value))
+			for i, item := range value {
+				items[i] = fmt.Sprint(item)
+			}
+			values[prefix+key] = strings.Join(items, ",")
+		default:
+			values[prefix+key] = fmt.Sprint(value)
+		}
+	}
+}
+
+// set parses raw into value. Lists are comma separated.
+func set(value reflect.Value, raw string) error {
+	if value.Type() == reflect.TypeOf(time.Duration(0)) {
+		duration, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		value.SetInt(int64(duration))
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Int, reflect.Int64:
+		number, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		value.SetInt(number)
+	case reflect.Float64:
+		number, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		value.SetFloat(number)
+	case reflect.Bool:
+		boolean, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		value.SetBool(boolean)
+	case reflect.Slice:
+		items := strings.Split(raw, ",")
+		list := reflect.MakeSlice(value.Type(), 0, len(items))
+		for _, item := range items {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			element := reflect.New(value.Type().Elem()).Elem()
+			if err := set(element, item); err != nil {
+				return err
+			}
+			list = reflect.Append(list, element)
+		}
+		value.Set(list)
+	default:
+		return fmt.Errorf("unsupported type %s", value.Type())
+	}
+	return nil
+}
+
+func validate(target interface{}, fields []field) []string {
+	err := validator.New().Struct(target)
+	if err == nil {
+		return nil
+	}
+
+	byNamespace := map[string]field{}
+	for _, f := range fields {
+		byNamespace[f.namespace] = f
+	}
+
+	var problems []string
+	for _, fieldError := range err.(validator.ValidationErrors) {
+		f := byNamespace[fieldError.StructNamespace()]
+		switch fieldError.Tag() {
+		case "required":
+			problems = append(problems, fmt.Sprintf("%s is required", f.name()))
+		case "min", "gte":
+			problems = append(problems, fmt.Sprintf("%s must be at least %s", f.name(), fieldError.Param()))
+		case "max", "lte":
+			problems = append(problems, fmt.Sprintf("%s must be at most %s", f.name(), fieldError.Param()))
+		case "oneof":
+			problems = append(problems, fmt.Sprintf("%s must be one of %s", f.name(), fieldError.Param()))
+		default:
+			problems = append(problems, fmt.Sprintf("%s failed the %s validation", f.name(), fieldError.Tag()))
+		}
+	}
+	return problems
+}
+
+func settings(target interface{}) []Setting {
+	var result []Setting
+	for _, f := range fields(target) {
+		value := fmt.Sprint(f.value.Interface())
+		if f.value.Kind() == reflect.Slice {
+			items := make([]string, f.value.Len())
+			for i := range items {
+				items[i] = fmt.Sprint(f.value.Index(i).Interface())
+			}
+			value = strings.Join(items, ",")
+		}
+		if f.secret && value != "" {
+			value = "********"
+		}
+		result = append(result, Setting{Key: f.key, Env: f.env, Value: value})
+	}
+	return result
+}
+
+// flagValue records the flags given on the command line, to be applied after
+// the config file and the environment.
+type flagValue struct {
+	values map[string]string
+	key    string
+	isBool bool
+}
+
+func (v *flagValue) String() string {
+	if v == nil || v.values == nil {
+		return ""
+	}
+	return v.values[v.key]
+}
+
+func (v *flagValue) Set(value string) error {
+	v.values[v.key] = value
+	return nil
+}
+
+func (v *flagValue) IsBoolFlag() bool {
+	return v.isBool
+}
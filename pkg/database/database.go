@@ -1,39 +1,130 @@
 package database
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
 	"log"
 	"os"
-	"time"
+	"strconv"
 
 	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
 	"github.com/adrianosiqe/eulabs-challenge-api/pkg/config"
-	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
-func ConnectDatabase() (*gorm.DB, error) {
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=%s&parseTime=%s&loc=%s",
-		config.Cfg.DBUser, config.Cfg.DBPassword, config.Cfg.DBHost, config.Cfg.DBPort, config.Cfg.DBName, config.Cfg.DBCharset, config.Cfg.DBParseTime, config.Cfg.DBLoc)
+var logLevels = map[string]logger.LogLevel{
+	"silent": logger.Silent,
+	"error":  logger.Error,
+	"warn":   logger.Warn,
+	"info":   logger.Info,
+}
+
+// ConnectDatabase opens the connection pool of cfg.Driver. The database may not
+// accept connections yet when the API starts, so opening is retried until
+// cfg.ConnectTimeout.
+func ConnectDatabase(cfg config.DatabaseConfig) (*gorm.DB, error) {
+	driver, err := lookupDriver(cfg.Driver)
+	if err != nil {
+		return nil, err
+	}
+	dsn, err := driver.dsn(cfg)
+	if err != nil {
+		return nil, err
+	}
 
 	newLogger := logger.New(
 		log.New(os.Stdout, "\r\n", log.LstdFlags),
 		logger.Config{
-			SlowThreshold: time.Second,
-			LogLevel:      logger.Error,
-			Colorful:      true,
+			SlowThreshold: cfg.SlowThreshold,
+			LogLevel:      logLevels[cfg.LogLevel],
+			// Lookups of missing rows are answered with a 404, not errors.
+			IgnoreRecordNotFoundError: true,
+			Colorful:                  true,
 		},
 	)
 
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
-		Logger: newLogger,
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnectTimeout)
+	defer cancel()
+
+	var db *gorm.DB
+	err = retry(ctx, cfg.RetryInterval, cfg.RetryMaxInterval, func() error {
+		var err error
+		db, err = gorm.Open(driver.dialector(dsn), &gorm.Config{
+			Logger: newLogger,
+		})
+		if err != nil {
+			log.Printf("Database not ready: %v", err)
+		}
+		return err
 	})
+	if err != nil {
+		return nil, fmt.Errorf("connecting to the database: %w", err)
+	}
+
+	sqlDB, err := db.DB()
 	if err != nil {
 		return nil, err
 	}
+	if cfg.Driver == "sqlite" {
+		// SQLite allows a single writer, and every connection to :memory: is
+		// a new database, so a single connection is kept for good.
+		cfg.MaxOpenConns, cfg.MaxIdleConns, cfg.ConnMaxLifetime, cfg.ConnMaxIdleTime = 1, 1, 0, 0
+	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
 
-	db.AutoMigrate(&models.Product{})
+	if len(cfg.Replicas) > 0 {
+		if err := connectReplicas(db, driver, cfg); err != nil {
+			return nil, err
+		}
+	}
 
 	return db, nil
 }
+
+// connectReplicas routes the reads marked with ReadFromReplica to the replicas
+// of cfg. A replica that is down at startup joins once its health check passes.
+func connectReplicas(db *gorm.DB, driver driver, cfg config.DatabaseConfig) error {
+	replicas := NewReplicas(cfg.ReadYourWrites)
+	for i, dsn := range cfg.Replicas {
+		replicaDB, err := sql.Open(driver.sqlDriver, dsn)
+		if err != nil {
+			return fmt.Errorf("replica %d: %w", i+1, err)
+		}
+		replicaDB.SetMaxOpenConns(cfg.MaxOpenConns)
+		replicaDB.SetMaxIdleConns(cfg.MaxIdleConns)
+		replicaDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+		replicaDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+		replicas.Add(strconv.Itoa(i+1), replicaDB)
+	}
+
+	if err := replicas.Register(db); err != nil {
+		return err
+	}
+	replicas.CheckHealth(context.Background(), cfg.DialTimeout)
+	go replicas.Watch(context.Background(), cfg.ReplicaHealthInterval, cfg.DialTimeout)
+	return nil
+}
+
+// Migrate applies the pending migrations, or runs GORM's AutoMigrate when
+// AutoMigrate is enabled.
+func Migrate(db *gorm.DB, cfg config.DatabaseConfig) error {
+	if cfg.AutoMigrate {
+		return db.AutoMigrate(&models.Product{}, &models.Category{}, &models.Tag{}, &models.ProductOption{}, &models.ProductVariant{}, &models.ProductMedia{}, &models.PriceChange{}, &models.AttributeDefinition{}, &models.ProductAttribute{}, &models.APIKey{}, &models.StockLevel{}, &models.StockMovement{}, &models.Reservation{})
+	}
+
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	applied, err := migrator.Up(context.Background())
+	for _, migration := range applied {
+		log.Printf("Applied migration %d_%s", migration.Version, migration.Name)
+	}
+	return err
+}
@@ -0,0 +1,87 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/internal/domains/models"
+	"github.com/adrianosiqe/eulabs-challenge-api/pkg/config"
+	"github.com/adrianosiqe/eulabs-challenge-api/pkg/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDSN(t *testing.T) {
+	cfg := config.DatabaseConfig{
+		User: "root", Password: "p@ss word", Host: "db", Name: "eulabs",
+		Charset: "utf8mb4", ParseTime: true, Loc: "UTC", SSLMode: "disable", DialTimeout: 1500 * time.Millisecond,
+	}
+
+	t.Run("should build a MySQL DSN", func(t *testing.T) {
+		cfg := cfg
+		cfg.Driver = "mysql"
+
+		dsn, err := DSN(cfg)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "root:p@ss word@tcp(db:3306)/eulabs?parseTime=true&timeout=1.5s&charset=utf8mb4", dsn)
+	})
+
+	t.Run("should build a PostgreSQL DSN", func(t *testing.T) {
+		cfg := cfg
+		cfg.Driver = "postgres"
+		cfg.Port = 6432
+
+		dsn, err := DSN(cfg)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "postgres://root:p%40ss%20word@db:6432/eulabs?connect_timeout=2&sslmode=disable", dsn)
+	})
+
+	t.Run("should use the file name with SQLite", func(t *testing.T) {
+		dsn, err := DSN(config.DatabaseConfig{Driver: "sqlite", Name: "eulabs.db"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "eulabs.db", dsn)
+	})
+
+	t.Run("should return an error for an unknown driver", func(t *testing.T) {
+		_, err := DSN(config.DatabaseConfig{Driver: "oracle"})
+
+		assert.Error(t, err)
+	})
+}
+
+func TestSQLite(t *testing.T) {
+	cfg := config.DatabaseConfig{Driver: "sqlite", Name: ":memory:", LogLevel: "silent"}
+
+	t.Run("should apply and revert the migrations", func(t *testing.T) {
+		db, err := ConnectDatabase(cfg)
+		assert.NoError(t, err)
+		assert.NoError(t, Migrate(db, cfg))
+
+		product := &models.Product{Title: "Bulbasaur", Description: "Grass", Price: money.MustParseAmount("99.99")}
+		assert.NoError(t, db.Create(product).Error)
+
+		var found models.Product
+		assert.NoError(t, db.First(&found, product.ID).Error)
+		assert.Equal(t, money.MustParseAmount("99.99"), found.Price)
+
+		migrator, err := NewMigrator(db)
+		assert.NoError(t, err)
+		reverted, err := migrator.DownTo(context.Background(), 0)
+		assert.NoError(t, err)
+		assert.Len(t, reverted, len(migrator.migrations))
+		assert.False(t, db.Migrator().HasTable("products"))
+	})
+
+	t.Run("should auto migrate the models", func(t *testing.T) {
+		cfg := cfg
+		cfg.AutoMigrate = true
+
+		db, err := ConnectDatabase(cfg)
+		assert.NoError(t, err)
+		assert.NoError(t, Migrate(db, cfg))
+		assert.True(t, db.Migrator().HasTable(&models.APIKey{}))
+	})
+}
@@ -0,0 +1,98 @@
+package database
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/adrianosiqe/eulabs-challenge-api/pkg/config"
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// driver describes a supported DB_DRIVER.
+type driver struct {
+	// sqlDriver is the database/sql driver name, used for the read replicas.
+	sqlDriver string
+	dsn       func(cfg config.DatabaseConfig) (string, error)
+	dialector func(dsn string) gorm.Dialector
+}
+
+var drivers = map[string]driver{
+	"mysql":    {sqlDriver: "mysql", dsn: mysqlDSN, dialector: mysql.Open},
+	"postgres": {sqlDriver: "pgx", dsn: postgresDSN, dialector: postgres.Open},
+	"sqlite":   {sqlDriver: "sqlite3", dsn: sqliteDSN, dialector: sqlite.Open},
+}
+
+func lookupDriver(name string) (driver, error) {
+	d, ok := drivers[name]
+	if !ok {
+		return driver{}, fmt.Errorf("unsupported database driver %q", name)
+	}
+	return d, nil
+}
+
+// DSN builds the data source name of cfg for its driver.
+func DSN(cfg config.DatabaseConfig) (string, error) {
+	d, err := lookupDriver(cfg.Driver)
+	if err != nil {
+		return "", err
+	}
+	return d.dsn(cfg)
+}
+
+func address(host string, port, defaultPort int) string {
+	if port == 0 {
+		port = defaultPort
+	}
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
+
+func mysqlDSN(cfg config.DatabaseConfig) (string, error) {
+	loc, err := time.LoadLocation(cfg.Loc)
+	if err != nil {
+		return "", err
+	}
+
+	dsn := mysqldriver.NewConfig()
+	dsn.User = cfg.User
+	dsn.Passwd = cfg.Password
+	dsn.Net = "tcp"
+	dsn.Addr = address(cfg.Host, cfg.Port, 3306)
+	dsn.DBName = cfg.Name
+	if cfg.Charset != "" {
+		dsn.Params = map[string]string{"charset": cfg.Charset}
+	}
+	dsn.ParseTime = cfg.ParseTime
+	dsn.Loc = loc
+	dsn.Timeout = cfg.DialTimeout
+	return dsn.FormatDSN(), nil
+}
+
+func postgresDSN(cfg config.DatabaseConfig) (string, error) {
+	query := url.Values{}
+	query.Set("sslmode", cfg.SSLMode)
+	if cfg.DialTimeout > 0 {
+		// connect_timeout is in whole seconds.
+		query.Set("connect_timeout", strconv.Itoa(int(math.Ceil(cfg.DialTimeout.Seconds()))))
+	}
+
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.User, cfg.Password),
+		Host:     address(cfg.Host, cfg.Port, 5432),
+		Path:     "/" + cfg.Name,
+		RawQuery: query.Encode(),
+	}
+	return dsn.String(), nil
+}
+
+func sqliteDSN(cfg config.DatabaseConfig) (string, error) {
+	return cfg.Name, nil
+}
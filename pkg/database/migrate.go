@@ -0,0 +1,399 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed migrations
+var migrationFiles embed.FS
+
+const (
+	migrationsTable = "schema_migrations"
+	// migrationsLock names the advisory lock held while migrating, so replicas
+	// starting at the same time apply each migration once.
+	migrationsLock        = "eulabs_schema_migrations"
+	migrationsLockTimeout = 60
+	// migrationsLockKey is the PostgreSQL advisory lock key, which must be a
+	// number.
+	migrationsLockKey = 7253110531
+)
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+type Migrator struct {
+	db         *sql.DB
+	dialect    dialect
+	migrations []Migration
+}
+
+// dialect holds what differs between the supported databases. Each one has
+// its own copy of the migrations in migrations/<name>.
+type dialect struct {
+	name string
+	// lock and unlock hold an advisory lock while migrating; nil when the
+	// database is not shared between replicas.
+	lock, unlock func(ctx context.Context, conn *sql.Conn) error
+	// appliedAtType is the column type of the migration timestamps.
+	appliedAtType string
+	// placeholder returns the n-th (from 1) bind parameter of a query.
+	placeholder func(n int) string
+	// transactionalDDL runs each migration in a transaction, since the
+	// database can roll schema changes back.
+	transactionalDDL bool
+}
+
+var dialects = map[string]dialect{
+	"mysql": {
+		name: "mysql",
+		lock: func(ctx context.Context, conn *sql.Conn) error {
+			var locked sql.NullInt64
+			if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", migrationsLock, migrationsLockTimeout).Scan(&locked); err != nil {
+				return err
+			}
+			if locked.Int64 != 1 {
+				return errors.New("timed out waiting for the migrations lock")
+			}
+			return nil
+		},
+		unlock: func(ctx context.Context, conn *sql.Conn) error {
+			_, err := conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", migrationsLock)
+			return err
+		},
+		appliedAtType: "DATETIME(3)",
+		placeholder:   func(int) string { return "?" },
+	},
+	"postgres": {
+		name: "postgres",
+		lock: func(ctx context.Context, conn *sql.Conn) error {
+			if _, err := conn.ExecContext(ctx, fmt.Sprintf("SET lock_timeout = '%ds'", migrationsLockTimeout)); err != nil {
+				return err
+			}
+			_, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationsLockKey)
+
+			// The timeout is a setting of the session, which goes back to the
+			// pool with the connection, and must not limit the migrations.
+			if _, resetErr := conn.ExecContext(context.Background(), "RESET lock_timeout"); resetErr != nil {
+				if err == nil {
+					conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationsLockKey)
+				}
+				return resetErr
+			}
+			return err
+		},
+		unlock: func(ctx context.Context, conn *sql.Conn) error {
+			_, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", migrationsLockKey)
+			return err
+		},
+		appliedAtType:    "TIMESTAMPTZ",
+		placeholder:      func(n int) string { return "$" + strconv.Itoa(n) },
+		transactionalDDL: true,
+	},
+	"sqlite": {
+		name:             "sqlite",
+		appliedAtType:    "DATETIME",
+		placeholder:      func(int) string { return "?" },
+		transactionalDDL: true,
+	},
+}
+
+func NewMigrator(db *gorm.DB) (*Migrator, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	dialect, ok := dialects[db.Dialector.Name()]
+	if !ok {
+		return nil, fmt.Errorf("no migrations for the %s dialect", db.Dialector.Name())
+	}
+
+	migrations, err := LoadMigrations(migrationFiles, path.Join("migrations", dialect.name))
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: sqlDB, dialect: dialect, migrations: migrations}, nil
+}
+
+// LoadMigrations reads the <version>_<name>.up.sql and .down.sql pairs of dir,
+// sorted by version.
+func LoadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %s", entry.Name())
+		}
+
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			status := MigrationStatus{Migration: migration}
+			if appliedAt, ok := applied[migration.Version]; ok {
+				status.AppliedAt = &appliedAt
+			}
+			statuses = append(statuses, status)
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+// Up applies every pending migration and returns them.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := m.apply(ctx, conn, migration, true); err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// DownTo reverts the applied migrations newer than version, newest first, and
+// returns them. DownTo(0) reverts everything.
+func (m *Migrator) DownTo(ctx context.Context, version int64) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			migration := m.migrations[i]
+			if migration.Version <= version {
+				break
+			}
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			if err := m.apply(ctx, conn, migration, false); err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Redo reverts and re-applies the latest applied migration.
+func (m *Migrator) Redo(ctx context.Context) (*Migration, error) {
+	var redone *Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			if err := m.apply(ctx, conn, migration, false); err != nil {
+				return err
+			}
+			if err := m.apply(ctx, conn, migration, true); err != nil {
+				return err
+			}
+			redone = &migration
+			return nil
+		}
+		return errors.New("no migration has been applied")
+	})
+	return redone, err
+}
+
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) (err error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if m.dialect.lock != nil {
+		if err := m.dialect.lock(ctx, conn); err != nil {
+			return err
+		}
+		defer func() {
+			if unlockErr := m.dialect.unlock(context.Background(), conn); err == nil {
+				err = unlockErr
+			}
+		}()
+	}
+
+	_, err = conn.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+migrationsTable+" (version BIGINT NOT NULL PRIMARY KEY, name VARCHAR(255) NOT NULL, applied_at "+m.dialect.appliedAtType+" NOT NULL)")
+	if err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM "+migrationsTable)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int64]time.Time{}
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// apply runs the up or down script of a migration and records the result.
+// MySQL commits DDL implicitly, so there a failing script may be partially
+// applied; the other dialects run it in a transaction.
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration, up bool) (err error) {
+	script := migration.Down
+	if up {
+		script = migration.Up
+	}
+
+	var exec execer = conn
+	if m.dialect.transactionalDDL {
+		var tx *sql.Tx
+		if tx, err = conn.BeginTx(ctx, nil); err != nil {
+			return err
+		}
+		defer func() {
+			if err != nil {
+				tx.Rollback()
+				return
+			}
+			err = tx.Commit()
+		}()
+		exec = tx
+	}
+
+	for _, statement := range splitStatements(script) {
+		if _, err := exec.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+	}
+
+	p := m.dialect.placeholder
+	if up {
+		_, err = exec.ExecContext(ctx, "INSERT INTO "+migrationsTable+" (version, name, applied_at) VALUES ("+p(1)+", "+p(2)+", "+p(3)+")", migration.Version, migration.Name, time.Now().UTC())
+	} else {
+		_, err = exec.ExecContext(ctx, "DELETE FROM "+migrationsTable+" WHERE version = "+p(1), migration.Version)
+	}
+	return err
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// splitStatements splits a script on the semicolons ending a line.
+func splitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSpace(current.String()))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
@@ -0,0 +1,240 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var testMigrations = fstest.MapFS{
+	"migrations/0001_create_products.up.sql":   {Data: []byte("CREATE TABLE products (id INT);\nCREATE INDEX idx ON products (id);\n")},
+	"migrations/0001_create_products.down.sql": {Data: []byte("DROP TABLE products;\n")},
+	"migrations/0002_add_sku.up.sql":           {Data: []byte("ALTER TABLE products ADD sku VARCHAR(64);\n")},
+	"migrations/0002_add_sku.down.sql":         {Data: []byte("ALTER TABLE products DROP sku;\n")},
+}
+
+func newTestMigrator(t *testing.T) (*Migrator, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	migrations, err := LoadMigrations(testMigrations, "migrations")
+	assert.NoError(t, err)
+
+	return &Migrator{db: db, dialect: dialects["mysql"], migrations: migrations}, mock
+}
+
+func expectLock(mock sqlmock.Sqlmock, applied ...int64) {
+	mock.ExpectQuery("SELECT GET_LOCK").WithArgs(migrationsLock, migrationsLockTimeout).WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(1))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	rows := sqlmock.NewRows([]string{"version", "applied_at"})
+	for _, version := range applied {
+		rows.AddRow(version, time.Now())
+	}
+	mock.ExpectQuery("SELECT version, applied_at FROM schema_migrations").WillReturnRows(rows)
+}
+
+func expectUnlock(mock sqlmock.Sqlmock) {
+	mock.ExpectExec("SELECT RELEASE_LOCK").WithArgs(migrationsLock).WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func TestLoadMigrations(t *testing.T) {
+	t.Run("should load the embedded migrations", func(t *testing.T) {
+		migrations, err := LoadMigrations(migrationFiles, "migrations/mysql")
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), migrations[0].Version)
+		assert.Equal(t, "create_products", migrations[0].Name)
+	})
+
+	t.Run("should have the same migrations for every dialect", func(t *testing.T) {
+		names := func(dir string) []string {
+			migrations, err := LoadMigrations(migrationFiles, dir)
+			assert.NoError(t, err)
+
+			var names []string
+			for _, migration := range migrations {
+				names = append(names, fmt.Sprintf("%d_%s", migration.Version, migration.Name))
+			}
+			return names
+		}
+
+		for name := range dialects {
+			assert.Equal(t, names("migrations/mysql"), names("migrations/"+name), name)
+		}
+	})
+
+	t.Run("should sort and split the migrations", func(t *testing.T) {
+		migrations, err := LoadMigrations(testMigrations, "migrations")
+
+		assert.NoError(t, err)
+		assert.Len(t, migrations, 2)
+		assert.Equal(t, int64(2), migrations[1].Version)
+		assert.Len(t, splitStatements(migrations[0].Up), 2)
+	})
+
+	t.Run("should return an error for a missing down file", func(t *testing.T) {
+		_, err := LoadMigrations(fstest.MapFS{
+			"migrations/0001_create_products.up.sql": {Data: []byte("CREATE TABLE products (id INT);")},
+		}, "migrations")
+
+		assert.Error(t, err)
+	})
+}
+
+func TestMigratorStatus(t *testing.T) {
+	t.Run("should report applied and pending migrations", func(t *testing.T) {
+		migrator, mock := newTestMigrator(t)
+		expectLock(mock, 1)
+		expectUnlock(mock)
+
+		statuses, err := migrator.Status(context.Background())
+
+		assert.NoError(t, err)
+		assert.NotNil(t, statuses[0].AppliedAt)
+		assert.Nil(t, statuses[1].AppliedAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestMigratorUp(t *testing.T) {
+	t.Run("should apply pending migrations", func(t *testing.T) {
+		migrator, mock := newTestMigrator(t)
+		expectLock(mock, 1)
+		mock.ExpectExec("ALTER TABLE products ADD sku").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("INSERT INTO schema_migrations").WithArgs(2, "add_sku", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+		expectUnlock(mock)
+
+		applied, err := migrator.Up(context.Background())
+
+		assert.NoError(t, err)
+		assert.Len(t, applied, 1)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should stop at the failing migration", func(t *testing.T) {
+		migrator, mock := newTestMigrator(t)
+		expectLock(mock)
+		mock.ExpectExec("CREATE TABLE products").WillReturnError(fmt.Errorf("some error"))
+		expectUnlock(mock)
+
+		_, err := migrator.Up(context.Background())
+
+		assert.ErrorContains(t, err, "migration 1_create_products")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should return an error when the lock is held", func(t *testing.T) {
+		migrator, mock := newTestMigrator(t)
+		mock.ExpectQuery("SELECT GET_LOCK").WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(0))
+
+		_, err := migrator.Up(context.Background())
+
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestMigratorDialects(t *testing.T) {
+	t.Run("should use an advisory lock, a transaction and numbered placeholders on PostgreSQL", func(t *testing.T) {
+		migrator, mock := newTestMigrator(t)
+		migrator.dialect = dialects["postgres"]
+		mock.ExpectExec("SET lock_timeout").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("SELECT pg_advisory_lock").WithArgs(migrationsLockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("RESET lock_timeout").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations (.+) TIMESTAMPTZ").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT version, applied_at FROM schema_migrations").WillReturnRows(sqlmock.NewRows([]string{"version", "applied_at"}).AddRow(1, time.Now()))
+		mock.ExpectBegin()
+		mock.ExpectExec("ALTER TABLE products ADD sku").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO schema_migrations \(version, name, applied_at\) VALUES \(\$1, \$2, \$3\)`).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		mock.ExpectExec("SELECT pg_advisory_unlock").WithArgs(migrationsLockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		applied, err := migrator.Up(context.Background())
+
+		assert.NoError(t, err)
+		assert.Len(t, applied, 1)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should reset the lock timeout of the connection when the lock times out on PostgreSQL", func(t *testing.T) {
+		migrator, mock := newTestMigrator(t)
+		migrator.dialect = dialects["postgres"]
+		mock.ExpectExec("SET lock_timeout").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("SELECT pg_advisory_lock").WithArgs(migrationsLockKey).WillReturnError(fmt.Errorf("canceling statement due to lock timeout"))
+		mock.ExpectExec("RESET lock_timeout").WillReturnResult(sqlmock.NewResult(0, 0))
+
+		_, err := migrator.Up(context.Background())
+
+		assert.ErrorContains(t, err, "lock timeout")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should release the lock when the timeout can't be reset on PostgreSQL", func(t *testing.T) {
+		migrator, mock := newTestMigrator(t)
+		migrator.dialect = dialects["postgres"]
+		mock.ExpectExec("SET lock_timeout").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("SELECT pg_advisory_lock").WithArgs(migrationsLockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("RESET lock_timeout").WillReturnError(fmt.Errorf("some error"))
+		mock.ExpectExec("SELECT pg_advisory_unlock").WithArgs(migrationsLockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		_, err := migrator.Up(context.Background())
+
+		assert.ErrorContains(t, err, "some error")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should roll back a failing migration on SQLite", func(t *testing.T) {
+		migrator, mock := newTestMigrator(t)
+		migrator.dialect = dialects["sqlite"]
+		mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT version, applied_at FROM schema_migrations").WillReturnRows(sqlmock.NewRows([]string{"version", "applied_at"}))
+		mock.ExpectBegin()
+		mock.ExpectExec("CREATE TABLE products").WillReturnError(fmt.Errorf("some error"))
+		mock.ExpectRollback()
+
+		_, err := migrator.Up(context.Background())
+
+		assert.ErrorContains(t, err, "migration 1_create_products")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestMigratorDownTo(t *testing.T) {
+	t.Run("should revert migrations newer than the version", func(t *testing.T) {
+		migrator, mock := newTestMigrator(t)
+		expectLock(mock, 1, 2)
+		mock.ExpectExec("ALTER TABLE products DROP sku").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("DELETE FROM schema_migrations").WithArgs(2).WillReturnResult(sqlmock.NewResult(1, 1))
+		expectUnlock(mock)
+
+		reverted, err := migrator.DownTo(context.Background(), 1)
+
+		assert.NoError(t, err)
+		assert.Len(t, reverted, 1)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestMigratorRedo(t *testing.T) {
+	t.Run("should revert and apply the latest migration", func(t *testing.T) {
+		migrator, mock := newTestMigrator(t)
+		expectLock(mock, 1, 2)
+		mock.ExpectExec("ALTER TABLE products DROP sku").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("DELETE FROM schema_migrations").WithArgs(2).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("ALTER TABLE products ADD sku").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("INSERT INTO schema_migrations").WillReturnResult(sqlmock.NewResult(1, 1))
+		expectUnlock(mock)
+
+		redone, err := migrator.Redo(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), redone.Version)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
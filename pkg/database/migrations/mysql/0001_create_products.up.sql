@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS `products` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `title` VARCHAR(255),
+  `description` TEXT,
+  `price` DECIMAL(20,2),
+  `created_at` DATETIME(3) NULL,
+  `updated_at` DATETIME(3) NULL,
+  `deleted_at` DATETIME(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_products_deleted_at` (`deleted_at`)
+);
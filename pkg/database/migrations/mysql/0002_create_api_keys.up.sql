@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS `api_keys` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `name` VARCHAR(255),
+  `prefix` VARCHAR(32),
+  `hash` CHAR(64),
+  `scopes` VARCHAR(255),
+  `expires_at` DATETIME(3) NULL,
+  `last_used_at` DATETIME(3) NULL,
+  `revoked_at` DATETIME(3) NULL,
+  `created_at` DATETIME(3) NULL,
+  `updated_at` DATETIME(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_api_keys_prefix` (`prefix`)
+);
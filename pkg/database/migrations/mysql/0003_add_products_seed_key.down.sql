@@ -0,0 +1,2 @@
+DROP INDEX `idx_products_seed_key` ON `products`;
+ALTER TABLE `products` DROP COLUMN `seed_key`;
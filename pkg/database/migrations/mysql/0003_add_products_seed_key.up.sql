@@ -0,0 +1,2 @@
+ALTER TABLE `products` ADD COLUMN `seed_key` VARCHAR(100) NULL AFTER `price`;
+CREATE INDEX `idx_products_seed_key` ON `products` (`seed_key`);
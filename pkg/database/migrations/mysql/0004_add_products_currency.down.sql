@@ -0,0 +1,2 @@
+ALTER TABLE `products` DROP COLUMN `currency`;
+ALTER TABLE `products` MODIFY `price` DECIMAL(20,2);
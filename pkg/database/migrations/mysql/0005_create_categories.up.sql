@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS `categories` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `name` VARCHAR(255),
+  `parent_id` BIGINT UNSIGNED NULL,
+  `path` VARCHAR(255),
+  `created_at` DATETIME(3) NULL,
+  `updated_at` DATETIME(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_categories_parent_id` (`parent_id`),
+  INDEX `idx_categories_path` (`path`),
+  CONSTRAINT `fk_categories_parent` FOREIGN KEY (`parent_id`) REFERENCES `categories` (`id`)
+);
+CREATE TABLE IF NOT EXISTS `product_categories` (
+  `product_id` BIGINT UNSIGNED NOT NULL,
+  `category_id` BIGINT UNSIGNED NOT NULL,
+  PRIMARY KEY (`product_id`, `category_id`),
+  INDEX `idx_product_categories_category_id` (`category_id`),
+  CONSTRAINT `fk_product_categories_product` FOREIGN KEY (`product_id`) REFERENCES `products` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `fk_product_categories_category` FOREIGN KEY (`category_id`) REFERENCES `categories` (`id`) ON DELETE CASCADE
+);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS `tags` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `name` VARCHAR(50) NOT NULL,
+  `created_at` DATETIME(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_tags_name` (`name`)
+);
+CREATE TABLE IF NOT EXISTS `product_tags` (
+  `product_id` BIGINT UNSIGNED NOT NULL,
+  `tag_id` BIGINT UNSIGNED NOT NULL,
+  PRIMARY KEY (`product_id`, `tag_id`),
+  INDEX `idx_product_tags_tag_id` (`tag_id`),
+  CONSTRAINT `fk_product_tags_product` FOREIGN KEY (`product_id`) REFERENCES `products` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `fk_product_tags_tag` FOREIGN KEY (`tag_id`) REFERENCES `tags` (`id`) ON DELETE CASCADE
+);
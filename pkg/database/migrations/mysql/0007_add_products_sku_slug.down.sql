@@ -0,0 +1,4 @@
+DROP INDEX `idx_products_slug` ON `products`;
+DROP INDEX `idx_products_sku` ON `products`;
+ALTER TABLE `products` DROP COLUMN `slug`;
+ALTER TABLE `products` DROP COLUMN `sku`;
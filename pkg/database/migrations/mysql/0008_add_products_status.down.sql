@@ -0,0 +1,3 @@
+DROP INDEX `idx_products_status` ON `products`;
+ALTER TABLE `products` DROP COLUMN `published_at`;
+ALTER TABLE `products` DROP COLUMN `status`;
@@ -0,0 +1,4 @@
+DROP INDEX `idx_products_unpublish_at` ON `products`;
+DROP INDEX `idx_products_publish_at` ON `products`;
+ALTER TABLE `products` DROP COLUMN `unpublish_at`;
+ALTER TABLE `products` DROP COLUMN `publish_at`;
@@ -0,0 +1,4 @@
+ALTER TABLE `products` ADD COLUMN `publish_at` DATETIME(3) NULL AFTER `published_at`;
+ALTER TABLE `products` ADD COLUMN `unpublish_at` DATETIME(3) NULL AFTER `publish_at`;
+CREATE INDEX `idx_products_publish_at` ON `products` (`publish_at`);
+CREATE INDEX `idx_products_unpublish_at` ON `products` (`unpublish_at`);
@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS `stock_levels` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `product_id` BIGINT UNSIGNED NOT NULL,
+  `warehouse` VARCHAR(50) NOT NULL,
+  `on_hand` BIGINT NOT NULL DEFAULT 0,
+  `updated_at` DATETIME(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_stock_levels_product_warehouse` (`product_id`, `warehouse`),
+  CONSTRAINT `fk_stock_levels_product` FOREIGN KEY (`product_id`) REFERENCES `products` (`id`) ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS `stock_movements` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `product_id` BIGINT UNSIGNED NOT NULL,
+  `warehouse` VARCHAR(50) NOT NULL,
+  `delta` BIGINT NOT NULL,
+  `reason` VARCHAR(20) NOT NULL,
+  `note` VARCHAR(255) NULL,
+  `reservation_id` BIGINT UNSIGNED NULL,
+  `created_at` DATETIME(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_stock_movements_product_id` (`product_id`),
+  CONSTRAINT `fk_stock_movements_product` FOREIGN KEY (`product_id`) REFERENCES `products` (`id`) ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS `reservations` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `product_id` BIGINT UNSIGNED NOT NULL,
+  `warehouse` VARCHAR(50) NOT NULL,
+  `quantity` BIGINT NOT NULL,
+  `status` VARCHAR(20) NOT NULL,
+  `expires_at` DATETIME(3) NULL,
+  `created_at` DATETIME(3) NULL,
+  `updated_at` DATETIME(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_reservations_product_id` (`product_id`),
+  INDEX `idx_reservations_status` (`status`),
+  INDEX `idx_reservations_expires_at` (`expires_at`),
+  CONSTRAINT `fk_reservations_product` FOREIGN KEY (`product_id`) REFERENCES `products` (`id`) ON DELETE CASCADE
+);
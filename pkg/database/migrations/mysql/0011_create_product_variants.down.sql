@@ -0,0 +1,7 @@
+ALTER TABLE `reservations` DROP COLUMN `variant_id`;
+ALTER TABLE `stock_movements` DROP COLUMN `variant_id`;
+CREATE UNIQUE INDEX `idx_stock_levels_product_warehouse` ON `stock_levels` (`product_id`, `warehouse`);
+DROP INDEX `idx_stock_levels_product_variant_warehouse` ON `stock_levels`;
+ALTER TABLE `stock_levels` DROP COLUMN `variant_id`;
+DROP TABLE IF EXISTS `product_variants`;
+DROP TABLE IF EXISTS `product_options`;
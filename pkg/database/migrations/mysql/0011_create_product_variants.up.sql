@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS `product_options` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `product_id` BIGINT UNSIGNED NOT NULL,
+  `name` VARCHAR(50) NOT NULL,
+  `option_values` VARCHAR(1000) NOT NULL,
+  `position` INT NOT NULL DEFAULT 0,
+  PRIMARY KEY (`id`),
+  INDEX `idx_product_options_product_id` (`product_id`),
+  CONSTRAINT `fk_product_options_product` FOREIGN KEY (`product_id`) REFERENCES `products` (`id`) ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS `product_variants` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `product_id` BIGINT UNSIGNED NOT NULL,
+  `options` VARCHAR(1000) NOT NULL,
+  `options_key` VARCHAR(400) NOT NULL,
+  `sku` VARCHAR(64) NULL,
+  `price` DECIMAL(20,4) NULL,
+  `created_at` DATETIME(3) NULL,
+  `updated_at` DATETIME(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_product_variants_options` (`product_id`, `options_key`),
+  UNIQUE INDEX `idx_product_variants_sku` (`sku`),
+  CONSTRAINT `fk_product_variants_product` FOREIGN KEY (`product_id`) REFERENCES `products` (`id`) ON DELETE CASCADE
+);
+-- Variants have their own stock; the existing stock is the product's, with
+-- variant_id 0.
+ALTER TABLE `stock_levels` ADD COLUMN `variant_id` BIGINT UNSIGNED NOT NULL DEFAULT 0 AFTER `product_id`;
+CREATE UNIQUE INDEX `idx_stock_levels_product_variant_warehouse` ON `stock_levels` (`product_id`, `variant_id`, `warehouse`);
+DROP INDEX `idx_stock_levels_product_warehouse` ON `stock_levels`;
+ALTER TABLE `stock_movements` ADD COLUMN `variant_id` BIGINT UNSIGNED NOT NULL DEFAULT 0 AFTER `product_id`;
+ALTER TABLE `reservations` ADD COLUMN `variant_id` BIGINT UNSIGNED NOT NULL DEFAULT 0 AFTER `product_id`;
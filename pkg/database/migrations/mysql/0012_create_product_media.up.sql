@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS `product_media` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `product_id` BIGINT UNSIGNED NOT NULL,
+  `filename` VARCHAR(255) NOT NULL,
+  `content_type` VARCHAR(50) NOT NULL,
+  `size` BIGINT NOT NULL,
+  `width` INT NOT NULL,
+  `height` INT NOT NULL,
+  `position` INT NOT NULL DEFAULT 0,
+  `is_primary` BOOLEAN NOT NULL DEFAULT FALSE,
+  `file_key` VARCHAR(255) NOT NULL,
+  `thumbnail_key` VARCHAR(255) NOT NULL,
+  `created_at` DATETIME(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_product_media_product_id` (`product_id`),
+  CONSTRAINT `fk_product_media_product` FOREIGN KEY (`product_id`) REFERENCES `products` (`id`) ON DELETE CASCADE
+);
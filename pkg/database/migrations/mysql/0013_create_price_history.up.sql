@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS `price_history` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `product_id` BIGINT UNSIGNED NOT NULL,
+  `old_price` DECIMAL(20,4) NOT NULL,
+  `old_currency` CHAR(3) NOT NULL,
+  `new_price` DECIMAL(20,4) NOT NULL,
+  `new_currency` CHAR(3) NOT NULL,
+  `changed_by` VARCHAR(255) NOT NULL DEFAULT '',
+  `reason` VARCHAR(255) NOT NULL DEFAULT '',
+  `changed_at` DATETIME(3) NOT NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_price_history_product_id` (`product_id`),
+  INDEX `idx_price_history_changed_at` (`changed_at`),
+  CONSTRAINT `fk_price_history_product` FOREIGN KEY (`product_id`) REFERENCES `products` (`id`) ON DELETE CASCADE
+);
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS `attribute_definitions` (
+  `key` VARCHAR(50) NOT NULL,
+  `name` VARCHAR(100) NOT NULL,
+  `type` VARCHAR(10) NOT NULL,
+  `unit` VARCHAR(20) NOT NULL DEFAULT '',
+  `enum_values` VARCHAR(1000) NULL,
+  `min_value` DOUBLE NULL,
+  `max_value` DOUBLE NULL,
+  `max_length` INT NOT NULL DEFAULT 0,
+  `created_at` DATETIME(3) NULL,
+  `updated_at` DATETIME(3) NULL,
+  PRIMARY KEY (`key`)
+);
+CREATE TABLE IF NOT EXISTS `product_attributes` (
+  `product_id` BIGINT UNSIGNED NOT NULL,
+  `attribute_key` VARCHAR(50) NOT NULL,
+  `string_value` VARCHAR(255) NULL,
+  `number_value` DOUBLE NULL,
+  `bool_value` BOOLEAN NULL,
+  PRIMARY KEY (`product_id`, `attribute_key`),
+  INDEX `idx_product_attributes_number` (`attribute_key`, `number_value`),
+  INDEX `idx_product_attributes_string` (`attribute_key`, `string_value`),
+  CONSTRAINT `fk_product_attributes_product` FOREIGN KEY (`product_id`) REFERENCES `products` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `fk_product_attributes_definition` FOREIGN KEY (`attribute_key`) REFERENCES `attribute_definitions` (`key`) ON DELETE CASCADE
+);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS products (
+  id BIGSERIAL PRIMARY KEY,
+  title VARCHAR(255),
+  description TEXT,
+  price DECIMAL(20,2),
+  created_at TIMESTAMPTZ NULL,
+  updated_at TIMESTAMPTZ NULL,
+  deleted_at TIMESTAMPTZ NULL
+);
+CREATE INDEX IF NOT EXISTS idx_products_deleted_at ON products (deleted_at);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+  id BIGSERIAL PRIMARY KEY,
+  name VARCHAR(255),
+  prefix VARCHAR(32),
+  hash CHAR(64),
+  scopes VARCHAR(255),
+  expires_at TIMESTAMPTZ NULL,
+  last_used_at TIMESTAMPTZ NULL,
+  revoked_at TIMESTAMPTZ NULL,
+  created_at TIMESTAMPTZ NULL,
+  updated_at TIMESTAMPTZ NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_prefix ON api_keys (prefix);
@@ -0,0 +1,2 @@
+ALTER TABLE products DROP COLUMN currency;
+ALTER TABLE products ALTER COLUMN price TYPE DECIMAL(20,2);
@@ -0,0 +1,4 @@
+-- Prices get up to four decimal places, for the currencies that need three or
+-- four. The existing prices are in the default currency.
+ALTER TABLE products ALTER COLUMN price TYPE DECIMAL(20,4);
+ALTER TABLE products ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'BRL';
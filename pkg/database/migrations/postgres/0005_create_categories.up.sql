@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS categories (
+  id BIGSERIAL PRIMARY KEY,
+  name VARCHAR(255),
+  parent_id BIGINT NULL REFERENCES categories (id),
+  path VARCHAR(255),
+  created_at TIMESTAMPTZ NULL,
+  updated_at TIMESTAMPTZ NULL
+);
+CREATE INDEX IF NOT EXISTS idx_categories_parent_id ON categories (parent_id);
+CREATE INDEX IF NOT EXISTS idx_categories_path ON categories (path varchar_pattern_ops);
+CREATE TABLE IF NOT EXISTS product_categories (
+  product_id BIGINT NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+  category_id BIGINT NOT NULL REFERENCES categories (id) ON DELETE CASCADE,
+  PRIMARY KEY (product_id, category_id)
+);
+CREATE INDEX IF NOT EXISTS idx_product_categories_category_id ON product_categories (category_id);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS tags (
+  id BIGSERIAL PRIMARY KEY,
+  name VARCHAR(50) NOT NULL,
+  created_at TIMESTAMPTZ NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_name ON tags (name);
+CREATE TABLE IF NOT EXISTS product_tags (
+  product_id BIGINT NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+  tag_id BIGINT NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+  PRIMARY KEY (product_id, tag_id)
+);
+CREATE INDEX IF NOT EXISTS idx_product_tags_tag_id ON product_tags (tag_id);
@@ -0,0 +1,7 @@
+-- Existing products get a slug from their id, as SQL can't derive one from
+-- the title the way the application does. New products get theirs on create.
+ALTER TABLE products ADD COLUMN sku VARCHAR(64) NULL;
+ALTER TABLE products ADD COLUMN slug VARCHAR(255) NULL;
+UPDATE products SET slug = 'product-' || id;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_sku ON products (sku);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_slug ON products (slug);
@@ -0,0 +1,4 @@
+ALTER TABLE products ADD COLUMN publish_at TIMESTAMPTZ NULL;
+ALTER TABLE products ADD COLUMN unpublish_at TIMESTAMPTZ NULL;
+CREATE INDEX IF NOT EXISTS idx_products_publish_at ON products (publish_at);
+CREATE INDEX IF NOT EXISTS idx_products_unpublish_at ON products (unpublish_at);
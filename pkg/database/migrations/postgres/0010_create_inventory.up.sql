@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS stock_levels (
+  id BIGSERIAL PRIMARY KEY,
+  product_id BIGINT NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+  warehouse VARCHAR(50) NOT NULL,
+  on_hand BIGINT NOT NULL DEFAULT 0,
+  updated_at TIMESTAMPTZ NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stock_levels_product_warehouse ON stock_levels (product_id, warehouse);
+CREATE TABLE IF NOT EXISTS stock_movements (
+  id BIGSERIAL PRIMARY KEY,
+  product_id BIGINT NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+  warehouse VARCHAR(50) NOT NULL,
+  delta BIGINT NOT NULL,
+  reason VARCHAR(20) NOT NULL,
+  note VARCHAR(255) NULL,
+  reservation_id BIGINT NULL,
+  created_at TIMESTAMPTZ NULL
+);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_product_id ON stock_movements (product_id);
+CREATE TABLE IF NOT EXISTS reservations (
+  id BIGSERIAL PRIMARY KEY,
+  product_id BIGINT NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+  warehouse VARCHAR(50) NOT NULL,
+  quantity BIGINT NOT NULL,
+  status VARCHAR(20) NOT NULL,
+  expires_at TIMESTAMPTZ NULL,
+  created_at TIMESTAMPTZ NULL,
+  updated_at TIMESTAMPTZ NULL
+);
+CREATE INDEX IF NOT EXISTS idx_reservations_product_id ON reservations (product_id);
+CREATE INDEX IF NOT EXISTS idx_reservations_status ON reservations (status);
+CREATE INDEX IF NOT EXISTS idx_reservations_expires_at ON reservations (expires_at);
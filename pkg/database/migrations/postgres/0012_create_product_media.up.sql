@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS product_media (
+  id BIGSERIAL PRIMARY KEY,
+  product_id BIGINT NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+  filename VARCHAR(255) NOT NULL,
+  content_type VARCHAR(50) NOT NULL,
+  size BIGINT NOT NULL,
+  width INTEGER NOT NULL,
+  height INTEGER NOT NULL,
+  position INTEGER NOT NULL DEFAULT 0,
+  is_primary BOOLEAN NOT NULL DEFAULT FALSE,
+  file_key VARCHAR(255) NOT NULL,
+  thumbnail_key VARCHAR(255) NOT NULL,
+  created_at TIMESTAMPTZ NULL
+);
+CREATE INDEX IF NOT EXISTS idx_product_media_product_id ON product_media (product_id);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS price_history (
+  id BIGSERIAL PRIMARY KEY,
+  product_id BIGINT NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+  old_price DECIMAL(20,4) NOT NULL,
+  old_currency CHAR(3) NOT NULL,
+  new_price DECIMAL(20,4) NOT NULL,
+  new_currency CHAR(3) NOT NULL,
+  changed_by VARCHAR(255) NOT NULL DEFAULT '',
+  reason VARCHAR(255) NOT NULL DEFAULT '',
+  changed_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_price_history_product_id ON price_history (product_id);
+CREATE INDEX IF NOT EXISTS idx_price_history_changed_at ON price_history (changed_at);
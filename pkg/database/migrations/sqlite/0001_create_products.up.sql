@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS products (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  title VARCHAR(255),
+  description TEXT,
+  price DECIMAL(20,2),
+  created_at DATETIME NULL,
+  updated_at DATETIME NULL,
+  deleted_at DATETIME NULL
+);
+CREATE INDEX IF NOT EXISTS idx_products_deleted_at ON products (deleted_at);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name VARCHAR(255),
+  prefix VARCHAR(32),
+  hash CHAR(64),
+  scopes VARCHAR(255),
+  expires_at DATETIME NULL,
+  last_used_at DATETIME NULL,
+  revoked_at DATETIME NULL,
+  created_at DATETIME NULL,
+  updated_at DATETIME NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_prefix ON api_keys (prefix);
@@ -0,0 +1,3 @@
+-- SQLite keeps the decimals of price whatever its declared scale. The existing
+-- prices are in the default currency.
+ALTER TABLE products ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'BRL';
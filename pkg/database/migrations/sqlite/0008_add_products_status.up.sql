@@ -0,0 +1,6 @@
+-- Products created before the publication lifecycle were visible to everyone,
+-- so they start out published.
+ALTER TABLE products ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'draft';
+ALTER TABLE products ADD COLUMN published_at DATETIME NULL;
+UPDATE products SET status = 'published', published_at = created_at;
+CREATE INDEX IF NOT EXISTS idx_products_status ON products (status);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_products_unpublish_at;
+DROP INDEX IF EXISTS idx_products_publish_at;
+ALTER TABLE products DROP COLUMN unpublish_at;
+ALTER TABLE products DROP COLUMN publish_at;
@@ -0,0 +1,7 @@
+ALTER TABLE reservations DROP COLUMN variant_id;
+ALTER TABLE stock_movements DROP COLUMN variant_id;
+DROP INDEX IF EXISTS idx_stock_levels_product_variant_warehouse;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stock_levels_product_warehouse ON stock_levels (product_id, warehouse);
+ALTER TABLE stock_levels DROP COLUMN variant_id;
+DROP TABLE IF EXISTS product_variants;
+DROP TABLE IF EXISTS product_options;
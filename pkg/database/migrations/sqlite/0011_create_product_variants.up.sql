@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS product_options (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  product_id INTEGER NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+  name VARCHAR(50) NOT NULL,
+  option_values VARCHAR(1000) NOT NULL,
+  position INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_product_options_product_id ON product_options (product_id);
+CREATE TABLE IF NOT EXISTS product_variants (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  product_id INTEGER NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+  options VARCHAR(1000) NOT NULL,
+  options_key VARCHAR(400) NOT NULL,
+  sku VARCHAR(64) NULL,
+  price DECIMAL(20,4) NULL,
+  created_at DATETIME NULL,
+  updated_at DATETIME NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_variants_options ON product_variants (product_id, options_key);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_variants_sku ON product_variants (sku);
+-- Variants have their own stock; the existing stock is the product's, with
+-- variant_id 0.
+ALTER TABLE stock_levels ADD COLUMN variant_id INTEGER NOT NULL DEFAULT 0;
+DROP INDEX IF EXISTS idx_stock_levels_product_warehouse;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stock_levels_product_variant_warehouse ON stock_levels (product_id, variant_id, warehouse);
+ALTER TABLE stock_movements ADD COLUMN variant_id INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE reservations ADD COLUMN variant_id INTEGER NOT NULL DEFAULT 0;
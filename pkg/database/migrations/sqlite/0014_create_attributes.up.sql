@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS attribute_definitions (
+  "key" VARCHAR(50) PRIMARY KEY,
+  name VARCHAR(100) NOT NULL,
+  type VARCHAR(10) NOT NULL,
+  unit VARCHAR(20) NOT NULL DEFAULT '',
+  enum_values VARCHAR(1000) NULL,
+  min_value REAL NULL,
+  max_value REAL NULL,
+  max_length INTEGER NOT NULL DEFAULT 0,
+  created_at DATETIME NULL,
+  updated_at DATETIME NULL
+);
+CREATE TABLE IF NOT EXISTS product_attributes (
+  product_id INTEGER NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+  attribute_key VARCHAR(50) NOT NULL REFERENCES attribute_definitions ("key") ON DELETE CASCADE,
+  string_value VARCHAR(255) NULL,
+  number_value REAL NULL,
+  bool_value BOOLEAN NULL,
+  PRIMARY KEY (product_id, attribute_key)
+);
+CREATE INDEX IF NOT EXISTS idx_product_attributes_number ON product_attributes (attribute_key, number_value);
+CREATE INDEX IF NOT EXISTS idx_product_attributes_string ON product_attributes (attribute_key, string_value);
@@ -0,0 +1,228 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ReadFromReplica marks a query that may be served by a read replica:
+//
+//	db.Set(database.ReadFromReplica, true).Find(&products)
+//
+// Unmarked queries, writes and transactions always use the primary.
+const ReadFromReplica = "database:read_from_replica"
+
+// routedTo holds the replica a statement was routed to, along with the pool of
+// the primary to read from if the replica fails.
+const routedTo = "database:routed_to"
+
+type routed struct {
+	replica *replica
+	primary gorm.ConnPool
+}
+
+type sessionKey struct{}
+
+// WithSession returns a copy of ctx whose statements get a read-your-writes
+// window of their own, e.g. keyed by the principal of a request:
+//
+//	db.WithContext(database.WithSession(ctx, principal)).Create(&product)
+func WithSession(ctx context.Context, session string) context.Context {
+	return context.WithValue(ctx, sessionKey{}, session)
+}
+
+func sessionOf(db *gorm.DB) (string, bool) {
+	if db.Statement.Context == nil {
+		return "", false
+	}
+	session, ok := db.Statement.Context.Value(sessionKey{}).(string)
+	return session, ok && session != ""
+}
+
+type replica struct {
+	name    string
+	db      *sql.DB
+	healthy atomic.Bool
+}
+
+// Replicas routes the queries marked with ReadFromReplica to the healthy read
+// replicas in turn, falling back to the primary when none is healthy or the
+// replica fails the query. After a write, the reads of the same session (see
+// WithSession) stay on the primary for readYourWrites. Statements without a
+// session share a single window for the whole instance, and writes made by
+// other instances don't open it.
+type Replicas struct {
+	replicas       []*replica
+	next           atomic.Uint64
+	readYourWrites time.Duration
+	lastWrite      atomic.Int64
+
+	mu       sync.Mutex
+	sessions map[string]time.Time
+}
+
+func NewReplicas(readYourWrites time.Duration) *Replicas {
+	return &Replicas{readYourWrites: readYourWrites, sessions: map[string]time.Time{}}
+}
+
+// Add registers a replica. It only receives queries once a health check
+// succeeds.
+func (r *Replicas) Add(name string, db *sql.DB) {
+	r.replicas = append(r.replicas, &replica{name: name, db: db})
+}
+
+// Register installs the routing callbacks on the primary connection.
+func (r *Replicas) Register(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Query().Before("gorm:query").Register("database:route_read", r.route); err != nil {
+		return err
+	}
+	if err := callbacks.Query().After("gorm:query").Register("database:fall_back", r.fallBack(callbacks.Query().Get("gorm:query"))); err != nil {
+		return err
+	}
+	if err := callbacks.Row().Before("gorm:row").Register("database:route_read", r.route); err != nil {
+		return err
+	}
+	if err := callbacks.Row().After("gorm:row").Register("database:fall_back", r.fallBack(callbacks.Row().Get("gorm:row"))); err != nil {
+		return err
+	}
+	if err := callbacks.Create().After("gorm:create").Register("database:track_write", r.trackWrite); err != nil {
+		return err
+	}
+	if err := callbacks.Update().After("gorm:update").Register("database:track_write", r.trackWrite); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().After("gorm:delete").Register("database:track_write", r.trackWrite); err != nil {
+		return err
+	}
+	return callbacks.Raw().After("gorm:raw").Register("database:track_write", r.trackWrite)
+}
+
+func (r *Replicas) route(db *gorm.DB) {
+	if _, ok := db.Statement.Settings.Load(ReadFromReplica); !ok {
+		return
+	}
+	if _, inTransaction := db.Statement.ConnPool.(gorm.TxCommitter); inTransaction {
+		return
+	}
+	if r.wroteRecently(db) {
+		return
+	}
+	if replica := r.pick(); replica != nil {
+		db.Statement.Settings.Store(routedTo, routed{replica: replica, primary: db.Statement.ConnPool})
+		db.Statement.ConnPool = replica.db
+	}
+}
+
+// fallBack runs query again on the primary when the replica the statement was
+// routed to fails it. The replica is taken out of rotation until its next
+// health check passes.
+func (r *Replicas) fallBack(query func(*gorm.DB)) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.Statement.Settings.LoadAndDelete(routedTo)
+		if !ok {
+			return
+		}
+		target := value.(routed)
+		if db.Error == nil || errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			return
+		}
+
+		if target.replica.healthy.Swap(false) {
+			log.Printf("Read replica %s is unhealthy: %v", target.replica.name, db.Error)
+		}
+		db.Error = nil
+		db.Statement.ConnPool = target.primary
+		query(db)
+	}
+}
+
+// pick returns the next healthy replica, or nil when the read must go to the
+// primary.
+func (r *Replicas) pick() *replica {
+	for range r.replicas {
+		replica := r.replicas[(r.next.Add(1)-1)%uint64(len(r.replicas))]
+		if replica.healthy.Load() {
+			return replica
+		}
+	}
+	return nil
+}
+
+// wroteRecently reports whether the session of db, or the instance when db has
+// none, wrote within readYourWrites.
+func (r *Replicas) wroteRecently(db *gorm.DB) bool {
+	if r.readYourWrites <= 0 {
+		return false
+	}
+	if session, ok := sessionOf(db); ok {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return time.Since(r.sessions[session]) < r.readYourWrites
+	}
+	return time.Since(time.Unix(0, r.lastWrite.Load())) < r.readYourWrites
+}
+
+func (r *Replicas) trackWrite(db *gorm.DB) {
+	if db.Error != nil || r.readYourWrites <= 0 {
+		return
+	}
+	now := time.Now()
+	session, ok := sessionOf(db)
+	if !ok {
+		r.lastWrite.Store(now.UnixNano())
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sessions[session] = now
+	// The sessions whose window closed are dropped, so the map only holds
+	// the ones written to within readYourWrites.
+	for key, at := range r.sessions {
+		if now.Sub(at) >= r.readYourWrites {
+			delete(r.sessions, key)
+		}
+	}
+}
+
+// CheckHealth pings every replica, taking the failing ones out of rotation and
+// putting the recovered ones back.
+func (r *Replicas) CheckHealth(ctx context.Context, timeout time.Duration) {
+	for _, replica := range r.replicas {
+		pingCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := replica.db.PingContext(pingCtx)
+		cancel()
+
+		healthy := err == nil
+		if replica.healthy.Swap(healthy) != healthy {
+			if healthy {
+				log.Printf("Read replica %s is healthy", replica.name)
+			} else {
+				log.Printf("Read replica %s is unhealthy: %v", replica.name, err)
+			}
+		}
+	}
+}
+
+// Watch runs CheckHealth every interval until ctx is done.
+func (r *Replicas) Watch(ctx context.Context, interval, timeout time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		r.CheckHealth(ctx, timeout)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
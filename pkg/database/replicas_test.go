@@ -0,0 +1,173 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+type product struct {
+	ID    uint
+	Title string
+}
+
+func newReplicaDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	assert.NoError(t, err)
+	return db, mock
+}
+
+func newPrimary(t *testing.T, replicas *Replicas) (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{Conn: db, SkipInitializeWithVersion: true}), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, replicas.Register(gormDB))
+	return gormDB, mock
+}
+
+func expectProducts(mock sqlmock.Sqlmock, title string) {
+	mock.ExpectQuery("SELECT (.+) FROM `products`").WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).AddRow(1, title))
+}
+
+func TestReplicas(t *testing.T) {
+	t.Run("should spread the marked reads among the healthy replicas", func(t *testing.T) {
+		replicas := NewReplicas(0)
+		first, firstMock := newReplicaDB(t)
+		second, secondMock := newReplicaDB(t)
+		replicas.Add("first", first)
+		replicas.Add("second", second)
+		firstMock.ExpectPing()
+		secondMock.ExpectPing()
+		replicas.CheckHealth(context.Background(), time.Second)
+
+		db, primaryMock := newPrimary(t, replicas)
+		expectProducts(firstMock, "first")
+		expectProducts(secondMock, "second")
+		expectProducts(primaryMock, "primary")
+
+		var products []product
+		assert.NoError(t, db.Set(ReadFromReplica, true).Find(&products).Error)
+		assert.Equal(t, "first", products[0].Title)
+		assert.NoError(t, db.Set(ReadFromReplica, true).Find(&products).Error)
+		assert.Equal(t, "second", products[0].Title)
+		assert.NoError(t, db.Find(&products).Error)
+		assert.Equal(t, "primary", products[0].Title)
+
+		assert.NoError(t, firstMock.ExpectationsWereMet())
+		assert.NoError(t, secondMock.ExpectationsWereMet())
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+	})
+
+	t.Run("should fail over to the primary when the replicas are unhealthy", func(t *testing.T) {
+		replicas := NewReplicas(0)
+		replicaDB, replicaMock := newReplicaDB(t)
+		replicas.Add("replica", replicaDB)
+		replicaMock.ExpectPing().WillReturnError(fmt.Errorf("connection refused"))
+		replicas.CheckHealth(context.Background(), time.Second)
+
+		db, primaryMock := newPrimary(t, replicas)
+		expectProducts(primaryMock, "primary")
+
+		var products []product
+		assert.NoError(t, db.Set(ReadFromReplica, true).Find(&products).Error)
+		assert.Equal(t, "primary", products[0].Title)
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+	})
+
+	t.Run("should read from the primary right after a write", func(t *testing.T) {
+		replicas := NewReplicas(time.Minute)
+		replicaDB, replicaMock := newReplicaDB(t)
+		replicas.Add("replica", replicaDB)
+		replicaMock.ExpectPing()
+		replicas.CheckHealth(context.Background(), time.Second)
+
+		db, primaryMock := newPrimary(t, replicas)
+		primaryMock.ExpectBegin()
+		primaryMock.ExpectExec("INSERT INTO `products`").WillReturnResult(sqlmock.NewResult(1, 1))
+		primaryMock.ExpectCommit()
+		expectProducts(primaryMock, "primary")
+
+		assert.NoError(t, db.Create(&product{Title: "Bulbasaur"}).Error)
+
+		var products []product
+		assert.NoError(t, db.Set(ReadFromReplica, true).Find(&products).Error)
+		assert.Equal(t, "primary", products[0].Title)
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+	})
+
+	t.Run("should only keep the session that wrote on the primary", func(t *testing.T) {
+		replicas := NewReplicas(time.Minute)
+		replicaDB, replicaMock := newReplicaDB(t)
+		replicas.Add("replica", replicaDB)
+		replicaMock.ExpectPing()
+		replicas.CheckHealth(context.Background(), time.Second)
+
+		db, primaryMock := newPrimary(t, replicas)
+		primaryMock.ExpectBegin()
+		primaryMock.ExpectExec("INSERT INTO `products`").WillReturnResult(sqlmock.NewResult(1, 1))
+		primaryMock.ExpectCommit()
+		expectProducts(primaryMock, "primary")
+		expectProducts(replicaMock, "replica")
+		expectProducts(replicaMock, "replica")
+
+		writer := WithSession(context.Background(), "writer@example.com")
+		reader := WithSession(context.Background(), "reader@example.com")
+		assert.NoError(t, db.WithContext(writer).Create(&product{Title: "Bulbasaur"}).Error)
+
+		var products []product
+		assert.NoError(t, db.WithContext(writer).Set(ReadFromReplica, true).Find(&products).Error)
+		assert.Equal(t, "primary", products[0].Title)
+		assert.NoError(t, db.WithContext(reader).Set(ReadFromReplica, true).Find(&products).Error)
+		assert.Equal(t, "replica", products[0].Title)
+		assert.NoError(t, db.Set(ReadFromReplica, true).Find(&products).Error)
+		assert.Equal(t, "replica", products[0].Title)
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+		assert.NoError(t, replicaMock.ExpectationsWereMet())
+	})
+
+	t.Run("should read from the primary when the replica fails the query", func(t *testing.T) {
+		replicas := NewReplicas(0)
+		replicaDB, replicaMock := newReplicaDB(t)
+		replicas.Add("replica", replicaDB)
+		replicaMock.ExpectPing()
+		replicas.CheckHealth(context.Background(), time.Second)
+
+		db, primaryMock := newPrimary(t, replicas)
+		replicaMock.ExpectQuery("SELECT (.+) FROM `products`").WillReturnError(fmt.Errorf("connection refused"))
+		expectProducts(primaryMock, "primary")
+		expectProducts(primaryMock, "primary")
+
+		var products []product
+		assert.NoError(t, db.Set(ReadFromReplica, true).Find(&products).Error)
+		assert.Equal(t, "primary", products[0].Title)
+		assert.NoError(t, db.Set(ReadFromReplica, true).Find(&products).Error)
+		assert.Equal(t, "primary", products[0].Title)
+		assert.NoError(t, replicaMock.ExpectationsWereMet())
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+	})
+
+	t.Run("should not fall back when the replica finds nothing", func(t *testing.T) {
+		replicas := NewReplicas(0)
+		replicaDB, replicaMock := newReplicaDB(t)
+		replicas.Add("replica", replicaDB)
+		replicaMock.ExpectPing()
+		replicas.CheckHealth(context.Background(), time.Second)
+
+		db, primaryMock := newPrimary(t, replicas)
+		replicaMock.ExpectQuery("SELECT (.+) FROM `products`").WillReturnRows(sqlmock.NewRows([]string{"id", "title"}))
+
+		var found product
+		assert.ErrorIs(t, db.Set(ReadFromReplica, true).First(&found).Error, gorm.ErrRecordNotFound)
+		assert.NoError(t, replicaMock.ExpectationsWereMet())
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+	})
+}
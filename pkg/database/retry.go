@@ -0,0 +1,30 @@
+package database
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// retry calls fn until it succeeds or ctx is done, and then returns the last
+// error. The wait between attempts doubles up to maxInterval, and a random
+// part of it is dropped so that replicas restarting together spread out.
+func retry(ctx context.Context, interval, maxInterval time.Duration, fn func() error) error {
+	for {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+
+		wait := interval/2 + time.Duration(rand.Int63n(int64(interval/2)+1))
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+
+		if interval *= 2; interval > maxInterval {
+			interval = maxInterval
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetry(t *testing.T) {
+	t.Run("should retry until it succeeds", func(t *testing.T) {
+		attempts := 0
+		err := retry(context.Background(), time.Millisecond, 4*time.Millisecond, func() error {
+			if attempts++; attempts < 4 {
+				return errors.New("connection refused")
+			}
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 4, attempts)
+	})
+
+	t.Run("should return the last error once the deadline passes", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		attempts := 0
+		err := retry(ctx, time.Millisecond, 2*time.Millisecond, func() error {
+			attempts++
+			return errors.New("connection refused")
+		})
+
+		assert.EqualError(t, err, "connection refused")
+		assert.Greater(t, attempts, 1)
+	})
+
+	t.Run("should try once without a deadline left", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		attempts := 0
+		err := retry(ctx, time.Second, time.Second, func() error {
+			attempts++
+			return errors.New("connection refused")
+		})
+
+		assert.Error(t, err)
+		assert.Equal(t, 1, attempts)
+	})
+}
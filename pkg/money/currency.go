@@ -0,0 +1,64 @@
+package money
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Currency is an ISO 4217 alphabetic code, such as "BRL".
+type Currency string
+
+// DefaultCurrency is the currency of the prices stored before currencies were
+// introduced, and of the products created without one.
+const DefaultCurrency Currency = "BRL"
+
+// minorUnits holds the decimal places of the supported currencies, from ISO
+// 4217.
+var minorUnits = map[Currency]int{
+	"AED": 2, "ARS": 2, "AUD": 2, "BHD": 3, "BOB": 2, "BRL": 2, "CAD": 2,
+	"CHF": 2, "CLF": 4, "CLP": 0, "CNY": 2, "COP": 2, "CZK": 2, "DKK": 2,
+	"EGP": 2, "EUR": 2, "GBP": 2, "HKD": 2, "HUF": 2, "IDR": 2, "ILS": 2,
+	"INR": 2, "IQD": 3, "ISK": 0, "JOD": 3, "JPY": 0, "KRW": 0, "KWD": 3,
+	"LYD": 3, "MXN": 2, "MYR": 2, "NOK": 2, "NZD": 2, "OMR": 3, "PEN": 2,
+	"PHP": 2, "PLN": 2, "PYG": 0, "RON": 2, "SAR": 2, "SEK": 2, "SGD": 2,
+	"THB": 2, "TND": 3, "TRY": 2, "TWD": 2, "UAH": 2, "USD": 2, "UYI": 0,
+	"UYU": 2, "UYW": 4, "VND": 0, "XAF": 0, "XOF": 0, "ZAR": 2,
+}
+
+// ParseCurrency returns the currency of a code, in any case.
+func ParseCurrency(code string) (Currency, error) {
+	currency := Currency(strings.ToUpper(strings.TrimSpace(code)))
+	if !currency.Valid() {
+		return "", fmt.Errorf("%w %q", ErrUnknownCurrency, code)
+	}
+	return currency, nil
+}
+
+func (c Currency) Valid() bool {
+	_, ok := minorUnits[c]
+	return ok
+}
+
+// MinorUnits returns the number of decimal places of the currency, 2 for
+// unknown ones.
+func (c Currency) MinorUnits() int {
+	if places, ok := minorUnits[c]; ok {
+		return places
+	}
+	return 2
+}
+
+// UnmarshalText accepts the known codes in any case, so that invalid
+// currencies are rejected when decoding a request.
+func (c *Currency) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*c = ""
+		return nil
+	}
+	currency, err := ParseCurrency(string(text))
+	if err != nil {
+		return err
+	}
+	*c = currency
+	return nil
+}
@@ -0,0 +1,325 @@
+// Package money represents prices without the rounding errors of floats. An
+// Amount is a fixed-point decimal and a Money pairs it with an ISO 4217
+// currency.
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Scale is the number of decimal places kept by an Amount, enough for every
+// ISO 4217 currency.
+const Scale = 4
+
+const unit = 10000 // 10^Scale
+
+var (
+	ErrInvalidAmount    = errors.New("invalid amount")
+	ErrUnknownCurrency  = errors.New("unknown currency")
+	ErrCurrencyMismatch = errors.New("currencies don't match")
+	ErrPrecision        = errors.New("too many decimal places for the currency")
+	ErrOverflow         = errors.New("amount out of range")
+	ErrNoParts          = errors.New("money must be allocated in at least one part")
+)
+
+// Amount is a decimal number with Scale decimal places, stored as an integer
+// number of ten-thousandths. It is written to JSON as a number and to the
+// database as a DECIMAL, both exactly.
+type Amount int64
+
+// ParseAmount parses a decimal such as "1093.45" or "-3".
+func ParseAmount(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	digits := strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+
+	whole, fraction, _ := strings.Cut(digits, ".")
+	if whole == "" && fraction == "" || strings.Trim(whole+fraction, "0123456789") != "" {
+		return 0, fmt.Errorf("%w %q", ErrInvalidAmount, s)
+	}
+	if trimmed := strings.TrimRight(fraction, "0"); len(trimmed) > Scale {
+		return 0, fmt.Errorf("%w %q: more than %d decimal places", ErrInvalidAmount, s, Scale)
+	}
+	fraction = (fraction + strings.Repeat("0", Scale))[:Scale]
+
+	value, err := strconv.ParseInt("0"+whole+fraction, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w %q", ErrOverflow, s)
+	}
+	if negative {
+		value = -value
+	}
+	return Amount(value), nil
+}
+
+// MustParseAmount is like ParseAmount but panics on invalid input. It is meant
+// for constants and tests.
+func MustParseAmount(s string) Amount {
+	amount, err := ParseAmount(s)
+	if err != nil {
+		panic(err)
+	}
+	return amount
+}
+
+// FromMinorUnits returns the amount of n minor units (e.g. cents) of currency.
+func FromMinorUnits(n int64, currency Currency) Amount {
+	return Amount(n * pow10(Scale-currency.MinorUnits()))
+}
+
+// MinorUnits returns the amount in minor units of currency, rounding half
+// away from zero.
+func (a Amount) MinorUnits(currency Currency) int64 {
+	return int64(a.Round(currency.MinorUnits())) / pow10(Scale-currency.MinorUnits())
+}
+
+// Round rounds the amount to places decimal places, half away from zero.
+func (a Amount) Round(places int) Amount {
+	if places >= Scale {
+		return a
+	}
+	step := pow10(Scale - places)
+	remainder := int64(a) % step
+	rounded := int64(a) - remainder
+	switch {
+	case remainder*2 >= step:
+		rounded += step
+	case remainder*2 <= -step:
+		rounded -= step
+	}
+	return Amount(rounded)
+}
+
+// Places returns the number of decimal places needed to write the amount.
+func (a Amount) Places() int {
+	places := Scale
+	for value := int64(a); places > 0 && value%10 == 0; value /= 10 {
+		places--
+	}
+	return places
+}
+
+func (a Amount) Add(b Amount) Amount { return a + b }
+
+func (a Amount) Sub(b Amount) Amount { return a - b }
+
+func (a Amount) Mul(n int64) Amount { return a * Amount(n) }
+
+func (a Amount) Neg() Amount { return -a }
+
+// Cmp returns -1, 0 or 1 when a is less than, equal to or greater than b.
+func (a Amount) Cmp(b Amount) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// String formats the amount with as few decimal places as needed, e.g.
+// "249.9".
+func (a Amount) String() string {
+	return a.StringFixed(a.Places())
+}
+
+// StringFixed formats the amount with places decimal places, rounding it if
+// needed.
+func (a Amount) StringFixed(places int) string {
+	if places > Scale {
+		places = Scale
+	}
+	if places < 0 {
+		places = 0
+	}
+	value := int64(a.Round(places))
+
+	sign := ""
+	if value < 0 {
+		sign = "-"
+	}
+	abs := uint64(value)
+	if value < 0 {
+		abs = uint64(-value)
+	}
+
+	whole, fraction := abs/unit, abs%unit
+	if places == 0 {
+		return sign + strconv.FormatUint(whole, 10)
+	}
+	digits := fmt.Sprintf("%0*d", Scale, fraction)[:places]
+	return sign + strconv.FormatUint(whole, 10) + "." + digits
+}
+
+// Float64 returns the closest float to the amount, for display and
+// statistics only.
+func (a Amount) Float64() float64 {
+	return float64(a) / unit
+}
+
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON accepts a number or a string holding one.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	text := strings.Trim(string(data), `"`)
+	if text == "null" {
+		return nil
+	}
+	return a.UnmarshalText([]byte(text))
+}
+
+func (a Amount) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+func (a *Amount) UnmarshalText(text []byte) error {
+	amount, err := parseNumber(string(text))
+	if err != nil {
+		return err
+	}
+	*a = amount
+	return nil
+}
+
+// parseNumber is ParseAmount also accepting exponents, as in 1e3.
+func parseNumber(s string) (Amount, error) {
+	if !strings.ContainsAny(s, "eE") {
+		return ParseAmount(s)
+	}
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w %q", ErrInvalidAmount, s)
+	}
+	return ParseAmount(strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+// Value stores the amount as a decimal string, which every supported
+// database converts to its DECIMAL column without loss.
+func (a Amount) Value() (driver.Value, error) {
+	return a.StringFixed(Scale), nil
+}
+
+func (a *Amount) Scan(src interface{}) error {
+	var err error
+	switch value := src.(type) {
+	case nil:
+		*a = 0
+	case int64:
+		*a = Amount(value * unit)
+	case float64:
+		*a, err = ParseAmount(strconv.FormatFloat(value, 'f', -1, 64))
+	case []byte:
+		*a, err = ParseAmount(string(value))
+	case string:
+		*a, err = ParseAmount(value)
+	default:
+		err = fmt.Errorf("cannot scan %T into an amount", src)
+	}
+	return err
+}
+
+// Money is an amount of a currency.
+type Money struct {
+	Amount   Amount
+	Currency Currency
+}
+
+// New returns n minor units (e.g. cents) of currency.
+func New(n int64, currency Currency) Money {
+	return Money{Amount: FromMinorUnits(n, currency), Currency: currency}
+}
+
+// Parse parses a decimal amount of currency, such as "1093.45", rejecting
+// unknown currencies and more decimal places than the currency has.
+func Parse(s string, currency Currency) (Money, error) {
+	amount, err := ParseAmount(s)
+	if err != nil {
+		return Money{}, err
+	}
+	m := Money{Amount: amount, Currency: currency}
+	return m, m.Validate()
+}
+
+// Validate checks the currency code and that the amount has no more decimal
+// places than the currency's minor unit.
+func (m Money) Validate() error {
+	if !m.Currency.Valid() {
+		return fmt.Errorf("%w %q", ErrUnknownCurrency, string(m.Currency))
+	}
+	if m.Amount.Places() > m.Currency.MinorUnits() {
+		return fmt.Errorf("%w: %s allows %d", ErrPrecision, m.Currency, m.Currency.MinorUnits())
+	}
+	return nil
+}
+
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	return Money{Amount: m.Amount.Add(other.Amount), Currency: m.Currency}, nil
+}
+
+func (m Money) Sub(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	return Money{Amount: m.Amount.Sub(other.Amount), Currency: m.Currency}, nil
+}
+
+func (m Money) Mul(n int64) Money {
+	return Money{Amount: m.Amount.Mul(n), Currency: m.Currency}
+}
+
+// Cmp compares two amounts of the same currency.
+func (m Money) Cmp(other Money) (int, error) {
+	if m.Currency != other.Currency {
+		return 0, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	return m.Amount.Cmp(other.Amount), nil
+}
+
+// Allocate splits m into n parts that differ by at most one minor unit and
+// add up to m, e.g. 10.00 in 3 is 3.34, 3.33 and 3.33. It fails with
+// ErrNoParts when n is not positive.
+func (m Money) Allocate(n int) ([]Money, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("%w: %d", ErrNoParts, n)
+	}
+
+	total := m.Amount.MinorUnits(m.Currency)
+	share, remainder := total/int64(n), total%int64(n)
+
+	parts := make([]Money, n)
+	for i := range parts {
+		minor := share
+		if int64(i) < remainder {
+			minor++
+		} else if int64(i) < -remainder {
+			minor--
+		}
+		parts[i] = New(minor, m.Currency)
+	}
+	return parts, nil
+}
+
+func (m Money) IsZero() bool { return m.Amount == 0 }
+
+func (m Money) IsPositive() bool { return m.Amount > 0 }
+
+// String formats the money with the decimal places of its currency, e.g.
+// "1093.45 BRL".
+func (m Money) String() string {
+	return m.Amount.StringFixed(m.Currency.MinorUnits()) + " " + string(m.Currency)
+}
+
+func pow10(n int) int64 {
+	return int64(math.Pow10(n))
+}
@@ -0,0 +1,151 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAmount(t *testing.T) {
+	t.Run("should parse decimals exactly", func(t *testing.T) {
+		for text, want := range map[string]Amount{
+			"1093.45": 10934500,
+			"249.90":  2499000,
+			"-3":      -30000,
+			".5":      5000,
+			"0.0001":  1,
+			"10.5000": 105000,
+		} {
+			amount, err := ParseAmount(text)
+
+			assert.NoError(t, err, text)
+			assert.Equal(t, want, amount, text)
+		}
+	})
+
+	t.Run("should return an error for invalid amounts", func(t *testing.T) {
+		for _, text := range []string{"", "abc", "1.2.3", "0.00001", "1,5", "99999999999999999999"} {
+			_, err := ParseAmount(text)
+
+			assert.Error(t, err, text)
+		}
+	})
+}
+
+func TestAmountFormatting(t *testing.T) {
+	t.Run("should write as few decimal places as needed", func(t *testing.T) {
+		assert.Equal(t, "1093.45", MustParseAmount("1093.45").String())
+		assert.Equal(t, "249.9", MustParseAmount("249.90").String())
+		assert.Equal(t, "10", MustParseAmount("10").String())
+		assert.Equal(t, "-0.5", MustParseAmount("-0.5").String())
+	})
+
+	t.Run("should round half away from zero", func(t *testing.T) {
+		assert.Equal(t, "1.01", MustParseAmount("1.005").StringFixed(2))
+		assert.Equal(t, "-1.01", MustParseAmount("-1.005").StringFixed(2))
+		assert.Equal(t, "1.00", MustParseAmount("1.0049").StringFixed(2))
+		assert.Equal(t, "2", MustParseAmount("1.5").StringFixed(0))
+	})
+}
+
+func TestAmountJSON(t *testing.T) {
+	t.Run("should round trip as a number", func(t *testing.T) {
+		var value struct {
+			Price Amount `json:"price"`
+		}
+
+		err := json.Unmarshal([]byte(`{"price": 1093.45}`), &value)
+		data, _ := json.Marshal(value)
+
+		assert.NoError(t, err)
+		assert.Equal(t, MustParseAmount("1093.45"), value.Price)
+		assert.JSONEq(t, `{"price": 1093.45}`, string(data))
+	})
+
+	t.Run("should accept strings and exponents", func(t *testing.T) {
+		var amount Amount
+
+		assert.NoError(t, json.Unmarshal([]byte(`"19.99"`), &amount))
+		assert.Equal(t, MustParseAmount("19.99"), amount)
+		assert.NoError(t, json.Unmarshal([]byte(`1e3`), &amount))
+		assert.Equal(t, MustParseAmount("1000"), amount)
+	})
+}
+
+func TestAmountSQL(t *testing.T) {
+	t.Run("should store a decimal string", func(t *testing.T) {
+		value, err := MustParseAmount("1093.45").Value()
+
+		assert.NoError(t, err)
+		assert.Equal(t, "1093.4500", value)
+	})
+
+	t.Run("should scan what the drivers return", func(t *testing.T) {
+		for _, src := range []interface{}{[]byte("1093.45"), "1093.4500", 1093.45} {
+			var amount Amount
+
+			assert.NoError(t, amount.Scan(src))
+			assert.Equal(t, MustParseAmount("1093.45"), amount)
+		}
+	})
+}
+
+func TestMoney(t *testing.T) {
+	t.Run("should validate the currency and its precision", func(t *testing.T) {
+		_, err := Parse("10.5", "XYZ")
+		assert.ErrorIs(t, err, ErrUnknownCurrency)
+
+		_, err = Parse("10.5", "JPY")
+		assert.ErrorIs(t, err, ErrPrecision)
+
+		_, err = Parse("10.505", "KWD")
+		assert.NoError(t, err)
+	})
+
+	t.Run("should add amounts of the same currency", func(t *testing.T) {
+		sum, err := New(1050, "BRL").Add(New(250, "BRL"))
+		assert.NoError(t, err)
+		assert.Equal(t, "13.00 BRL", sum.String())
+
+		_, err = New(1050, "BRL").Add(New(250, "USD"))
+		assert.ErrorIs(t, err, ErrCurrencyMismatch)
+	})
+
+	t.Run("should allocate without losing cents", func(t *testing.T) {
+		parts, err := New(1000, "BRL").Allocate(3)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []Money{New(334, "BRL"), New(333, "BRL"), New(333, "BRL")}, parts)
+	})
+
+	t.Run("should not allocate in no parts", func(t *testing.T) {
+		for _, n := range []int{0, -1} {
+			parts, err := New(1000, "BRL").Allocate(n)
+
+			assert.ErrorIs(t, err, ErrNoParts)
+			assert.Nil(t, parts)
+		}
+	})
+
+	t.Run("should convert to minor units", func(t *testing.T) {
+		assert.Equal(t, int64(109345), MustParseAmount("1093.45").MinorUnits("BRL"))
+		assert.Equal(t, int64(1093), MustParseAmount("1093.45").MinorUnits("JPY"))
+	})
+}
+
+func TestParseCurrency(t *testing.T) {
+	t.Run("should accept known codes in any case", func(t *testing.T) {
+		currency, err := ParseCurrency("usd")
+
+		assert.NoError(t, err)
+		assert.Equal(t, Currency("USD"), currency)
+		assert.Equal(t, 0, Currency("JPY").MinorUnits())
+	})
+
+	t.Run("should reject unknown codes", func(t *testing.T) {
+		_, err := ParseCurrency("ABC")
+
+		assert.ErrorIs(t, err, ErrUnknownCurrency)
+	})
+}